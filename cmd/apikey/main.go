@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"video-server/internal/config"
+	"video-server/module/param"
+)
+
+const usage = `usage:
+  apikey create -name NAME -subject SUBJECT [-role ROLE] [-tenant TENANT] [-expires DURATION]
+  apikey list
+  apikey revoke ID
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.NewCommand()
+	if err != nil {
+		logrus.WithError(err).Fatal("Load API Key Command Failed")
+		return
+	}
+
+	conn, err := cfg.Database.DB()
+	if err != nil {
+		logrus.WithError(err).Fatal("Get Database connection")
+		return
+	}
+	defer conn.Close()
+
+	ctx := context.Background()
+	ucs := cfg.Usecase.AuthUsecase
+
+	switch os.Args[1] {
+	case "create":
+		flags := flag.NewFlagSet("create", flag.ExitOnError)
+		name := flags.String("name", "", "what the key is used for")
+		subject := flags.String("subject", "", "principal the key authenticates as")
+		role := flags.String("role", "uploader", "viewer, uploader or admin")
+		tenant := flags.String("tenant", "", "tenant the key's files belong to")
+		expires := flags.Duration("expires", 0, "lifetime of the key, 0 never expires")
+		_ = flags.Parse(os.Args[2:])
+
+		params := &param.CreateAPIKey{Name: *name, Subject: *subject, Role: *role, Tenant: *tenant}
+		if *expires > 0 {
+			expiresAt := time.Now().Add(*expires)
+			params.ExpiresAt = &expiresAt
+		}
+		apiKey, key, err := ucs.CreateAPIKey(ctx, params)
+		if err != nil {
+			logrus.WithError(err).Fatal("Create API Key Failed")
+			return
+		}
+
+		// the key is printed alone so it can be piped into a secret store
+		fmt.Fprintf(os.Stderr, "created API key %d, it is shown only once\n", apiKey.ID)
+		fmt.Println(key)
+	case "list":
+		keys, err := ucs.ListAPIKeys(ctx)
+		if err != nil {
+			logrus.WithError(err).Fatal("List API Keys Failed")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tSUBJECT\tROLE\tTENANT\tPREFIX\tCREATED\tSTATUS")
+		now := time.Now()
+		for _, key := range keys {
+			status := "active"
+			switch {
+			case key.RevokedAt != nil:
+				status = "revoked " + key.RevokedAt.Format(time.RFC3339)
+			case !key.IsActive(now):
+				status = "expired " + key.ExpiresAt.Format(time.RFC3339)
+			case key.ExpiresAt != nil:
+				status = "expires " + key.ExpiresAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", key.ID, key.Name, key.Subject, key.Role, key.Tenant, key.Prefix, key.CreatedAt.Format(time.RFC3339), status)
+		}
+		w.Flush()
+	case "revoke":
+		if len(os.Args) != 3 {
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(2)
+		}
+		id, err := strconv.Atoi(os.Args[2])
+		if err != nil {
+			logrus.WithField("id", os.Args[2]).Fatal("Invalid API Key ID")
+			return
+		}
+		if err := ucs.RevokeAPIKey(ctx, id); err != nil {
+			logrus.WithError(err).Fatal("Revoke API Key Failed")
+			return
+		}
+		fmt.Fprintf(os.Stderr, "revoked API key %d\n", id)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
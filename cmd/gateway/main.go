@@ -1,27 +1,97 @@
 package main
 
 import (
-	"fmt"
-	"log"
-	"net/http"
+	"context"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
 
 	"video-server/internal/config"
+	"video-server/internal/server"
+	moduleconfig "video-server/module/config"
 )
 
 func main() {
 	cfg, err := config.NewGatewayServer()
 	if err != nil {
-		log.Fatalf("Load Gateway Server Failed: %v", err)
+		logrus.WithError(err).Fatal("Load Gateway Server Failed")
+		return
+	}
+
+	shutdownTracing, err := config.SetupTracing(context.Background(), cfg.TracingConfig)
+	if err != nil {
+		logrus.WithError(err).Fatal("Init Tracing Failed")
 		return
 	}
 
 	conn, err := cfg.Database.DB()
 	if err != nil {
-		log.Fatalf("Get Database connection: %v", err)
+		logrus.WithError(err).Fatal("Get Database connection")
 		return
 	}
 	defer conn.Close()
 
-	fmt.Println("Listening to port 8080")
-	http.ListenAndServe(":8080", cfg.Router)
+	workerCfg := moduleconfig.WorkerConfig{
+		JobWorkers:      cfg.JobConfig.Workers,
+		JobPollInterval: cfg.JobConfig.PollInterval,
+	}
+	if cfg.ReconcileConfig.Enabled {
+		workerCfg.ReconcileInterval = cfg.ReconcileConfig.Interval
+		workerCfg.ReconcileGracePeriod = cfg.ReconcileConfig.GracePeriod
+		workerCfg.ReconcileDryRun = cfg.ReconcileConfig.DryRun
+	}
+
+	srv, err := config.NewHTTPServer(cfg.ServerConfig, cfg.Handler)
+	if err != nil {
+		logrus.WithError(err).Fatal("Init HTTP Server Failed")
+		return
+	}
+
+	// stop on SIGINT or SIGTERM, in-flight requests and jobs are drained
+	// first
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	workers := moduleconfig.RegisterWorker(ctx, cfg.Usecase, workerCfg)
+
+	metricsErr := make(chan error, 1)
+	if cfg.MetricsHandler != nil {
+		metricsSrv := config.NewMetricsServer(cfg.MetricsConfig, cfg.MetricsHandler)
+		l, err := net.Listen("tcp", metricsSrv.Addr)
+		if err != nil {
+			logrus.WithError(err).Fatal("Init Metrics Server Failed")
+			return
+		}
+		logrus.WithField("addr", metricsSrv.Addr).Info("Serving metrics")
+		go func() {
+			metricsErr <- server.Serve(ctx, metricsSrv, l, cfg.ServerConfig.ShutdownTimeout)
+		}()
+	} else {
+		metricsErr <- nil
+	}
+
+	logrus.WithField("addr", srv.Addr).Info("Listening")
+	err = server.Run(ctx, srv, cfg.ServerConfig.ShutdownTimeout)
+	stop()
+	if err := <-metricsErr; err != nil {
+		logrus.WithError(err).Warn("Metrics Server")
+	}
+
+	logrus.Info("Waiting for background jobs")
+	waitCtx, cancel := context.WithTimeout(context.Background(), cfg.ServerConfig.ShutdownTimeout)
+	defer cancel()
+	if err := workers.Wait(waitCtx); err != nil {
+		logrus.WithError(err).Warn("Background jobs cut off")
+	}
+	// spans of the drained requests and jobs are flushed last
+	if err := shutdownTracing(waitCtx); err != nil {
+		logrus.WithError(err).Warn("Flush traces")
+	}
+	if err != nil {
+		logrus.WithError(err).Fatal("HTTP Server")
+	}
+	logrus.Info("Stopped")
 }
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+
+	"github.com/sirupsen/logrus"
+
+	"video-server/internal/config"
+	"video-server/module/param"
+)
+
+func main() {
+	cfg, err := config.NewCommand()
+	if err != nil {
+		logrus.WithError(err).Fatal("Load Reconcile Job Failed")
+		return
+	}
+
+	conn, err := cfg.Database.DB()
+	if err != nil {
+		logrus.WithError(err).Fatal("Get Database connection")
+		return
+	}
+	defer conn.Close()
+
+	dryRun := flag.Bool("dry-run", cfg.ReconcileConfig.DryRun, "report mismatches without fixing them")
+	grace := flag.Duration("grace", cfg.ReconcileConfig.GracePeriod, "leave objects and rows younger than this alone")
+	flag.Parse()
+
+	report, err := cfg.Usecase.ReconcileUsecase.Reconcile(context.Background(), &param.Reconcile{
+		GracePeriod: *grace,
+		DryRun:      *dryRun,
+	})
+	if err != nil {
+		logrus.WithError(err).Fatal("Reconcile Failed")
+		return
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		logrus.WithError(err).Fatal("Write Report Failed")
+	}
+}
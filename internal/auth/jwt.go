@@ -0,0 +1,148 @@
+// Package auth verifies the bearer tokens of API requests. Tokens are JWTs
+// signed with HS256 by a shared secret or with RS256 by a key that is
+// configured as PEM or published in a JWKS file.
+package auth
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var (
+	ErrInvalidToken = errors.New("auth: invalid token")
+	ErrUnknownKey   = errors.New("auth: no key to verify the token")
+)
+
+// Claims of an accepted token.
+type Claims struct {
+	jwt.RegisteredClaims
+	Role   string `json:"role,omitempty"`
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// TokenVerifier checks the signature and claims of a token.
+type TokenVerifier interface {
+	Verify(token string) (*Claims, error)
+}
+
+type Config struct {
+	// HMACSecrets verify HS256 tokens, more than one allows rotating them
+	HMACSecrets [][]byte
+	// RSAPublicKeys verify RS256 tokens regardless of their kid
+	RSAPublicKeys []*rsa.PublicKey
+	// JWKS verifies RS256 tokens by their kid
+	JWKS *JWKS
+
+	// Issuer and Audience are required to match when set
+	Issuer   string
+	Audience string
+	// Leeway allows for clock skew when checking exp and nbf
+	Leeway time.Duration
+}
+
+type Verifier struct {
+	config Config
+	parser *jwt.Parser
+}
+
+func NewVerifier(cfg Config) *Verifier {
+	return &Verifier{
+		config: cfg,
+		// claims are validated below to apply the leeway
+		parser: jwt.NewParser(
+			jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg()}),
+			jwt.WithoutClaimsValidation(),
+		),
+	}
+}
+
+// Verify accepts a token signed by one of the configured keys that has a
+// subject and hasn't expired. Tokens without exp are rejected.
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	unverified, _, err := v.parser.ParseUnverified(token, &Claims{})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	keys, err := v.keys(unverified)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims *Claims
+	for _, key := range keys {
+		claims = &Claims{}
+		_, err = v.parser.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+			return key, nil
+		})
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if err := v.validate(claims, time.Now()); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// keys lists the candidates for the signing method of the token, HMAC
+// secrets are never offered to RS256 tokens and the other way round.
+func (v *Verifier) keys(token *jwt.Token) ([]interface{}, error) {
+	var keys []interface{}
+	switch token.Method {
+	case jwt.SigningMethodHS256:
+		for _, secret := range v.config.HMACSecrets {
+			keys = append(keys, secret)
+		}
+	case jwt.SigningMethodRS256:
+		kid, _ := token.Header["kid"].(string)
+		if v.config.JWKS != nil && kid != "" {
+			key, err := v.config.JWKS.Key(kid)
+			if err != nil {
+				return nil, err
+			}
+			if key != nil {
+				keys = append(keys, key)
+			}
+		}
+		for _, key := range v.config.RSAPublicKeys {
+			keys = append(keys, key)
+		}
+	default:
+		return nil, fmt.Errorf("%w: signing method %v", ErrInvalidToken, token.Header["alg"])
+	}
+
+	if len(keys) == 0 {
+		return nil, ErrUnknownKey
+	}
+	return keys, nil
+}
+
+func (v *Verifier) validate(claims *Claims, now time.Time) error {
+	switch {
+	case claims.Subject == "":
+		return fmt.Errorf("%w: no subject", ErrInvalidToken)
+	case !claims.VerifyExpiresAt(now.Add(-v.config.Leeway), true):
+		return fmt.Errorf("%w: expired", ErrInvalidToken)
+	case !claims.VerifyNotBefore(now.Add(v.config.Leeway), false):
+		return fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+	case v.config.Issuer != "" && !claims.VerifyIssuer(v.config.Issuer, true):
+		return fmt.Errorf("%w: issuer %q", ErrInvalidToken, claims.Issuer)
+	case v.config.Audience != "" && !claims.VerifyAudience(v.config.Audience, true):
+		return fmt.Errorf("%w: audience %q", ErrInvalidToken, claims.Audience)
+	}
+	return nil
+}
+
+// ParseRSAPublicKey reads a PEM encoded RSA public key.
+func ParseRSAPublicKey(pem []byte) (*rsa.PublicKey, error) {
+	return jwt.ParseRSAPublicKeyFromPEM(pem)
+}
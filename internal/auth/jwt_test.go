@@ -0,0 +1,200 @@
+package auth_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"video-server/internal/auth"
+)
+
+var secret = []byte("secret")
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.Claims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func claims(subject string, expiresIn time.Duration) jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Subject:   subject,
+		Issuer:    "issuer",
+		Audience:  jwt.ClaimStrings{"video-server"},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+	}
+}
+
+func jwksFile(t *testing.T, keys map[string]*rsa.PublicKey) string {
+	var entries []string
+	for kid, key := range keys {
+		entries = append(entries, fmt.Sprintf(`{"kty":"RSA","kid":%q,"use":"sig","alg":"RS256","n":%q,"e":%q}`,
+			kid,
+			base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		))
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	data := `{"keys":[{"kty":"EC","kid":"ec","crv":"P-256"}`
+	for _, entry := range entries {
+		data += "," + entry
+	}
+	data += "]}"
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+	return path
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	pemKey, err := auth.ParseRSAPublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	require.NoError(t, err)
+
+	jwks, err := auth.LoadJWKS(jwksFile(t, map[string]*rsa.PublicKey{"k1": &otherKey.PublicKey}))
+	require.NoError(t, err)
+
+	verifier := auth.NewVerifier(auth.Config{
+		HMACSecrets:   [][]byte{[]byte("old"), secret},
+		RSAPublicKeys: []*rsa.PublicKey{pemKey},
+		JWKS:          jwks,
+		Issuer:        "issuer",
+		Audience:      "video-server",
+		Leeway:        time.Minute,
+	})
+
+	testcases := map[string]struct {
+		token   string
+		subject string
+		err     error
+	}{
+		"hs256": {
+			token:   sign(t, jwt.SigningMethodHS256, secret, "", claims("alice", time.Hour)),
+			subject: "alice",
+		},
+		"rs256 pem": {
+			token:   sign(t, jwt.SigningMethodRS256, rsaKey, "", claims("bob", time.Hour)),
+			subject: "bob",
+		},
+		"rs256 jwks": {
+			token:   sign(t, jwt.SigningMethodRS256, otherKey, "k1", claims("carol", time.Hour)),
+			subject: "carol",
+		},
+		"expired within leeway": {
+			token:   sign(t, jwt.SigningMethodHS256, secret, "", claims("alice", -30*time.Second)),
+			subject: "alice",
+		},
+		"expired": {
+			token: sign(t, jwt.SigningMethodHS256, secret, "", claims("alice", -time.Hour)),
+			err:   auth.ErrInvalidToken,
+		},
+		"no expiry": {
+			token: sign(t, jwt.SigningMethodHS256, secret, "", jwt.RegisteredClaims{Subject: "alice", Issuer: "issuer", Audience: jwt.ClaimStrings{"video-server"}}),
+			err:   auth.ErrInvalidToken,
+		},
+		"no subject": {
+			token: sign(t, jwt.SigningMethodHS256, secret, "", claims("", time.Hour)),
+			err:   auth.ErrInvalidToken,
+		},
+		"wrong audience": {
+			token: sign(t, jwt.SigningMethodHS256, secret, "", jwt.RegisteredClaims{Subject: "alice", Issuer: "issuer", Audience: jwt.ClaimStrings{"other"}, ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}),
+			err:   auth.ErrInvalidToken,
+		},
+		"wrong secret": {
+			token: sign(t, jwt.SigningMethodHS256, []byte("guess"), "", claims("alice", time.Hour)),
+			err:   auth.ErrInvalidToken,
+		},
+		"unknown kid falls back to pem": {
+			token:   sign(t, jwt.SigningMethodRS256, rsaKey, "k2", claims("bob", time.Hour)),
+			subject: "bob",
+		},
+		"rs256 by unknown key": {
+			token: sign(t, jwt.SigningMethodRS256, otherKey, "k2", claims("mallory", time.Hour)),
+			err:   auth.ErrInvalidToken,
+		},
+		"unsupported method": {
+			token: sign(t, jwt.SigningMethodHS512, secret, "", claims("alice", time.Hour)),
+			err:   auth.ErrInvalidToken,
+		},
+		"unsigned": {
+			token: sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", claims("alice", time.Hour)),
+			err:   auth.ErrInvalidToken,
+		},
+		"malformed": {
+			token: "not-a-token",
+			err:   auth.ErrInvalidToken,
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			result, err := verifier.Verify(tc.token)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.subject, result.Subject)
+		})
+	}
+}
+
+func TestVerifier_VerifyNoKeys(t *testing.T) {
+	verifier := auth.NewVerifier(auth.Config{})
+	_, err := verifier.Verify(sign(t, jwt.SigningMethodHS256, secret, "", claims("alice", time.Hour)))
+	assert.ErrorIs(t, err, auth.ErrUnknownKey)
+}
+
+func TestJWKS_Reload(t *testing.T) {
+	first, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	second, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	path := jwksFile(t, map[string]*rsa.PublicKey{"k1": &first.PublicKey})
+	jwks, err := auth.LoadJWKS(path)
+	require.NoError(t, err)
+
+	key, err := jwks.Key("k2")
+	require.NoError(t, err)
+	assert.Nil(t, key)
+
+	rotated := jwksFile(t, map[string]*rsa.PublicKey{"k2": &second.PublicKey})
+	data, err := os.ReadFile(rotated)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+	later := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(path, later, later))
+
+	key, err = jwks.Key("k2")
+	require.NoError(t, err)
+	assert.Equal(t, second.PublicKey.N, key.N)
+}
+
+func TestParseJWKS(t *testing.T) {
+	_, err := auth.ParseJWKS([]byte(`{"keys":[{"kty":"RSA","kid":"k","n":"!","e":"AQAB"}]}`))
+	assert.Error(t, err)
+
+	keys, err := auth.ParseJWKS([]byte(`{"keys":[{"kty":"RSA","kid":"enc","use":"enc","n":"AQAB","e":"AQAB"}]}`))
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+}
@@ -0,0 +1,20 @@
+package auth_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"video-server/internal/auth"
+)
+
+func TestSigner(t *testing.T) {
+	signer := auth.NewSigner(secret)
+	signature := signer.Sign("1.2.1700000000")
+
+	assert.True(t, signer.Verify("1.2.1700000000", signature))
+	assert.False(t, signer.Verify("1.3.1700000000", signature))
+	assert.False(t, signer.Verify("1.2.1700000000", signature+"A"))
+	assert.False(t, signer.Verify("1.2.1700000000", "not base64!"))
+	assert.False(t, auth.NewSigner([]byte("other")).Verify("1.2.1700000000", signature))
+}
@@ -1,13 +1,23 @@
 package config
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
+	"net/http"
 	"os"
+	"time"
 
 	"github.com/kelseyhightower/envconfig"
 	"github.com/subosito/gotenv"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+
+	"video-server/internal/auth"
+	"video-server/internal/logging"
+	"video-server/internal/server"
+	"video-server/internal/storage"
+	"video-server/internal/tracing"
 )
 
 type DatabaseConfig struct {
@@ -17,6 +27,9 @@ type DatabaseConfig struct {
 	Password    string `required:"true" envconfig:"PASSWORD"`
 	Database    string `required:"true" envconfig:"DATABASE"`
 	QueryString string `required:"true" envconfig:"QUERYSTRING"`
+	// queries taking SlowQuery or longer are logged as warnings, 0 never
+	// are
+	SlowQuery time.Duration `envconfig:"SLOW_QUERY" default:"200ms"`
 }
 
 func (c *DatabaseConfig) RWDataSourceName() string {
@@ -32,7 +45,296 @@ func (c *DatabaseConfig) RWDataSourceName() string {
 }
 
 func NewDB(dbCfg DatabaseConfig) (*gorm.DB, error) {
-	return gorm.Open(mysql.Open(dbCfg.RWDataSourceName()), &gorm.Config{})
+	db, err := gorm.Open(mysql.Open(dbCfg.RWDataSourceName()), &gorm.Config{
+		Logger: logging.NewGormLogger(dbCfg.SlowQuery),
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Use(tracing.NewGormPlugin())
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+type ServerConfig struct {
+	Addr string `envconfig:"ADDR" default:":8080"`
+	// uploads and downloads are long lived bodies, ReadTimeout and
+	// WriteTimeout bound whole requests and are off by default, 0 doesn't
+	// limit
+	ReadHeaderTimeout time.Duration `envconfig:"READ_HEADER_TIMEOUT" default:"10s"`
+	ReadTimeout       time.Duration `envconfig:"READ_TIMEOUT" default:"0"`
+	WriteTimeout      time.Duration `envconfig:"WRITE_TIMEOUT" default:"0"`
+	IdleTimeout       time.Duration `envconfig:"IDLE_TIMEOUT" default:"2m"`
+	MaxHeaderBytes    int           `envconfig:"MAX_HEADER_BYTES" default:"1048576"`
+	// in-flight requests and background jobs are each given this long to
+	// finish on SIGINT or SIGTERM
+	ShutdownTimeout time.Duration `envconfig:"SHUTDOWN_TIMEOUT" default:"30s"`
+
+	// serve TLS when both files are set, renewed files are picked up within
+	// TLSReloadInterval
+	TLSCertFile       string        `envconfig:"TLS_CERT_FILE"`
+	TLSKeyFile        string        `envconfig:"TLS_KEY_FILE"`
+	TLSReloadInterval time.Duration `envconfig:"TLS_RELOAD_INTERVAL" default:"1m"`
+}
+
+// NewHTTPServer builds the server of handler, its TLSConfig is only set
+// when a certificate is configured.
+func NewHTTPServer(serverCfg ServerConfig, handler http.Handler) (*http.Server, error) {
+	srv := &http.Server{
+		Addr:              serverCfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: serverCfg.ReadHeaderTimeout,
+		ReadTimeout:       serverCfg.ReadTimeout,
+		WriteTimeout:      serverCfg.WriteTimeout,
+		IdleTimeout:       serverCfg.IdleTimeout,
+		MaxHeaderBytes:    serverCfg.MaxHeaderBytes,
+	}
+	if serverCfg.TLSCertFile == "" && serverCfg.TLSKeyFile == "" {
+		return srv, nil
+	}
+	if serverCfg.TLSCertFile == "" || serverCfg.TLSKeyFile == "" {
+		return nil, fmt.Errorf("TLS needs both a certificate and a key file")
+	}
+
+	reloader, err := server.NewCertReloader(serverCfg.TLSCertFile, serverCfg.TLSKeyFile, serverCfg.TLSReloadInterval)
+	if err != nil {
+		return nil, err
+	}
+	srv.TLSConfig = &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+	return srv, nil
+}
+
+type StorageConfig struct {
+	Driver string `envconfig:"DRIVER" default:"local"`
+	Path   string `envconfig:"LOCAL_PATH" default:"files"`
+
+	// S3 compatible backend, only used when Driver is "s3"
+	Endpoint  string `envconfig:"ENDPOINT"`
+	Region    string `envconfig:"REGION"`
+	Bucket    string `envconfig:"BUCKET"`
+	AccessKey string `envconfig:"ACCESS_KEY"`
+	SecretKey string `envconfig:"SECRET_KEY"`
+	UseSSL    bool   `envconfig:"USE_SSL" default:"true"`
+}
+
+type FileConfig struct {
+	// reject uploads whose name is already used by another file
+	UniqueNames bool `envconfig:"UNIQUE_NAMES" default:"false"`
+	// largest accepted upload in bytes, 0 doesn't limit
+	MaxSize int64 `envconfig:"MAX_SIZE" default:"5368709120"`
+
+	// containers and codecs accepted on upload, codecs match on their
+	// RFC 6381 prefix so avc1 accepts every H.264 profile
+	AllowedContainers  []string `envconfig:"ALLOWED_CONTAINERS" default:"mp4,mov,webm,matroska,mpegts"`
+	AllowedVideoCodecs []string `envconfig:"ALLOWED_VIDEO_CODECS" default:"avc1,avc3,hvc1,hev1,av01,vp8,vp9,vp09"`
+	AllowedAudioCodecs []string `envconfig:"ALLOWED_AUDIO_CODECS" default:"mp4a,opus,vorbis,flac,ac-3,ec-3"`
+}
+
+type TenantConfig struct {
+	// header scoping requests to a tenant, it must match the tenant of the
+	// credentials and picks the tenant when auth is disabled
+	Header string `envconfig:"HEADER" default:"X-Tenant-ID"`
+
+	// quota of every tenant, 0 doesn't limit
+	MaxBytes int64 `envconfig:"MAX_BYTES" default:"0"`
+	MaxFiles int64 `envconfig:"MAX_FILES" default:"0"`
+	// overrides of the quota for single tenants as tenant:limit pairs
+	TenantMaxBytes map[string]int64 `envconfig:"OVERRIDE_MAX_BYTES"`
+	TenantMaxFiles map[string]int64 `envconfig:"OVERRIDE_MAX_FILES"`
+}
+
+type MetricsConfig struct {
+	// Addr is the listener of /metrics, apart from the API as the metrics
+	// cover every tenant. Keep it off public networks, empty doesn't serve
+	// them.
+	Addr string `envconfig:"ADDR" default:":9090"`
+}
+
+// NewMetricsServer builds the server of the metrics listener.
+func NewMetricsServer(metricsCfg MetricsConfig, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              metricsCfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+type HealthConfig struct {
+	// the readiness probe fails when the local storage volume has less
+	// free space, 0 doesn't check
+	MinFreeBytes int64 `envconfig:"MIN_FREE_BYTES" default:"1073741824"`
+	// bounds every dependency check of the readiness probe
+	Timeout time.Duration `envconfig:"TIMEOUT" default:"5s"`
+}
+
+type ThumbnailConfig struct {
+	// ffmpeg decodes H.264 frames, thumbnails of such files are
+	// unavailable when it isn't found
+	FFmpegPath string `envconfig:"FFMPEG_PATH" default:"ffmpeg"`
+}
+
+type ReconcileConfig struct {
+	// run the reconcile job inside the gateway
+	Enabled  bool          `envconfig:"ENABLED" default:"false"`
+	Interval time.Duration `envconfig:"INTERVAL" default:"24h"`
+	// mismatches younger than this are reported but left alone
+	GracePeriod time.Duration `envconfig:"GRACE_PERIOD" default:"24h"`
+	DryRun      bool          `envconfig:"DRY_RUN" default:"false"`
+}
+
+type JobConfig struct {
+	// workers processing uploaded files inside the gateway, 0 leaves the
+	// queue to other instances
+	Workers      int           `envconfig:"WORKERS" default:"2"`
+	PollInterval time.Duration `envconfig:"POLL_INTERVAL" default:"1s"`
+	// a failed job is retried after Backoff, doubling up to MaxBackoff,
+	// until it is dead after MaxAttempts
+	MaxAttempts int           `envconfig:"MAX_ATTEMPTS" default:"5"`
+	Backoff     time.Duration `envconfig:"BACKOFF" default:"10s"`
+	MaxBackoff  time.Duration `envconfig:"MAX_BACKOFF" default:"1h"`
+	// a running job whose worker is gone is taken over after Lease
+	Lease time.Duration `envconfig:"LEASE" default:"10m"`
+}
+
+type LogConfig struct {
+	// one of panic, fatal, error, warn, info, debug or trace, queries are
+	// logged at debug
+	Level string `envconfig:"LEVEL" default:"info"`
+	// json or text
+	Format string `envconfig:"FORMAT" default:"json"`
+}
+
+// SetupLogging configures the logger every request logger derives from,
+// writing to stderr.
+func SetupLogging(logCfg LogConfig) error {
+	return logging.Setup(logging.Config{
+		Level:  logCfg.Level,
+		Format: logCfg.Format,
+	}, os.Stderr)
+}
+
+type TracingConfig struct {
+	ServiceName string `envconfig:"SERVICE_NAME" default:"video-server"`
+	// none only propagates trace context, otlp sends spans to a collector
+	// and file writes them as JSON lines
+	Exporter     string `envconfig:"EXPORTER" default:"none"`
+	OTLPEndpoint string `envconfig:"OTLP_ENDPOINT" default:"localhost:4318"`
+	OTLPInsecure bool   `envconfig:"OTLP_INSECURE" default:"true"`
+	FilePath     string `envconfig:"FILE_PATH" default:"traces.json"`
+	// share of the traces started here that are kept
+	SampleRatio float64 `envconfig:"SAMPLE_RATIO" default:"1"`
+}
+
+// SetupTracing installs the tracer provider of tracingCfg, the returned
+// func flushes the spans left.
+func SetupTracing(ctx context.Context, tracingCfg TracingConfig) (func(context.Context) error, error) {
+	return tracing.Setup(ctx, tracing.Config{
+		ServiceName:  tracingCfg.ServiceName,
+		Exporter:     tracingCfg.Exporter,
+		OTLPEndpoint: tracingCfg.OTLPEndpoint,
+		OTLPInsecure: tracingCfg.OTLPInsecure,
+		FilePath:     tracingCfg.FilePath,
+		SampleRatio:  tracingCfg.SampleRatio,
+	})
+}
+
+type AuthConfig struct {
+	// require an API key or a bearer token on every request
+	Enabled bool `envconfig:"ENABLED" default:"true"`
+	// paths served without credentials
+	PublicPaths []string `envconfig:"PUBLIC_PATHS"`
+
+	// bearer tokens are JWTs signed with HS256 by one of HMACSecrets, or
+	// with RS256 by a PEM public key or a key of the JWKS file
+	HMACSecrets       []string      `envconfig:"HMAC_SECRETS"`
+	RSAPublicKeyFiles []string      `envconfig:"RSA_PUBLIC_KEY_FILES"`
+	JWKSFile          string        `envconfig:"JWKS_FILE"`
+	Issuer            string        `envconfig:"ISSUER"`
+	Audience          string        `envconfig:"AUDIENCE"`
+	Leeway            time.Duration `envconfig:"LEEWAY" default:"1m"`
+}
+
+type LinkConfig struct {
+	// Secret signs download links, they are disabled without one. Every
+	// instance needs the same secret, rotating it invalidates all links.
+	Secret     string        `envconfig:"SECRET"`
+	DefaultTTL time.Duration `envconfig:"DEFAULT_TTL" default:"1h"`
+	MaxTTL     time.Duration `envconfig:"MAX_TTL" default:"168h"`
+}
+
+// NewLinkSigner returns nil when no secret is configured.
+func NewLinkSigner(linkCfg LinkConfig) *auth.Signer {
+	if linkCfg.Secret == "" {
+		return nil
+	}
+	return auth.NewSigner([]byte(linkCfg.Secret))
+}
+
+// NewTokenVerifier returns nil when no key is configured, only API keys are
+// accepted then.
+func NewTokenVerifier(authCfg AuthConfig) (auth.TokenVerifier, error) {
+	cfg := auth.Config{
+		Issuer:   authCfg.Issuer,
+		Audience: authCfg.Audience,
+		Leeway:   authCfg.Leeway,
+	}
+	for _, secret := range authCfg.HMACSecrets {
+		cfg.HMACSecrets = append(cfg.HMACSecrets, []byte(secret))
+	}
+	for _, path := range authCfg.RSAPublicKeyFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := auth.ParseRSAPublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		cfg.RSAPublicKeys = append(cfg.RSAPublicKeys, key)
+	}
+	if authCfg.JWKSFile != "" {
+		jwks, err := auth.LoadJWKS(authCfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.JWKS = jwks
+	}
+
+	if len(cfg.HMACSecrets) == 0 && len(cfg.RSAPublicKeys) == 0 && cfg.JWKS == nil {
+		return nil, nil
+	}
+	return auth.NewVerifier(cfg), nil
+}
+
+func NewBlobStore(storageCfg StorageConfig) (storage.BlobStore, error) {
+	store, err := newBlobStore(storageCfg)
+	if err != nil {
+		return nil, err
+	}
+	return storage.WithTracing(store), nil
+}
+
+func newBlobStore(storageCfg StorageConfig) (storage.BlobStore, error) {
+	switch storageCfg.Driver {
+	case "local":
+		return storage.NewLocalStore(storageCfg.Path)
+	case "s3":
+		return storage.NewS3Store(context.Background(), storage.S3Config{
+			Endpoint:  storageCfg.Endpoint,
+			Region:    storageCfg.Region,
+			Bucket:    storageCfg.Bucket,
+			AccessKey: storageCfg.AccessKey,
+			SecretKey: storageCfg.SecretKey,
+			UseSSL:    storageCfg.UseSSL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", storageCfg.Driver)
+	}
 }
 
 func loadGatewayConfig() (GatewayConfig, error) {
@@ -1,27 +1,95 @@
 package config
 
 import (
+	"net/http"
+
 	"github.com/julienschmidt/httprouter"
 	"gorm.io/gorm"
 
+	"video-server/internal/storage"
 	"video-server/module/config"
 	"video-server/module/entity"
 )
 
 type GatewayConfig struct {
-	Environment    string         `envconfig:"ENVIRONMENT" default:"dev"`
-	DatabaseConfig DatabaseConfig `envconfig:"DB"`
+	Environment     string          `envconfig:"ENVIRONMENT" default:"dev"`
+	LogConfig       LogConfig       `envconfig:"LOG"`
+	ServerConfig    ServerConfig    `envconfig:"SERVER"`
+	DatabaseConfig  DatabaseConfig  `envconfig:"DB"`
+	StorageConfig   StorageConfig   `envconfig:"STORAGE"`
+	FileConfig      FileConfig      `envconfig:"FILE"`
+	TenantConfig    TenantConfig    `envconfig:"TENANT"`
+	ThumbnailConfig ThumbnailConfig `envconfig:"THUMBNAIL"`
+	ReconcileConfig ReconcileConfig `envconfig:"RECONCILE"`
+	JobConfig       JobConfig       `envconfig:"JOB"`
+	AuthConfig      AuthConfig      `envconfig:"AUTH"`
+	LinkConfig      LinkConfig      `envconfig:"LINK"`
+	HealthConfig    HealthConfig    `envconfig:"HEALTH"`
+	TracingConfig   TracingConfig   `envconfig:"TRACING"`
+	MetricsConfig   MetricsConfig   `envconfig:"METRICS"`
 
 	Database *gorm.DB           `ignored:"true"`
+	Storage  storage.BlobStore  `ignored:"true"`
 	Router   *httprouter.Router `ignored:"true"`
+	Handler  http.Handler       `ignored:"true"`
+	Usecase  *config.Usecase    `ignored:"true"`
+	// MetricsHandler serves /metrics on MetricsConfig.Addr, nil when
+	// metrics are not served
+	MetricsHandler http.Handler `ignored:"true"`
 }
 
 func NewGatewayServer() (GatewayConfig, error) {
+	cfg, err := newModule()
+	if err != nil {
+		return cfg, err
+	}
+
+	// init router
+	cfg.Router = httprouter.New()
+
+	// register handler
+	config.RegisterHandler(cfg.Router, cfg.Usecase)
+
+	// register metrics
+	conn, err := cfg.Database.DB()
+	if err != nil {
+		return cfg, err
+	}
+	config.RegisterMetrics(conn, cfg.Usecase)
+	if cfg.MetricsConfig.Addr != "" {
+		metricsRouter := httprouter.New()
+		config.RegisterMetricsHandler(metricsRouter)
+		cfg.MetricsHandler = metricsRouter
+	}
+
+	// register middleware
+	cfg.Handler = config.RegisterMiddleware(cfg.Router, cfg.Usecase, config.MiddlewareConfig{
+		AuthEnabled:  cfg.AuthConfig.Enabled,
+		PublicPaths:  cfg.AuthConfig.PublicPaths,
+		TenantHeader: cfg.TenantConfig.Header,
+	})
+
+	return cfg, nil
+}
+
+// NewCommand loads the module without serving it, for one-off jobs and
+// admin commands.
+func NewCommand() (GatewayConfig, error) {
+	return newModule()
+}
+
+func newModule() (GatewayConfig, error) {
 	cfg, err := loadGatewayConfig()
 	if err != nil {
 		return cfg, err
 	}
 
+	// init logging, before anything that logs
+	err = SetupLogging(cfg.LogConfig)
+	if err != nil {
+		return cfg, err
+	}
+
 	// init DB
 	cfg.Database, err = NewDB(cfg.DatabaseConfig)
 	if err != nil {
@@ -31,17 +99,57 @@ func NewGatewayServer() (GatewayConfig, error) {
 	// migrate DB
 	migrateDB(cfg.Database)
 
-	// init router
-	cfg.Router = httprouter.New()
+	// init storage
+	cfg.Storage, err = NewBlobStore(cfg.StorageConfig)
+	if err != nil {
+		return cfg, err
+	}
+
+	// init token verifier
+	verifier, err := NewTokenVerifier(cfg.AuthConfig)
+	if err != nil {
+		return cfg, err
+	}
 
 	// register module
 	moduleRepo := config.RegisterRepository(cfg.Database)
-	moduleUsecase := config.RegisterUsecase(moduleRepo)
-	config.RegisterHandler(cfg.Router, moduleUsecase)
+	cfg.Usecase = config.RegisterUsecase(moduleRepo, cfg.Storage, config.UsecaseConfig{
+		UniqueFileNames:    cfg.FileConfig.UniqueNames,
+		MaxFileSize:        cfg.FileConfig.MaxSize,
+		AllowedContainers:  cfg.FileConfig.AllowedContainers,
+		AllowedVideoCodecs: cfg.FileConfig.AllowedVideoCodecs,
+		AllowedAudioCodecs: cfg.FileConfig.AllowedAudioCodecs,
+		FFmpegPath:         cfg.ThumbnailConfig.FFmpegPath,
+		QuotaMaxBytes:      cfg.TenantConfig.MaxBytes,
+		QuotaMaxFiles:      cfg.TenantConfig.MaxFiles,
+		TenantMaxBytes:     cfg.TenantConfig.TenantMaxBytes,
+		TenantMaxFiles:     cfg.TenantConfig.TenantMaxFiles,
+		JobMaxAttempts:     cfg.JobConfig.MaxAttempts,
+		JobBackoff:         cfg.JobConfig.Backoff,
+		JobMaxBackoff:      cfg.JobConfig.MaxBackoff,
+		JobLease:           cfg.JobConfig.Lease,
+		TokenVerifier:      verifier,
+		LinkSigner:         NewLinkSigner(cfg.LinkConfig),
+		LinkDefaultTTL:     cfg.LinkConfig.DefaultTTL,
+		LinkMaxTTL:         cfg.LinkConfig.MaxTTL,
+		HealthMinFreeBytes: cfg.HealthConfig.MinFreeBytes,
+		HealthTimeout:      cfg.HealthConfig.Timeout,
+	})
 
 	return cfg, nil
 }
 
 func migrateDB(db *gorm.DB) {
-	db.AutoMigrate(&entity.File{})
+	// file names used to be unique, uniqueness is now an opt-in usecase policy
+	if db.Migrator().HasIndex(&entity.File{}, "name") {
+		db.Migrator().DropIndex(&entity.File{}, "name")
+	}
+
+	// blobs used to be shared by every tenant, they are keyed by tenant now
+	// and existing ones belong to the default tenant
+	if db.Migrator().HasTable(&entity.Blob{}) && !db.Migrator().HasColumn(&entity.Blob{}, "TenantID") {
+		db.Exec("ALTER TABLE `blobs` ADD COLUMN `tenant_id` varchar(255) NOT NULL DEFAULT '' FIRST, DROP PRIMARY KEY, ADD PRIMARY KEY (`tenant_id`, `digest`)")
+	}
+
+	db.AutoMigrate(&entity.File{}, &entity.VideoMetadata{}, &entity.Blob{}, &entity.Upload{}, &entity.Job{}, &entity.APIKey{}, &entity.FileGrant{}, &entity.DownloadLink{}, &entity.LinkPlayback{})
 }
@@ -0,0 +1,262 @@
+// Package dash renders MPEG-DASH media presentation descriptions (ISO/IEC
+// 23009-1) for movies packaged as fMP4 segments, one representation per
+// track.
+package dash
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	ContentType = "application/dash+xml"
+
+	ManifestName = "manifest.mpd"
+
+	InitSegmentTemplate  = "init-$RepresentationID$.mp4"
+	MediaSegmentTemplate = "segment-$RepresentationID$-$Time$.m4s"
+
+	profileLive           = "urn:mpeg:dash:profile:isoff-live:2011"
+	audioChannelsSchemeID = "urn:mpeg:dash:23003:3:audio_channel_configuration:2011"
+)
+
+// InitSegmentName is the URI of the init segment of a track, as expanded
+// from InitSegmentTemplate.
+func InitSegmentName(trackID uint32) string {
+	return fmt.Sprintf("init-%d.mp4", trackID)
+}
+
+// MediaSegmentName is the URI of the track segment starting at time, as
+// expanded from MediaSegmentTemplate.
+func MediaSegmentName(trackID uint32, time uint64) string {
+	return fmt.Sprintf("segment-%d-%d.m4s", trackID, time)
+}
+
+// ParseInitSegmentName returns the track encoded by InitSegmentName.
+func ParseInitSegmentName(name string) (uint32, bool) {
+	if !strings.HasPrefix(name, "init-") || !strings.HasSuffix(name, ".mp4") {
+		return 0, false
+	}
+	trackID, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, "init-"), ".mp4"), 10, 32)
+	if err != nil || trackID == 0 {
+		return 0, false
+	}
+	return uint32(trackID), true
+}
+
+// ParseMediaSegmentName returns the track and start time encoded by
+// MediaSegmentName.
+func ParseMediaSegmentName(name string) (uint32, uint64, bool) {
+	if !strings.HasPrefix(name, "segment-") || !strings.HasSuffix(name, ".m4s") {
+		return 0, 0, false
+	}
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(name, "segment-"), ".m4s"), "-")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	trackID, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil || trackID == 0 {
+		return 0, 0, false
+	}
+	time, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return uint32(trackID), time, true
+}
+
+// WithQuery appends query to the segment URIs of a manifest rendered by
+// Manifest, for clients that must send it along with each request.
+func WithQuery(manifest []byte, query string) []byte {
+	// $ starts a template identifier and is escaped by doubling it
+	suffix := &bytes.Buffer{}
+	_ = xml.EscapeText(suffix, []byte("?"+strings.ReplaceAll(query, "$", "$$")))
+
+	for _, template := range []string{InitSegmentTemplate, MediaSegmentTemplate} {
+		manifest = bytes.ReplaceAll(manifest, []byte(`"`+template+`"`), []byte(`"`+template+suffix.String()+`"`))
+	}
+	return manifest
+}
+
+// Segment is a segment of a representation in its timescale.
+type Segment struct {
+	Time     uint64
+	Duration uint64
+}
+
+type Representation struct {
+	ID        uint32
+	Codecs    string
+	Bandwidth int64
+	Timescale uint32
+	Segments  []Segment
+
+	// video
+	Width     int
+	Height    int
+	FrameRate float64
+
+	// audio
+	SampleRate int
+	Channels   int
+}
+
+// AdaptationSet groups interchangeable representations of one content type,
+// video or audio.
+type AdaptationSet struct {
+	ContentType     string
+	Representations []Representation
+}
+
+type mpd struct {
+	XMLName                   xml.Name `xml:"urn:mpeg:dash:schema:mpd:2011 MPD"`
+	Profiles                  string   `xml:"profiles,attr"`
+	Type                      string   `xml:"type,attr"`
+	MediaPresentationDuration string   `xml:"mediaPresentationDuration,attr"`
+	MinBufferTime             string   `xml:"minBufferTime,attr"`
+	Period                    period   `xml:"Period"`
+}
+
+type period struct {
+	ID             string          `xml:"id,attr"`
+	Start          string          `xml:"start,attr"`
+	AdaptationSets []adaptationSet `xml:"AdaptationSet"`
+}
+
+type adaptationSet struct {
+	ID               int              `xml:"id,attr"`
+	ContentType      string           `xml:"contentType,attr"`
+	MimeType         string           `xml:"mimeType,attr"`
+	SegmentAlignment bool             `xml:"segmentAlignment,attr"`
+	StartWithSAP     int              `xml:"startWithSAP,attr"`
+	Representations  []representation `xml:"Representation"`
+}
+
+type representation struct {
+	ID                string          `xml:"id,attr"`
+	Codecs            string          `xml:"codecs,attr,omitempty"`
+	Bandwidth         int64           `xml:"bandwidth,attr"`
+	Width             int             `xml:"width,attr,omitempty"`
+	Height            int             `xml:"height,attr,omitempty"`
+	FrameRate         string          `xml:"frameRate,attr,omitempty"`
+	AudioSamplingRate int             `xml:"audioSamplingRate,attr,omitempty"`
+	ChannelConfig     *descriptor     `xml:"AudioChannelConfiguration,omitempty"`
+	SegmentTemplate   segmentTemplate `xml:"SegmentTemplate"`
+}
+
+type descriptor struct {
+	SchemeIDURI string `xml:"schemeIdUri,attr"`
+	Value       string `xml:"value,attr"`
+}
+
+type segmentTemplate struct {
+	Timescale      uint32 `xml:"timescale,attr"`
+	Initialization string `xml:"initialization,attr"`
+	Media          string `xml:"media,attr"`
+	Timeline       []s    `xml:"SegmentTimeline>S"`
+}
+
+type s struct {
+	T *uint64 `xml:"t,attr"`
+	D uint64  `xml:"d,attr"`
+	R int     `xml:"r,attr,omitempty"`
+}
+
+// Manifest renders a static, single period MPD where every representation
+// addresses its segments by start time through a SegmentTimeline.
+func Manifest(duration float64, sets ...AdaptationSet) ([]byte, error) {
+	doc := mpd{
+		Profiles:                  profileLive,
+		Type:                      "static",
+		MediaPresentationDuration: formatDuration(duration),
+		MinBufferTime:             formatDuration(2),
+		Period:                    period{ID: "0", Start: formatDuration(0)},
+	}
+
+	for i, set := range sets {
+		aset := adaptationSet{
+			ID:               i,
+			ContentType:      set.ContentType,
+			MimeType:         set.ContentType + "/mp4",
+			SegmentAlignment: true,
+			StartWithSAP:     1,
+		}
+		for _, rep := range set.Representations {
+			aset.Representations = append(aset.Representations, newRepresentation(rep))
+		}
+		doc.Period.AdaptationSets = append(doc.Period.AdaptationSets, aset)
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
+
+func newRepresentation(rep Representation) representation {
+	out := representation{
+		ID:                strconv.FormatUint(uint64(rep.ID), 10),
+		Codecs:            rep.Codecs,
+		Bandwidth:         rep.Bandwidth,
+		Width:             rep.Width,
+		Height:            rep.Height,
+		AudioSamplingRate: rep.SampleRate,
+		SegmentTemplate: segmentTemplate{
+			Timescale:      rep.Timescale,
+			Initialization: InitSegmentTemplate,
+			Media:          MediaSegmentTemplate,
+			Timeline:       timeline(rep.Segments),
+		},
+	}
+	if rep.FrameRate > 0 {
+		out.FrameRate = formatFrameRate(rep.FrameRate)
+	}
+	if rep.Channels > 0 {
+		out.ChannelConfig = &descriptor{SchemeIDURI: audioChannelsSchemeID, Value: strconv.Itoa(rep.Channels)}
+	}
+	return out
+}
+
+// timeline run-length encodes segments of equal duration, restating the
+// start time only after a gap.
+func timeline(segments []Segment) []s {
+	out := []s{}
+	var next uint64
+	for i, segment := range segments {
+		last := len(out) - 1
+		if i > 0 && segment.Time == next && segment.Duration == out[last].D {
+			out[last].R++
+		} else {
+			entry := s{D: segment.Duration}
+			if i == 0 || segment.Time != next {
+				t := segment.Time
+				entry.T = &t
+			}
+			out = append(out, entry)
+		}
+		next = segment.Time + segment.Duration
+	}
+	return out
+}
+
+// formatDuration writes an xs:duration in seconds, e.g. PT5.760S.
+func formatDuration(seconds float64) string {
+	return fmt.Sprintf("PT%.3fS", seconds)
+}
+
+// formatFrameRate writes a frame rate as an integer or a fraction, using
+// the NTSC 1001 denominator for rates such as 29.97.
+func formatFrameRate(rate float64) string {
+	if rounded := float64(int64(rate + 0.5)); rate-rounded < 0.001 && rounded-rate < 0.001 {
+		return strconv.FormatInt(int64(rounded), 10)
+	}
+	ntsc := rate * 1001 / 1000
+	if rounded := float64(int64(ntsc + 0.5)); ntsc-rounded < 0.01 && rounded-ntsc < 0.01 {
+		return fmt.Sprintf("%d000/1001", int64(rounded))
+	}
+	return fmt.Sprintf("%d/1000", int64(rate*1000+0.5))
+}
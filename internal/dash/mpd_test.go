@@ -0,0 +1,141 @@
+package dash_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"video-server/internal/dash"
+)
+
+func TestManifest(t *testing.T) {
+	manifest, err := dash.Manifest(12.5,
+		dash.AdaptationSet{
+			ContentType: "video",
+			Representations: []dash.Representation{{
+				ID:        1,
+				Codecs:    "avc1.640028",
+				Bandwidth: 4000000,
+				Timescale: 90000,
+				Width:     1920,
+				Height:    1080,
+				FrameRate: 29.97,
+				Segments: []dash.Segment{
+					{Time: 0, Duration: 540000},
+					{Time: 540000, Duration: 540000},
+					{Time: 1080000, Duration: 45000},
+				},
+			}},
+		},
+		dash.AdaptationSet{
+			ContentType: "audio",
+			Representations: []dash.Representation{{
+				ID:         2,
+				Codecs:     "mp4a.40.2",
+				Bandwidth:  128000,
+				Timescale:  48000,
+				SampleRate: 48000,
+				Channels:   2,
+				Segments: []dash.Segment{
+					{Time: 1024, Duration: 288000},
+					{Time: 300000, Duration: 288000},
+				},
+			}},
+		},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-live:2011" type="static" mediaPresentationDuration="PT12.500S" minBufferTime="PT2.000S">
+  <Period id="0" start="PT0.000S">
+    <AdaptationSet id="0" contentType="video" mimeType="video/mp4" segmentAlignment="true" startWithSAP="1">
+      <Representation id="1" codecs="avc1.640028" bandwidth="4000000" width="1920" height="1080" frameRate="30000/1001">
+        <SegmentTemplate timescale="90000" initialization="init-$RepresentationID$.mp4" media="segment-$RepresentationID$-$Time$.m4s">
+          <SegmentTimeline>
+            <S t="0" d="540000" r="1"></S>
+            <S d="45000"></S>
+          </SegmentTimeline>
+        </SegmentTemplate>
+      </Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" contentType="audio" mimeType="audio/mp4" segmentAlignment="true" startWithSAP="1">
+      <Representation id="2" codecs="mp4a.40.2" bandwidth="128000" audioSamplingRate="48000">
+        <AudioChannelConfiguration schemeIdUri="urn:mpeg:dash:23003:3:audio_channel_configuration:2011" value="2"></AudioChannelConfiguration>
+        <SegmentTemplate timescale="48000" initialization="init-$RepresentationID$.mp4" media="segment-$RepresentationID$-$Time$.m4s">
+          <SegmentTimeline>
+            <S t="1024" d="288000"></S>
+            <S t="300000" d="288000"></S>
+          </SegmentTimeline>
+        </SegmentTemplate>
+      </Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`, string(manifest))
+}
+
+func TestWithQuery(t *testing.T) {
+	manifest, err := dash.Manifest(6, dash.AdaptationSet{
+		ContentType: "audio",
+		Representations: []dash.Representation{{
+			ID:        2,
+			Timescale: 44100,
+			Segments:  []dash.Segment{{Time: 0, Duration: 264600}},
+		}},
+	})
+	require.NoError(t, err)
+
+	signed := string(dash.WithQuery(manifest, "link=1&sig=a$b"))
+	assert.Contains(t, signed, `initialization="init-$RepresentationID$.mp4?link=1&amp;sig=a$$b"`)
+	assert.Contains(t, signed, `media="segment-$RepresentationID$-$Time$.m4s?link=1&amp;sig=a$$b"`)
+	assert.NotContains(t, signed, `.mp4"`)
+}
+
+func TestParseInitSegmentName(t *testing.T) {
+	testCases := []struct {
+		name    string
+		trackID uint32
+		ok      bool
+	}{
+		{name: dash.InitSegmentName(1), trackID: 1, ok: true},
+		{name: "init-12.mp4", trackID: 12, ok: true},
+		{name: "init-0.mp4", ok: false},
+		{name: "init-a.mp4", ok: false},
+		{name: "init.mp4", ok: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			trackID, ok := dash.ParseInitSegmentName(tc.name)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.trackID, trackID)
+		})
+	}
+}
+
+func TestParseMediaSegmentName(t *testing.T) {
+	testCases := []struct {
+		name    string
+		trackID uint32
+		time    uint64
+		ok      bool
+	}{
+		{name: dash.MediaSegmentName(1, 0), trackID: 1, time: 0, ok: true},
+		{name: "segment-2-540000.m4s", trackID: 2, time: 540000, ok: true},
+		{name: "segment-2.m4s", ok: false},
+		{name: "segment-0-10.m4s", ok: false},
+		{name: "segment-1-2-3.m4s", ok: false},
+		{name: "segment-1--3.m4s", ok: false},
+		{name: "segment-1-10.mp4", ok: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			trackID, time, ok := dash.ParseMediaSegmentName(tc.name)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.trackID, trackID)
+			assert.Equal(t, tc.time, time)
+		})
+	}
+}
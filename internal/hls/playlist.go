@@ -0,0 +1,105 @@
+// Package hls renders HTTP Live Streaming playlists (RFC 8216) for movies
+// packaged as fMP4 segments.
+package hls
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	ContentType = "application/vnd.apple.mpegurl"
+
+	MasterPlaylistName = "master.m3u8"
+	MediaPlaylistName  = "media.m3u8"
+	InitSegmentName    = "init.mp4"
+)
+
+// SegmentName is the URI of the n-th media segment, relative to the
+// playlists.
+func SegmentName(index int) string {
+	return fmt.Sprintf("segment-%d.m4s", index)
+}
+
+// ParseSegmentName returns the index encoded by SegmentName.
+func ParseSegmentName(name string) (int, bool) {
+	if !strings.HasPrefix(name, "segment-") || !strings.HasSuffix(name, ".m4s") {
+		return 0, false
+	}
+	index, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "segment-"), ".m4s"))
+	if err != nil || index < 0 {
+		return 0, false
+	}
+	return index, true
+}
+
+var uriAttribute = regexp.MustCompile(`URI="([^"]*)"`)
+
+// WithQuery appends query to every URI of playlist, for clients that must
+// send it along with each request.
+func WithQuery(playlist, query string) string {
+	lines := strings.Split(playlist, "\n")
+	for i, line := range lines {
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#"):
+			lines[i] = uriAttribute.ReplaceAllString(line, `URI="${1}?`+query+`"`)
+		default:
+			lines[i] = line + "?" + query
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+type Variant struct {
+	Bandwidth        int64
+	AverageBandwidth int64
+	Codecs           []string
+	Width            int
+	Height           int
+	FrameRate        float64
+	URI              string
+}
+
+// MasterPlaylist lists the variant streams of a presentation.
+func MasterPlaylist(variants ...Variant) string {
+	b := &strings.Builder{}
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-INDEPENDENT-SEGMENTS\n")
+
+	for _, variant := range variants {
+		attributes := []string{fmt.Sprintf("BANDWIDTH=%d", variant.Bandwidth)}
+		if variant.AverageBandwidth > 0 {
+			attributes = append(attributes, fmt.Sprintf("AVERAGE-BANDWIDTH=%d", variant.AverageBandwidth))
+		}
+		if len(variant.Codecs) > 0 {
+			attributes = append(attributes, fmt.Sprintf("CODECS=%q", strings.Join(variant.Codecs, ",")))
+		}
+		if variant.Width > 0 && variant.Height > 0 {
+			attributes = append(attributes, fmt.Sprintf("RESOLUTION=%dx%d", variant.Width, variant.Height))
+		}
+		if variant.FrameRate > 0 {
+			attributes = append(attributes, fmt.Sprintf("FRAME-RATE=%.3f", variant.FrameRate))
+		}
+		fmt.Fprintf(b, "#EXT-X-STREAM-INF:%s\n%s\n", strings.Join(attributes, ","), variant.URI)
+	}
+
+	return b.String()
+}
+
+// MediaPlaylist is a VOD playlist of fMP4 segments sharing one init segment.
+func MediaPlaylist(targetDuration int, durations []float64) string {
+	b := &strings.Builder{}
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:7\n")
+	fmt.Fprintf(b, "#EXT-X-TARGETDURATION:%d\n", targetDuration)
+	b.WriteString("#EXT-X-MEDIA-SEQUENCE:0\n#EXT-X-PLAYLIST-TYPE:VOD\n#EXT-X-INDEPENDENT-SEGMENTS\n")
+	fmt.Fprintf(b, "#EXT-X-MAP:URI=%q\n", InitSegmentName)
+
+	for i, duration := range durations {
+		fmt.Fprintf(b, "#EXTINF:%.3f,\n%s\n", duration, SegmentName(i))
+	}
+
+	b.WriteString("#EXT-X-ENDLIST\n")
+	return b.String()
+}
@@ -0,0 +1,87 @@
+package hls_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"video-server/internal/hls"
+)
+
+func TestMasterPlaylist(t *testing.T) {
+	playlist := hls.MasterPlaylist(hls.Variant{
+		Bandwidth:        4000000,
+		AverageBandwidth: 3955000,
+		Codecs:           []string{"avc1.640028", "mp4a.40.2"},
+		Width:            1920,
+		Height:           1080,
+		FrameRate:        29.97,
+		URI:              hls.MediaPlaylistName,
+	})
+
+	assert.Equal(t, "#EXTM3U\n"+
+		"#EXT-X-VERSION:7\n"+
+		"#EXT-X-INDEPENDENT-SEGMENTS\n"+
+		"#EXT-X-STREAM-INF:BANDWIDTH=4000000,AVERAGE-BANDWIDTH=3955000,CODECS=\"avc1.640028,mp4a.40.2\",RESOLUTION=1920x1080,FRAME-RATE=29.970\n"+
+		"media.m3u8\n", playlist)
+}
+
+func TestMediaPlaylist(t *testing.T) {
+	playlist := hls.MediaPlaylist(7, []float64{6.006, 2.5})
+
+	assert.Equal(t, "#EXTM3U\n"+
+		"#EXT-X-VERSION:7\n"+
+		"#EXT-X-TARGETDURATION:7\n"+
+		"#EXT-X-MEDIA-SEQUENCE:0\n"+
+		"#EXT-X-PLAYLIST-TYPE:VOD\n"+
+		"#EXT-X-INDEPENDENT-SEGMENTS\n"+
+		"#EXT-X-MAP:URI=\"init.mp4\"\n"+
+		"#EXTINF:6.006,\n"+
+		"segment-0.m4s\n"+
+		"#EXTINF:2.500,\n"+
+		"segment-1.m4s\n"+
+		"#EXT-X-ENDLIST\n", playlist)
+}
+
+func TestWithQuery(t *testing.T) {
+	playlist := hls.WithQuery(hls.MediaPlaylist(7, []float64{6.006})+hls.MasterPlaylist(hls.Variant{Bandwidth: 1, URI: hls.MediaPlaylistName}), "link=1&sig=abc")
+
+	assert.Equal(t, "#EXTM3U\n"+
+		"#EXT-X-VERSION:7\n"+
+		"#EXT-X-TARGETDURATION:7\n"+
+		"#EXT-X-MEDIA-SEQUENCE:0\n"+
+		"#EXT-X-PLAYLIST-TYPE:VOD\n"+
+		"#EXT-X-INDEPENDENT-SEGMENTS\n"+
+		"#EXT-X-MAP:URI=\"init.mp4?link=1&sig=abc\"\n"+
+		"#EXTINF:6.006,\n"+
+		"segment-0.m4s?link=1&sig=abc\n"+
+		"#EXT-X-ENDLIST\n"+
+		"#EXTM3U\n"+
+		"#EXT-X-VERSION:7\n"+
+		"#EXT-X-INDEPENDENT-SEGMENTS\n"+
+		"#EXT-X-STREAM-INF:BANDWIDTH=1\n"+
+		"media.m3u8?link=1&sig=abc\n", playlist)
+}
+
+func TestParseSegmentName(t *testing.T) {
+	testCases := []struct {
+		name  string
+		index int
+		ok    bool
+	}{
+		{name: "segment-0.m4s", index: 0, ok: true},
+		{name: "segment-12.m4s", index: 12, ok: true},
+		{name: "segment--1.m4s", ok: false},
+		{name: "segment-a.m4s", ok: false},
+		{name: "segment-1.mp4", ok: false},
+		{name: "init.mp4", ok: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			index, ok := hls.ParseSegmentName(tc.name)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.index, index)
+		})
+	}
+}
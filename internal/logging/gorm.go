@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GormLogger writes what gorm logs to the logger of the context a statement
+// was given with WithContext. Failed queries are errors, slow ones
+// warnings and the others only show at debug level.
+type GormLogger struct {
+	slowThreshold time.Duration
+}
+
+// NewGormLogger warns of queries taking slowThreshold or longer, 0 never
+// does.
+func NewGormLogger(slowThreshold time.Duration) *GormLogger {
+	return &GormLogger{
+		slowThreshold: slowThreshold,
+	}
+}
+
+// LogMode is ignored, levels are those of the logger.
+func (l *GormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	FromContext(ctx).Infof(msg, data...)
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	FromContext(ctx).Warnf(msg, data...)
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	FromContext(ctx).Errorf(msg, data...)
+}
+
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	logger := FromContext(ctx)
+
+	var level logrus.Level
+	var msg string
+	switch {
+	// not finding a record is an answer, not a failure
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		level, msg = logrus.ErrorLevel, "query failed"
+		logger = logger.WithError(err)
+	case l.slowThreshold > 0 && elapsed >= l.slowThreshold:
+		level, msg = logrus.WarnLevel, "slow query"
+	default:
+		level, msg = logrus.DebugLevel, "query"
+	}
+	if !logger.Logger.IsLevelEnabled(level) {
+		return
+	}
+
+	sql, rows := fc()
+	logger.WithFields(logrus.Fields{
+		"sql":         sql,
+		"rows":        rows,
+		"duration_ms": Milliseconds(elapsed),
+	}).Log(level, msg)
+}
+
+// Milliseconds is d in milliseconds, fractions kept, for duration fields.
+func Milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
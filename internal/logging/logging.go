@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Formats of Config.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+type Config struct {
+	// Level is the least severe level written, one of logrus' level names
+	Level string
+	// Format is FormatJSON or FormatText
+	Format string
+}
+
+// Setup configures the standard logger of logrus to write to out, the
+// logger every context falls back to. The log package of the standard
+// library is redirected to it at info level.
+func Setup(cfg Config, out io.Writer) error {
+	level, err := logrus.ParseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+
+	var formatter logrus.Formatter
+	switch cfg.Format {
+	case "", FormatJSON:
+		formatter = &logrus.JSONFormatter{}
+	case FormatText:
+		formatter = &logrus.TextFormatter{FullTimestamp: true}
+	default:
+		return fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+
+	logger := logrus.StandardLogger()
+	logger.SetOutput(out)
+	logger.SetFormatter(formatter)
+	logger.SetLevel(level)
+
+	log.SetFlags(0)
+	log.SetOutput(logger.WriterLevel(logrus.InfoLevel))
+	return nil
+}
+
+type contextKey struct{}
+
+// contextLogger is the logger of a context, fields are added to it in place
+// so the middleware that created it logs what was learnt further in.
+type contextLogger struct {
+	mu    sync.Mutex
+	entry *logrus.Entry
+}
+
+// WithLogger returns a context carrying entry, replacing any logger of ctx.
+func WithLogger(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, contextKey{}, &contextLogger{entry: entry})
+}
+
+// FromContext returns the logger of ctx, the standard logger without one.
+func FromContext(ctx context.Context) *logrus.Entry {
+	logger, ok := ctx.Value(contextKey{}).(*contextLogger)
+	if !ok {
+		return logrus.NewEntry(logrus.StandardLogger()).WithContext(ctx)
+	}
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	return logger.entry.WithContext(ctx)
+}
+
+// AddFields adds fields to the logger of ctx, for whoever logs with ctx or
+// a context derived from the same logger later. It does nothing without a
+// logger.
+func AddFields(ctx context.Context, fields logrus.Fields) {
+	logger, ok := ctx.Value(contextKey{}).(*contextLogger)
+	if !ok {
+		return
+	}
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	logger.entry = logger.entry.WithFields(fields)
+}
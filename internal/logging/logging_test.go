@@ -0,0 +1,178 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"video-server/internal/logging"
+)
+
+// setup configures the standard logger for the test, writing to the
+// returned buffer.
+func setup(t *testing.T, cfg logging.Config) *bytes.Buffer {
+	out := &bytes.Buffer{}
+	require.NoError(t, logging.Setup(cfg, out))
+	restore(t)
+	return out
+}
+
+// restore puts the standard logger back to its defaults after the test.
+func restore(t *testing.T) {
+	t.Cleanup(func() {
+		_ = logging.Setup(logging.Config{Level: "info", Format: logging.FormatText}, os.Stderr)
+	})
+}
+
+// lines decodes the JSON lines of out.
+func lines(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		entry := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestSetup(t *testing.T) {
+	testcases := map[string]struct {
+		cfg logging.Config
+		err string
+	}{
+		"json": {
+			cfg: logging.Config{Level: "info", Format: logging.FormatJSON},
+		},
+		"text": {
+			cfg: logging.Config{Level: "debug", Format: logging.FormatText},
+		},
+		"unknown level": {
+			cfg: logging.Config{Level: "loud", Format: logging.FormatJSON},
+			err: `not a valid logrus Level: "loud"`,
+		},
+		"unknown format": {
+			cfg: logging.Config{Level: "info", Format: "xml"},
+			err: `unknown log format "xml"`,
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			err := logging.Setup(tc.cfg, out)
+			restore(t)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+
+			logrus.WithField("key", "value").Info("hello")
+			if tc.cfg.Format == logging.FormatJSON {
+				entries := lines(t, out)
+				require.Len(t, entries, 1)
+				assert.Equal(t, "hello", entries[0]["msg"])
+				assert.Equal(t, "info", entries[0]["level"])
+				assert.Equal(t, "value", entries[0]["key"])
+			} else {
+				assert.Contains(t, out.String(), "msg=hello key=value")
+			}
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	out := setup(t, logging.Config{Level: "info", Format: logging.FormatJSON})
+
+	// without a logger the standard one is used
+	logging.FromContext(context.Background()).Info("standard")
+	logging.AddFields(context.Background(), logrus.Fields{"ignored": true})
+
+	ctx := logging.WithLogger(context.Background(), logrus.WithField("request_id", "req-123"))
+	logging.FromContext(ctx).Info("request")
+	logging.AddFields(context.WithValue(ctx, struct{}{}, nil), logrus.Fields{"principal": "alice"})
+	logging.FromContext(ctx).Info("authenticated")
+
+	entries := lines(t, out)
+	require.Len(t, entries, 3)
+	assert.NotContains(t, entries[0], "request_id")
+	assert.NotContains(t, entries[0], "ignored")
+	assert.Equal(t, "req-123", entries[1]["request_id"])
+	assert.NotContains(t, entries[1], "principal")
+	assert.Equal(t, "req-123", entries[2]["request_id"])
+	assert.Equal(t, "alice", entries[2]["principal"])
+}
+
+func TestGormLogger_Trace(t *testing.T) {
+	testcases := map[string]struct {
+		level   string
+		elapsed time.Duration
+		err     error
+		msg     string
+		logged  string
+	}{
+		"fast query": {
+			level:   "info",
+			elapsed: time.Millisecond,
+		},
+		"fast query debug": {
+			level:   "debug",
+			elapsed: time.Millisecond,
+			logged:  "debug",
+			msg:     "query",
+		},
+		"slow query": {
+			level:   "info",
+			elapsed: time.Second,
+			logged:  "warning",
+			msg:     "slow query",
+		},
+		"record not found": {
+			level:   "info",
+			elapsed: time.Millisecond,
+			err:     gorm.ErrRecordNotFound,
+		},
+		"failed query": {
+			level:   "info",
+			elapsed: time.Millisecond,
+			err:     errors.New("DB Error"),
+			logged:  "error",
+			msg:     "query failed",
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			out := setup(t, logging.Config{Level: tc.level, Format: logging.FormatJSON})
+			ctx := logging.WithLogger(context.Background(), logrus.WithField("request_id", "req-123"))
+
+			logger := logging.NewGormLogger(500 * time.Millisecond)
+			logger.Trace(ctx, time.Now().Add(-tc.elapsed), func() (string, int64) {
+				return "SELECT 1", 1
+			}, tc.err)
+
+			entries := lines(t, out)
+			if tc.logged == "" {
+				assert.Empty(t, entries)
+				return
+			}
+			require.Len(t, entries, 1)
+			assert.Equal(t, tc.logged, entries[0]["level"])
+			assert.Equal(t, tc.msg, entries[0]["msg"])
+			assert.Equal(t, "SELECT 1", entries[0]["sql"])
+			assert.Equal(t, "req-123", entries[0]["request_id"])
+			assert.GreaterOrEqual(t, entries[0]["duration_ms"], float64(tc.elapsed/time.Millisecond))
+		})
+	}
+}
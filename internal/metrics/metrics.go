@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "video_server"
+
+// Registry holds every metric of the service, it is served on /metrics.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests and HTTPRequestDuration are labelled with the route
+	// pattern rather than the path, so file IDs don't multiply the series
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests answered, by method, route and status code.",
+	}, []string{"method", "route", "code"})
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time to answer HTTP requests, by method and route.",
+		// downloads and uploads run for minutes
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 300},
+	}, []string{"method", "route"})
+
+	// ReceivedBytes and SentBytes count request and response bodies, the
+	// upload and download routes make up most of them
+	ReceivedBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_received_bytes_total",
+		Help:      "Bytes read from request bodies, by method and route.",
+	}, []string{"method", "route"})
+	SentBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_sent_bytes_total",
+		Help:      "Bytes written to response bodies, by method and route.",
+	}, []string{"method", "route"})
+
+	// ActiveStreams counts downloads and streaming requests in flight
+	ActiveStreams = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_streams",
+		Help:      "Downloads and streaming requests in flight, by kind.",
+	}, []string{"kind"})
+
+	// UploadedFiles and UploadedBytes count the files stored, rejected ones
+	// are counted by UploadRejections with the code of their error
+	UploadedFiles = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "uploaded_files_total",
+		Help:      "Files accepted on upload.",
+	})
+	UploadedBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "uploaded_bytes_total",
+		Help:      "Bytes of the files accepted on upload.",
+	})
+	UploadRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upload_rejections_total",
+		Help:      "Uploads rejected, by error code.",
+	}, []string{"reason"})
+)
+
+// Kinds of ActiveStreams.
+const (
+	StreamDownload = "download"
+	StreamHLS      = "hls"
+	StreamDASH     = "dash"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		ReceivedBytes,
+		SentBytes,
+		ActiveStreams,
+		UploadedFiles,
+		UploadedBytes,
+		UploadRejections,
+	)
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// TenantUsage is what a tenant stores, the default tenant is empty.
+type TenantUsage struct {
+	Tenant string
+	Files  int64
+	Bytes  int64
+}
+
+var (
+	storedFilesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "stored_files"),
+		"Files stored, by tenant.",
+		[]string{"tenant"}, nil,
+	)
+	storedBytesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "stored_bytes"),
+		"Bytes of the files stored, by tenant.",
+		[]string{"tenant"}, nil,
+	)
+)
+
+type usageCollector struct {
+	list    func(ctx context.Context) ([]*TenantUsage, error)
+	timeout time.Duration
+}
+
+// NewUsageCollector reports what list returns on every scrape, a scrape
+// during which list fails or exceeds timeout goes without the series.
+func NewUsageCollector(list func(ctx context.Context) ([]*TenantUsage, error), timeout time.Duration) prometheus.Collector {
+	return &usageCollector{list: list, timeout: timeout}
+}
+
+func (c *usageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- storedFilesDesc
+	ch <- storedBytesDesc
+}
+
+func (c *usageCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	usages, err := c.list(ctx)
+	if err != nil {
+		logrus.WithError(err).Error("metrics: list usage")
+		return
+	}
+	for _, usage := range usages {
+		ch <- prometheus.MustNewConstMetric(storedFilesDesc, prometheus.GaugeValue, float64(usage.Files), usage.Tenant)
+		ch <- prometheus.MustNewConstMetric(storedBytesDesc, prometheus.GaugeValue, float64(usage.Bytes), usage.Tenant)
+	}
+}
@@ -0,0 +1,55 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"video-server/internal/metrics"
+)
+
+func TestUsageCollector(t *testing.T) {
+	testcases := map[string]struct {
+		usages   []*metrics.TenantUsage
+		err      error
+		expected string
+	}{
+		"tenants": {
+			usages: []*metrics.TenantUsage{
+				{Tenant: "", Files: 1, Bytes: 100},
+				{Tenant: "acme", Files: 2, Bytes: 300},
+			},
+			expected: `
+# HELP video_server_stored_bytes Bytes of the files stored, by tenant.
+# TYPE video_server_stored_bytes gauge
+video_server_stored_bytes{tenant=""} 100
+video_server_stored_bytes{tenant="acme"} 300
+# HELP video_server_stored_files Files stored, by tenant.
+# TYPE video_server_stored_files gauge
+video_server_stored_files{tenant=""} 1
+video_server_stored_files{tenant="acme"} 2
+`,
+		},
+		"list error": {
+			err:      errors.New("DB Error"),
+			expected: "",
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			collector := metrics.NewUsageCollector(func(ctx context.Context) ([]*metrics.TenantUsage, error) {
+				_, ok := ctx.Deadline()
+				assert.True(t, ok)
+				return tc.usages, tc.err
+			}, time.Second)
+
+			err := testutil.CollectAndCompare(collector, strings.NewReader(tc.expected))
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package mp4
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrInvalidBox = errors.New("mp4: invalid box")
+	ErrNoMovie    = errors.New("mp4: moov box not found")
+)
+
+// maxMovieSize bounds how much of a file is buffered for the moov box.
+const maxMovieSize = 64 << 20
+
+// Box is a parsed ISO BMFF box. Payload excludes the header, Raw includes it
+// so boxes can be copied verbatim into generated files.
+type Box struct {
+	Type    string
+	Offset  int64
+	Size    int64
+	Payload []byte
+	Raw     []byte
+}
+
+// Children parses the payload of a container box.
+func (b *Box) Children() ([]*Box, error) {
+	return ReadBoxes(b.Payload, b.Offset+int64(len(b.Raw)-len(b.Payload)))
+}
+
+// Child returns the first direct child of the given type or nil.
+func (b *Box) Child(typ string) *Box {
+	children, err := b.Children()
+	if err != nil {
+		return nil
+	}
+	for _, child := range children {
+		if child.Type == typ {
+			return child
+		}
+	}
+	return nil
+}
+
+// Path walks down nested container boxes, e.g. Path("mdia", "minf", "stbl").
+func (b *Box) Path(types ...string) *Box {
+	box := b
+	for _, typ := range types {
+		if box = box.Child(typ); box == nil {
+			return nil
+		}
+	}
+	return box
+}
+
+// ReadBoxes parses consecutive boxes held in memory, base is the file offset
+// of buf used for error messages.
+func ReadBoxes(buf []byte, base int64) ([]*Box, error) {
+	boxes := []*Box{}
+	for pos := 0; pos < len(buf); {
+		if len(buf)-pos < 8 {
+			return nil, fmt.Errorf("%w: %d trailing bytes at offset %d", ErrInvalidBox, len(buf)-pos, base+int64(pos))
+		}
+
+		size := int64(binary.BigEndian.Uint32(buf[pos:]))
+		typ := string(buf[pos+4 : pos+8])
+		header := 8
+		switch size {
+		case 0:
+			size = int64(len(buf) - pos)
+		case 1:
+			if len(buf)-pos < 16 {
+				return nil, fmt.Errorf("%w: truncated %q header at offset %d", ErrInvalidBox, typ, base+int64(pos))
+			}
+			size = int64(binary.BigEndian.Uint64(buf[pos+8:]))
+			header = 16
+		}
+		if size < int64(header) || size > int64(len(buf)-pos) {
+			return nil, fmt.Errorf("%w: %q at offset %d has size %d, %d bytes available", ErrInvalidBox, typ, base+int64(pos), size, len(buf)-pos)
+		}
+
+		end := pos + int(size)
+		boxes = append(boxes, &Box{
+			Type:    typ,
+			Offset:  base + int64(pos),
+			Size:    size,
+			Payload: buf[pos+header : end],
+			Raw:     buf[pos:end],
+		})
+		pos = end
+	}
+	return boxes, nil
+}
+
+// TopLevelBox is a top level box located in a file without its payload.
+type TopLevelBox struct {
+	Type       string
+	Offset     int64
+	Size       int64
+	HeaderSize int64
+}
+
+// ScanTopLevel lists the top level boxes of a file without reading payloads,
+// media data can be gigabytes.
+func ScanTopLevel(r io.ReaderAt, size int64) ([]*TopLevelBox, error) {
+	boxes := []*TopLevelBox{}
+	header := make([]byte, 16)
+	for offset := int64(0); offset < size; {
+		if size-offset < 8 {
+			return nil, fmt.Errorf("%w: %d trailing bytes at offset %d", ErrInvalidBox, size-offset, offset)
+		}
+		if _, err := r.ReadAt(header[:8], offset); err != nil {
+			return nil, err
+		}
+
+		boxSize := int64(binary.BigEndian.Uint32(header))
+		typ := string(header[4:8])
+		headerSize := int64(8)
+		switch boxSize {
+		case 0:
+			boxSize = size - offset
+		case 1:
+			if _, err := r.ReadAt(header[8:16], offset+8); err != nil {
+				return nil, err
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:]))
+			headerSize = 16
+		}
+		if boxSize < headerSize || boxSize > size-offset {
+			return nil, fmt.Errorf("%w: %q at offset %d has size %d, %d bytes available", ErrInvalidBox, typ, offset, boxSize, size-offset)
+		}
+
+		boxes = append(boxes, &TopLevelBox{
+			Type:       typ,
+			Offset:     offset,
+			Size:       boxSize,
+			HeaderSize: headerSize,
+		})
+		offset += boxSize
+	}
+	return boxes, nil
+}
+
+// ReadBox loads a top level box, including payload, into memory.
+func ReadBox(r io.ReaderAt, top *TopLevelBox) (*Box, error) {
+	if top.Size > maxMovieSize {
+		return nil, fmt.Errorf("%w: %q box of %d bytes is too large", ErrInvalidBox, top.Type, top.Size)
+	}
+
+	raw := make([]byte, top.Size)
+	if _, err := r.ReadAt(raw, top.Offset); err != nil {
+		return nil, err
+	}
+	return &Box{
+		Type:    top.Type,
+		Offset:  top.Offset,
+		Size:    top.Size,
+		Payload: raw[top.HeaderSize:],
+		Raw:     raw,
+	}, nil
+}
+
+// fullBox splits the version and flags off a full box payload.
+func fullBox(b *Box) (uint8, uint32, []byte, error) {
+	if len(b.Payload) < 4 {
+		return 0, 0, nil, fmt.Errorf("%w: %q at offset %d is too short", ErrInvalidBox, b.Type, b.Offset)
+	}
+	version := b.Payload[0]
+	flags := binary.BigEndian.Uint32(b.Payload) & 0x00ffffff
+	return version, flags, b.Payload[4:], nil
+}
@@ -0,0 +1,219 @@
+package mp4
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// sampleEntryChildren returns the boxes nested in a sample entry, which start
+// after the fixed visual or audio sample entry fields.
+func sampleEntryChildren(entry *Box, handler string) []*Box {
+	offset := 0
+	switch handler {
+	case HandlerVideo:
+		offset = 78
+	case HandlerAudio:
+		offset = 28
+		if len(entry.Payload) >= 10 {
+			// QuickTime sound sample description versions 1 and 2
+			switch binary.BigEndian.Uint16(entry.Payload[8:]) {
+			case 1:
+				offset += 16
+			case 2:
+				offset += 36
+			}
+		}
+	default:
+		return nil
+	}
+	if len(entry.Payload) < offset {
+		return nil
+	}
+
+	children, err := ReadBoxes(entry.Payload[offset:], entry.Offset+8+int64(offset))
+	if err != nil {
+		return nil
+	}
+	return children
+}
+
+func findBox(boxes []*Box, typ string) *Box {
+	for _, box := range boxes {
+		if box.Type == typ {
+			return box
+		}
+	}
+	return nil
+}
+
+// codecString builds the RFC 6381 codec parameter used in HLS and DASH
+// manifests, falling back to the sample entry type.
+func codecString(entry *Box, handler string) string {
+	children := sampleEntryChildren(entry, handler)
+
+	switch entry.Type {
+	case "avc1", "avc2", "avc3", "avc4":
+		if avcC := findBox(children, "avcC"); avcC != nil && len(avcC.Payload) >= 4 {
+			return fmt.Sprintf("%s.%02x%02x%02x", entry.Type, avcC.Payload[1], avcC.Payload[2], avcC.Payload[3])
+		}
+	case "hvc1", "hev1":
+		if hvcC := findBox(children, "hvcC"); hvcC != nil && len(hvcC.Payload) >= 13 {
+			return hevcCodecString(entry.Type, hvcC.Payload)
+		}
+	case "av01":
+		if av1C := findBox(children, "av1C"); av1C != nil && len(av1C.Payload) >= 3 {
+			profile := av1C.Payload[1] >> 5
+			level := av1C.Payload[1] & 0x1f
+			tier := "M"
+			if av1C.Payload[2]&0x80 != 0 {
+				tier = "H"
+			}
+			depth := 8
+			if av1C.Payload[2]&0x40 != 0 {
+				depth = 10
+				if av1C.Payload[2]&0x20 != 0 {
+					depth = 12
+				}
+			}
+			return fmt.Sprintf("av01.%d.%02d%s.%02d", profile, level, tier, depth)
+		}
+	case "vp08", "vp09":
+		if vpcC := findBox(children, "vpcC"); vpcC != nil && len(vpcC.Payload) >= 7 {
+			// full box: version and flags precede the profile
+			return fmt.Sprintf("%s.%02d.%02d.%02d", entry.Type, vpcC.Payload[4], vpcC.Payload[5], vpcC.Payload[6]>>4)
+		}
+	case "mp4a":
+		if esds := findBox(children, "esds"); esds != nil {
+			if codec := mp4aCodecString(esds); codec != "" {
+				return codec
+			}
+		}
+		return "mp4a.40.2"
+	case "Opus":
+		return "opus"
+	case "fLaC":
+		return "flac"
+	}
+
+	return strings.TrimSpace(entry.Type)
+}
+
+func hevcCodecString(typ string, hvcC []byte) string {
+	profileSpace := []string{"", "A", "B", "C"}[hvcC[1]>>6]
+	tier := "L"
+	if hvcC[1]&0x20 != 0 {
+		tier = "H"
+	}
+	profile := hvcC[1] & 0x1f
+
+	// compatibility flags are written in reverse bit order
+	compat := binary.BigEndian.Uint32(hvcC[2:])
+	var reversed uint32
+	for i := 0; i < 32; i++ {
+		reversed = reversed<<1 | compat&1
+		compat >>= 1
+	}
+
+	codec := fmt.Sprintf("%s.%s%d.%X.%s%d", typ, profileSpace, profile, reversed, tier, hvcC[12])
+
+	constraints := hvcC[6:12]
+	last := len(constraints)
+	for last > 0 && constraints[last-1] == 0 {
+		last--
+	}
+	for _, b := range constraints[:last] {
+		codec += fmt.Sprintf(".%X", b)
+	}
+	return codec
+}
+
+// mp4aCodecString reads the object type and audio object type from the
+// ES descriptor, e.g. mp4a.40.2 for AAC-LC.
+func mp4aCodecString(esds *Box) string {
+	_, _, data, err := fullBox(esds)
+	if err != nil {
+		return ""
+	}
+
+	tag, body := readDescriptor(data)
+	if tag != 0x03 || len(body) < 3 {
+		return ""
+	}
+	flags := body[2]
+	body = body[3:]
+	if flags&0x80 != 0 {
+		body = skipBytes(body, 2)
+	}
+	if flags&0x40 != 0 && len(body) > 0 {
+		body = skipBytes(body, 1+int(body[0]))
+	}
+	if flags&0x20 != 0 {
+		body = skipBytes(body, 2)
+	}
+
+	tag, body = readDescriptor(body)
+	if tag != 0x04 || len(body) < 13 {
+		return ""
+	}
+	objectType := body[0]
+	if objectType != 0x40 {
+		return fmt.Sprintf("mp4a.%02x", objectType)
+	}
+
+	tag, body = readDescriptor(body[13:])
+	if tag != 0x05 || len(body) < 1 {
+		return "mp4a.40"
+	}
+	audioObjectType := int(body[0] >> 3)
+	if audioObjectType == 31 && len(body) >= 2 {
+		audioObjectType = 32 + int(body[0]&0x07)<<3 | int(body[1]>>5)
+	}
+	return fmt.Sprintf("mp4a.40.%d", audioObjectType)
+}
+
+// readDescriptor parses an MPEG-4 descriptor tag and its variable length
+// size, returning the tag and its body.
+func readDescriptor(data []byte) (byte, []byte) {
+	if len(data) < 2 {
+		return 0, nil
+	}
+	tag := data[0]
+	size := 0
+	i := 1
+	for ; i < len(data) && i <= 4; i++ {
+		size = size<<7 | int(data[i]&0x7f)
+		if data[i]&0x80 == 0 {
+			i++
+			break
+		}
+	}
+	if i+size > len(data) {
+		return tag, data[i:]
+	}
+	return tag, data[i : i+size]
+}
+
+func skipBytes(data []byte, n int) []byte {
+	if n > len(data) {
+		return nil
+	}
+	return data[n:]
+}
+
+// ConfigBox returns a box nested in the track's sample entry, such as the
+// avcC decoder configuration, or nil.
+func (t *Track) ConfigBox(typ string) *Box {
+	if t.trak == nil {
+		return nil
+	}
+	stsd := t.trak.Path("mdia", "minf", "stbl", "stsd")
+	if stsd == nil {
+		return nil
+	}
+	entry, err := sampleEntry(stsd)
+	if err != nil {
+		return nil
+	}
+	return findBox(sampleEntryChildren(entry, t.Handler), typ)
+}
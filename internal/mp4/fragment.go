@@ -0,0 +1,357 @@
+package mp4
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+var ErrNoSamples = errors.New("mp4: no samples to fragment")
+
+const (
+	sampleFlagsSync    = 0x02000000 // sample_depends_on = 2
+	sampleFlagsNonSync = 0x01010000 // sample_depends_on = 1, is_non_sync_sample
+
+	trunFlags = 0x000001 | 0x000100 | 0x000200 | 0x000400 | 0x000800
+)
+
+// Segment is a slice of the movie that starts at a sync sample of the
+// reference (video) track, every track contributes the samples decoded in
+// the same time span.
+type Segment struct {
+	Index    int
+	Start    float64
+	Duration float64
+
+	samples map[uint32][2]int
+}
+
+// SampleRange returns the [first, last) sample indexes of a track.
+func (s *Segment) SampleRange(trackID uint32) (int, int) {
+	r := s.samples[trackID]
+	return r[0], r[1]
+}
+
+// Timing returns the decode time of the first sample of a track in the
+// segment and the summed duration of its samples, in the track timescale.
+// ok is false when the track has no samples in the segment.
+func (s *Segment) Timing(track *Track) (start uint64, duration uint64, ok bool) {
+	first, last := s.SampleRange(track.ID)
+	if first >= last {
+		return 0, 0, false
+	}
+	for _, sample := range track.Samples[first:last] {
+		duration += uint64(sample.Duration)
+	}
+	return track.Samples[first].DTS, duration, true
+}
+
+// Bytes is the media size of the segment for the given tracks, all tracks
+// when none are given.
+func (s *Segment) Bytes(m *Movie, trackIDs ...uint32) int64 {
+	var total int64
+	for _, track := range m.selectTracks(trackIDs) {
+		first, last := s.SampleRange(track.ID)
+		for _, sample := range track.Samples[first:last] {
+			total += int64(sample.Size)
+		}
+	}
+	return total
+}
+
+// Segments splits the movie at sync samples of the reference track, each
+// segment being at least target seconds long except the last.
+func (m *Movie) Segments(target float64) []*Segment {
+	reference := m.referenceTrack()
+	if reference == nil || len(reference.Samples) == 0 {
+		return nil
+	}
+
+	// segment start times in seconds
+	base := reference.Samples[0].DTS
+	starts := []float64{0}
+	last := 0.0
+	for _, sample := range reference.Samples[1:] {
+		t := float64(sample.DTS-base) / float64(reference.Timescale)
+		if sample.Sync && t-last >= target {
+			starts = append(starts, t)
+			last = t
+		}
+	}
+	end := reference.DurationSeconds()
+
+	segments := make([]*Segment, len(starts))
+	for i, start := range starts {
+		segEnd := end
+		if i+1 < len(starts) {
+			segEnd = starts[i+1]
+		}
+		segments[i] = &Segment{
+			Index:    i,
+			Start:    start,
+			Duration: segEnd - start,
+			samples:  map[uint32][2]int{},
+		}
+	}
+
+	for _, track := range m.Tracks {
+		if len(track.Samples) == 0 {
+			continue
+		}
+		trackBase := track.Samples[0].DTS
+		next := 0
+		for i, segment := range segments {
+			first := next
+			if i+1 == len(segments) {
+				next = len(track.Samples)
+			} else {
+				limit := segments[i+1].Start
+				for next < len(track.Samples) && float64(track.Samples[next].DTS-trackBase)/float64(track.Timescale) < limit {
+					next++
+				}
+			}
+			segment.samples[track.ID] = [2]int{first, next}
+		}
+	}
+
+	return segments
+}
+
+// TargetDuration is the longest segment duration rounded up, as required by
+// EXT-X-TARGETDURATION.
+func TargetDuration(segments []*Segment) int {
+	target := 0
+	for _, segment := range segments {
+		if d := int(math.Ceil(segment.Duration)); d > target {
+			target = d
+		}
+	}
+	return target
+}
+
+// referenceTrack drives segmentation, video when present so segments start
+// on key frames.
+func (m *Movie) referenceTrack() *Track {
+	if video := m.VideoTrack(); video != nil && len(video.Samples) > 0 {
+		return video
+	}
+	for _, track := range m.Tracks {
+		if len(track.Samples) > 0 {
+			return track
+		}
+	}
+	return nil
+}
+
+func (m *Movie) selectTracks(trackIDs []uint32) []*Track {
+	if len(trackIDs) == 0 {
+		return m.Tracks
+	}
+	tracks := []*Track{}
+	for _, id := range trackIDs {
+		if track := m.Track(id); track != nil {
+			tracks = append(tracks, track)
+		}
+	}
+	return tracks
+}
+
+// WriteInit writes a CMAF initialization segment (ftyp + moov with mvex)
+// for the given tracks, all tracks when none are given.
+func (m *Movie) WriteInit(w io.Writer, trackIDs ...uint32) error {
+	tracks := m.selectTracks(trackIDs)
+	if len(tracks) == 0 {
+		return ErrNoSamples
+	}
+
+	var nextTrackID uint32
+	traks := [][]byte{}
+	trexs := [][]byte{}
+	for _, track := range tracks {
+		trak, err := initTrak(track)
+		if err != nil {
+			return err
+		}
+		traks = append(traks, trak)
+		trexs = append(trexs, makeFullBox("trex", 0, 0, (&buffer{}).
+			u32(track.ID). // track_ID
+			u32(1).        // default_sample_description_index
+			u32(0).        // default_sample_duration
+			u32(0).        // default_sample_size
+			u32(0).        // default_sample_flags
+			data))
+		if track.ID >= nextTrackID {
+			nextTrackID = track.ID + 1
+		}
+	}
+
+	moov := [][]byte{makeMvhd(m.Timescale, nextTrackID)}
+	moov = append(moov, traks...)
+	moov = append(moov, makeBox("mvex", trexs...))
+
+	_, err := w.Write(append(
+		makeFtyp("ftyp", "iso6", 0, "iso6", "iso5", "cmfc", "mp41"),
+		makeBox("moov", moov...)...,
+	))
+	return err
+}
+
+func makeMvhd(timescale uint32, nextTrackID uint32) []byte {
+	b := (&buffer{}).
+		u32(0).          // creation_time
+		u32(0).          // modification_time
+		u32(timescale).  // timescale
+		u32(0).          // duration, unknown for fragmented files
+		u32(0x00010000). // rate 1.0
+		u16(0x0100).     // volume 1.0
+		zeros(10)
+	writeUnityMatrix(b)
+	return makeFullBox("mvhd", 0, 0, b.zeros(24).u32(nextTrackID).data)
+}
+
+func writeUnityMatrix(b *buffer) {
+	b.u32(0x00010000).u32(0).u32(0)
+	b.u32(0).u32(0x00010000).u32(0)
+	b.u32(0).u32(0).u32(0x40000000)
+}
+
+// initTrak rebuilds a trak with an empty sample table, reusing the source
+// header and sample description boxes verbatim.
+func initTrak(track *Track) ([]byte, error) {
+	tkhd := track.trak.Child("tkhd")
+	mdia := track.trak.Child("mdia")
+	if tkhd == nil || mdia == nil {
+		return nil, missingBox(track.trak, "tkhd/mdia")
+	}
+	mdhd, hdlr, minf := mdia.Child("mdhd"), mdia.Child("hdlr"), mdia.Child("minf")
+	if mdhd == nil || hdlr == nil || minf == nil {
+		return nil, missingBox(mdia, "mdhd/hdlr/minf")
+	}
+	stsd := minf.Path("stbl", "stsd")
+	if stsd == nil {
+		return nil, missingBox(minf, "stbl/stsd")
+	}
+
+	minfChildren := [][]byte{}
+	for _, typ := range []string{"vmhd", "smhd", "sthd", "nmhd"} {
+		if header := minf.Child(typ); header != nil {
+			minfChildren = append(minfChildren, header.Raw)
+		}
+	}
+	minfChildren = append(minfChildren,
+		makeBox("dinf", makeFullBox("dref", 0, 0, (&buffer{}).u32(1).data, makeFullBox("url ", 0, 1))),
+		makeBox("stbl",
+			stsd.Raw,
+			makeFullBox("stts", 0, 0, (&buffer{}).u32(0).data),
+			makeFullBox("stsc", 0, 0, (&buffer{}).u32(0).data),
+			makeFullBox("stsz", 0, 0, (&buffer{}).u32(0).u32(0).data),
+			makeFullBox("stco", 0, 0, (&buffer{}).u32(0).data),
+		),
+	)
+
+	trak := [][]byte{tkhd.Raw}
+	if edts := track.trak.Child("edts"); edts != nil {
+		trak = append(trak, edts.Raw)
+	}
+	trak = append(trak, makeBox("mdia", mdhd.Raw, hdlr.Raw, makeBox("minf", minfChildren...)))
+
+	return makeBox("trak", trak...), nil
+}
+
+type trackFragment struct {
+	track   *Track
+	samples []Sample
+	size    int64
+}
+
+// WriteSegment writes a CMAF media segment (styp + moof + mdat), reading the
+// sample data from r. sequence numbers the moof and must increase.
+func (m *Movie) WriteSegment(w io.Writer, r io.ReaderAt, segment *Segment, sequence uint32, trackIDs ...uint32) error {
+	fragments := []*trackFragment{}
+	var dataSize int64
+	for _, track := range m.selectTracks(trackIDs) {
+		first, last := segment.SampleRange(track.ID)
+		if first >= last {
+			continue
+		}
+		fragment := &trackFragment{track: track, samples: track.Samples[first:last]}
+		for _, sample := range fragment.samples {
+			fragment.size += int64(sample.Size)
+		}
+		dataSize += fragment.size
+		fragments = append(fragments, fragment)
+	}
+	if len(fragments) == 0 {
+		return ErrNoSamples
+	}
+
+	mdatHeader := (&buffer{}).u32(uint32(dataSize + 8)).str("mdat").data
+	if dataSize+8 > math.MaxUint32 {
+		mdatHeader = (&buffer{}).u32(1).str("mdat").u64(uint64(dataSize + 16)).data
+	}
+
+	// the moof size only depends on sample counts, build it once with zero
+	// data offsets to learn it, then again with the real offsets
+	moof := makeMoof(fragments, sequence, 0)
+	moof = makeMoof(fragments, sequence, int64(len(moof)+len(mdatHeader)))
+
+	styp := makeFtyp("styp", "msdh", 0, "msdh", "msix")
+	if _, err := w.Write(append(append(styp, moof...), mdatHeader...)); err != nil {
+		return err
+	}
+
+	for _, fragment := range fragments {
+		if err := copySamples(w, r, fragment.samples); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func makeMoof(fragments []*trackFragment, sequence uint32, dataOffset int64) []byte {
+	trafs := [][]byte{makeFullBox("mfhd", 0, 0, (&buffer{}).u32(sequence).data)}
+	for _, fragment := range fragments {
+		trun := (&buffer{}).u32(uint32(len(fragment.samples))).u32(uint32(dataOffset))
+		for _, sample := range fragment.samples {
+			flags := uint32(sampleFlagsNonSync)
+			if sample.Sync {
+				flags = sampleFlagsSync
+			}
+			trun.u32(sample.Duration).u32(sample.Size).u32(flags).u32(uint32(sample.CTSOffset))
+		}
+
+		trafs = append(trafs, makeBox("traf",
+			// default-base-is-moof, data offsets are relative to the moof
+			makeFullBox("tfhd", 0, 0x020000, (&buffer{}).u32(fragment.track.ID).data),
+			makeFullBox("tfdt", 1, 0, (&buffer{}).u64(fragment.samples[0].DTS).data),
+			makeFullBox("trun", 1, trunFlags, trun.data),
+		))
+		dataOffset += fragment.size
+	}
+	return makeBox("moof", trafs...)
+}
+
+// copySamples copies sample data, merging samples stored back to back into
+// a single read.
+func copySamples(w io.Writer, r io.ReaderAt, samples []Sample) error {
+	for i := 0; i < len(samples); {
+		start := samples[i].Offset
+		end := start + int64(samples[i].Size)
+		j := i + 1
+		for j < len(samples) && samples[j].Offset == end {
+			end += int64(samples[j].Size)
+			j++
+		}
+
+		n, err := io.Copy(w, io.NewSectionReader(r, start, end-start))
+		if err != nil {
+			return err
+		}
+		if n != end-start {
+			return fmt.Errorf("mp4: sample data at offset %d truncated, read %d of %d bytes", start, n, end-start)
+		}
+		i = j
+	}
+	return nil
+}
@@ -0,0 +1,539 @@
+package mp4
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	HandlerVideo = "vide"
+	HandlerAudio = "soun"
+)
+
+type Sample struct {
+	Offset    int64
+	Size      uint32
+	DTS       uint64
+	Duration  uint32
+	CTSOffset int32
+	Sync      bool
+}
+
+type Track struct {
+	ID        uint32
+	Handler   string
+	Timescale uint32
+	Duration  uint64
+	Format    string
+	Codec     string
+
+	// video
+	Width  uint16
+	Height uint16
+
+	// audio
+	Channels   uint16
+	SampleRate uint32
+
+	Samples []Sample
+
+	trak *Box
+}
+
+func (t *Track) IsVideo() bool {
+	return t.Handler == HandlerVideo
+}
+
+func (t *Track) IsAudio() bool {
+	return t.Handler == HandlerAudio
+}
+
+// DurationSeconds prefers the sample table over mdhd, which some muxers
+// leave at zero.
+func (t *Track) DurationSeconds() float64 {
+	if t.Timescale == 0 {
+		return 0
+	}
+	if n := len(t.Samples); n > 0 {
+		last := t.Samples[n-1]
+		return float64(last.DTS+uint64(last.Duration)-t.Samples[0].DTS) / float64(t.Timescale)
+	}
+	return float64(t.Duration) / float64(t.Timescale)
+}
+
+// Bytes is the total size of the track's media samples.
+func (t *Track) Bytes() int64 {
+	var total int64
+	for _, sample := range t.Samples {
+		total += int64(sample.Size)
+	}
+	return total
+}
+
+// FrameRate is the average sample rate of a video track.
+func (t *Track) FrameRate() float64 {
+	duration := t.DurationSeconds()
+	if !t.IsVideo() || duration == 0 {
+		return 0
+	}
+	return float64(len(t.Samples)) / duration
+}
+
+type Movie struct {
+	MajorBrand string
+	Timescale  uint32
+	Duration   uint64
+	Tracks     []*Track
+
+	// Boxes lists the top level boxes in file order.
+	Boxes []*TopLevelBox
+}
+
+func (m *Movie) DurationSeconds() float64 {
+	var duration float64
+	for _, track := range m.Tracks {
+		if d := track.DurationSeconds(); d > duration {
+			duration = d
+		}
+	}
+	if duration == 0 && m.Timescale > 0 {
+		duration = float64(m.Duration) / float64(m.Timescale)
+	}
+	return duration
+}
+
+func (m *Movie) Track(id uint32) *Track {
+	for _, track := range m.Tracks {
+		if track.ID == id {
+			return track
+		}
+	}
+	return nil
+}
+
+// VideoTrack returns the first video track or nil.
+func (m *Movie) VideoTrack() *Track {
+	for _, track := range m.Tracks {
+		if track.IsVideo() {
+			return track
+		}
+	}
+	return nil
+}
+
+// Parse reads the movie header and sample tables of an MP4/MOV file. Media
+// data is not read, samples reference it by offset.
+func Parse(r io.ReaderAt, size int64) (*Movie, error) {
+	boxes, err := ScanTopLevel(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	movie := &Movie{Boxes: boxes}
+	var moov *Box
+	for _, top := range boxes {
+		switch top.Type {
+		case "ftyp":
+			ftyp, err := ReadBox(r, top)
+			if err != nil {
+				return nil, err
+			}
+			if len(ftyp.Payload) >= 4 {
+				movie.MajorBrand = string(ftyp.Payload[:4])
+			}
+		case "moov":
+			if moov, err = ReadBox(r, top); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if moov == nil {
+		return nil, ErrNoMovie
+	}
+
+	children, err := moov.Children()
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range children {
+		switch child.Type {
+		case "mvhd":
+			if err := parseMvhd(child, movie); err != nil {
+				return nil, err
+			}
+		case "trak":
+			track, err := parseTrak(child)
+			if err != nil {
+				return nil, err
+			}
+			for i, sample := range track.Samples {
+				if sample.Offset+int64(sample.Size) > size {
+					return nil, fmt.Errorf("%w: sample %d of track %d ends at %d, past the end of the file (%d bytes)", ErrInvalidBox, i+1, track.ID, sample.Offset+int64(sample.Size), size)
+				}
+			}
+			movie.Tracks = append(movie.Tracks, track)
+		}
+	}
+
+	return movie, nil
+}
+
+func parseMvhd(b *Box, movie *Movie) error {
+	version, _, data, err := fullBox(b)
+	if err != nil {
+		return err
+	}
+
+	if version == 1 {
+		if len(data) < 28 {
+			return shortBox(b)
+		}
+		movie.Timescale = binary.BigEndian.Uint32(data[16:])
+		movie.Duration = binary.BigEndian.Uint64(data[20:])
+		return nil
+	}
+	if len(data) < 16 {
+		return shortBox(b)
+	}
+	movie.Timescale = binary.BigEndian.Uint32(data[8:])
+	movie.Duration = uint64(binary.BigEndian.Uint32(data[12:]))
+	return nil
+}
+
+func parseTrak(trak *Box) (*Track, error) {
+	track := &Track{trak: trak}
+
+	tkhd := trak.Child("tkhd")
+	if tkhd == nil {
+		return nil, missingBox(trak, "tkhd")
+	}
+	version, _, data, err := fullBox(tkhd)
+	if err != nil {
+		return nil, err
+	}
+	idOffset := 8
+	if version == 1 {
+		idOffset = 16
+	}
+	if len(data) < idOffset+4 {
+		return nil, shortBox(tkhd)
+	}
+	track.ID = binary.BigEndian.Uint32(data[idOffset:])
+
+	mdhd := trak.Path("mdia", "mdhd")
+	if mdhd == nil {
+		return nil, missingBox(trak, "mdia/mdhd")
+	}
+	version, _, data, err = fullBox(mdhd)
+	if err != nil {
+		return nil, err
+	}
+	if version == 1 {
+		if len(data) < 28 {
+			return nil, shortBox(mdhd)
+		}
+		track.Timescale = binary.BigEndian.Uint32(data[16:])
+		track.Duration = binary.BigEndian.Uint64(data[20:])
+	} else {
+		if len(data) < 16 {
+			return nil, shortBox(mdhd)
+		}
+		track.Timescale = binary.BigEndian.Uint32(data[8:])
+		track.Duration = uint64(binary.BigEndian.Uint32(data[12:]))
+	}
+	if track.Timescale == 0 {
+		return nil, fmt.Errorf("%w: track %d has a zero timescale", ErrInvalidBox, track.ID)
+	}
+
+	hdlr := trak.Path("mdia", "hdlr")
+	if hdlr == nil {
+		return nil, missingBox(trak, "mdia/hdlr")
+	}
+	_, _, data, err = fullBox(hdlr)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 8 {
+		return nil, shortBox(hdlr)
+	}
+	track.Handler = string(data[4:8])
+
+	stbl := trak.Path("mdia", "minf", "stbl")
+	if stbl == nil {
+		return nil, missingBox(trak, "mdia/minf/stbl")
+	}
+	if err := parseSampleDescription(stbl, track); err != nil {
+		return nil, err
+	}
+	if err := parseSampleTable(stbl, track); err != nil {
+		return nil, fmt.Errorf("track %d: %w", track.ID, err)
+	}
+
+	return track, nil
+}
+
+func parseSampleDescription(stbl *Box, track *Track) error {
+	stsd := stbl.Child("stsd")
+	if stsd == nil {
+		return missingBox(stbl, "stsd")
+	}
+	entry, err := sampleEntry(stsd)
+	if err != nil {
+		return err
+	}
+
+	track.Format = entry.Type
+	switch track.Handler {
+	case HandlerVideo:
+		if len(entry.Payload) < 78 {
+			return shortBox(entry)
+		}
+		track.Width = binary.BigEndian.Uint16(entry.Payload[24:])
+		track.Height = binary.BigEndian.Uint16(entry.Payload[26:])
+	case HandlerAudio:
+		if len(entry.Payload) < 28 {
+			return shortBox(entry)
+		}
+		track.Channels = binary.BigEndian.Uint16(entry.Payload[16:])
+		track.SampleRate = binary.BigEndian.Uint32(entry.Payload[24:]) >> 16
+	}
+	track.Codec = codecString(entry, track.Handler)
+
+	return nil
+}
+
+// sampleEntry returns the first entry of an stsd box.
+func sampleEntry(stsd *Box) (*Box, error) {
+	_, _, data, err := fullBox(stsd)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 4 {
+		return nil, shortBox(stsd)
+	}
+
+	entries, err := ReadBoxes(data[4:], stsd.Offset+16)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: stsd at offset %d has no sample entry", ErrInvalidBox, stsd.Offset)
+	}
+	return entries[0], nil
+}
+
+func parseSampleTable(stbl *Box, track *Track) error {
+	sizes, err := parseStsz(stbl)
+	if err != nil {
+		return err
+	}
+	count := len(sizes)
+	track.Samples = make([]Sample, count)
+	for i, size := range sizes {
+		track.Samples[i].Size = size
+	}
+
+	// decoding times
+	stts := stbl.Child("stts")
+	if stts == nil {
+		return missingBox(stbl, "stts")
+	}
+	entries, err := tableEntries(stts, 8)
+	if err != nil {
+		return err
+	}
+	var dts uint64
+	i := 0
+	for _, entry := range entries {
+		n := binary.BigEndian.Uint32(entry)
+		delta := binary.BigEndian.Uint32(entry[4:])
+		for j := uint32(0); j < n; j++ {
+			if i >= count {
+				return fmt.Errorf("%w: stts describes more samples than stsz (%d)", ErrInvalidBox, count)
+			}
+			track.Samples[i].DTS = dts
+			track.Samples[i].Duration = delta
+			dts += uint64(delta)
+			i++
+		}
+	}
+	if i != count {
+		return fmt.Errorf("%w: stts describes %d samples, stsz %d", ErrInvalidBox, i, count)
+	}
+
+	// composition offsets
+	if ctts := stbl.Child("ctts"); ctts != nil {
+		entries, err := tableEntries(ctts, 8)
+		if err != nil {
+			return err
+		}
+		i := 0
+		for _, entry := range entries {
+			n := binary.BigEndian.Uint32(entry)
+			offset := int32(binary.BigEndian.Uint32(entry[4:]))
+			for j := uint32(0); j < n; j++ {
+				if i >= count {
+					return fmt.Errorf("%w: ctts describes more samples than stsz (%d)", ErrInvalidBox, count)
+				}
+				track.Samples[i].CTSOffset = offset
+				i++
+			}
+		}
+	}
+
+	// sync samples, every sample is a sync sample when stss is absent
+	if stss := stbl.Child("stss"); stss != nil {
+		entries, err := tableEntries(stss, 4)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			n := binary.BigEndian.Uint32(entry)
+			if n == 0 || int(n) > count {
+				return fmt.Errorf("%w: stss references sample %d of %d", ErrInvalidBox, n, count)
+			}
+			track.Samples[n-1].Sync = true
+		}
+	} else {
+		for i := range track.Samples {
+			track.Samples[i].Sync = true
+		}
+	}
+
+	return parseChunks(stbl, track)
+}
+
+func parseStsz(stbl *Box) ([]uint32, error) {
+	if stsz := stbl.Child("stsz"); stsz != nil {
+		_, _, data, err := fullBox(stsz)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) < 8 {
+			return nil, shortBox(stsz)
+		}
+		uniform := binary.BigEndian.Uint32(data)
+		count := binary.BigEndian.Uint32(data[4:])
+		sizes := make([]uint32, 0, minInt(int(count), len(data)))
+		if uniform != 0 {
+			if count > maxMovieSize {
+				return nil, fmt.Errorf("%w: stsz declares %d samples", ErrInvalidBox, count)
+			}
+			for i := uint32(0); i < count; i++ {
+				sizes = append(sizes, uniform)
+			}
+			return sizes, nil
+		}
+		if uint64(len(data)-8) < uint64(count)*4 {
+			return nil, fmt.Errorf("%w: stsz declares %d samples but holds %d", ErrInvalidBox, count, (len(data)-8)/4)
+		}
+		for i := uint32(0); i < count; i++ {
+			sizes = append(sizes, binary.BigEndian.Uint32(data[8+4*i:]))
+		}
+		return sizes, nil
+	}
+
+	return nil, missingBox(stbl, "stsz")
+}
+
+func parseChunks(stbl *Box, track *Track) error {
+	var offsets []int64
+	if stco := stbl.Child("stco"); stco != nil {
+		entries, err := tableEntries(stco, 4)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			offsets = append(offsets, int64(binary.BigEndian.Uint32(entry)))
+		}
+	} else if co64 := stbl.Child("co64"); co64 != nil {
+		entries, err := tableEntries(co64, 8)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			offsets = append(offsets, int64(binary.BigEndian.Uint64(entry)))
+		}
+	} else {
+		return missingBox(stbl, "stco")
+	}
+
+	stsc := stbl.Child("stsc")
+	if stsc == nil {
+		return missingBox(stbl, "stsc")
+	}
+	entries, err := tableEntries(stsc, 12)
+	if err != nil {
+		return err
+	}
+
+	sample := 0
+	for i, entry := range entries {
+		firstChunk := int(binary.BigEndian.Uint32(entry))
+		perChunk := int(binary.BigEndian.Uint32(entry[4:]))
+		lastChunk := len(offsets)
+		if i+1 < len(entries) {
+			lastChunk = int(binary.BigEndian.Uint32(entries[i+1])) - 1
+		}
+		if firstChunk < 1 || lastChunk > len(offsets) || firstChunk > lastChunk+1 {
+			return fmt.Errorf("%w: stsc entry %d references chunks %d-%d of %d", ErrInvalidBox, i+1, firstChunk, lastChunk, len(offsets))
+		}
+
+		for chunk := firstChunk; chunk <= lastChunk; chunk++ {
+			offset := offsets[chunk-1]
+			for j := 0; j < perChunk; j++ {
+				if sample >= len(track.Samples) {
+					return fmt.Errorf("%w: stsc describes more samples than stsz (%d)", ErrInvalidBox, len(track.Samples))
+				}
+				track.Samples[sample].Offset = offset
+				offset += int64(track.Samples[sample].Size)
+				sample++
+			}
+		}
+	}
+	if sample != len(track.Samples) {
+		return fmt.Errorf("%w: stsc describes %d samples, stsz %d", ErrInvalidBox, sample, len(track.Samples))
+	}
+
+	return nil
+}
+
+// tableEntries splits the fixed size entries of a full box that starts with
+// an entry count.
+func tableEntries(b *Box, entrySize int) ([][]byte, error) {
+	_, _, data, err := fullBox(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 4 {
+		return nil, shortBox(b)
+	}
+
+	count := binary.BigEndian.Uint32(data)
+	if uint64(len(data)-4) < uint64(count)*uint64(entrySize) {
+		return nil, fmt.Errorf("%w: %q at offset %d declares %d entries but holds %d", ErrInvalidBox, b.Type, b.Offset, count, (len(data)-4)/entrySize)
+	}
+
+	entries := make([][]byte, count)
+	for i := range entries {
+		start := 4 + i*entrySize
+		entries[i] = data[start : start+entrySize]
+	}
+	return entries, nil
+}
+
+func missingBox(parent *Box, typ string) error {
+	return fmt.Errorf("%w: %q at offset %d has no %q box", ErrInvalidBox, parent.Type, parent.Offset, typ)
+}
+
+func shortBox(b *Box) error {
+	return fmt.Errorf("%w: %q at offset %d is too short (%d bytes)", ErrInvalidBox, b.Type, b.Offset, b.Size)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,208 @@
+package mp4_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"video-server/internal/mp4"
+)
+
+const samplePath = "../../test/post_1/sample.mp4"
+
+func openSample(t *testing.T) (*os.File, *mp4.Movie) {
+	f, err := os.Open(samplePath)
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() })
+
+	info, err := f.Stat()
+	require.NoError(t, err)
+
+	movie, err := mp4.Parse(f, info.Size())
+	require.NoError(t, err)
+	return f, movie
+}
+
+func TestParse(t *testing.T) {
+	_, movie := openSample(t)
+
+	assert.InDelta(t, 5.76, movie.DurationSeconds(), 0.05)
+	require.Len(t, movie.Tracks, 2)
+
+	video := movie.VideoTrack()
+	require.NotNil(t, video)
+	assert.Equal(t, "avc1.640028", video.Codec)
+	assert.Equal(t, uint16(1920), video.Width)
+	assert.Equal(t, uint16(1080), video.Height)
+	assert.Len(t, video.Samples, 171)
+	assert.True(t, video.Samples[0].Sync)
+
+	audio := movie.Track(2)
+	require.NotNil(t, audio)
+	assert.True(t, audio.IsAudio())
+	assert.Equal(t, "mp4a.40.2", audio.Codec)
+	assert.Equal(t, uint32(44100), audio.SampleRate)
+	assert.Equal(t, uint16(2), audio.Channels)
+	assert.Len(t, audio.Samples, 248)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: []byte{}},
+		{name: "text", data: []byte("hello, this is not a movie")},
+		{name: "truncated box", data: []byte{0, 0, 0, 64, 'f', 't', 'y', 'p', 'i', 's', 'o', 'm'}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := mp4.Parse(bytes.NewReader(tc.data), int64(len(tc.data)))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMovie_Segments(t *testing.T) {
+	_, movie := openSample(t)
+
+	// the sample has a single key frame so it can't be split
+	segments := movie.Segments(1)
+	require.Len(t, segments, 1)
+	assert.Equal(t, 6, mp4.TargetDuration(segments))
+
+	first, last := segments[0].SampleRange(1)
+	assert.Equal(t, 0, first)
+	assert.Equal(t, 171, last)
+	first, last = segments[0].SampleRange(2)
+	assert.Equal(t, 0, first)
+	assert.Equal(t, 248, last)
+	assert.Equal(t, movie.VideoTrack().Bytes()+movie.Track(2).Bytes(), segments[0].Bytes(movie))
+}
+
+func TestMovie_WriteInit(t *testing.T) {
+	_, movie := openSample(t)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, movie.WriteInit(buf))
+
+	init, err := mp4.Parse(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	assert.Equal(t, "iso6", init.MajorBrand)
+	require.Len(t, init.Tracks, 2)
+	assert.Equal(t, "avc1.640028", init.Tracks[0].Codec)
+	assert.Equal(t, "mp4a.40.2", init.Tracks[1].Codec)
+	assert.Empty(t, init.Tracks[0].Samples)
+
+	buf.Reset()
+	require.NoError(t, movie.WriteInit(buf, 2))
+	init, err = mp4.Parse(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, init.Tracks, 1)
+	assert.True(t, init.Tracks[0].IsAudio())
+}
+
+func TestMovie_WriteSegment(t *testing.T) {
+	f, movie := openSample(t)
+	segments := movie.Segments(6)
+	require.NotEmpty(t, segments)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, movie.WriteSegment(buf, f, segments[0], 1))
+
+	boxes, err := mp4.ReadBoxes(buf.Bytes(), 0)
+	require.NoError(t, err)
+	require.Len(t, boxes, 3)
+	assert.Equal(t, "styp", boxes[0].Type)
+	assert.Equal(t, "moof", boxes[1].Type)
+	assert.Equal(t, "mdat", boxes[2].Type)
+	assert.Equal(t, segments[0].Bytes(movie), int64(len(boxes[2].Payload)))
+
+	trafs := 0
+	children, err := boxes[1].Children()
+	require.NoError(t, err)
+	for _, child := range children {
+		if child.Type == "traf" {
+			trafs++
+			assert.NotNil(t, child.Child("trun"))
+		}
+	}
+	assert.Equal(t, 2, trafs)
+
+	// the first video sample is copied right after the mdat header
+	video := movie.VideoTrack().Samples[0]
+	expected := make([]byte, video.Size)
+	_, err = f.ReadAt(expected, video.Offset)
+	require.NoError(t, err)
+	assert.Equal(t, expected, boxes[2].Payload[:video.Size])
+}
+
+func TestMovie_SegmentsSplitAtKeyFrames(t *testing.T) {
+	samples := func(n int, duration uint32, syncEvery int) []mp4.Sample {
+		out := make([]mp4.Sample, n)
+		for i := range out {
+			out[i] = mp4.Sample{
+				Offset:   int64(i * 10),
+				Size:     10,
+				DTS:      uint64(i) * uint64(duration),
+				Duration: duration,
+				Sync:     syncEvery > 0 && i%syncEvery == 0,
+			}
+		}
+		return out
+	}
+
+	// 20s of 25fps video with a key frame every 2s, 20s of 1s audio packets
+	movie := &mp4.Movie{
+		Timescale: 1000,
+		Tracks: []*mp4.Track{
+			{ID: 1, Handler: mp4.HandlerVideo, Timescale: 25, Samples: samples(500, 1, 50)},
+			{ID: 2, Handler: mp4.HandlerAudio, Timescale: 1, Samples: samples(20, 1, 1)},
+		},
+	}
+
+	segments := movie.Segments(5)
+	require.Len(t, segments, 4)
+	for i, start := range []float64{0, 6, 12, 18} {
+		assert.Equal(t, start, segments[i].Start)
+	}
+	assert.Equal(t, 2.0, segments[3].Duration)
+	assert.Equal(t, 6, mp4.TargetDuration(segments))
+
+	first, last := segments[1].SampleRange(1)
+	assert.Equal(t, 150, first)
+	assert.Equal(t, 300, last)
+	first, last = segments[1].SampleRange(2)
+	assert.Equal(t, 6, first)
+	assert.Equal(t, 12, last)
+}
+
+func TestSegment_Timing(t *testing.T) {
+	_, movie := openSample(t)
+	segments := movie.Segments(6)
+	require.Len(t, segments, 1)
+
+	video := movie.VideoTrack()
+	start, duration, ok := segments[0].Timing(video)
+	assert.True(t, ok)
+	assert.Equal(t, video.Samples[0].DTS, start)
+	last := video.Samples[len(video.Samples)-1]
+	assert.Equal(t, last.DTS+uint64(last.Duration)-start, duration)
+
+	_, _, ok = segments[0].Timing(&mp4.Track{ID: 9})
+	assert.False(t, ok)
+}
+
+func TestTrack_ConfigBox(t *testing.T) {
+	_, movie := openSample(t)
+
+	video := movie.VideoTrack()
+	avcC := video.ConfigBox("avcC")
+	require.NotNil(t, avcC)
+	assert.Equal(t, []byte{0x01, 0x64, 0x00, 0x28}, avcC.Payload[:4])
+	assert.Nil(t, video.ConfigBox("hvcC"))
+}
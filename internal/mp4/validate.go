@@ -0,0 +1,79 @@
+package mp4
+
+import (
+	"fmt"
+)
+
+// containerBoxes hold only boxes, their children are checked recursively.
+var containerBoxes = map[string]bool{
+	"trak": true,
+	"mdia": true,
+	"minf": true,
+	"stbl": true,
+	"edts": true,
+	"dinf": true,
+}
+
+// Validate checks what Parse leaves out: the sizes of every nested box of
+// the tracks and that each sample lies inside a media data box.
+func (m *Movie) Validate() error {
+	if len(m.Tracks) == 0 {
+		return fmt.Errorf("%w: movie has no tracks", ErrInvalidBox)
+	}
+
+	fragmented := false
+	mdats := []*TopLevelBox{}
+	for _, box := range m.Boxes {
+		switch box.Type {
+		case "moof":
+			fragmented = true
+		case "mdat":
+			mdats = append(mdats, box)
+		}
+	}
+
+	samples := 0
+	for _, track := range m.Tracks {
+		if err := validateChildren(track.trak); err != nil {
+			return fmt.Errorf("track %d: %w", track.ID, err)
+		}
+
+		for i, sample := range track.Samples {
+			if !insideMediaData(mdats, sample) {
+				return fmt.Errorf("%w: sample %d of track %d (%d bytes at offset %d) is outside the media data", ErrInvalidBox, i+1, track.ID, sample.Size, sample.Offset)
+			}
+		}
+		samples += len(track.Samples)
+	}
+
+	// fragmented files carry their samples in moof boxes
+	if samples == 0 && !fragmented {
+		return fmt.Errorf("%w: movie has no samples", ErrInvalidBox)
+	}
+	return nil
+}
+
+func validateChildren(box *Box) error {
+	children, err := box.Children()
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if containerBoxes[child.Type] {
+			if err := validateChildren(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func insideMediaData(mdats []*TopLevelBox, sample Sample) bool {
+	end := sample.Offset + int64(sample.Size)
+	for _, mdat := range mdats {
+		if sample.Offset >= mdat.Offset+mdat.HeaderSize && end <= mdat.Offset+mdat.Size {
+			return true
+		}
+	}
+	return false
+}
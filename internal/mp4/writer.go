@@ -0,0 +1,69 @@
+package mp4
+
+import "encoding/binary"
+
+// buffer accumulates big endian box payloads.
+type buffer struct {
+	data []byte
+}
+
+func (b *buffer) u8(v uint8) *buffer {
+	b.data = append(b.data, v)
+	return b
+}
+
+func (b *buffer) u16(v uint16) *buffer {
+	b.data = binary.BigEndian.AppendUint16(b.data, v)
+	return b
+}
+
+func (b *buffer) u32(v uint32) *buffer {
+	b.data = binary.BigEndian.AppendUint32(b.data, v)
+	return b
+}
+
+func (b *buffer) u64(v uint64) *buffer {
+	b.data = binary.BigEndian.AppendUint64(b.data, v)
+	return b
+}
+
+func (b *buffer) str(v string) *buffer {
+	b.data = append(b.data, v...)
+	return b
+}
+
+func (b *buffer) raw(v ...[]byte) *buffer {
+	for _, p := range v {
+		b.data = append(b.data, p...)
+	}
+	return b
+}
+
+func (b *buffer) zeros(n int) *buffer {
+	b.data = append(b.data, make([]byte, n)...)
+	return b
+}
+
+// makeBox wraps payloads in a box header.
+func makeBox(typ string, payloads ...[]byte) []byte {
+	size := 8
+	for _, p := range payloads {
+		size += len(p)
+	}
+	b := &buffer{data: make([]byte, 0, size)}
+	return b.u32(uint32(size)).str(typ).raw(payloads...).data
+}
+
+// makeFullBox wraps payloads in a full box header.
+func makeFullBox(typ string, version uint8, flags uint32, payloads ...[]byte) []byte {
+	header := (&buffer{}).u32(uint32(version)<<24 | flags&0x00ffffff).data
+	return makeBox(typ, append([][]byte{header}, payloads...)...)
+}
+
+func makeFtyp(typ string, major string, minor uint32, compatible ...string) []byte {
+	b := (&buffer{}).str(major).u32(minor)
+	for _, brand := range compatible {
+		b.str(brand)
+	}
+	return makeBox(typ, b.data)
+}
@@ -0,0 +1,196 @@
+package probe
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const nalTypeSPS = 7
+
+type spsInfo struct {
+	profile     byte
+	constraints byte
+	level       byte
+	width       int
+	height      int
+}
+
+// codec is the RFC 6381 avc1 codec string.
+func (s spsInfo) codec() string {
+	return fmt.Sprintf("avc1.%02x%02x%02x", s.profile, s.constraints, s.level)
+}
+
+// findNALUnit returns the first NAL unit of the given type in an Annex B
+// byte stream, with emulation prevention bytes removed.
+func findNALUnit(data []byte, nalType byte) []byte {
+	startCode := []byte{0, 0, 1}
+	for {
+		i := bytes.Index(data, startCode)
+		if i < 0 || i+3 >= len(data) {
+			return nil
+		}
+		data = data[i+3:]
+		if data[0]&0x1F != nalType {
+			continue
+		}
+
+		end := bytes.Index(data, startCode)
+		if end < 0 {
+			end = len(data)
+		}
+		return unescapeRBSP(data[:end])
+	}
+}
+
+func unescapeRBSP(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	zeros := 0
+	for _, b := range data {
+		if zeros >= 2 && b == 0x03 {
+			zeros = 0
+			continue
+		}
+		out = append(out, b)
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+	}
+	return out
+}
+
+type bitReader struct {
+	data []byte
+	pos  int
+	err  bool
+}
+
+func (r *bitReader) bit() uint {
+	if r.pos >= len(r.data)*8 {
+		r.err = true
+		return 0
+	}
+	b := r.data[r.pos/8] >> (7 - r.pos%8) & 1
+	r.pos++
+	return uint(b)
+}
+
+func (r *bitReader) bits(n int) uint {
+	var v uint
+	for i := 0; i < n; i++ {
+		v = v<<1 | r.bit()
+	}
+	return v
+}
+
+// ue reads an unsigned Exp-Golomb code.
+func (r *bitReader) ue() uint {
+	zeros := 0
+	for r.bit() == 0 {
+		if r.err || zeros > 31 {
+			r.err = true
+			return 0
+		}
+		zeros++
+	}
+	return 1<<zeros - 1 + r.bits(zeros)
+}
+
+// se reads a signed Exp-Golomb code.
+func (r *bitReader) se() int {
+	v := r.ue()
+	if v%2 == 1 {
+		return int(v+1) / 2
+	}
+	return -int(v / 2)
+}
+
+// parseSPS reads the profile, level and coded picture size of an H.264
+// sequence parameter set, ITU-T H.264 section 7.3.2.1.1.
+func parseSPS(nal []byte) (spsInfo, bool) {
+	if len(nal) < 4 {
+		return spsInfo{}, false
+	}
+	info := spsInfo{profile: nal[1], constraints: nal[2], level: nal[3]}
+	r := &bitReader{data: nal[4:]}
+
+	r.ue() // seq_parameter_set_id
+	chromaFormat := uint(1)
+	switch info.profile {
+	case 100, 110, 122, 244, 44, 83, 86, 118, 128, 138, 139, 134, 135:
+		chromaFormat = r.ue()
+		if chromaFormat == 3 {
+			r.bit() // separate_colour_plane_flag
+		}
+		r.ue()  // bit_depth_luma_minus8
+		r.ue()  // bit_depth_chroma_minus8
+		r.bit() // qpprime_y_zero_transform_bypass_flag
+		if r.bit() == 1 {
+			lists := 8
+			if chromaFormat == 3 {
+				lists = 12
+			}
+			for i := 0; i < lists; i++ {
+				if r.bit() == 0 {
+					continue
+				}
+				size := 16
+				if i >= 6 {
+					size = 64
+				}
+				last, next := 8, 8
+				for j := 0; j < size && next != 0; j++ {
+					next = (last + r.se() + 256) % 256
+					if next != 0 {
+						last = next
+					}
+				}
+			}
+		}
+	}
+
+	r.ue() // log2_max_frame_num_minus4
+	switch r.ue() {
+	case 0:
+		r.ue() // log2_max_pic_order_cnt_lsb_minus4
+	case 1:
+		r.bit() // delta_pic_order_always_zero_flag
+		r.se()  // offset_for_non_ref_pic
+		r.se()  // offset_for_top_to_bottom_field
+		cycle := r.ue()
+		for i := uint(0); i < cycle && !r.err; i++ {
+			r.se()
+		}
+	}
+	r.ue()  // max_num_ref_frames
+	r.bit() // gaps_in_frame_num_value_allowed_flag
+
+	widthInMbs := r.ue() + 1
+	heightInMapUnits := r.ue() + 1
+	frameMbsOnly := r.bit()
+	if frameMbsOnly == 0 {
+		r.bit() // mb_adaptive_frame_field_flag
+	}
+	r.bit() // direct_8x8_inference_flag
+
+	var cropLeft, cropRight, cropTop, cropBottom uint
+	if r.bit() == 1 {
+		cropLeft, cropRight, cropTop, cropBottom = r.ue(), r.ue(), r.ue(), r.ue()
+	}
+	if r.err {
+		return spsInfo{}, false
+	}
+
+	cropUnitX, cropUnitY := uint(1), 2-frameMbsOnly
+	switch chromaFormat {
+	case 1:
+		cropUnitX, cropUnitY = 2, 2*(2-frameMbsOnly)
+	case 2:
+		cropUnitX = 2
+	}
+
+	info.width = int(widthInMbs*16 - cropUnitX*(cropLeft+cropRight))
+	info.height = int((2-frameMbsOnly)*heightInMapUnits*16 - cropUnitY*(cropTop+cropBottom))
+	return info, true
+}
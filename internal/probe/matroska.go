@@ -0,0 +1,510 @@
+package probe
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Matroska element IDs, see https://www.matroska.org/technical/elements.html
+const (
+	idEBML          = 0x1A45DFA3
+	idDocType       = 0x4282
+	idSegment       = 0x18538067
+	idInfo          = 0x1549A966
+	idTimecodeScale = 0x2AD7B1
+	idDuration      = 0x4489
+	idTracks        = 0x1654AE6B
+	idTrackEntry    = 0xAE
+	idTrackNumber   = 0xD7
+	idTrackType     = 0x83
+	idCodecID       = 0x86
+	idCodecPrivate  = 0x63A2
+	idDefaultDur    = 0x23E383
+	idVideo         = 0xE0
+	idPixelWidth    = 0xB0
+	idPixelHeight   = 0xBA
+	idAudio         = 0xE1
+	idCluster       = 0x1F43B675
+	idTimecode      = 0xE7
+	idSimpleBlock   = 0xA3
+	idBlockGroup    = 0xA0
+	idBlock         = 0xA1
+
+	trackTypeVideo = 1
+	trackTypeAudio = 2
+
+	// maxElementSize bounds the header elements read into memory
+	maxElementSize = 16 << 20
+
+	unknownSize = -1
+)
+
+var ebmlMagic = []byte{0x1A, 0x45, 0xDF, 0xA3}
+
+// clusterChildren are the elements allowed in a cluster.
+var clusterChildren = map[uint32]bool{
+	idTimecode:    true,
+	idSimpleBlock: true,
+	idBlockGroup:  true,
+	0xA7:          true, // Position
+	0xAB:          true, // PrevSize
+	0x5854:        true, // SilentTracks
+	0xAF:          true, // EncryptedBlock
+	0xEC:          true, // Void
+	0xBF:          true, // CRC-32
+}
+
+type element struct {
+	id     uint32
+	offset int64 // start of the header
+	data   int64 // start of the payload
+	size   int64 // payload size, unknownSize for live streams
+}
+
+type node struct {
+	id   uint32
+	data []byte
+}
+
+func probeMatroska(r io.ReaderAt, size int64, deep bool) (*Metadata, error) {
+	header, err := readElement(r, 0, size)
+	if err != nil {
+		return nil, err
+	}
+	if header.id != idEBML {
+		return nil, fmt.Errorf("%w: missing EBML header", ErrInvalidFile)
+	}
+	headerData, err := readPayload(r, header)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := &Metadata{Container: ContainerMatroska}
+	nodes, err := parseNodes(headerData)
+	if err != nil {
+		return nil, err
+	}
+	if docType := findNode(nodes, idDocType); docType != nil && string(docType.data) == "webm" {
+		metadata.Container = ContainerWebM
+	}
+
+	segment, err := readElement(r, header.data+header.size, size)
+	if err != nil {
+		return nil, err
+	}
+	if segment.id != idSegment {
+		return nil, fmt.Errorf("%w: missing Segment element", ErrInvalidFile)
+	}
+	segmentEnd := size
+	if segment.size != unknownSize {
+		segmentEnd = segment.data + segment.size
+	}
+
+	var timecodeScale uint64 = 1000000
+	var duration float64
+	var lastCluster uint64
+	var tracks map[uint64]bool
+	clusters := 0
+
+	for offset := segment.data; offset < segmentEnd; {
+		child, err := readElement(r, offset, segmentEnd)
+		if err != nil {
+			return nil, err
+		}
+		next := child.data + child.size
+
+		switch child.id {
+		case idInfo:
+			data, err := readPayload(r, child)
+			if err != nil {
+				return nil, err
+			}
+			timecodeScale, duration, err = parseInfo(data)
+			if err != nil {
+				return nil, err
+			}
+		case idTracks:
+			data, err := readPayload(r, child)
+			if err != nil {
+				return nil, err
+			}
+			if tracks, err = parseTracks(data, metadata); err != nil {
+				return nil, err
+			}
+		case idCluster:
+			// the headers are enough unless validating or Info lacks a
+			// duration
+			if !deep && duration > 0 && tracks != nil {
+				next = segmentEnd
+				break
+			}
+			if tracks == nil {
+				return nil, fmt.Errorf("%w: cluster at offset %d precedes the Tracks element", ErrInvalidFile, child.offset)
+			}
+			var timecode uint64
+			next, timecode, err = walkCluster(r, child, segmentEnd, deep, tracks)
+			if err != nil {
+				return nil, err
+			}
+			if timecode > lastCluster {
+				lastCluster = timecode
+			}
+			clusters++
+		default:
+			if child.size == unknownSize {
+				return nil, fmt.Errorf("%w: element 0x%X at offset %d has an unknown size", ErrInvalidFile, child.id, child.offset)
+			}
+		}
+
+		offset = next
+	}
+
+	if tracks == nil {
+		return nil, fmt.Errorf("%w: missing Tracks element", ErrInvalidFile)
+	}
+	if deep && clusters == 0 {
+		return nil, fmt.Errorf("%w: segment has no clusters", ErrInvalidFile)
+	}
+	if duration == 0 {
+		duration = float64(lastCluster)
+	}
+	metadata.Duration = duration * float64(timecodeScale) / 1e9
+
+	return metadata, nil
+}
+
+// walkCluster finds the end of a cluster and reads its timecode. Clusters of
+// live recordings have an unknown size and end where an element that can't
+// be a cluster child starts. deep also checks every block references a
+// declared track.
+func walkCluster(r io.ReaderAt, cluster element, end int64, deep bool, tracks map[uint64]bool) (int64, uint64, error) {
+	clusterEnd := end
+	if cluster.size != unknownSize {
+		clusterEnd = cluster.data + cluster.size
+	}
+
+	var timecode uint64
+	hasTimecode := false
+	for offset := cluster.data; offset < clusterEnd; {
+		child, err := readElement(r, offset, clusterEnd)
+		if err != nil {
+			return 0, 0, err
+		}
+		if !clusterChildren[child.id] {
+			if cluster.size != unknownSize {
+				return 0, 0, fmt.Errorf("%w: unexpected element 0x%X in cluster at offset %d", ErrInvalidFile, child.id, cluster.offset)
+			}
+			clusterEnd = offset
+			break
+		}
+		if child.size == unknownSize {
+			return 0, 0, fmt.Errorf("%w: element 0x%X at offset %d has an unknown size", ErrInvalidFile, child.id, child.offset)
+		}
+
+		switch child.id {
+		case idTimecode:
+			data, err := readPayload(r, child)
+			if err != nil {
+				return 0, 0, err
+			}
+			timecode, hasTimecode = readUint(data), true
+		case idSimpleBlock, idBlockGroup:
+			if deep {
+				if err := checkBlock(r, child, tracks); err != nil {
+					return 0, 0, err
+				}
+			}
+		}
+
+		// a known size cluster only needs its timecode, its first child
+		if !deep && hasTimecode && cluster.size != unknownSize {
+			break
+		}
+		offset = child.data + child.size
+	}
+
+	if !hasTimecode {
+		return 0, 0, fmt.Errorf("%w: cluster at offset %d has no timecode", ErrInvalidFile, cluster.offset)
+	}
+	return clusterEnd, timecode, nil
+}
+
+// checkBlock verifies a block starts with the number of a declared track.
+func checkBlock(r io.ReaderAt, block element, tracks map[uint64]bool) error {
+	offset := block.data
+	if block.id == idBlockGroup {
+		data, err := readPayload(r, block)
+		if err != nil {
+			return err
+		}
+		children, err := parseNodes(data)
+		if err != nil {
+			return fmt.Errorf("block group at offset %d: %w", block.offset, err)
+		}
+		inner := findNode(children, idBlock)
+		if inner == nil {
+			return fmt.Errorf("%w: block group at offset %d has no block", ErrInvalidFile, block.offset)
+		}
+		return checkBlockTrack(inner.data, block.offset, tracks)
+	}
+
+	header := make([]byte, minInt64(block.size, 8))
+	if _, err := r.ReadAt(header, offset); err != nil {
+		return fmt.Errorf("%w: block at offset %d is truncated", ErrInvalidFile, block.offset)
+	}
+	return checkBlockTrack(header, block.offset, tracks)
+}
+
+func checkBlockTrack(data []byte, offset int64, tracks map[uint64]bool) error {
+	track, length, ok := readVint(data, false)
+	// track number, then a 16 bit timecode and flags
+	if !ok || len(data) < length+3 {
+		return fmt.Errorf("%w: block at offset %d is too short", ErrInvalidFile, offset)
+	}
+	if !tracks[track] {
+		return fmt.Errorf("%w: block at offset %d references undeclared track %d", ErrInvalidFile, offset, track)
+	}
+	return nil
+}
+
+func parseInfo(data []byte) (uint64, float64, error) {
+	nodes, err := parseNodes(data)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var scale uint64 = 1000000
+	if n := findNode(nodes, idTimecodeScale); n != nil {
+		scale = readUint(n.data)
+	}
+	var duration float64
+	if n := findNode(nodes, idDuration); n != nil {
+		duration = readFloat(n.data)
+	}
+	return scale, duration, nil
+}
+
+// parseTracks fills the codec information and returns the declared track
+// numbers.
+func parseTracks(data []byte, metadata *Metadata) (map[uint64]bool, error) {
+	entries, err := parseNodes(data)
+	if err != nil {
+		return nil, err
+	}
+
+	tracks := map[uint64]bool{}
+	for _, entry := range entries {
+		if entry.id != idTrackEntry {
+			continue
+		}
+		nodes, err := parseNodes(entry.data)
+		if err != nil {
+			return nil, err
+		}
+		metadata.Tracks++
+		if n := findNode(nodes, idTrackNumber); n != nil {
+			tracks[readUint(n.data)] = true
+		}
+
+		var trackType uint64
+		if n := findNode(nodes, idTrackType); n != nil {
+			trackType = readUint(n.data)
+		}
+		var codecID string
+		if n := findNode(nodes, idCodecID); n != nil {
+			codecID = strings.TrimRight(string(n.data), "\x00")
+		}
+		var codecPrivate []byte
+		if n := findNode(nodes, idCodecPrivate); n != nil {
+			codecPrivate = n.data
+		}
+
+		switch {
+		case trackType == trackTypeVideo && metadata.VideoCodec == "":
+			metadata.VideoCodec = matroskaCodec(codecID, codecPrivate)
+			if n := findNode(nodes, idDefaultDur); n != nil {
+				if frameDuration := readUint(n.data); frameDuration > 0 {
+					metadata.FrameRate = 1e9 / float64(frameDuration)
+				}
+			}
+			if video := findNode(nodes, idVideo); video != nil {
+				settings, err := parseNodes(video.data)
+				if err != nil {
+					return nil, err
+				}
+				if n := findNode(settings, idPixelWidth); n != nil {
+					metadata.Width = int(readUint(n.data))
+				}
+				if n := findNode(settings, idPixelHeight); n != nil {
+					metadata.Height = int(readUint(n.data))
+				}
+			}
+		case trackType == trackTypeAudio && metadata.AudioCodec == "":
+			metadata.AudioCodec = matroskaCodec(codecID, codecPrivate)
+		}
+	}
+
+	if len(tracks) == 0 {
+		return nil, fmt.Errorf("%w: no tracks declared", ErrInvalidFile)
+	}
+	return tracks, nil
+}
+
+// matroskaCodec maps a Matroska codec ID to the codec string used for the
+// same codec in MP4 files.
+func matroskaCodec(codecID string, private []byte) string {
+	switch codecID {
+	case "V_MPEG4/ISO/AVC":
+		if len(private) >= 4 {
+			return fmt.Sprintf("avc1.%02x%02x%02x", private[1], private[2], private[3])
+		}
+		return "avc1"
+	case "V_MPEGH/ISO/HEVC":
+		return "hvc1"
+	case "V_AV1":
+		return "av01"
+	case "V_VP8":
+		return "vp8"
+	case "V_VP9":
+		return "vp9"
+	case "A_AAC":
+		if len(private) >= 1 {
+			return fmt.Sprintf("mp4a.40.%d", private[0]>>3)
+		}
+		return "mp4a.40.2"
+	case "A_OPUS":
+		return "opus"
+	case "A_VORBIS":
+		return "vorbis"
+	case "A_FLAC":
+		return "flac"
+	case "A_MPEG/L3":
+		return "mp4a.6b"
+	case "A_AC3":
+		return "ac-3"
+	case "A_EAC3":
+		return "ec-3"
+	}
+	return strings.ToLower(codecID)
+}
+
+// readElement reads the element header at offset, an element ID then a
+// data size, both EBML variable size integers.
+func readElement(r io.ReaderAt, offset int64, end int64) (element, error) {
+	buf := make([]byte, 12)
+	n, err := r.ReadAt(buf, offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return element{}, err
+	}
+	if int64(n) > end-offset {
+		n = int(end - offset)
+	}
+
+	id, idLen, ok := readVint(buf[:n], true)
+	if !ok {
+		return element{}, fmt.Errorf("%w: bad element ID at offset %d", ErrInvalidFile, offset)
+	}
+	size, sizeLen, ok := readVint(buf[idLen:n], false)
+	if !ok {
+		return element{}, fmt.Errorf("%w: bad element size at offset %d", ErrInvalidFile, offset)
+	}
+
+	el := element{id: uint32(id), offset: offset, data: offset + int64(idLen+sizeLen), size: int64(size)}
+	if size == math.MaxUint64 {
+		el.size = unknownSize
+	} else if el.size > end-el.data {
+		return element{}, fmt.Errorf("%w: element 0x%X at offset %d is %d bytes, past the end of its parent", ErrInvalidFile, el.id, offset, el.size)
+	}
+	return el, nil
+}
+
+func readPayload(r io.ReaderAt, el element) ([]byte, error) {
+	if el.size == unknownSize || el.size > maxElementSize {
+		return nil, fmt.Errorf("%w: element 0x%X at offset %d is too large", ErrInvalidFile, el.id, el.offset)
+	}
+	buf := make([]byte, el.size)
+	if _, err := r.ReadAt(buf, el.data); err != nil {
+		return nil, fmt.Errorf("%w: element 0x%X at offset %d is truncated", ErrInvalidFile, el.id, el.offset)
+	}
+	return buf, nil
+}
+
+// parseNodes splits a master element payload into its children.
+func parseNodes(data []byte) ([]node, error) {
+	nodes := []node{}
+	for len(data) > 0 {
+		id, idLen, ok := readVint(data, true)
+		if !ok {
+			return nil, fmt.Errorf("%w: bad element ID", ErrInvalidFile)
+		}
+		size, sizeLen, ok := readVint(data[idLen:], false)
+		if !ok || size > uint64(len(data)-idLen-sizeLen) {
+			return nil, fmt.Errorf("%w: element 0x%X overflows its parent", ErrInvalidFile, id)
+		}
+		start := idLen + sizeLen
+		nodes = append(nodes, node{id: uint32(id), data: data[start : start+int(size)]})
+		data = data[start+int(size):]
+	}
+	return nodes, nil
+}
+
+func findNode(nodes []node, id uint32) *node {
+	for i := range nodes {
+		if nodes[i].id == id {
+			return &nodes[i]
+		}
+	}
+	return nil
+}
+
+// readVint decodes an EBML variable size integer. IDs keep their length
+// marker bit, sizes drop it and return MaxUint64 when all value bits are set
+// (unknown size).
+func readVint(data []byte, keepMarker bool) (uint64, int, bool) {
+	if len(data) == 0 || data[0] == 0 {
+		return 0, 0, false
+	}
+	length := 1
+	for mask := byte(0x80); data[0]&mask == 0; mask >>= 1 {
+		length++
+	}
+	if length > 8 || (keepMarker && length > 4) || len(data) < length {
+		return 0, 0, false
+	}
+
+	value := uint64(data[0])
+	if !keepMarker {
+		value &= uint64(0xFF >> length)
+	}
+	allOnes := value == uint64(0xFF>>length)
+	for _, b := range data[1:length] {
+		value = value<<8 | uint64(b)
+		allOnes = allOnes && b == 0xFF
+	}
+	if !keepMarker && allOnes {
+		return math.MaxUint64, length, true
+	}
+	return value, length, true
+}
+
+func readUint(data []byte) uint64 {
+	var value uint64
+	for _, b := range data {
+		value = value<<8 | uint64(b)
+	}
+	return value
+}
+
+func readFloat(data []byte) float64 {
+	switch len(data) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(data))
+	}
+	return 0
+}
@@ -0,0 +1,45 @@
+package probe
+
+import (
+	"io"
+
+	"video-server/internal/mp4"
+)
+
+// quickTimeBrand is the major brand of QuickTime movies.
+const quickTimeBrand = "qt  "
+
+func probeMP4(r io.ReaderAt, size int64, deep bool) (*Metadata, error) {
+	movie, err := mp4.Parse(r, size)
+	if err != nil {
+		return nil, err
+	}
+	if deep {
+		if err := movie.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	metadata := &Metadata{
+		Container: ContainerMP4,
+		Duration:  movie.DurationSeconds(),
+		Tracks:    len(movie.Tracks),
+	}
+	if movie.MajorBrand == quickTimeBrand {
+		metadata.Container = ContainerMOV
+	}
+
+	for _, track := range movie.Tracks {
+		switch {
+		case track.IsVideo() && metadata.VideoCodec == "":
+			metadata.VideoCodec = track.Codec
+			metadata.Width = int(track.Width)
+			metadata.Height = int(track.Height)
+			metadata.FrameRate = track.FrameRate()
+		case track.IsAudio() && metadata.AudioCodec == "":
+			metadata.AudioCodec = track.Codec
+		}
+	}
+
+	return metadata, nil
+}
@@ -0,0 +1,397 @@
+package probe
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+const (
+	tsPacketSize            = 188
+	tsTimestampedPacketSize = 192 // M2TS, a 4 byte timestamp precedes each packet
+	tsSyncByte              = 0x47
+
+	// tsWindow is how much of the head and of the tail of the file is
+	// scanned, enough for the tables and a few seconds of timestamps
+	tsWindow = 4 << 20
+
+	ptsClock = 90000
+	ptsWrap  = 1 << 33
+
+	pidPAT = 0
+)
+
+// PMT stream types, ISO/IEC 13818-1 table 2-34 and ATSC A/52.
+const (
+	streamTypeMPEG1Video = 0x01
+	streamTypeMPEG2Video = 0x02
+	streamTypeMPEG1Audio = 0x03
+	streamTypeMPEG2Audio = 0x04
+	streamTypeAAC        = 0x0F
+	streamTypeH264       = 0x1B
+	streamTypeHEVC       = 0x24
+	streamTypeAC3        = 0x81
+	streamTypeEAC3       = 0x87
+)
+
+// tsPacketLength returns the packet size when head looks like a transport
+// stream, 0 otherwise.
+func tsPacketLength(head []byte) int {
+	for _, size := range []int{tsPacketSize, tsTimestampedPacketSize} {
+		start := size - tsPacketSize
+		if len(head) >= start+2*size+1 && head[start] == tsSyncByte && head[start+size] == tsSyncByte && head[start+2*size] == tsSyncByte {
+			return size
+		}
+	}
+	return 0
+}
+
+type tsStream struct {
+	pid        uint16
+	streamType byte
+
+	pts     []uint64
+	payload []byte
+}
+
+func (s *tsStream) isVideo() bool {
+	switch s.streamType {
+	case streamTypeMPEG1Video, streamTypeMPEG2Video, streamTypeH264, streamTypeHEVC:
+		return true
+	}
+	return false
+}
+
+func (s *tsStream) isAudio() bool {
+	switch s.streamType {
+	case streamTypeMPEG1Audio, streamTypeMPEG2Audio, streamTypeAAC, streamTypeAC3, streamTypeEAC3:
+		return true
+	}
+	return false
+}
+
+type tsDemuxer struct {
+	packetSize int
+	pmtPID     int
+	streams    map[uint16]*tsStream
+	order      []uint16
+}
+
+func probeMPEGTS(r io.ReaderAt, size int64, packetSize int, deep bool) (*Metadata, error) {
+	if deep {
+		if err := checkPackets(r, size, packetSize); err != nil {
+			return nil, err
+		}
+	}
+
+	head, err := readWindow(r, 0, minInt64(size, tsWindow), packetSize)
+	if err != nil {
+		return nil, err
+	}
+
+	demuxer := &tsDemuxer{packetSize: packetSize, pmtPID: -1, streams: map[uint16]*tsStream{}}
+	demuxer.scan(head, true)
+	if len(demuxer.streams) == 0 {
+		return nil, fmt.Errorf("%w: no program map table in the first %d bytes", ErrInvalidFile, len(head))
+	}
+
+	metadata := &Metadata{Container: ContainerMPEGTS, Tracks: len(demuxer.streams)}
+	if deep {
+		for _, pid := range demuxer.order {
+			stream := demuxer.streams[pid]
+			if (stream.isVideo() || stream.isAudio()) && len(stream.pts) == 0 {
+				return nil, fmt.Errorf("%w: stream %d declared in the program map has no timestamped packets", ErrInvalidFile, pid)
+			}
+		}
+	}
+
+	var video, audio, timed *tsStream
+	for _, pid := range demuxer.order {
+		stream := demuxer.streams[pid]
+		if stream.isVideo() && video == nil {
+			video = stream
+		}
+		if stream.isAudio() && audio == nil {
+			audio = stream
+		}
+	}
+	if video != nil {
+		metadata.VideoCodec, metadata.Width, metadata.Height = videoCodec(video)
+		metadata.FrameRate = frameRate(video.pts)
+		timed = video
+	}
+	if audio != nil {
+		metadata.AudioCodec = audioCodec(audio)
+		if timed == nil {
+			timed = audio
+		}
+	}
+	if timed == nil || len(timed.pts) == 0 {
+		return metadata, nil
+	}
+
+	first := minPTS(timed.pts)
+
+	// the last timestamps are in the tail of the file
+	tailStart := size - tsWindow
+	if tailStart < 0 {
+		tailStart = 0
+	}
+	tailStart -= tailStart % int64(packetSize)
+	tail := head
+	if tailStart > 0 {
+		if tail, err = readWindow(r, tailStart, size-tailStart, packetSize); err != nil {
+			return nil, err
+		}
+		timed.pts = nil
+		demuxer.scan(tail, false)
+	}
+	last := maxPTS(timed.pts, first)
+
+	metadata.Duration = float64((last+ptsWrap-first)%ptsWrap) / ptsClock
+	if metadata.FrameRate > 0 {
+		metadata.Duration += 1 / metadata.FrameRate
+	}
+
+	return metadata, nil
+}
+
+// checkPackets verifies the file is a whole number of packets, each starting
+// with the sync byte.
+func checkPackets(r io.ReaderAt, size int64, packetSize int) error {
+	if size%int64(packetSize) != 0 {
+		return fmt.Errorf("%w: size %d is not a multiple of the %d byte packet size, the last packet is truncated", ErrInvalidFile, size, packetSize)
+	}
+
+	chunk := make([]byte, 1024*packetSize)
+	for offset := int64(0); offset < size; offset += int64(len(chunk)) {
+		n, err := r.ReadAt(chunk, offset)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		for i := packetSize - tsPacketSize; i < n; i += packetSize {
+			if chunk[i] != tsSyncByte {
+				return fmt.Errorf("%w: packet at offset %d has no sync byte", ErrInvalidFile, offset+int64(i)-int64(packetSize-tsPacketSize))
+			}
+		}
+	}
+	return nil
+}
+
+func readWindow(r io.ReaderAt, offset int64, length int64, packetSize int) ([]byte, error) {
+	length -= length % int64(packetSize)
+	buf := make([]byte, length)
+	n, err := r.ReadAt(buf, offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return buf[:n-n%packetSize], nil
+}
+
+// scan walks the packets of a window. Program tables are only looked for in
+// the head, payloads are only kept from the head for codec detection.
+func (d *tsDemuxer) scan(data []byte, head bool) {
+	for offset := 0; offset+d.packetSize <= len(data); offset += d.packetSize {
+		packet := data[offset+d.packetSize-tsPacketSize : offset+d.packetSize]
+		if packet[0] != tsSyncByte {
+			continue
+		}
+
+		start := packet[1]&0x40 != 0
+		pid := uint16(packet[1]&0x1F)<<8 | uint16(packet[2])
+		control := packet[3] >> 4 & 0x3
+		if control&0x1 == 0 {
+			continue
+		}
+		payload := packet[4:]
+		if control&0x2 != 0 {
+			if len(payload) == 0 || int(payload[0]) >= len(payload) {
+				continue
+			}
+			payload = payload[1+int(payload[0]):]
+		}
+
+		switch {
+		case pid == pidPAT && head && start && d.pmtPID < 0:
+			d.parsePAT(payload)
+		case int(pid) == d.pmtPID && head && start && len(d.streams) == 0:
+			d.parsePMT(payload)
+		default:
+			stream := d.streams[pid]
+			if stream == nil {
+				continue
+			}
+			if start {
+				if pts, ok := pesPTS(payload); ok {
+					stream.pts = append(stream.pts, pts)
+				}
+			}
+			if head && len(stream.payload) < 64<<10 {
+				stream.payload = append(stream.payload, payload...)
+			}
+		}
+	}
+}
+
+// psiSection strips the pointer field and returns the section up to its
+// CRC.
+func psiSection(payload []byte, tableID byte) []byte {
+	if len(payload) == 0 || int(payload[0])+1 > len(payload) {
+		return nil
+	}
+	section := payload[1+int(payload[0]):]
+	if len(section) < 3 || section[0] != tableID {
+		return nil
+	}
+	length := int(section[1]&0x0F)<<8 | int(section[2])
+	if length < 4 || 3+length > len(section) {
+		return nil
+	}
+	return section[:3+length-4]
+}
+
+func (d *tsDemuxer) parsePAT(payload []byte) {
+	section := psiSection(payload, 0x00)
+	if len(section) < 8 {
+		return
+	}
+	for entry := section[8:]; len(entry) >= 4; entry = entry[4:] {
+		program := uint16(entry[0])<<8 | uint16(entry[1])
+		if program != 0 {
+			d.pmtPID = int(entry[2]&0x1F)<<8 | int(entry[3])
+			return
+		}
+	}
+}
+
+func (d *tsDemuxer) parsePMT(payload []byte) {
+	section := psiSection(payload, 0x02)
+	if len(section) < 12 {
+		return
+	}
+	infoLength := int(section[10]&0x0F)<<8 | int(section[11])
+	if 12+infoLength > len(section) {
+		return
+	}
+	for entry := section[12+infoLength:]; len(entry) >= 5; {
+		pid := uint16(entry[1]&0x1F)<<8 | uint16(entry[2])
+		esInfoLength := int(entry[3]&0x0F)<<8 | int(entry[4])
+		d.streams[pid] = &tsStream{pid: pid, streamType: entry[0]}
+		d.order = append(d.order, pid)
+		if 5+esInfoLength > len(entry) {
+			return
+		}
+		entry = entry[5+esInfoLength:]
+	}
+}
+
+// pesPTS reads the presentation timestamp of a PES packet header.
+func pesPTS(payload []byte) (uint64, bool) {
+	if len(payload) < 14 || payload[0] != 0 || payload[1] != 0 || payload[2] != 1 {
+		return 0, false
+	}
+	if payload[7]&0x80 == 0 {
+		return 0, false
+	}
+	b := payload[9:14]
+	pts := uint64(b[0]>>1&0x07)<<30 | uint64(b[1])<<22 | uint64(b[2]>>1)<<15 | uint64(b[3])<<7 | uint64(b[4]>>1)
+	return pts, true
+}
+
+// pesData strips the PES header from the start of a stream payload.
+func pesData(payload []byte) []byte {
+	if len(payload) < 9 || payload[0] != 0 || payload[1] != 0 || payload[2] != 1 {
+		return payload
+	}
+	start := 9 + int(payload[8])
+	if start > len(payload) {
+		return nil
+	}
+	return payload[start:]
+}
+
+func videoCodec(stream *tsStream) (string, int, int) {
+	switch stream.streamType {
+	case streamTypeH264:
+		if sps := findNALUnit(pesData(stream.payload), nalTypeSPS); sps != nil {
+			if info, ok := parseSPS(sps); ok {
+				return info.codec(), info.width, info.height
+			}
+		}
+		return "avc1", 0, 0
+	case streamTypeHEVC:
+		return "hvc1", 0, 0
+	case streamTypeMPEG2Video:
+		return "mp4v.61", 0, 0
+	case streamTypeMPEG1Video:
+		return "mp4v.6b", 0, 0
+	}
+	return "", 0, 0
+}
+
+func audioCodec(stream *tsStream) string {
+	switch stream.streamType {
+	case streamTypeAAC:
+		data := pesData(stream.payload)
+		// ADTS header, the profile is the object type minus one
+		if len(data) >= 3 && data[0] == 0xFF && data[1]&0xF0 == 0xF0 {
+			return fmt.Sprintf("mp4a.40.%d", data[2]>>6+1)
+		}
+		return "mp4a.40.2"
+	case streamTypeMPEG1Audio:
+		return "mp4a.6b"
+	case streamTypeMPEG2Audio:
+		return "mp4a.69"
+	case streamTypeAC3:
+		return "ac-3"
+	case streamTypeEAC3:
+		return "ec-3"
+	}
+	return ""
+}
+
+// frameRate estimates the frame rate from the spacing of the presentation
+// timestamps, one per frame in practice.
+func frameRate(pts []uint64) float64 {
+	if len(pts) < 2 {
+		return 0
+	}
+	sorted := append([]uint64{}, pts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	span := sorted[len(sorted)-1] - sorted[0]
+	if span == 0 {
+		return 0
+	}
+	return float64(len(sorted)-1) * ptsClock / float64(span)
+}
+
+func minPTS(pts []uint64) uint64 {
+	first := pts[0]
+	for _, p := range pts[1:] {
+		if p < first {
+			first = p
+		}
+	}
+	return first
+}
+
+// maxPTS returns the latest timestamp, unwrapping the 33 bit clock relative
+// to first.
+func maxPTS(pts []uint64, first uint64) uint64 {
+	last := first
+	var lastDelta uint64
+	for _, p := range pts {
+		if delta := (p + ptsWrap - first) % ptsWrap; delta > lastDelta && delta < ptsWrap/2 {
+			last, lastDelta = p, delta
+		}
+	}
+	return last
+}
+
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
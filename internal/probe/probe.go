@@ -0,0 +1,97 @@
+// Package probe extracts stream metadata from MP4/MOV, WebM/Matroska and
+// MPEG-TS files by reading their container headers, without decoding any
+// media.
+package probe
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+const (
+	ContainerMP4      = "mp4"
+	ContainerMOV      = "mov"
+	ContainerWebM     = "webm"
+	ContainerMatroska = "matroska"
+	ContainerMPEGTS   = "mpegts"
+)
+
+var (
+	ErrUnknownFormat = errors.New("probe: unknown container format")
+	ErrInvalidFile   = errors.New("probe: invalid file")
+)
+
+type Metadata struct {
+	Container string
+
+	// Duration in seconds
+	Duration  float64
+	Width     int
+	Height    int
+	FrameRate float64
+
+	// Bitrate is the average over the whole file in bits per second
+	Bitrate int64
+
+	// VideoCodec and AudioCodec are RFC 6381 codec strings where the
+	// container carries enough information, of the first track of each kind
+	VideoCodec string
+	AudioCodec string
+
+	Tracks int
+}
+
+// Probe detects the container of a file from its first bytes and reads its
+// metadata.
+func Probe(r io.ReaderAt, size int64) (*Metadata, error) {
+	return probe(r, size, false)
+}
+
+// Validate reads the metadata like Probe and walks the whole container
+// structure: every box or element size, the sample tables against the media
+// data and, for transport streams, every packet. Errors wrap ErrInvalidFile
+// or mp4.ErrInvalidBox and say what is wrong.
+func Validate(r io.ReaderAt, size int64) (*Metadata, error) {
+	return probe(r, size, true)
+}
+
+func probe(r io.ReaderAt, size int64, deep bool) (*Metadata, error) {
+	head := make([]byte, 2*tsPacketSize+tsTimestampedPacketSize)
+	n, err := r.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	head = head[:n]
+
+	var metadata *Metadata
+	switch {
+	case bytes.HasPrefix(head, ebmlMagic):
+		metadata, err = probeMatroska(r, size, deep)
+	case tsPacketLength(head) > 0:
+		metadata, err = probeMPEGTS(r, size, tsPacketLength(head), deep)
+	case isMP4(head):
+		metadata, err = probeMP4(r, size, deep)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if metadata.Duration > 0 {
+		metadata.Bitrate = int64(float64(size*8) / metadata.Duration)
+	}
+	return metadata, nil
+}
+
+func isMP4(head []byte) bool {
+	if len(head) < 8 {
+		return false
+	}
+	switch string(head[4:8]) {
+	case "ftyp", "moov", "mdat", "free", "skip", "wide", "pnot":
+		return true
+	}
+	return false
+}
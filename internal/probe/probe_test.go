@@ -0,0 +1,345 @@
+package probe_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"video-server/internal/probe"
+)
+
+const samplePath = "../../test/post_1/sample.mp4"
+
+// sps is the sequence parameter set of the sample movie, High profile level
+// 4.0 at 1920x1080
+const sps = "67640028acd940780227e5c044000003000400000300f03c60c658"
+
+func TestProbe_MP4(t *testing.T) {
+	f, err := os.Open(samplePath)
+	require.NoError(t, err)
+	defer f.Close()
+	info, err := f.Stat()
+	require.NoError(t, err)
+
+	metadata, err := probe.Probe(f, info.Size())
+	require.NoError(t, err)
+
+	assert.Equal(t, probe.ContainerMP4, metadata.Container)
+	assert.InDelta(t, 5.76, metadata.Duration, 0.05)
+	assert.Equal(t, 1920, metadata.Width)
+	assert.Equal(t, 1080, metadata.Height)
+	assert.InDelta(t, 30, metadata.FrameRate, 0.1)
+	assert.Equal(t, "avc1.640028", metadata.VideoCodec)
+	assert.Equal(t, "mp4a.40.2", metadata.AudioCodec)
+	assert.Equal(t, 2, metadata.Tracks)
+	assert.InDelta(t, float64(info.Size()*8)/metadata.Duration, float64(metadata.Bitrate), 1)
+}
+
+func TestProbe_Matroska(t *testing.T) {
+	tracks := ebml(0x1654AE6B,
+		ebml(0xAE,
+			ebml(0xD7, []byte{1}),
+			ebml(0x83, []byte{1}),
+			ebml(0x86, []byte("V_VP9")),
+			ebml(0x23E383, uintBytes(40000000)),
+			ebml(0xE0,
+				ebml(0xB0, uintBytes(1280)),
+				ebml(0xBA, uintBytes(720)),
+			),
+		),
+		ebml(0xAE,
+			ebml(0xD7, []byte{2}),
+			ebml(0x83, []byte{2}),
+			ebml(0x86, []byte("A_OPUS")),
+		),
+	)
+	clusters := append(append(
+		ebml(0x1F43B675, ebml(0xE7, uintBytes(0)), ebml(0xA3, make([]byte, 32))),
+		ebml(0x1F43B675, ebml(0xE7, uintBytes(5000)), ebml(0xA3, make([]byte, 32)))...),
+		ebml(0x1F43B675, ebml(0xE7, uintBytes(10000)), ebml(0xA3, make([]byte, 32)))...,
+	)
+
+	testCases := map[string]struct {
+		docType  string
+		info     []byte
+		expected *probe.Metadata
+	}{
+		"webm with duration": {
+			docType: "webm",
+			info: ebml(0x1549A966,
+				ebml(0x2AD7B1, uintBytes(1000000)),
+				ebml(0x4489, floatBytes(12500)),
+			),
+			expected: &probe.Metadata{
+				Container:  probe.ContainerWebM,
+				Duration:   12.5,
+				Width:      1280,
+				Height:     720,
+				FrameRate:  25,
+				VideoCodec: "vp9",
+				AudioCodec: "opus",
+				Tracks:     2,
+			},
+		},
+		"matroska without duration": {
+			docType: "matroska",
+			info:    ebml(0x1549A966, ebml(0x2AD7B1, uintBytes(1000000))),
+			expected: &probe.Metadata{
+				Container:  probe.ContainerMatroska,
+				Duration:   10,
+				Width:      1280,
+				Height:     720,
+				FrameRate:  25,
+				VideoCodec: "vp9",
+				AudioCodec: "opus",
+				Tracks:     2,
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			segment := append(append(append([]byte{}, tc.info...), tracks...), clusters...)
+			data := append(ebml(0x1A45DFA3, ebml(0x4282, []byte(tc.docType))), ebml(0x18538067, segment)...)
+
+			metadata, err := probe.Probe(bytes.NewReader(data), int64(len(data)))
+			require.NoError(t, err)
+
+			tc.expected.Bitrate = int64(float64(len(data)*8) / tc.expected.Duration)
+			assert.Equal(t, tc.expected, metadata)
+		})
+	}
+}
+
+func TestProbe_MPEGTS(t *testing.T) {
+	spsData, _ := hex.DecodeString(sps)
+
+	data := []byte{}
+	data = append(data, tsPacket(0, psi(0x00, []byte{0x00, 0x01, 0xE1, 0x00}))...)
+	data = append(data, tsPacket(0x100, psi(0x02, []byte{
+		0xE1, 0x01, 0xF0, 0x00, // PCR PID, no program info
+		0x1B, 0xE1, 0x01, 0xF0, 0x00, // H.264
+		0x0F, 0xE1, 0x02, 0xF0, 0x00, // AAC
+	}))...)
+
+	// 3 seconds at 30 fps, with a 1s audio frame cadence
+	start := uint64(126000)
+	for i := uint64(0); i < 90; i++ {
+		es := []byte{0x00, 0x00, 0x00, 0x01, 0x09, 0xF0}
+		if i == 0 {
+			es = append(append(es, 0x00, 0x00, 0x00, 0x01), spsData...)
+		}
+		data = append(data, tsPacket(0x101, pes(0xE0, start+i*3000, es))...)
+		if i%30 == 0 {
+			adts := []byte{0xFF, 0xF1, 0x50, 0x80, 0x02, 0x1F, 0xFC}
+			data = append(data, tsPacket(0x102, pes(0xC0, start+i*3000, adts))...)
+		}
+	}
+
+	metadata, err := probe.Probe(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	assert.Equal(t, &probe.Metadata{
+		Container:  probe.ContainerMPEGTS,
+		Duration:   3,
+		Width:      1920,
+		Height:     1080,
+		FrameRate:  30,
+		Bitrate:    int64(len(data) * 8 / 3),
+		VideoCodec: "avc1.640028",
+		AudioCodec: "mp4a.40.2",
+		Tracks:     2,
+	}, roundMetadata(metadata))
+}
+
+func TestProbe_Invalid(t *testing.T) {
+	testCases := map[string]struct {
+		data []byte
+		err  error
+	}{
+		"empty": {
+			data: []byte{},
+			err:  probe.ErrUnknownFormat,
+		},
+		"text": {
+			data: []byte("this is not a video file"),
+			err:  probe.ErrUnknownFormat,
+		},
+		"matroska without segment": {
+			data: ebml(0x1A45DFA3, ebml(0x4282, []byte("webm"))),
+			err:  probe.ErrInvalidFile,
+		},
+		"matroska element past the end": {
+			data: append(ebml(0x1A45DFA3, ebml(0x4282, []byte("webm"))), 0x18, 0x53, 0x80, 0x67, 0x88),
+			err:  probe.ErrInvalidFile,
+		},
+		"transport stream without tables": {
+			data: bytes.Repeat(tsPacket(0x101, []byte{0xFF}), 4),
+			err:  probe.ErrInvalidFile,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := probe.Probe(bytes.NewReader(tc.data), int64(len(tc.data)))
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+// ebml builds an element with an 8 byte size field.
+func ebml(id uint32, children ...[]byte) []byte {
+	payload := bytes.Join(children, nil)
+	out := []byte{}
+	for shift := 24; shift >= 0; shift -= 8 {
+		if b := byte(id >> shift); b != 0 || len(out) > 0 {
+			out = append(out, b)
+		}
+	}
+	size := make([]byte, 8)
+	binary.BigEndian.PutUint64(size, uint64(len(payload)))
+	size[0] = 0x01
+	return append(append(out, size...), payload...)
+}
+
+func uintBytes(v uint64) []byte {
+	out := make([]byte, 8)
+	binary.BigEndian.PutUint64(out, v)
+	return out
+}
+
+func floatBytes(v float64) []byte {
+	return uintBytes(math.Float64bits(v))
+}
+
+// tsPacket wraps a payload starting in this packet, padded with stuffing.
+func tsPacket(pid uint16, payload []byte) []byte {
+	packet := []byte{0x47, 0x40 | byte(pid>>8), byte(pid), 0x10}
+	packet = append(packet, payload...)
+	for len(packet) < 188 {
+		packet = append(packet, 0xFF)
+	}
+	return packet[:188]
+}
+
+// psi builds a table section with a pointer field and a dummy CRC.
+func psi(tableID byte, body []byte) []byte {
+	length := 5 + len(body) + 4
+	section := []byte{0x00, tableID, 0xB0 | byte(length>>8), byte(length), 0x00, 0x01, 0xC1, 0x00, 0x00}
+	return append(append(section, body...), 0, 0, 0, 0)
+}
+
+func pes(streamID byte, pts uint64, data []byte) []byte {
+	header := []byte{0x00, 0x00, 0x01, streamID, 0x00, 0x00, 0x80, 0x80, 0x05,
+		0x21 | byte(pts>>29)&0x0E,
+		byte(pts >> 22),
+		byte(pts>>14) | 0x01,
+		byte(pts >> 7),
+		byte(pts<<1) | 0x01,
+	}
+	return append(header, data...)
+}
+
+func roundMetadata(m *probe.Metadata) *probe.Metadata {
+	m.Duration = math.Round(m.Duration*1000) / 1000
+	m.FrameRate = math.Round(m.FrameRate*1000) / 1000
+	return m
+}
+
+func TestValidate(t *testing.T) {
+	sample, err := os.ReadFile(samplePath)
+	require.NoError(t, err)
+
+	// first chunk of the video track moved into the ftyp box
+	movedChunk := append([]byte{}, sample...)
+	stco := bytes.Index(movedChunk, []byte("stco"))
+	binary.BigEndian.PutUint32(movedChunk[stco+12:], 0)
+
+	tracks := ebml(0x1654AE6B, ebml(0xAE,
+		ebml(0xD7, []byte{1}),
+		ebml(0x83, []byte{1}),
+		ebml(0x86, []byte("V_VP8")),
+	))
+	info := ebml(0x1549A966, ebml(0x4489, floatBytes(1000)))
+	webm := func(segment ...[]byte) []byte {
+		return append(ebml(0x1A45DFA3, ebml(0x4282, []byte("webm"))), ebml(0x18538067, bytes.Join(segment, nil))...)
+	}
+	block := func(track byte) []byte {
+		return ebml(0xA3, []byte{0x80 | track, 0x00, 0x00, 0x80, 0xAA})
+	}
+	// a live recording, the cluster size is unknown
+	liveCluster := append([]byte{0x1F, 0x43, 0xB6, 0x75, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF},
+		append(ebml(0xE7, uintBytes(0)), block(1)...)...)
+
+	ts := []byte{}
+	ts = append(ts, tsPacket(0, psi(0x00, []byte{0x00, 0x01, 0xE1, 0x00}))...)
+	ts = append(ts, tsPacket(0x100, psi(0x02, []byte{0xE1, 0x01, 0xF0, 0x00, 0x1B, 0xE1, 0x01, 0xF0, 0x00}))...)
+	for i := uint64(0); i < 10; i++ {
+		ts = append(ts, tsPacket(0x101, pes(0xE0, i*3000, []byte{0x00, 0x00, 0x01, 0x09}))...)
+	}
+	badSync := append([]byte{}, ts...)
+	badSync[5*188] = 0x00
+
+	testCases := map[string]struct {
+		data []byte
+		err  string
+	}{
+		"mp4": {
+			data: sample,
+		},
+		"mp4 truncated": {
+			data: sample[:len(sample)-1000],
+			err:  `mp4: invalid box: "moov" at offset 2840583 has size 7625, 6625 bytes available`,
+		},
+		"mp4 sample outside media data": {
+			data: movedChunk,
+			err:  "mp4: invalid box: sample 1 of track 1 (200572 bytes at offset 0) is outside the media data",
+		},
+		"webm": {
+			data: webm(info, tracks, ebml(0x1F43B675, ebml(0xE7, uintBytes(0)), block(1))),
+		},
+		"webm live recording": {
+			data: webm(info, tracks, liveCluster, liveCluster),
+		},
+		"webm undeclared track": {
+			data: webm(info, tracks, ebml(0x1F43B675, ebml(0xE7, uintBytes(0)), block(2))),
+			err:  "probe: invalid file: block at offset 152 references undeclared track 2",
+		},
+		"webm cluster without timecode": {
+			data: webm(info, tracks, ebml(0x1F43B675, block(1))),
+			err:  "probe: invalid file: cluster at offset 123 has no timecode",
+		},
+		"webm without clusters": {
+			data: webm(info, tracks),
+			err:  "probe: invalid file: segment has no clusters",
+		},
+		"transport stream": {
+			data: ts,
+		},
+		"transport stream truncated": {
+			data: ts[:len(ts)-100],
+			err:  "probe: invalid file: size 2156 is not a multiple of the 188 byte packet size, the last packet is truncated",
+		},
+		"transport stream lost sync": {
+			data: badSync,
+			err:  "probe: invalid file: packet at offset 940 has no sync byte",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := probe.Validate(bytes.NewReader(tc.data), int64(len(tc.data)))
+			if tc.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CertReloader serves a certificate pair from disk and picks up renewed
+// files without a restart. The files are checked at most once per interval
+// during handshakes, a pair that fails to load keeps the previous one in
+// use.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+// NewCertReloader fails when the pair can't be loaded, the server must not
+// start without a certificate.
+func NewCertReloader(certFile, keyFile string, interval time.Duration) (*CertReloader, error) {
+	c := &CertReloader{certFile: certFile, keyFile: keyFile, interval: interval}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload loads the pair from disk.
+func (c *CertReloader) Reload() error {
+	modTime, err := c.newestModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cert = &cert
+	c.modTime = modTime
+	c.checked = time.Now()
+	return nil
+}
+
+// GetCertificate is meant for tls.Config.GetCertificate.
+func (c *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	stale := time.Since(c.checked) >= c.interval
+	if stale {
+		c.checked = time.Now()
+	}
+	cert, modTime := c.cert, c.modTime
+	c.mu.Unlock()
+
+	if stale {
+		// files being replaced may be caught half written, the next check
+		// retries
+		if newest, err := c.newestModTime(); err != nil {
+			logrus.WithError(err).Warn("tls: check certificate")
+		} else if !newest.Equal(modTime) {
+			if err := c.Reload(); err != nil {
+				logrus.WithError(err).Error("tls: reload certificate")
+			} else {
+				logrus.WithField("cert_file", c.certFile).Info("tls: reloaded certificate")
+				c.mu.Lock()
+				cert = c.cert
+				c.mu.Unlock()
+			}
+		}
+	}
+	return cert, nil
+}
+
+func (c *CertReloader) newestModTime() (time.Time, error) {
+	var newest time.Time
+	for _, path := range []string{c.certFile, c.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+	}
+	return newest, nil
+}
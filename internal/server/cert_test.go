@@ -0,0 +1,115 @@
+package server_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"video-server/internal/server"
+)
+
+// writeCert writes a self-signed pair for commonName and dates the files at
+// modTime.
+func writeCert(t *testing.T, dir, commonName string, modTime time.Time) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+	return certFile, keyFile
+}
+
+func commonName(t *testing.T, reloader *server.CertReloader) string {
+	t.Helper()
+	cert, err := reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloader(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+
+	testcases := map[string]struct {
+		interval time.Duration
+		renew    func(t *testing.T, dir string)
+		expected string
+	}{
+		"renewed": {
+			renew: func(t *testing.T, dir string) {
+				writeCert(t, dir, "new.example.com", start.Add(time.Minute))
+			},
+			expected: "new.example.com",
+		},
+		"not checked yet": {
+			interval: time.Hour,
+			renew: func(t *testing.T, dir string) {
+				writeCert(t, dir, "new.example.com", start.Add(time.Minute))
+			},
+			expected: "old.example.com",
+		},
+		"unchanged": {
+			renew:    func(t *testing.T, dir string) {},
+			expected: "old.example.com",
+		},
+		"broken": {
+			renew: func(t *testing.T, dir string) {
+				certFile := filepath.Join(dir, "cert.pem")
+				require.NoError(t, os.WriteFile(certFile, []byte("half written"), 0o600))
+			},
+			expected: "old.example.com",
+		},
+		"removed": {
+			renew: func(t *testing.T, dir string) {
+				require.NoError(t, os.Remove(filepath.Join(dir, "key.pem")))
+			},
+			expected: "old.example.com",
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			certFile, keyFile := writeCert(t, dir, "old.example.com", start)
+			reloader, err := server.NewCertReloader(certFile, keyFile, tc.interval)
+			require.NoError(t, err)
+			assert.Equal(t, "old.example.com", commonName(t, reloader))
+
+			tc.renew(t, dir)
+			assert.Equal(t, tc.expected, commonName(t, reloader))
+		})
+	}
+}
+
+func TestNewCertReloader_Missing(t *testing.T) {
+	dir := t.TempDir()
+	_, err := server.NewCertReloader(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), time.Minute)
+	assert.Error(t, err)
+}
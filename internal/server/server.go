@@ -0,0 +1,53 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Run listens on srv.Addr and serves until ctx is done, see Serve.
+func Run(ctx context.Context, srv *http.Server, shutdownTimeout time.Duration) error {
+	l, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return err
+	}
+	return Serve(ctx, srv, l, shutdownTimeout)
+}
+
+// Serve serves srv on l until ctx is done, then stops accepting connections
+// and waits up to shutdownTimeout for in-flight requests to finish, those
+// still running after it are cut off. Requests are served over TLS when
+// srv.TLSConfig is set, it has to provide the certificates.
+func Serve(ctx context.Context, srv *http.Server, l net.Listener, shutdownTimeout time.Duration) error {
+	errc := make(chan error, 1)
+	go func() {
+		if srv.TLSConfig != nil {
+			errc <- srv.ServeTLS(l, "", "")
+		} else {
+			errc <- srv.Serve(l)
+		}
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err := srv.Shutdown(shutdownCtx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = srv.Close()
+		if err == nil {
+			err = context.DeadlineExceeded
+		}
+	}
+	if serveErr := <-errc; !errors.Is(serveErr, http.ErrServerClosed) {
+		return serveErr
+	}
+	return err
+}
@@ -0,0 +1,117 @@
+package server_test
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"video-server/internal/server"
+)
+
+func TestServe(t *testing.T) {
+	testcases := map[string]struct {
+		handlerDelay    time.Duration
+		shutdownTimeout time.Duration
+		err             error
+		body            string
+	}{
+		"drains in-flight request": {
+			handlerDelay:    50 * time.Millisecond,
+			shutdownTimeout: time.Second,
+			body:            "done",
+		},
+		"cuts off after timeout": {
+			handlerDelay:    time.Second,
+			shutdownTimeout: 50 * time.Millisecond,
+			err:             context.DeadlineExceeded,
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			started := make(chan struct{})
+			srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				time.Sleep(tc.handlerDelay)
+				_, _ = w.Write([]byte("done"))
+			})}
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			served := make(chan error, 1)
+			go func() { served <- server.Serve(ctx, srv, l, tc.shutdownTimeout) }()
+
+			type result struct {
+				body string
+				err  error
+			}
+			responses := make(chan result, 1)
+			go func() {
+				res, err := http.Get("http://" + l.Addr().String())
+				if err != nil {
+					responses <- result{err: err}
+					return
+				}
+				defer res.Body.Close()
+				body, err := io.ReadAll(res.Body)
+				responses <- result{body: string(body), err: err}
+			}()
+
+			<-started
+			cancel()
+			assert.ErrorIs(t, <-served, tc.err)
+
+			res := <-responses
+			if tc.body != "" {
+				require.NoError(t, res.err)
+				assert.Equal(t, tc.body, res.body)
+			} else {
+				assert.Error(t, res.err)
+			}
+
+			// the listener is closed
+			_, err = net.Dial("tcp", l.Addr().String())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestServe_TLS(t *testing.T) {
+	certFile, keyFile := writeCert(t, t.TempDir(), "localhost", time.Now())
+	reloader, err := server.NewCertReloader(certFile, keyFile, time.Minute)
+	require.NoError(t, err)
+
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("secure"))
+		}),
+		TLSConfig: &tls.Config{GetCertificate: reloader.GetCertificate},
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(ctx, srv, l, time.Second) }()
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}
+	res, err := client.Get("https://" + l.Addr().String())
+	require.NoError(t, err)
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "secure", string(body))
+	assert.NotNil(t, res.TLS)
+
+	cancel()
+	assert.NoError(t, <-served)
+}
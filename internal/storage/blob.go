@@ -0,0 +1,50 @@
+package storage
+
+//go:generate mockgen -source blob.go -destination mock/blob.go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var (
+	ErrBlobNotFound = errors.New("blob not found")
+	ErrInvalidKey   = errors.New("invalid blob key")
+)
+
+// Blob is a readable handle to a stored object. Both *os.File and the
+// S3 client object satisfy it, so callers can seek for range requests.
+type Blob interface {
+	io.Reader
+	io.ReaderAt
+	io.Seeker
+	io.Closer
+}
+
+type BlobInfo struct {
+	Key        string
+	Size       int64
+	ModifiedAt time.Time
+}
+
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	Get(ctx context.Context, key string) (Blob, error)
+	Stat(ctx context.Context, key string) (*BlobInfo, error)
+	Delete(ctx context.Context, key string) error
+	// Move renames a blob, replacing whatever is stored under dst
+	Move(ctx context.Context, src, dst string) error
+	List(ctx context.Context, prefix string) ([]*BlobInfo, error)
+}
+
+// DigestKey is the key of a content addressed blob, fanned out by the first
+// two hex characters so no single directory grows unbounded.
+func DigestKey(digest string) string {
+	if len(digest) < 2 {
+		return fmt.Sprintf("blobs/sha256/%s", digest)
+	}
+	return fmt.Sprintf("blobs/sha256/%s/%s", digest[:2], digest)
+}
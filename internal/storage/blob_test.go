@@ -0,0 +1,100 @@
+package storage_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"video-server/internal/storage"
+)
+
+// testBlobStore runs the behaviour every BlobStore implementation must share.
+func testBlobStore(t *testing.T, store storage.BlobStore) {
+	ctx := context.Background()
+	content := "some video bytes"
+
+	t.Run("Put and Get", func(t *testing.T) {
+		err := store.Put(ctx, "dir/sample.mp4", strings.NewReader(content), int64(len(content)))
+		require.NoError(t, err)
+
+		blob, err := store.Get(ctx, "dir/sample.mp4")
+		require.NoError(t, err)
+		defer blob.Close()
+
+		data, err := io.ReadAll(blob)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data))
+
+		buf := make([]byte, 5)
+		_, err = blob.ReadAt(buf, 5)
+		require.NoError(t, err)
+		assert.Equal(t, "video", string(buf))
+	})
+
+	t.Run("Put unknown size", func(t *testing.T) {
+		err := store.Put(ctx, "dir/streamed.mp4", strings.NewReader(content), -1)
+		require.NoError(t, err)
+
+		info, err := store.Stat(ctx, "dir/streamed.mp4")
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(content)), info.Size)
+		require.NoError(t, store.Delete(ctx, "dir/streamed.mp4"))
+	})
+
+	t.Run("Put overwrites", func(t *testing.T) {
+		err := store.Put(ctx, "dir/sample.mp4", strings.NewReader("short"), 5)
+		require.NoError(t, err)
+
+		info, err := store.Stat(ctx, "dir/sample.mp4")
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), info.Size)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		err := store.Put(ctx, "other/file.mp4", strings.NewReader(content), int64(len(content)))
+		require.NoError(t, err)
+
+		blobs, err := store.List(ctx, "dir/")
+		require.NoError(t, err)
+		require.Len(t, blobs, 1)
+		assert.Equal(t, "dir/sample.mp4", blobs[0].Key)
+
+		blobs, err = store.List(ctx, "dir/sam")
+		require.NoError(t, err)
+		require.Len(t, blobs, 1)
+		assert.Equal(t, "dir/sample.mp4", blobs[0].Key)
+
+		blobs, err = store.List(ctx, "missing/dir/")
+		require.NoError(t, err)
+		assert.Empty(t, blobs)
+	})
+
+	t.Run("Move", func(t *testing.T) {
+		err := store.Put(ctx, "staging/upload", strings.NewReader(content), int64(len(content)))
+		require.NoError(t, err)
+
+		require.NoError(t, store.Move(ctx, "staging/upload", "moved/sample.mp4"))
+
+		_, err = store.Stat(ctx, "staging/upload")
+		assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+		info, err := store.Stat(ctx, "moved/sample.mp4")
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(content)), info.Size)
+
+		assert.ErrorIs(t, store.Move(ctx, "staging/upload", "moved/sample.mp4"), storage.ErrBlobNotFound)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, store.Delete(ctx, "dir/sample.mp4"))
+
+		_, err := store.Get(ctx, "dir/sample.mp4")
+		assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+		_, err = store.Stat(ctx, "dir/sample.mp4")
+		assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+		assert.ErrorIs(t, store.Delete(ctx, "dir/sample.mp4"), storage.ErrBlobNotFound)
+	})
+}
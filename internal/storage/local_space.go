@@ -0,0 +1,21 @@
+//go:build unix
+
+package storage
+
+import (
+	"context"
+	"syscall"
+)
+
+// Space reports the space left to unprivileged users on the volume of the
+// root directory.
+func (s *localStore) Space(ctx context.Context) (*Space, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(s.root, &stat); err != nil {
+		return nil, err
+	}
+	return &Space{
+		Free:  int64(stat.Bavail) * int64(stat.Bsize),
+		Total: int64(stat.Blocks) * int64(stat.Bsize),
+	}, nil
+}
@@ -0,0 +1,44 @@
+package storage_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"video-server/internal/storage"
+)
+
+func TestLocalStore(t *testing.T) {
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	testBlobStore(t, store)
+}
+
+func TestLocalStore_InvalidKey(t *testing.T) {
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "../escape.mp4", "/abs.mp4", "a/../../b.mp4", "./a.mp4"} {
+		err := store.Put(context.Background(), key, strings.NewReader("x"), 1)
+		assert.ErrorIs(t, err, storage.ErrInvalidKey, key)
+	}
+}
+
+func TestLocalStore_Space(t *testing.T) {
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	var blobStore storage.BlobStore = store
+	reporter, ok := blobStore.(storage.SpaceReporter)
+	if !ok {
+		t.Skip("space isn't reported on this platform")
+	}
+	space, err := reporter.Space(context.Background())
+	require.NoError(t, err)
+	assert.Greater(t, space.Total, int64(0))
+	assert.LessOrEqual(t, space.Free, space.Total)
+}
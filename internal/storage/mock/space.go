@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: space.go
+
+// Package mock_storage is a generated GoMock package.
+package mock_storage
+
+import (
+	context "context"
+	reflect "reflect"
+	storage "video-server/internal/storage"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockSpaceReporter is a mock of SpaceReporter interface.
+type MockSpaceReporter struct {
+	ctrl     *gomock.Controller
+	recorder *MockSpaceReporterMockRecorder
+}
+
+// MockSpaceReporterMockRecorder is the mock recorder for MockSpaceReporter.
+type MockSpaceReporterMockRecorder struct {
+	mock *MockSpaceReporter
+}
+
+// NewMockSpaceReporter creates a new mock instance.
+func NewMockSpaceReporter(ctrl *gomock.Controller) *MockSpaceReporter {
+	mock := &MockSpaceReporter{ctrl: ctrl}
+	mock.recorder = &MockSpaceReporterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSpaceReporter) EXPECT() *MockSpaceReporterMockRecorder {
+	return m.recorder
+}
+
+// Space mocks base method.
+func (m *MockSpaceReporter) Space(ctx context.Context) (*storage.Space, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Space", ctx)
+	ret0, _ := ret[0].(*storage.Space)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Space indicates an expected call of Space.
+func (mr *MockSpaceReporterMockRecorder) Space(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Space", reflect.TypeOf((*MockSpaceReporter)(nil).Space), ctx)
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+// prefixStore keeps every key of a BlobStore under a common prefix, so
+// callers can address their partition of a shared backend with plain keys.
+type prefixStore struct {
+	store  BlobStore
+	prefix string
+}
+
+// WithPrefix returns a view of store that prepends prefix to every key and
+// lists only the blobs below it, with the prefix trimmed. An empty prefix
+// returns store itself.
+func WithPrefix(store BlobStore, prefix string) BlobStore {
+	if prefix == "" {
+		return store
+	}
+	return &prefixStore{store: store, prefix: prefix}
+}
+
+func (s *prefixStore) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	return s.store.Put(ctx, s.prefix+key, r, size)
+}
+
+func (s *prefixStore) Get(ctx context.Context, key string) (Blob, error) {
+	return s.store.Get(ctx, s.prefix+key)
+}
+
+func (s *prefixStore) Stat(ctx context.Context, key string) (*BlobInfo, error) {
+	info, err := s.store.Stat(ctx, s.prefix+key)
+	if err != nil {
+		return nil, err
+	}
+	return &BlobInfo{Key: key, Size: info.Size, ModifiedAt: info.ModifiedAt}, nil
+}
+
+func (s *prefixStore) Delete(ctx context.Context, key string) error {
+	return s.store.Delete(ctx, s.prefix+key)
+}
+
+func (s *prefixStore) Move(ctx context.Context, src, dst string) error {
+	return s.store.Move(ctx, s.prefix+src, s.prefix+dst)
+}
+
+func (s *prefixStore) List(ctx context.Context, prefix string) ([]*BlobInfo, error) {
+	blobs, err := s.store.List(ctx, s.prefix+prefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, blob := range blobs {
+		blob.Key = strings.TrimPrefix(blob.Key, s.prefix)
+	}
+	return blobs, nil
+}
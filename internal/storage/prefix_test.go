@@ -0,0 +1,43 @@
+package storage_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"video-server/internal/storage"
+)
+
+func TestPrefixStore(t *testing.T) {
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	testBlobStore(t, storage.WithPrefix(store, "tenants/acme/"))
+}
+
+func TestPrefixStore_Partition(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	acme := storage.WithPrefix(store, "tenants/acme/")
+
+	require.NoError(t, store.Put(ctx, "blobs/root.mp4", strings.NewReader("x"), 1))
+	require.NoError(t, acme.Put(ctx, "blobs/acme.mp4", strings.NewReader("y"), 1))
+
+	blobs, err := acme.List(ctx, "blobs/")
+	require.NoError(t, err)
+	require.Len(t, blobs, 1)
+	assert.Equal(t, "blobs/acme.mp4", blobs[0].Key)
+
+	info, err := store.Stat(ctx, "tenants/acme/blobs/acme.mp4")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), info.Size)
+
+	_, err = acme.Get(ctx, "blobs/root.mp4")
+	assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+
+	assert.Same(t, store, storage.WithPrefix(store, ""))
+}
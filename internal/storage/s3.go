@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// s3PartSize is the part of uploads of unknown size, minio-go buffers one
+// part in memory and would size it for a 5 TiB object otherwise. Such
+// objects are limited to 10000 parts, 156 GiB.
+const s3PartSize = 16 << 20
+
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+}
+
+type s3Store struct {
+	client *minio.Client
+	bucket string
+}
+
+func NewS3Store(ctx context.Context, cfg S3Config) (*s3Store, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		err = client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{Region: cfg.Region})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &s3Store{
+		client: client,
+		bucket: cfg.Bucket,
+	}, nil
+}
+
+func (s *s3Store) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, putObjectOptions(size))
+	return err
+}
+
+func putObjectOptions(size int64) minio.PutObjectOptions {
+	opts := minio.PutObjectOptions{}
+	if size < 0 {
+		opts.PartSize = s3PartSize
+	}
+	return opts
+}
+
+func (s *s3Store) Get(ctx context.Context, key string) (Blob, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+
+	// GetObject is lazy, stat forces the request so missing keys surface here
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		return nil, s.mapError(err)
+	}
+	return object, nil
+}
+
+func (s *s3Store) Stat(ctx context.Context, key string) (*BlobInfo, error) {
+	info, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, s.mapError(err)
+	}
+
+	return &BlobInfo{
+		Key:        info.Key,
+		Size:       info.Size,
+		ModifiedAt: info.LastModified,
+	}, nil
+}
+
+func (s *s3Store) Delete(ctx context.Context, key string) error {
+	// S3 deletes are idempotent, stat first to keep parity with the local store
+	if _, err := s.Stat(ctx, key); err != nil {
+		return err
+	}
+	return s.mapError(s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}))
+}
+
+// Move copies server side then removes the source, S3 has no rename. A
+// single copy is refused above 5 GiB, compose copies larger objects in
+// parts.
+func (s *s3Store) Move(ctx context.Context, src, dst string) error {
+	_, err := s.client.ComposeObject(ctx,
+		minio.CopyDestOptions{Bucket: s.bucket, Object: dst},
+		minio.CopySrcOptions{Bucket: s.bucket, Object: src},
+	)
+	if err != nil {
+		return s.mapError(err)
+	}
+	return s.mapError(s.client.RemoveObject(ctx, s.bucket, src, minio.RemoveObjectOptions{}))
+}
+
+func (s *s3Store) List(ctx context.Context, prefix string) ([]*BlobInfo, error) {
+	blobs := []*BlobInfo{}
+	for object := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+	}) {
+		if object.Err != nil {
+			return nil, s.mapError(object.Err)
+		}
+		blobs = append(blobs, &BlobInfo{
+			Key:        object.Key,
+			Size:       object.Size,
+			ModifiedAt: object.LastModified,
+		})
+	}
+
+	return blobs, nil
+}
+
+func (s *s3Store) mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NotFound":
+		return ErrBlobNotFound
+	}
+	return err
+}
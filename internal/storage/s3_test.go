@@ -0,0 +1,125 @@
+package storage_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"video-server/internal/storage"
+)
+
+// TestS3Store runs against a MinIO (or any S3 compatible) endpoint when
+// TEST_S3_ENDPOINT is set, e.g. `docker run -p 9000:9000 minio/minio server /data`.
+func TestS3Store(t *testing.T) {
+	endpoint := os.Getenv("TEST_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("TEST_S3_ENDPOINT not set")
+	}
+
+	store, err := storage.NewS3Store(context.Background(), storage.S3Config{
+		Endpoint:  endpoint,
+		Bucket:    fmt.Sprintf("video-server-test-%d", time.Now().UnixNano()),
+		AccessKey: os.Getenv("TEST_S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("TEST_S3_SECRET_KEY"),
+	})
+	require.NoError(t, err)
+
+	testBlobStore(t, store)
+}
+
+// newS3Stub returns a store of a stub endpoint answering with handler,
+// requests to the bucket itself succeed.
+func newS3Stub(t *testing.T, handler http.HandlerFunc) storage.BlobStore {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/video-server-test/" {
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	store, err := storage.NewS3Store(context.Background(), storage.S3Config{
+		Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+		Region:    "us-east-1",
+		Bucket:    "video-server-test",
+		AccessKey: "key",
+		SecretKey: "secret",
+	})
+	require.NoError(t, err)
+	return store
+}
+
+// TestS3Store_PutUnknownSize streams to a stub endpoint, minio-go buffers a
+// part per upload of unknown size and that part must stay small.
+func TestS3Store_PutUnknownSize(t *testing.T) {
+	var received []byte
+	store := newS3Stub(t, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch {
+		case r.Method == http.MethodPost && query.Has("uploads"):
+			fmt.Fprint(w, `<InitiateMultipartUploadResult><UploadId>upload</UploadId></InitiateMultipartUploadResult>`)
+		case r.Method == http.MethodPut:
+			data, _ := io.ReadAll(r.Body)
+			received = append(received, data...)
+			w.Header().Set("ETag", `"etag"`)
+		case r.Method == http.MethodPost && query.Has("uploadId"):
+			fmt.Fprint(w, `<CompleteMultipartUploadResult><Bucket>video-server-test</Bucket><Key>streamed.mp4</Key><ETag>"etag"</ETag></CompleteMultipartUploadResult>`)
+		}
+	})
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	err := store.Put(context.Background(), "streamed.mp4", strings.NewReader("some video bytes"), -1)
+	runtime.ReadMemStats(&after)
+	require.NoError(t, err)
+
+	// the body is signed in chunks, the content is in there
+	assert.Contains(t, string(received), "some video bytes")
+	assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(64<<20))
+}
+
+// TestS3Store_MoveLarge moves an object above the 5 GiB limit of a single
+// copy, it has to be copied in parts.
+func TestS3Store_MoveLarge(t *testing.T) {
+	const size = int64(6) << 30
+	var copies, partCopies int
+	var deleted bool
+	store := newS3Stub(t, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch {
+		case r.Method == http.MethodHead:
+			w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+			w.Header().Set("ETag", `"etag"`)
+			w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		case r.Method == http.MethodPost && query.Has("uploads"):
+			fmt.Fprint(w, `<InitiateMultipartUploadResult><UploadId>upload</UploadId></InitiateMultipartUploadResult>`)
+		case r.Method == http.MethodPut && query.Has("partNumber"):
+			partCopies++
+			fmt.Fprint(w, `<CopyPartResult><ETag>"etag"</ETag></CopyPartResult>`)
+		case r.Method == http.MethodPut:
+			copies++
+			fmt.Fprint(w, `<CopyObjectResult><ETag>"etag"</ETag></CopyObjectResult>`)
+		case r.Method == http.MethodPost && query.Has("uploadId"):
+			fmt.Fprint(w, `<CompleteMultipartUploadResult><Bucket>video-server-test</Bucket><Key>dst.mp4</Key><ETag>"etag"</ETag></CompleteMultipartUploadResult>`)
+		case r.Method == http.MethodDelete:
+			deleted = r.URL.Path == "/video-server-test/src.mp4"
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	require.NoError(t, store.Move(context.Background(), "src.mp4", "dst.mp4"))
+	assert.Zero(t, copies)
+	assert.Greater(t, partCopies, 1)
+	assert.True(t, deleted)
+}
@@ -0,0 +1,17 @@
+package storage
+
+//go:generate mockgen -source space.go -destination mock/space.go
+
+import "context"
+
+// Space is the capacity of the volume a store writes to, in bytes.
+type Space struct {
+	Free  int64
+	Total int64
+}
+
+// SpaceReporter is implemented by stores backed by a volume that can fill
+// up, object stores don't report space.
+type SpaceReporter interface {
+	Space(ctx context.Context) (*Space, error)
+}
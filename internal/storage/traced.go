@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"io"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"video-server/internal/tracing"
+)
+
+type tracedStore struct {
+	store BlobStore
+}
+
+// tracedSpaceStore is a tracedStore of a store reporting its space, so
+// tracing doesn't hide SpaceReporter.
+type tracedSpaceStore struct {
+	*tracedStore
+	space SpaceReporter
+}
+
+// WithTracing starts a span per operation on store. Reading a blob
+// returned by Get isn't traced, only opening it.
+func WithTracing(store BlobStore) BlobStore {
+	traced := &tracedStore{store: store}
+	if space, ok := store.(SpaceReporter); ok {
+		return &tracedSpaceStore{tracedStore: traced, space: space}
+	}
+	return traced
+}
+
+func (s *tracedStore) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	ctx, span := tracing.Start(ctx, "storage.Put", attribute.String("blob.key", key), attribute.Int64("blob.size", size))
+	defer span.End()
+
+	// the size is usually unknown up front, count what was read
+	counter := &countingReader{reader: r}
+	err := s.store.Put(ctx, key, counter, size)
+	span.SetAttributes(attribute.Int64("blob.written", counter.n))
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) Get(ctx context.Context, key string) (Blob, error) {
+	ctx, span := tracing.Start(ctx, "storage.Get", attribute.String("blob.key", key))
+	defer span.End()
+
+	blob, err := s.store.Get(ctx, key)
+	tracing.RecordError(span, err)
+	return blob, err
+}
+
+func (s *tracedStore) Stat(ctx context.Context, key string) (*BlobInfo, error) {
+	ctx, span := tracing.Start(ctx, "storage.Stat", attribute.String("blob.key", key))
+	defer span.End()
+
+	info, err := s.store.Stat(ctx, key)
+	tracing.RecordError(span, err)
+	return info, err
+}
+
+func (s *tracedStore) Delete(ctx context.Context, key string) error {
+	ctx, span := tracing.Start(ctx, "storage.Delete", attribute.String("blob.key", key))
+	defer span.End()
+
+	err := s.store.Delete(ctx, key)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) Move(ctx context.Context, src, dst string) error {
+	ctx, span := tracing.Start(ctx, "storage.Move", attribute.String("blob.key", src), attribute.String("blob.destination", dst))
+	defer span.End()
+
+	err := s.store.Move(ctx, src, dst)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) List(ctx context.Context, prefix string) ([]*BlobInfo, error) {
+	ctx, span := tracing.Start(ctx, "storage.List", attribute.String("blob.prefix", prefix))
+	defer span.End()
+
+	blobs, err := s.store.List(ctx, prefix)
+	span.SetAttributes(attribute.Int("blob.count", len(blobs)))
+	tracing.RecordError(span, err)
+	return blobs, err
+}
+
+func (s *tracedSpaceStore) Space(ctx context.Context) (*Space, error) {
+	ctx, span := tracing.Start(ctx, "storage.Space")
+	defer span.End()
+
+	space, err := s.space.Space(ctx)
+	tracing.RecordError(span, err)
+	return space, err
+}
+
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,55 @@
+package storage_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"video-server/internal/storage"
+)
+
+func TestTracedStore(t *testing.T) {
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	testBlobStore(t, storage.WithTracing(store))
+}
+
+func TestTracedStore_Spans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+	})
+
+	ctx := context.Background()
+	local, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	store := storage.WithTracing(local)
+
+	require.NoError(t, store.Put(ctx, "a.mp4", strings.NewReader("abc"), -1))
+	_, err = store.Get(ctx, "missing.mp4")
+	assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "storage.Put", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("blob.key", "a.mp4"))
+	assert.Contains(t, spans[0].Attributes(), attribute.Int64("blob.written", 3))
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, "storage.Get", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+
+	// tracing keeps what the store can tell about its disk
+	_, ok := store.(storage.SpaceReporter)
+	assert.True(t, ok)
+}
@@ -12,7 +12,7 @@ import (
 )
 
 var (
-	ErrorUnexpected = entity.NewError("Unexpected error", http.StatusInternalServerError)
+	ErrorUnexpected = entity.NewError("unexpected", "Unexpected error", http.StatusInternalServerError)
 )
 
 // revive:disable:cognitive-complexity,cyclomatic
@@ -0,0 +1,122 @@
+package thumbnail
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+var annexBStartCode = []byte{0, 0, 0, 1}
+
+// FFmpegExtractor decodes H.264 frames with an ffmpeg binary. Only the
+// located sample is piped to it as an Annex B stream, ffmpeg never reads
+// the file.
+type FFmpegExtractor struct {
+	path string
+}
+
+// NewFFmpegExtractor returns ErrUnavailable when path doesn't resolve to an
+// executable.
+func NewFFmpegExtractor(path string) (*FFmpegExtractor, error) {
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return &FFmpegExtractor{path: resolved}, nil
+}
+
+func (e *FFmpegExtractor) ExtractFrame(ctx context.Context, frame *Frame, w io.Writer) error {
+	switch frame.Track.Format {
+	case "avc1", "avc2", "avc3", "avc4":
+	default:
+		return ErrUnsupported
+	}
+
+	stream, err := AnnexB(frame)
+	if err != nil {
+		return err
+	}
+
+	stderr := &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, e.path,
+		"-hide_banner", "-loglevel", "error", "-nostdin",
+		"-f", "h264", "-i", "-",
+		"-frames:v", "1", "-q:v", "3",
+		"-f", "image2pipe", "-c:v", "mjpeg", "-",
+	)
+	cmd.Stdin = bytes.NewReader(stream)
+	cmd.Stdout = w
+	cmd.Stderr = stderr
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("thumbnail: ffmpeg: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// AnnexB converts an H.264 sync sample to a self contained Annex B stream,
+// prefixed with the parameter sets of the track's avcC box.
+func AnnexB(frame *Frame) ([]byte, error) {
+	avcC := frame.Track.ConfigBox("avcC")
+	if avcC == nil || len(avcC.Payload) < 6 {
+		return nil, fmt.Errorf("%w: missing avcC", ErrUnsupported)
+	}
+	config := avcC.Payload
+	lengthSize := int(config[4]&0x03) + 1
+
+	out := &bytes.Buffer{}
+	writeNAL := func(nal []byte) {
+		out.Write(annexBStartCode)
+		out.Write(nal)
+	}
+
+	// sequence then picture parameter sets, each prefixed by a 16-bit size
+	data := config[5:]
+	for _, mask := range []byte{0x1f, 0xff} {
+		if len(data) < 1 {
+			return nil, errShortConfig
+		}
+		count := int(data[0] & mask)
+		data = data[1:]
+		for i := 0; i < count; i++ {
+			if len(data) < 2 {
+				return nil, errShortConfig
+			}
+			n := int(binary.BigEndian.Uint16(data))
+			if len(data) < 2+n {
+				return nil, errShortConfig
+			}
+			writeNAL(data[2 : 2+n])
+			data = data[2+n:]
+		}
+	}
+
+	data = frame.Data
+	for len(data) > 0 {
+		if len(data) < lengthSize {
+			return nil, errShortSample
+		}
+		n := 0
+		for _, b := range data[:lengthSize] {
+			n = n<<8 | int(b)
+		}
+		data = data[lengthSize:]
+		if n > len(data) {
+			return nil, errShortSample
+		}
+		writeNAL(data[:n])
+		data = data[n:]
+	}
+
+	return out.Bytes(), nil
+}
+
+var (
+	errShortConfig = errors.New("thumbnail: truncated avcC")
+	errShortSample = errors.New("thumbnail: truncated H.264 sample")
+)
@@ -0,0 +1,36 @@
+package thumbnail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image/jpeg"
+	"io"
+)
+
+// JPEGQuality is used whenever a frame is encoded to JPEG.
+const JPEGQuality = 85
+
+// mjpegFormats are the sample entries whose samples are JPEG images.
+var mjpegFormats = map[string]bool{
+	"jpeg": true, // Photo JPEG
+	"mjpa": true, // Motion JPEG format A
+	"mjpg": true,
+}
+
+// JPEGExtractor decodes Motion JPEG frames without any external tool.
+type JPEGExtractor struct{}
+
+func (JPEGExtractor) ExtractFrame(ctx context.Context, frame *Frame, w io.Writer) error {
+	if !mjpegFormats[frame.Track.Format] {
+		return ErrUnsupported
+	}
+
+	// re-encoded rather than copied, interlaced MJPEG stores both fields
+	// in one sample and only the first is a complete image
+	img, err := jpeg.Decode(bytes.NewReader(frame.Data))
+	if err != nil {
+		return fmt.Errorf("thumbnail: decode frame: %w", err)
+	}
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: JPEGQuality})
+}
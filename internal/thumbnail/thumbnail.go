@@ -0,0 +1,120 @@
+// Package thumbnail extracts still images from the video track of MP4/MOV
+// files. The sync sample to show is located from the sample tables, only
+// that one sample is read and handed to a FrameExtractor to decode.
+package thumbnail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"video-server/internal/mp4"
+)
+
+const ContentType = "image/jpeg"
+
+var (
+	ErrNoVideo     = errors.New("thumbnail: no video track")
+	ErrNoKeyFrame  = errors.New("thumbnail: no sync sample")
+	ErrUnsupported = errors.New("thumbnail: codec not supported")
+	ErrUnavailable = errors.New("thumbnail: extractor not available")
+)
+
+// Frame is a single sync sample of a video track.
+type Frame struct {
+	Track *mp4.Track
+	// Time of the sample in seconds
+	Time float64
+	Data []byte
+}
+
+// FrameExtractor decodes a frame into a JPEG image. It returns
+// ErrUnsupported for codecs it can't decode.
+type FrameExtractor interface {
+	ExtractFrame(ctx context.Context, frame *Frame, w io.Writer) error
+}
+
+// Chain tries each extractor in turn until one supports the codec.
+type Chain []FrameExtractor
+
+func (c Chain) ExtractFrame(ctx context.Context, frame *Frame, w io.Writer) error {
+	for _, extractor := range c {
+		err := extractor.ExtractFrame(ctx, frame, w)
+		if !errors.Is(err, ErrUnsupported) {
+			return err
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupported, frame.Track.Format)
+}
+
+// NewExtractor decodes MJPEG in Go and everything else with the ffmpeg
+// binary at ffmpegPath, when there is one.
+func NewExtractor(ffmpegPath string) FrameExtractor {
+	chain := Chain{JPEGExtractor{}}
+	if ffmpeg, err := NewFFmpegExtractor(ffmpegPath); err == nil {
+		chain = append(chain, ffmpeg)
+	}
+	return chain
+}
+
+// Locate returns the video sync sample shown at time seconds, the last one
+// at or before it. A negative time selects the first sync sample.
+func Locate(movie *mp4.Movie, time float64) (*mp4.Track, *mp4.Sample, error) {
+	track := movie.VideoTrack()
+	if track == nil {
+		return nil, nil, ErrNoVideo
+	}
+	if track.Timescale == 0 {
+		return nil, nil, ErrNoKeyFrame
+	}
+
+	var found *mp4.Sample
+	for i := range track.Samples {
+		sample := &track.Samples[i]
+		if !sample.Sync {
+			continue
+		}
+		if found != nil && sampleTime(track, sample) > time {
+			break
+		}
+		found = sample
+		if time < 0 {
+			break
+		}
+	}
+	if found == nil {
+		return nil, nil, ErrNoKeyFrame
+	}
+	return track, found, nil
+}
+
+// Extract writes the frame shown at time seconds of the movie in r as a
+// JPEG image, see Locate.
+func Extract(ctx context.Context, extractor FrameExtractor, r io.ReaderAt, size int64, time float64, w io.Writer) error {
+	movie, err := mp4.Parse(r, size)
+	if err != nil {
+		return err
+	}
+	track, sample, err := Locate(movie, time)
+	if err != nil {
+		return err
+	}
+
+	data := make([]byte, sample.Size)
+	_, err = r.ReadAt(data, sample.Offset)
+	if err != nil {
+		return err
+	}
+
+	return extractor.ExtractFrame(ctx, &Frame{
+		Track: track,
+		Time:  sampleTime(track, sample),
+		Data:  data,
+	}, w)
+}
+
+// sampleTime is the presentation time of a sample in seconds.
+func sampleTime(track *mp4.Track, sample *mp4.Sample) float64 {
+	return float64(int64(sample.DTS)+int64(sample.CTSOffset)) / float64(track.Timescale)
+}
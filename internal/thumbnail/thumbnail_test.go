@@ -0,0 +1,156 @@
+package thumbnail_test
+
+import (
+	"bytes"
+	"context"
+	"image/jpeg"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"video-server/internal/mp4"
+	"video-server/internal/thumbnail"
+)
+
+const (
+	samplePath = "../../test/post_1/sample.mp4"
+	mjpegPath  = "../../test/mjpeg/sample.mov"
+)
+
+func openMovie(t *testing.T, path string) (*os.File, int64, *mp4.Movie) {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() })
+
+	info, err := f.Stat()
+	require.NoError(t, err)
+
+	movie, err := mp4.Parse(f, info.Size())
+	require.NoError(t, err)
+	return f, info.Size(), movie
+}
+
+func TestLocate(t *testing.T) {
+	_, _, movie := openMovie(t, samplePath)
+	video := movie.VideoTrack()
+
+	testcases := map[string]struct {
+		time float64
+		// index of the expected sample
+		index int
+	}{
+		"first sync sample": {time: -1, index: 0},
+		"start":             {time: 0, index: 0},
+		"past the end":      {time: 3600, index: lastSync(video)},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			track, sample, err := thumbnail.Locate(movie, tc.time)
+			require.NoError(t, err)
+			assert.Equal(t, video, track)
+			assert.True(t, sample.Sync)
+			assert.Equal(t, &video.Samples[tc.index], sample)
+		})
+	}
+}
+
+func TestLocate_NoVideo(t *testing.T) {
+	_, _, err := thumbnail.Locate(&mp4.Movie{}, 0)
+	assert.ErrorIs(t, err, thumbnail.ErrNoVideo)
+
+	_, _, err = thumbnail.Locate(&mp4.Movie{Tracks: []*mp4.Track{
+		{Handler: mp4.HandlerVideo, Timescale: 1000, Samples: []mp4.Sample{{Size: 1}}},
+	}}, 0)
+	assert.ErrorIs(t, err, thumbnail.ErrNoKeyFrame)
+}
+
+func lastSync(track *mp4.Track) int {
+	last := 0
+	for i, sample := range track.Samples {
+		if sample.Sync {
+			last = i
+		}
+	}
+	return last
+}
+
+func TestExtract_MJPEG(t *testing.T) {
+	f, size, _ := openMovie(t, mjpegPath)
+
+	testcases := map[string]struct {
+		time float64
+		// dominant channel of the frame
+		red bool
+	}{
+		"first frame":  {time: -1, red: true},
+		"second frame": {time: 1.5, red: false},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := thumbnail.Extract(context.Background(), thumbnail.NewExtractor(""), f, size, tc.time, buf)
+			require.NoError(t, err)
+
+			img, err := jpeg.Decode(buf)
+			require.NoError(t, err)
+			assert.Equal(t, 32, img.Bounds().Dx())
+			assert.Equal(t, 16, img.Bounds().Dy())
+
+			r, _, b, _ := img.At(8, 8).RGBA()
+			assert.Equal(t, tc.red, r > b)
+		})
+	}
+}
+
+func TestExtract_Unsupported(t *testing.T) {
+	f, size, _ := openMovie(t, samplePath)
+
+	err := thumbnail.Extract(context.Background(), thumbnail.Chain{thumbnail.JPEGExtractor{}}, f, size, 0, &bytes.Buffer{})
+	assert.ErrorIs(t, err, thumbnail.ErrUnsupported)
+}
+
+func TestAnnexB(t *testing.T) {
+	f, _, movie := openMovie(t, samplePath)
+	track, sample, err := thumbnail.Locate(movie, 0)
+	require.NoError(t, err)
+
+	data := make([]byte, sample.Size)
+	_, err = f.ReadAt(data, sample.Offset)
+	require.NoError(t, err)
+
+	stream, err := thumbnail.AnnexB(&thumbnail.Frame{Track: track, Data: data})
+	require.NoError(t, err)
+
+	types := []byte{}
+	for _, nal := range bytes.Split(stream, []byte{0, 0, 0, 1})[1:] {
+		types = append(types, nal[0]&0x1f)
+	}
+	require.GreaterOrEqual(t, len(types), 3)
+	// sequence and picture parameter sets, then an IDR slice
+	assert.Equal(t, []byte{7, 8}, types[:2])
+	assert.Contains(t, types[2:], byte(5))
+
+	_, err = thumbnail.AnnexB(&thumbnail.Frame{Track: track, Data: data[:3]})
+	assert.Error(t, err)
+}
+
+func TestFFmpegExtractor(t *testing.T) {
+	_, err := thumbnail.NewFFmpegExtractor("video-server-no-such-ffmpeg")
+	assert.ErrorIs(t, err, thumbnail.ErrUnavailable)
+
+	extractor, err := thumbnail.NewFFmpegExtractor("ffmpeg")
+	if err != nil {
+		t.Skip("ffmpeg not installed")
+	}
+
+	f, size, _ := openMovie(t, samplePath)
+	buf := &bytes.Buffer{}
+	require.NoError(t, thumbnail.Extract(context.Background(), extractor, f, size, 2, buf))
+
+	config, err := jpeg.DecodeConfig(buf)
+	require.NoError(t, err)
+	assert.Equal(t, 1920, config.Width)
+	assert.Equal(t, 1080, config.Height)
+}
@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"errors"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// spanKey holds the span of a statement between its callbacks.
+const spanKey = "tracing:span"
+
+// GormPlugin starts a span per gorm operation, a child of the span in the
+// context the statement was given with WithContext.
+type GormPlugin struct{}
+
+func NewGormPlugin() *GormPlugin {
+	return &GormPlugin{}
+}
+
+func (p *GormPlugin) Name() string {
+	return "tracing"
+}
+
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("*").Register("tracing:before_create", startQuery("gorm.create")),
+		cb.Create().After("*").Register("tracing:after_create", endQuery),
+		cb.Query().Before("*").Register("tracing:before_query", startQuery("gorm.query")),
+		cb.Query().After("*").Register("tracing:after_query", endQuery),
+		cb.Update().Before("*").Register("tracing:before_update", startQuery("gorm.update")),
+		cb.Update().After("*").Register("tracing:after_update", endQuery),
+		cb.Delete().Before("*").Register("tracing:before_delete", startQuery("gorm.delete")),
+		cb.Delete().After("*").Register("tracing:after_delete", endQuery),
+		cb.Row().Before("*").Register("tracing:before_row", startQuery("gorm.row")),
+		cb.Row().After("*").Register("tracing:after_row", endQuery),
+		cb.Raw().Before("*").Register("tracing:before_raw", startQuery("gorm.raw")),
+		cb.Raw().After("*").Register("tracing:after_raw", endQuery),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func startQuery(name string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement.Context == nil {
+			return
+		}
+		ctx, span := Start(db.Statement.Context, name, semconv.DBSystemMySQL)
+		db.Statement.Context = ctx
+		db.InstanceSet(spanKey, span)
+	}
+}
+
+// endQuery ends the span of startQuery, the statement is only built by then.
+func endQuery(db *gorm.DB) {
+	value, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	defer span.End()
+
+	span.SetAttributes(
+		semconv.DBStatementKey.String(db.Statement.SQL.String()),
+		semconv.DBSQLTableKey.String(db.Statement.Table),
+	)
+	// not finding a record is an answer, not a failure
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		RecordError(span, db.Error)
+	}
+}
@@ -0,0 +1,72 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+
+	"video-server/internal/testutil"
+	"video-server/internal/tracing"
+)
+
+type row struct {
+	ID   int
+	Name string
+}
+
+func TestGormPlugin(t *testing.T) {
+	testcases := map[string]struct {
+		mock   func(mock sqlmock.Sqlmock)
+		status codes.Code
+	}{
+		"found": {
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT \\* FROM `rows`").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+			},
+			status: codes.Unset,
+		},
+		"not found": {
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT \\* FROM `rows`").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+			},
+			status: codes.Unset,
+		},
+		"db error": {
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT \\* FROM `rows`").
+					WillReturnError(errors.New("DB Error"))
+			},
+			status: codes.Error,
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			recorder := recordSpans(t)
+			db, mock := testutil.NewDatabase()
+			require.NoError(t, db.Use(tracing.NewGormPlugin()))
+			tc.mock(mock)
+
+			ctx, parent := tracing.Start(context.Background(), "parent")
+			db.WithContext(ctx).First(&row{})
+			parent.End()
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 2)
+			query := spans[0]
+			assert.Equal(t, "gorm.query", query.Name())
+			assert.Equal(t, parent.SpanContext().SpanID(), query.Parent().SpanID())
+			assert.Equal(t, tc.status, query.Status().Code)
+			assert.Contains(t, query.Attributes(), semconv.DBSystemMySQL)
+			assert.Contains(t, query.Attributes(), semconv.DBSQLTableKey.String("rows"))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
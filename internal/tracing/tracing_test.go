@@ -0,0 +1,129 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"video-server/internal/tracing"
+)
+
+// recordSpans installs a tracer provider keeping the spans ended during
+// the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+	})
+	return recorder
+}
+
+func TestSetup(t *testing.T) {
+	testcases := map[string]struct {
+		exporter string
+		spans    bool
+		err      string
+	}{
+		"none": {
+			exporter: tracing.ExporterNone,
+		},
+		"file": {
+			exporter: tracing.ExporterFile,
+			spans:    true,
+		},
+		"unknown exporter": {
+			exporter: "zipkin",
+			err:      `unknown tracing exporter "zipkin"`,
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Cleanup(func() {
+				otel.SetTracerProvider(trace.NewNoopTracerProvider())
+			})
+			path := filepath.Join(t.TempDir(), "traces.json")
+
+			shutdown, err := tracing.Setup(context.Background(), tracing.Config{
+				ServiceName: "video-server-test",
+				Exporter:    tc.exporter,
+				FilePath:    path,
+				SampleRatio: 1,
+			})
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+
+			_, span := tracing.Start(context.Background(), "test.span")
+			span.End()
+			require.NoError(t, shutdown(context.Background()))
+
+			data, err := os.ReadFile(path)
+			if !tc.spans {
+				assert.True(t, os.IsNotExist(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, string(data), `"Name":"test.span"`)
+			assert.Contains(t, string(data), "video-server-test")
+		})
+	}
+}
+
+func TestStart(t *testing.T) {
+	ctx := context.Background()
+
+	// without a provider nothing is traced and ctx is left alone
+	spanCtx, span := tracing.Start(ctx, "untraced")
+	span.End()
+	assert.Equal(t, ctx, spanCtx)
+
+	recorder := recordSpans(t)
+	parentCtx, parent := tracing.Start(ctx, "parent")
+	_, child := tracing.Start(parentCtx, "child", tracing.FileIDKey.Int(1))
+	tracing.RecordError(child, errors.New("failed"))
+	child.End()
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "child", spans[0].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Contains(t, spans[0].Attributes(), tracing.FileIDKey.Int(1))
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+}
+
+func TestDetach(t *testing.T) {
+	recorder := recordSpans(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx, span := tracing.Start(ctx, "request")
+	cancel()
+
+	detached := tracing.Detach(ctx)
+	assert.NoError(t, detached.Err())
+	assert.Nil(t, detached.Done())
+	_, ok := detached.Deadline()
+	assert.False(t, ok)
+
+	_, child := tracing.Start(detached, "after request")
+	child.End()
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, span.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, "after request", spans[0].Name())
+}
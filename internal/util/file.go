@@ -1,100 +1,230 @@
 package util
 
 import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
-	"mime/multipart"
-	"os"
-	"path/filepath"
+	"path"
+	"strings"
+	"unicode"
 
 	"github.com/gabriel-vasile/mimetype"
-)
+	"github.com/google/uuid"
 
-var (
-	StoragePath = filepath.Join(".", "files")
+	"video-server/internal/storage"
 )
 
+var ErrFileTooLarge = errors.New("file too large")
+
+// sniffSize is how much of the content is held back to detect its type,
+// the same amount mimetype reads.
+const sniffSize = 3072
+
+// FileReader streams an uploaded file into storage in a single pass. The
+// content is staged by Store, can then be inspected through ReadAt, and is
+// either committed under its digest or discarded.
 type FileReader interface {
 	GetName() string
 	GetSize() int64
 	GetFileMimeType() (string, error)
-	Store() error
+	GetDigest() string
+	ReadAt(p []byte, off int64) (int, error)
+	Store(ctx context.Context, store storage.BlobStore, limit int64) error
+	Commit(ctx context.Context) error
+	Discard(ctx context.Context) error
 	Close() error
 }
 
 type fileReader struct {
-	File   multipart.File
-	Header *multipart.FileHeader
+	reader *bufio.Reader
+	source io.Reader
 
 	fileMimeType string
+	digest       string
 	name         string
 	size         int64
+
+	store   storage.BlobStore
+	staged  string
+	content storage.Blob
 }
 
-func NewFileReader(
-	file multipart.File,
-	header *multipart.FileHeader,
-) *fileReader {
+func NewFileReader(r io.Reader, name string) *fileReader {
 	return &fileReader{
-		File:   file,
-		Header: header,
-		name:   "",
+		reader: bufio.NewReaderSize(r, sniffSize),
+		source: r,
+		name:   name,
 		size:   -1,
 	}
 }
 
 func (f *fileReader) GetName() string {
-	if f.name == "" {
-		f.name = f.Header.Filename
-	}
 	return f.name
 }
 
+// GetSize returns the content length, it is only known once Store has run.
 func (f *fileReader) GetSize() int64 {
-	if f.size == -1 {
-		f.size = f.Header.Size
-	}
 	return f.size
 }
 
+// GetFileMimeType detects the type from the first bytes without consuming
+// them.
 func (f *fileReader) GetFileMimeType() (string, error) {
 	if f.fileMimeType == "" {
-		mimeType, err := mimetype.DetectReader(f.File)
-		if err != nil {
-			panic(fmt.Sprintf("File Read Error: %s", err))
+		head, err := f.reader.Peek(sniffSize)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return "", err
 		}
-		fileMimeType := mimeType.String()
-
-		// back to 0 position
-		_, err = f.File.Seek(0, io.SeekStart)
-		if err != nil {
-			panic(fmt.Sprintf("File Seek Error: %s", err))
-		}
-
-		f.fileMimeType = fileMimeType
+		f.fileMimeType = mimetype.Detect(head).String()
 	}
 
 	return f.fileMimeType, nil
 }
 
-func (f *fileReader) Store() error {
-	_ = os.MkdirAll(StoragePath, os.ModePerm)
+// GetDigest returns the hex encoded SHA-256 of the content, it is only known
+// once Store has run.
+func (f *fileReader) GetDigest() string {
+	return f.digest
+}
+
+// ReadAt reads the staged content at an offset.
+func (f *fileReader) ReadAt(p []byte, off int64) (int, error) {
+	if f.content == nil {
+		return 0, errors.New("file reader: content not stored")
+	}
+	return f.content.ReadAt(p, off)
+}
 
-	fullPath := StoragePath + "/" + f.GetName()
-	osFile, err := os.OpenFile(fullPath, os.O_WRONLY|os.O_CREATE, os.ModePerm)
+// Store writes the content to a staging key while hashing it, failing with
+// ErrFileTooLarge as soon as more than limit bytes arrive. The size is
+// unknown up front, stores hold at most a part of it in memory. A failed
+// Store leaves nothing behind.
+func (f *fileReader) Store(ctx context.Context, store storage.BlobStore, limit int64) error {
+	key := stagingKey()
+	hash := sha256.New()
+	counter := &limitedReader{reader: io.TeeReader(f.reader, hash), limit: limit}
+
+	err := store.Put(ctx, key, counter, -1)
+	if counter.exceeded {
+		err = ErrFileTooLarge
+	}
+	if err != nil {
+		_ = store.Delete(ctx, key)
+		return err
+	}
+
+	f.store = store
+	f.staged = key
+	f.digest = hex.EncodeToString(hash.Sum(nil))
+	f.size = counter.size
+
+	f.content, err = store.Get(ctx, key)
+	if err != nil {
+		_ = f.Discard(ctx)
+		return err
+	}
+	return nil
+}
+
+// Commit moves the staged content under its digest, dropping it when a blob
+// with the same content is already stored.
+func (f *fileReader) Commit(ctx context.Context) error {
+	if f.staged == "" {
+		return errors.New("file reader: content not stored")
+	}
+	f.closeContent()
+
+	key := storage.DigestKey(f.digest)
+	_, err := f.store.Stat(ctx, key)
+	switch {
+	case err == nil:
+		err = f.store.Delete(ctx, f.staged)
+	case errors.Is(err, storage.ErrBlobNotFound):
+		err = f.store.Move(ctx, f.staged, key)
+	}
 	if err != nil {
 		return err
 	}
-	defer osFile.Close()
 
-	_, err = io.Copy(osFile, f.File)
-	return err
+	f.staged = ""
+	return nil
+}
+
+// Discard removes the staged content.
+func (f *fileReader) Discard(ctx context.Context) error {
+	if f.staged == "" {
+		return nil
+	}
+	f.closeContent()
+
+	err := f.store.Delete(ctx, f.staged)
+	if err != nil && !errors.Is(err, storage.ErrBlobNotFound) {
+		return err
+	}
+
+	f.staged = ""
+	return nil
+}
+
+func (f *fileReader) closeContent() {
+	if f.content != nil {
+		f.content.Close()
+		f.content = nil
+	}
 }
 
 func (f *fileReader) Close() error {
-	closer, ok := interface{}(f.File).(io.Closer)
+	f.closeContent()
+
+	closer, ok := f.source.(io.Closer)
 	if !ok {
 		return nil
 	}
 	return closer.Close()
 }
+
+func stagingKey() string {
+	return fmt.Sprintf("staging/%s", uuid.NewString())
+}
+
+// limitedReader counts what passes through and stops with an error once
+// more than limit bytes were read, a limit <= 0 doesn't limit.
+type limitedReader struct {
+	reader   io.Reader
+	limit    int64
+	size     int64
+	exceeded bool
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.size += int64(n)
+	if r.limit > 0 && r.size > r.limit {
+		r.exceeded = true
+		return n, ErrFileTooLarge
+	}
+	return n, err
+}
+
+// SanitizeFileName reduces a client supplied name to a single path element
+// without control characters. The result is display metadata only and never
+// used as a storage location.
+func SanitizeFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+
+	if name == "" || name == "." || name == ".." || name == "/" {
+		return "file"
+	}
+	return name
+}
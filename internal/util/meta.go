@@ -3,6 +3,7 @@ package util
 var (
 	DefaultLimit  = 10
 	DefaultOffset = 0
+	MaxLimit      = 100
 )
 
 type OffsetPagination struct {
@@ -18,3 +19,17 @@ func NewOffsetPagination(limit int, offset int, total int) *OffsetPagination {
 		Total:  total,
 	}
 }
+
+// Pagination is an OffsetPagination that may also hand out an opaque cursor
+// to the page after the current one.
+type Pagination struct {
+	OffsetPagination
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func NewPagination(limit int, offset int, total int, nextCursor string) *Pagination {
+	return &Pagination{
+		OffsetPagination: *NewOffsetPagination(limit, offset, total),
+		NextCursor:       nextCursor,
+	}
+}
@@ -1,16 +1,47 @@
 package config
 
 import (
+	"net/http"
+
 	"github.com/julienschmidt/httprouter"
 
+	"video-server/internal/metrics"
 	"video-server/module/internal/handler"
 )
 
 func RegisterHandler(router *httprouter.Router, usecase *Usecase) {
+	router.NotFound = http.HandlerFunc(handler.NotFound)
+	router.MethodNotAllowed = http.HandlerFunc(handler.MethodNotAllowed)
+	router.PanicHandler = handler.Panic
 
-	healthHandler := handler.NewHealthHandler()
+	healthHandler := handler.NewHealthHandler(usecase.HealthUsecase)
 	fileHandler := handler.NewFileHandler(usecase.FileUsecase)
+	uploadHandler := handler.NewUploadHandler(usecase.UploadUsecase)
+	streamHandler := handler.NewStreamHandler(usecase.StreamUsecase)
+	thumbnailHandler := handler.NewThumbnailHandler(usecase.ThumbnailUsecase)
+	jobHandler := handler.NewJobHandler(usecase.JobUsecase)
+	grantHandler := handler.NewGrantHandler(usecase.GrantUsecase)
+	linkHandler := handler.NewLinkHandler(usecase.LinkUsecase)
+	usageHandler := handler.NewUsageHandler(usecase.UsageUsecase)
 
 	healthHandler.Register(router)
 	fileHandler.Register(router)
+	uploadHandler.Register(router)
+	streamHandler.Register(router)
+	thumbnailHandler.Register(router)
+	jobHandler.Register(router)
+	grantHandler.Register(router)
+	linkHandler.Register(router)
+	usageHandler.Register(router)
+}
+
+// RegisterMetricsHandler serves /metrics on router, the router of a listener
+// of its own: the metrics cover every tenant and bypass authentication.
+func RegisterMetricsHandler(router *httprouter.Router) {
+	router.NotFound = http.HandlerFunc(handler.NotFound)
+	router.MethodNotAllowed = http.HandlerFunc(handler.MethodNotAllowed)
+	router.PanicHandler = handler.Panic
+
+	metricsHandler := handler.NewMetricsHandler(metrics.Registry)
+	metricsHandler.Register(router)
 }
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/collectors"
+
+	"video-server/internal/metrics"
+)
+
+// usageScrapeTimeout bounds the query summing the stored files on every
+// scrape.
+const usageScrapeTimeout = 5 * time.Second
+
+// RegisterMetrics adds the connection pool of db and the stored files of
+// every tenant to the metrics registry.
+func RegisterMetrics(db *sql.DB, usecase *Usecase) {
+	metrics.Registry.MustRegister(
+		collectors.NewDBStatsCollector(db, "video_server"),
+		metrics.NewUsageCollector(func(ctx context.Context) ([]*metrics.TenantUsage, error) {
+			usages, err := usecase.UsageUsecase.ListUsage(ctx)
+			if err != nil {
+				return nil, err
+			}
+			result := make([]*metrics.TenantUsage, len(usages))
+			for i, usage := range usages {
+				result[i] = &metrics.TenantUsage{Tenant: usage.TenantID, Files: usage.Files, Bytes: usage.Bytes}
+			}
+			return result, nil
+		}, usageScrapeTimeout),
+	)
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/sirupsen/logrus"
+
+	"video-server/module/internal/handler"
+)
+
+type MiddlewareConfig struct {
+	// AuthEnabled requires an API key or bearer token outside PublicPaths
+	AuthEnabled bool
+	PublicPaths []string
+
+	// TenantHeader names the header scoping a request to a tenant, empty
+	// ignores it
+	TenantHeader string
+}
+
+// RegisterMiddleware wraps the routes of router in the middleware enabled
+// by cfg.
+func RegisterMiddleware(router *httprouter.Router, usecase *Usecase, cfg MiddlewareConfig) http.Handler {
+	var next http.Handler = router
+	// the tenant is checked against the principal, auth wraps around it
+	if cfg.TenantHeader != "" {
+		next = handler.NewTenantMiddleware(cfg.TenantHeader).Wrap(next)
+	}
+	if cfg.AuthEnabled {
+		// orchestrators probe without credentials
+		publicPaths := append([]string{"/v1/health", "/v1/health/live", "/v1/health/ready"}, cfg.PublicPaths...)
+		next = handler.NewAuthMiddleware(usecase.AuthUsecase, publicPaths).Wrap(next)
+	}
+	// requests refused by auth are counted too
+	next = handler.NewMetricsMiddleware(router).Wrap(next)
+	// access logs carry the request and trace IDs
+	next = handler.NewLoggingMiddleware(router, logrus.StandardLogger()).Wrap(next)
+	// spans are tagged with the request ID
+	next = handler.NewTracingMiddleware(router).Wrap(next)
+	// outermost, so every error answered carries the request ID
+	next = handler.NewRequestIDMiddleware().Wrap(next)
+
+	return next
+}
@@ -7,13 +7,37 @@ import (
 )
 
 type Repository struct {
-	FileRepository repository.FileRepository
+	FileRepository     repository.FileRepository
+	BlobRepository     repository.BlobRepository
+	UploadRepository   repository.UploadRepository
+	MetadataRepository repository.MetadataRepository
+	JobRepository      repository.JobRepository
+	APIKeyRepository   repository.APIKeyRepository
+	GrantRepository    repository.GrantRepository
+	LinkRepository     repository.LinkRepository
+	HealthRepository   repository.HealthRepository
 }
 
 func RegisterRepository(db *gorm.DB) *Repository {
 	fileRepo := repository.NewFileRepository(db)
+	blobRepo := repository.NewBlobRepository(db)
+	uploadRepo := repository.NewUploadRepository(db)
+	metadataRepo := repository.NewMetadataRepository(db)
+	jobRepo := repository.NewJobRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	grantRepo := repository.NewGrantRepository(db)
+	linkRepo := repository.NewLinkRepository(db)
+	healthRepo := repository.NewHealthRepository(db)
 
 	return &Repository{
-		FileRepository: fileRepo,
+		FileRepository:     fileRepo,
+		BlobRepository:     blobRepo,
+		UploadRepository:   uploadRepo,
+		MetadataRepository: metadataRepo,
+		JobRepository:      jobRepo,
+		APIKeyRepository:   apiKeyRepo,
+		GrantRepository:    grantRepo,
+		LinkRepository:     linkRepo,
+		HealthRepository:   healthRepo,
 	}
 }
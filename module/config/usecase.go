@@ -1,15 +1,135 @@
 package config
 
-import "video-server/module/internal/usecase"
+import (
+	"time"
+
+	"video-server/internal/auth"
+	"video-server/internal/storage"
+	"video-server/internal/thumbnail"
+	"video-server/module/internal/usecase"
+)
+
+type UsecaseConfig struct {
+	UniqueFileNames    bool
+	MaxFileSize        int64
+	AllowedContainers  []string
+	AllowedVideoCodecs []string
+	AllowedAudioCodecs []string
+	FFmpegPath         string
+
+	// QuotaMaxBytes and QuotaMaxFiles limit every tenant, the tenant maps
+	// override them for single tenants. 0 doesn't limit.
+	QuotaMaxBytes  int64
+	QuotaMaxFiles  int64
+	TenantMaxBytes map[string]int64
+	TenantMaxFiles map[string]int64
+
+	JobMaxAttempts int
+	JobBackoff     time.Duration
+	JobMaxBackoff  time.Duration
+	JobLease       time.Duration
+
+	// TokenVerifier checks bearer tokens, nil accepts API keys only
+	TokenVerifier auth.TokenVerifier
+
+	// LinkSigner signs download links, nil disables them
+	LinkSigner     *auth.Signer
+	LinkDefaultTTL time.Duration
+	LinkMaxTTL     time.Duration
+
+	// HealthMinFreeBytes is the free space the readiness probe requires of
+	// stores on a local volume, 0 doesn't check
+	HealthMinFreeBytes int64
+	HealthTimeout      time.Duration
+}
 
 type Usecase struct {
-	FileUsecase usecase.FileUsecase
+	FileUsecase      usecase.FileUsecase
+	UploadUsecase    usecase.UploadUsecase
+	StreamUsecase    usecase.StreamUsecase
+	ThumbnailUsecase usecase.ThumbnailUsecase
+	ReconcileUsecase usecase.ReconcileUsecase
+	JobUsecase       usecase.JobUsecase
+	AuthUsecase      usecase.AuthUsecase
+	GrantUsecase     usecase.GrantUsecase
+	LinkUsecase      usecase.LinkUsecase
+	UsageUsecase     usecase.UsageUsecase
+	HealthUsecase    usecase.HealthUsecase
 }
 
-func RegisterUsecase(repository *Repository) *Usecase {
-	fileUcs := usecase.NewFileUsecase(repository.FileRepository)
+func RegisterUsecase(repository *Repository, blobStore storage.BlobStore, cfg UsecaseConfig) *Usecase {
+	quota := newQuotaConfig(cfg)
+	thumbnailUcs := usecase.NewThumbnailUsecase(repository.FileRepository, repository.GrantRepository, blobStore, thumbnail.NewExtractor(cfg.FFmpegPath))
+	fileUcs := usecase.NewFileUsecase(repository.FileRepository, repository.BlobRepository, repository.MetadataRepository, repository.JobRepository, repository.GrantRepository, blobStore, usecase.FileConfig{
+		UniqueNames:        cfg.UniqueFileNames,
+		MaxSize:            cfg.MaxFileSize,
+		AllowedContainers:  cfg.AllowedContainers,
+		AllowedVideoCodecs: cfg.AllowedVideoCodecs,
+		AllowedAudioCodecs: cfg.AllowedAudioCodecs,
+		Quota:              quota,
+	})
+	uploadUcs := usecase.NewUploadUsecase(repository.UploadRepository, repository.FileRepository, blobStore, fileUcs, usecase.UploadConfig{
+		MaxSize: cfg.MaxFileSize,
+		Quota:   quota,
+	})
+	streamUcs := usecase.NewStreamUsecase(fileUcs, blobStore)
+	reconcileUcs := usecase.NewReconcileUsecase(repository.FileRepository, repository.BlobRepository, repository.UploadRepository, blobStore)
+	jobUcs := usecase.NewJobUsecase(repository.JobRepository, repository.FileRepository, blobStore, fileUcs, streamUcs, thumbnailUcs, usecase.JobConfig{
+		MaxAttempts: cfg.JobMaxAttempts,
+		Backoff:     cfg.JobBackoff,
+		MaxBackoff:  cfg.JobMaxBackoff,
+		Lease:       cfg.JobLease,
+	})
+	authUcs := usecase.NewAuthUsecase(repository.APIKeyRepository, repository.LinkRepository, cfg.TokenVerifier, cfg.LinkSigner)
+	grantUcs := usecase.NewGrantUsecase(repository.FileRepository, repository.GrantRepository)
+	linkUcs := usecase.NewLinkUsecase(repository.LinkRepository, repository.GrantRepository, fileUcs, cfg.LinkSigner, usecase.LinkConfig{
+		DefaultTTL: cfg.LinkDefaultTTL,
+		MaxTTL:     cfg.LinkMaxTTL,
+	})
+	usageUcs := usecase.NewUsageUsecase(repository.FileRepository, quota)
+	space, _ := blobStore.(storage.SpaceReporter)
+	healthUcs := usecase.NewHealthUsecase(repository.HealthRepository, blobStore, space, usecase.HealthConfig{
+		MinFreeBytes: cfg.HealthMinFreeBytes,
+		Timeout:      cfg.HealthTimeout,
+	})
 
 	return &Usecase{
-		FileUsecase: fileUcs,
+		FileUsecase:      fileUcs,
+		UploadUsecase:    uploadUcs,
+		StreamUsecase:    streamUcs,
+		ThumbnailUsecase: thumbnailUcs,
+		ReconcileUsecase: reconcileUcs,
+		JobUsecase:       jobUcs,
+		AuthUsecase:      authUcs,
+		GrantUsecase:     grantUcs,
+		LinkUsecase:      linkUcs,
+		UsageUsecase:     usageUcs,
+		HealthUsecase:    healthUcs,
+	}
+}
+
+// newQuotaConfig merges the per tenant overrides, a tenant overriding one
+// limit keeps the default of the other.
+func newQuotaConfig(cfg UsecaseConfig) usecase.QuotaConfig {
+	quota := usecase.QuotaConfig{
+		Default: usecase.Quota{MaxBytes: cfg.QuotaMaxBytes, MaxFiles: cfg.QuotaMaxFiles},
+		Tenants: map[string]usecase.Quota{},
+	}
+	for tenant, maxBytes := range cfg.TenantMaxBytes {
+		q, ok := quota.Tenants[tenant]
+		if !ok {
+			q = quota.Default
+		}
+		q.MaxBytes = maxBytes
+		quota.Tenants[tenant] = q
+	}
+	for tenant, maxFiles := range cfg.TenantMaxFiles {
+		q, ok := quota.Tenants[tenant]
+		if !ok {
+			q = quota.Default
+		}
+		q.MaxFiles = maxFiles
+		quota.Tenants[tenant] = q
 	}
+	return quota
 }
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"video-server/internal/logging"
+	"video-server/module/param"
+)
+
+type WorkerConfig struct {
+	ReconcileInterval    time.Duration
+	ReconcileGracePeriod time.Duration
+	ReconcileDryRun      bool
+
+	// JobWorkers run file jobs concurrently, each polls every
+	// JobPollInterval while the queue is empty
+	JobWorkers      int
+	JobPollInterval time.Duration
+}
+
+// Workers are the running background jobs.
+type Workers struct {
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+}
+
+// RegisterWorker starts the background jobs, they stop picking up work once
+// ctx is done. The work at hand runs on until Workers.Wait gives up on it.
+func RegisterWorker(ctx context.Context, usecase *Usecase, cfg WorkerConfig) *Workers {
+	workCtx, cancel := context.WithCancel(context.Background())
+	workers := &Workers{cancel: cancel}
+	if cfg.ReconcileInterval > 0 {
+		workers.start(func() { reconcileWorker(ctx, workCtx, usecase, cfg) })
+	}
+	for i := 0; i < cfg.JobWorkers; i++ {
+		workers.start(func() { jobWorker(ctx, workCtx, usecase, cfg) })
+	}
+	return workers
+}
+
+func (w *Workers) start(run func()) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		run()
+	}()
+}
+
+// Wait blocks until the workers are stopped. The work at hand is cancelled
+// when ctx is done first, jobs cut off this way are taken over by another
+// worker once their lease expires.
+func (w *Workers) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		w.cancel()
+		return nil
+	case <-ctx.Done():
+		w.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// jobWorker polls until ctx is done, jobs run with workCtx so a stopping
+// worker finishes the one at hand.
+func jobWorker(ctx, workCtx context.Context, usecase *Usecase, cfg WorkerConfig) {
+	for ctx.Err() == nil {
+		ran, err := usecase.JobUsecase.RunNext(workCtx)
+		if err != nil {
+			logging.FromContext(workCtx).WithError(err).Error("job worker")
+		} else if ran {
+			// keep draining the queue
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(cfg.JobPollInterval):
+		}
+	}
+}
+
+func reconcileWorker(ctx, workCtx context.Context, usecase *Usecase, cfg WorkerConfig) {
+	ticker := time.NewTicker(cfg.ReconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		report, err := usecase.ReconcileUsecase.Reconcile(workCtx, &param.Reconcile{
+			GracePeriod: cfg.ReconcileGracePeriod,
+			DryRun:      cfg.ReconcileDryRun,
+		})
+		if err != nil {
+			logging.FromContext(workCtx).WithError(err).Error("reconcile failed")
+			continue
+		}
+
+		// one line per run so the log can be alerted on
+		logging.FromContext(workCtx).WithField("report", report).Info("reconcile report")
+	}
+}
@@ -0,0 +1,133 @@
+package entity
+
+import (
+	"context"
+	"regexp"
+	"time"
+)
+
+// How a request proved who it is.
+const (
+	AuthMethodAPIKey = "api_key"
+	AuthMethodJWT    = "jwt"
+	AuthMethodLink   = "link"
+)
+
+// Roles, each allows what the previous one does. Viewers read the files
+// they own or were granted, uploaders create files too and may delete and
+// share their own, admins act on every file of their tenant.
+const (
+	RoleViewer   = "viewer"
+	RoleUploader = "uploader"
+	RoleAdmin    = "admin"
+)
+
+var roleRanks = map[string]int{
+	RoleViewer:   1,
+	RoleUploader: 2,
+	RoleAdmin:    3,
+}
+
+// IsRole tells whether role is one of the known roles.
+func IsRole(role string) bool {
+	return roleRanks[role] > 0
+}
+
+// tenantPattern keeps tenant names safe to use in storage keys.
+var tenantPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{0,63}$`)
+
+// IsTenant tells whether tenant is a valid tenant name, the empty tenant
+// being the default one.
+func IsTenant(tenant string) bool {
+	return tenant == "" || tenantPattern.MatchString(tenant)
+}
+
+// APIKey is a long-lived credential of a service. Only the SHA-256 of the
+// key is stored, the key itself is shown once when it is created.
+type APIKey struct {
+	ID   int    `gorm:"primaryKey" json:"keyid"`
+	Name string `gorm:"size:255" json:"name"`
+	// Subject is the principal the key authenticates as
+	Subject string `gorm:"size:255" json:"subject"`
+	Role    string `gorm:"size:16;default:uploader" json:"role"`
+	Tenant  string `gorm:"size:255" json:"tenant"`
+	// Prefix is the start of the key, enough to tell keys apart in a list
+	Prefix    string     `gorm:"size:16" json:"prefix"`
+	Hash      string     `gorm:"size:64;uniqueIndex" json:"-"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// IsActive tells whether the key may still be used at t.
+func (k *APIKey) IsActive(t time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || t.Before(*k.ExpiresAt))
+}
+
+func (k *APIKey) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"ID":        k.ID,
+		"Name":      k.Name,
+		"Subject":   k.Subject,
+		"Role":      k.Role,
+		"Tenant":    k.Tenant,
+		"Prefix":    k.Prefix,
+		"Hash":      k.Hash,
+		"ExpiresAt": k.ExpiresAt,
+		"RevokedAt": k.RevokedAt,
+		"CreatedAt": k.CreatedAt,
+		"UpdatedAt": k.UpdatedAt,
+	}
+}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject string
+	Role    string
+	// Tenant isolates principals and their files from other tenants
+	Tenant string
+	Method string
+	// KeyID is the API key or download link used, 0 for tokens
+	KeyID int
+	// FileID confines a download link to the file it was signed for
+	FileID int
+}
+
+// HasRole tells whether the principal has role or one above it.
+func (p *Principal) HasRole(role string) bool {
+	return roleRanks[p.Role] >= roleRanks[role]
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the caller attached by the auth middleware,
+// nil for unauthenticated contexts like background jobs.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx scoped to tenant, for requests without a
+// principal that name their tenant.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant of the principal, or the one ctx was
+// scoped to without a principal. ok is false for unscoped contexts, which
+// reach every tenant.
+func TenantFromContext(ctx context.Context) (tenant string, ok bool) {
+	if p := PrincipalFromContext(ctx); p != nil {
+		return p.Tenant, true
+	}
+	tenant, ok = ctx.Value(tenantKey{}).(string)
+	return tenant, ok
+}
@@ -0,0 +1,24 @@
+package entity
+
+import "time"
+
+// Blob is a stored object addressed by the SHA-256 of its content. Files
+// of a tenant with identical content share one blob, RefCount tracks how
+// many do. Tenants never share blobs, each stores its own copy.
+type Blob struct {
+	TenantID  string    `gorm:"primaryKey;size:255" json:"-"`
+	Digest    string    `gorm:"primaryKey;size:64" json:"digest"`
+	Size      int64     `json:"size"`
+	RefCount  int64     `json:"ref_count"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (b *Blob) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"TenantID":  b.TenantID,
+		"Digest":    b.Digest,
+		"Size":      b.Size,
+		"RefCount":  b.RefCount,
+		"CreatedAt": b.CreatedAt,
+	}
+}
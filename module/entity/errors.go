@@ -8,27 +8,107 @@ import (
 
 var (
 	// General
-	ErrorBadRequest = NewError("Bad Request", http.StatusBadRequest)
+	ErrorBadRequest = NewError("bad_request", "Bad Request", http.StatusBadRequest)
 
-	ErrorParamType = NewError("Wrong param type", http.StatusUnprocessableEntity)
+	ErrorNotFound         = NewError("not_found", "Not found", http.StatusNotFound)
+	ErrorMethodNotAllowed = NewError("method_not_allowed", "Method not allowed", http.StatusMethodNotAllowed)
 
-	ErrorFileNotFound    = NewError("File not found", http.StatusNotFound)
-	ErrorFileExists      = NewError("File exists", http.StatusConflict)
-	ErrorFileUnsupported = NewError("File unsupported", http.StatusUnsupportedMediaType)
+	ErrorParamType = NewError("param_type", "Wrong param type", http.StatusUnprocessableEntity)
+
+	ErrorUnauthorized = NewError("unauthorized", "Unauthorized", http.StatusUnauthorized)
+	ErrorForbidden    = NewError("forbidden", "Forbidden", http.StatusForbidden)
+
+	ErrorFileNotFound    = NewError("file_not_found", "File not found", http.StatusNotFound)
+	ErrorFileExists      = NewError("file_exists", "File exists", http.StatusConflict)
+	ErrorFileUnsupported = NewError("file_unsupported", "File unsupported", http.StatusUnsupportedMediaType)
+	ErrorFileInvalid     = NewError("file_invalid", "File invalid", http.StatusUnprocessableEntity)
+	ErrorFileTooLarge    = NewError("file_too_large", "File too large", http.StatusRequestEntityTooLarge)
+
+	ErrorQuotaExceeded = NewError("quota_exceeded", "Quota exceeded", http.StatusInsufficientStorage)
+
+	ErrorMetadataNotFound = NewError("metadata_not_found", "Metadata not found", http.StatusNotFound)
+
+	ErrorRangeNotSatisfiable = NewError("range_not_satisfiable", "Range not satisfiable", http.StatusRequestedRangeNotSatisfiable)
+
+	ErrorStreamUnsupported = NewError("stream_unsupported", "File can't be streamed", http.StatusUnsupportedMediaType)
+	ErrorSegmentNotFound   = NewError("segment_not_found", "Segment not found", http.StatusNotFound)
+
+	ErrorThumbnailUnsupported = NewError("thumbnail_unsupported", "Thumbnail can't be extracted", http.StatusUnsupportedMediaType)
+
+	ErrorJobNotFound = NewError("job_not_found", "Job not found", http.StatusNotFound)
+
+	ErrorGrantNotFound = NewError("grant_not_found", "Grant not found", http.StatusNotFound)
+
+	ErrorAPIKeyNotFound = NewError("api_key_not_found", "API key not found", http.StatusNotFound)
+
+	ErrorLinkNotFound = NewError("link_not_found", "Link not found", http.StatusNotFound)
+	ErrorLinkDisabled = NewError("links_disabled", "Signed links are not enabled", http.StatusNotImplemented)
+
+	ErrorUploadNotFound       = NewError("upload_not_found", "Upload not found", http.StatusNotFound)
+	ErrorUploadOffsetMismatch = NewError("upload_offset_mismatch", "Upload offset mismatch", http.StatusConflict)
+	ErrorUploadCompleting     = NewError("upload_completing", "Upload is being completed", http.StatusConflict)
+	ErrorUploadContentType    = NewError("upload_content_type", "Upload content type unsupported", http.StatusUnsupportedMediaType)
+	ErrorUploadVersion        = NewError("upload_version", "Upload protocol version unsupported", http.StatusPreconditionFailed)
 )
 
+// RequestError is an error answered to the client. Code identifies the
+// kind of error for clients and stays stable, the message may change.
 type RequestError struct {
 	StatusCode int
+	Code       string
 	Err        error
+	// Fields tells which request parameters were invalid, if any
+	Fields []FieldError
+}
+
+// FieldError is what was wrong with one request parameter.
+type FieldError struct {
+	Name   string
+	Reason string
 }
 
 func (r RequestError) Error() string {
 	return fmt.Sprintf("status %d: err %v", r.StatusCode, r.Err)
 }
 
-func NewError(message string, code int) error {
+// Is matches a RequestError built from target by WithReason.
+func (r RequestError) Is(target error) bool {
+	t, ok := target.(RequestError)
+	return ok && t.StatusCode == r.StatusCode && errors.Is(r.Err, t.Err)
+}
+
+// NewError declares a RequestError answered with status, code being the
+// machine-readable name of the error.
+func NewError(code, message string, status int) error {
 	return RequestError{
-		StatusCode: code,
+		StatusCode: status,
+		Code:       code,
 		Err:        errors.New(message),
 	}
 }
+
+// WithReason extends the message of a RequestError with what exactly was
+// wrong, other errors are returned as is.
+func WithReason(err error, reason string) error {
+	e, ok := err.(RequestError)
+	if !ok {
+		return err
+	}
+	return RequestError{
+		StatusCode: e.StatusCode,
+		Code:       e.Code,
+		Err:        fmt.Errorf("%w: %s", e.Err, reason),
+		Fields:     e.Fields,
+	}
+}
+
+// WithField is WithReason for an invalid request parameter, the parameter
+// is listed in Fields.
+func WithField(err error, name, reason string) error {
+	e, ok := WithReason(err, reason).(RequestError)
+	if !ok {
+		return err
+	}
+	e.Fields = append(e.Fields[:len(e.Fields):len(e.Fields)], FieldError{Name: name, Reason: reason})
+	return e
+}
@@ -2,12 +2,32 @@ package entity
 
 import "time"
 
+// A file is pending from the moment its row is inserted until its content
+// is in place, and failed when that never happened. Reconcile marks ready
+// files whose content went missing as broken.
+const (
+	FileStatusPending = "pending"
+	FileStatusReady   = "ready"
+	FileStatusFailed  = "failed"
+	FileStatusBroken  = "broken"
+)
+
 type File struct {
-	ID        int       `gorm:"primaryKey" json:"fileid"`
-	Name      string    `gorm:"unique" json:"name"`
-	Size      int64     `json:"size"`
-	MimeType  string    `json:"-"`
+	ID       int    `gorm:"primaryKey" json:"fileid"`
+	Name     string `gorm:"index" json:"name"`
+	Size     int64  `json:"size"`
+	MimeType string `json:"-"`
+	Digest   string `gorm:"size:64;index" json:"-"`
+	Status   string `gorm:"size:16;index;default:ready" json:"-"`
+	// OwnerID is the subject that uploaded the file, files uploaded before
+	// authentication have none and are only reachable by admins
+	OwnerID   string    `gorm:"size:255;index" json:"-"`
+	TenantID  string    `gorm:"size:255;index" json:"-"`
 	CreatedAt time.Time `json:"created_at"`
+
+	Metadata *VideoMetadata  `gorm:"foreignKey:FileID;constraint:OnDelete:CASCADE" json:"-"`
+	Grants   []*FileGrant    `gorm:"foreignKey:FileID;constraint:OnDelete:CASCADE" json:"-"`
+	Links    []*DownloadLink `gorm:"foreignKey:FileID;constraint:OnDelete:CASCADE" json:"-"`
 }
 
 func (f *File) ToMap() map[string]interface{} {
@@ -16,6 +36,10 @@ func (f *File) ToMap() map[string]interface{} {
 		"Name":      f.Name,
 		"Size":      f.Size,
 		"MimeType":  f.MimeType,
+		"Digest":    f.Digest,
+		"Status":    f.Status,
+		"OwnerID":   f.OwnerID,
+		"TenantID":  f.TenantID,
 		"CreatedAt": f.CreatedAt,
 	}
 }
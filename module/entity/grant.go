@@ -0,0 +1,24 @@
+package entity
+
+import "time"
+
+// FileGrant shares a file with another principal of its tenant, who may
+// then read it like its owner.
+type FileGrant struct {
+	ID      int    `gorm:"primaryKey" json:"-"`
+	FileID  int    `gorm:"uniqueIndex:idx_file_grants_subject,priority:1" json:"fileid"`
+	Subject string `gorm:"size:255;uniqueIndex:idx_file_grants_subject,priority:2;index" json:"subject"`
+	// GrantedBy is the subject that shared the file
+	GrantedBy string    `gorm:"size:255" json:"granted_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (g *FileGrant) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"ID":        g.ID,
+		"FileID":    g.FileID,
+		"Subject":   g.Subject,
+		"GrantedBy": g.GrantedBy,
+		"CreatedAt": g.CreatedAt,
+	}
+}
@@ -0,0 +1,36 @@
+package entity
+
+type HealthStatus string
+
+const (
+	HealthUp   HealthStatus = "up"
+	HealthDown HealthStatus = "down"
+)
+
+// Health components checked for readiness.
+const (
+	HealthDatabase = "database"
+	HealthStorage  = "storage"
+	HealthDisk     = "disk"
+)
+
+// Health is the state of the service, it is down when one of its
+// components is.
+type Health struct {
+	Status     HealthStatus
+	Components map[string]*ComponentHealth
+}
+
+// ComponentHealth is the result of one check, Error tells which step
+// failed. Space is only reported by the disk check.
+type ComponentHealth struct {
+	Status       HealthStatus
+	Error        string
+	Space        *DiskSpace
+	MinFreeBytes int64
+}
+
+type DiskSpace struct {
+	FreeBytes  int64
+	TotalBytes int64
+}
@@ -0,0 +1,71 @@
+package entity
+
+import "time"
+
+// Post-upload processing of a file, each is a job of its own.
+const (
+	// JobTypeProbe records the container metadata
+	JobTypeProbe = "probe"
+	// JobTypeHash verifies the stored content against its digest
+	JobTypeHash = "hash"
+	// JobTypePackage stores the HLS and DASH playlists and segments
+	JobTypePackage = "package"
+	// JobTypeThumbnail extracts the poster frame
+	JobTypeThumbnail = "thumbnail"
+)
+
+// FileJobTypes are enqueued for every new file.
+var FileJobTypes = []string{JobTypeProbe, JobTypeHash, JobTypePackage, JobTypeThumbnail}
+
+// A job is queued until a worker claims it and running while it holds the
+// lease. A failed attempt queues it again with a backoff until it runs out
+// of attempts and is dead. Jobs that don't apply to their file, like a
+// thumbnail of a WebM upload, are skipped.
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusSkipped   = "skipped"
+	JobStatusDead      = "dead"
+)
+
+type Job struct {
+	ID       int    `gorm:"primaryKey" json:"jobid"`
+	FileID   int    `gorm:"index" json:"fileid"`
+	Type     string `gorm:"size:32" json:"type"`
+	Status   string `gorm:"size:16;index:idx_jobs_due,priority:1" json:"status"`
+	Attempts int    `json:"attempts"`
+	// Progress of the current attempt in percent
+	Progress int    `json:"progress"`
+	Error    string `gorm:"type:text" json:"error,omitempty"`
+
+	// RunAt is when a queued job is due, LockedUntil when the lease of a
+	// running job expires and another worker may take it over
+	RunAt       time.Time  `gorm:"index:idx_jobs_due,priority:2" json:"run_at"`
+	LockedBy    string     `gorm:"size:36" json:"-"`
+	LockedUntil *time.Time `json:"-"`
+
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func (j *Job) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"ID":          j.ID,
+		"FileID":      j.FileID,
+		"Type":        j.Type,
+		"Status":      j.Status,
+		"Attempts":    j.Attempts,
+		"Progress":    j.Progress,
+		"Error":       j.Error,
+		"RunAt":       j.RunAt,
+		"LockedBy":    j.LockedBy,
+		"LockedUntil": j.LockedUntil,
+		"StartedAt":   j.StartedAt,
+		"FinishedAt":  j.FinishedAt,
+		"CreatedAt":   j.CreatedAt,
+		"UpdatedAt":   j.UpdatedAt,
+	}
+}
@@ -0,0 +1,43 @@
+package entity
+
+import "time"
+
+// DownloadLink lets clients without credentials, like third-party players,
+// read one file through a signed URL until it expires.
+type DownloadLink struct {
+	ID       int    `gorm:"primaryKey" json:"linkid"`
+	FileID   int    `gorm:"index" json:"fileid"`
+	TenantID string `gorm:"size:255" json:"-"`
+	// CreatedBy is the subject that signed the link
+	CreatedBy string `gorm:"size:255" json:"created_by"`
+	// IP binds the link to one client address, empty accepts any
+	IP string `gorm:"size:45" json:"ip,omitempty"`
+	// MaxDownloads limits how often the file is fetched, 0 doesn't limit
+	MaxDownloads int       `json:"max_downloads,omitempty"`
+	Downloads    int       `json:"downloads"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// LinkPlayback is one client reading a file through a link with a download
+// limit. Its requests count as a single download while they keep coming
+// before ExpiresAt, so players can seek and fetch segments.
+type LinkPlayback struct {
+	LinkID    int    `gorm:"primaryKey;autoIncrement:false"`
+	Client    string `gorm:"primaryKey;size:45"`
+	ExpiresAt time.Time
+}
+
+func (l *DownloadLink) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"ID":           l.ID,
+		"FileID":       l.FileID,
+		"TenantID":     l.TenantID,
+		"CreatedBy":    l.CreatedBy,
+		"IP":           l.IP,
+		"MaxDownloads": l.MaxDownloads,
+		"Downloads":    l.Downloads,
+		"ExpiresAt":    l.ExpiresAt,
+		"CreatedAt":    l.CreatedAt,
+	}
+}
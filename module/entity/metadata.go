@@ -0,0 +1,39 @@
+package entity
+
+import "time"
+
+// VideoMetadata is what the container headers of a file say about its
+// streams, probed on upload.
+type VideoMetadata struct {
+	FileID     int       `gorm:"primaryKey;autoIncrement:false" json:"fileid"`
+	Container  string    `gorm:"size:16" json:"container"`
+	Duration   float64   `json:"duration"`
+	Width      int       `json:"width"`
+	Height     int       `json:"height"`
+	FrameRate  float64   `json:"frame_rate"`
+	Bitrate    int64     `json:"bitrate"`
+	VideoCodec string    `gorm:"size:64" json:"video_codec"`
+	AudioCodec string    `gorm:"size:64" json:"audio_codec"`
+	Tracks     int       `json:"tracks"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (VideoMetadata) TableName() string {
+	return "video_metadata"
+}
+
+func (m *VideoMetadata) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"FileID":     m.FileID,
+		"Container":  m.Container,
+		"Duration":   m.Duration,
+		"Width":      m.Width,
+		"Height":     m.Height,
+		"FrameRate":  m.FrameRate,
+		"Bitrate":    m.Bitrate,
+		"VideoCodec": m.VideoCodec,
+		"AudioCodec": m.AudioCodec,
+		"Tracks":     m.Tracks,
+		"CreatedAt":  m.CreatedAt,
+	}
+}
@@ -0,0 +1,49 @@
+package entity
+
+import "time"
+
+// Kinds of drift between storage and the files and uploads tables.
+const (
+	MismatchOrphanBlob   = "orphan_blob"
+	MismatchStaleStaging = "stale_staging"
+	MismatchMissingBlob  = "missing_blob"
+	MismatchRestoredBlob = "restored_blob"
+	MismatchStalePending = "stale_pending"
+	MismatchFailedFile   = "failed_file"
+	MismatchStaleUpload  = "stale_upload"
+)
+
+// What reconcile did about a mismatch.
+const (
+	ActionDeleted      = "deleted"
+	ActionMarkedBroken = "marked_broken"
+	ActionMarkedReady  = "marked_ready"
+	ActionMarkedFailed = "marked_failed"
+	// the object or row is younger than the grace period
+	ActionKept = "kept"
+	// dry run, nothing was changed
+	ActionNone  = "none"
+	ActionError = "error"
+)
+
+type Mismatch struct {
+	Kind   string `json:"kind"`
+	Key    string `json:"key,omitempty"`
+	FileID int    `json:"fileid,omitempty"`
+	Size   int64  `json:"size,omitempty"`
+	// last modification of the object, or creation of the row
+	ModifiedAt time.Time `json:"modified_at"`
+	Action     string    `json:"action"`
+	Error      string    `json:"error,omitempty"`
+}
+
+type ReconcileReport struct {
+	StartedAt      time.Time   `json:"started_at"`
+	FinishedAt     time.Time   `json:"finished_at"`
+	DryRun         bool        `json:"dry_run"`
+	GracePeriod    string      `json:"grace_period"`
+	FilesChecked   int         `json:"files_checked"`
+	UploadsChecked int         `json:"uploads_checked"`
+	BlobsChecked   int         `json:"blobs_checked"`
+	Mismatches     []*Mismatch `json:"mismatches"`
+}
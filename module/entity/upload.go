@@ -0,0 +1,43 @@
+package entity
+
+import "time"
+
+// UploadOptions is what the server accepts of resumable uploads.
+type UploadOptions struct {
+	// MaxSize is the largest Upload-Length, 0 doesn't limit
+	MaxSize int64
+}
+
+type Upload struct {
+	ID       string `gorm:"primaryKey;size:36" json:"uploadid"`
+	FileID   *int   `json:"fileid"`
+	Name     string `json:"name"`
+	Length   int64  `json:"length"`
+	Offset   int64  `json:"offset"`
+	Metadata string `json:"-"`
+	OwnerID  string `gorm:"size:255" json:"-"`
+	TenantID string `gorm:"size:255" json:"-"`
+	// CompletingAt is when a request claimed the creation of the file
+	CompletingAt *time.Time `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (u *Upload) IsComplete() bool {
+	return u.FileID != nil
+}
+
+func (u *Upload) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"ID":        u.ID,
+		"FileID":    u.FileID,
+		"Name":      u.Name,
+		"Length":    u.Length,
+		"Offset":    u.Offset,
+		"Metadata":  u.Metadata,
+		"OwnerID":   u.OwnerID,
+		"TenantID":  u.TenantID,
+		"CreatedAt": u.CreatedAt,
+		"UpdatedAt": u.UpdatedAt,
+	}
+}
@@ -0,0 +1,22 @@
+package entity
+
+// Usage is what a tenant stores, counted by file size so content shared
+// by several files counts once per file.
+type Usage struct {
+	TenantID string
+	Files    int64
+	Bytes    int64
+	// MaxFiles and MaxBytes are the tenant's quota, 0 doesn't limit
+	MaxFiles int64
+	MaxBytes int64
+}
+
+func (u *Usage) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"TenantID": u.TenantID,
+		"Files":    u.Files,
+		"Bytes":    u.Bytes,
+		"MaxFiles": u.MaxFiles,
+		"MaxBytes": u.MaxBytes,
+	}
+}
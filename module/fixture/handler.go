@@ -25,3 +25,176 @@ func NewFileHandler(
 
 	return svc, mocks
 }
+
+type MockUploadHandler struct {
+	// Usecase
+	UploadUsecase *mock_usecase.MockUploadUsecase
+}
+
+func NewUploadHandler(
+	ctrl *gomock.Controller,
+) (*handler.UploadHandler, *MockUploadHandler) {
+	mocks := &MockUploadHandler{
+		UploadUsecase: mock_usecase.NewMockUploadUsecase(ctrl),
+	}
+
+	svc := handler.NewUploadHandler(
+		mocks.UploadUsecase,
+	)
+
+	return svc, mocks
+}
+
+type MockStreamHandler struct {
+	// Usecase
+	StreamUsecase *mock_usecase.MockStreamUsecase
+}
+
+func NewStreamHandler(
+	ctrl *gomock.Controller,
+) (*handler.StreamHandler, *MockStreamHandler) {
+	mocks := &MockStreamHandler{
+		StreamUsecase: mock_usecase.NewMockStreamUsecase(ctrl),
+	}
+
+	svc := handler.NewStreamHandler(
+		mocks.StreamUsecase,
+	)
+
+	return svc, mocks
+}
+
+type MockThumbnailHandler struct {
+	// Usecase
+	ThumbnailUsecase *mock_usecase.MockThumbnailUsecase
+}
+
+func NewThumbnailHandler(
+	ctrl *gomock.Controller,
+) (*handler.ThumbnailHandler, *MockThumbnailHandler) {
+	mocks := &MockThumbnailHandler{
+		ThumbnailUsecase: mock_usecase.NewMockThumbnailUsecase(ctrl),
+	}
+
+	svc := handler.NewThumbnailHandler(
+		mocks.ThumbnailUsecase,
+	)
+
+	return svc, mocks
+}
+
+type MockJobHandler struct {
+	// Usecase
+	JobUsecase *mock_usecase.MockJobUsecase
+}
+
+func NewJobHandler(
+	ctrl *gomock.Controller,
+) (*handler.JobHandler, *MockJobHandler) {
+	mocks := &MockJobHandler{
+		JobUsecase: mock_usecase.NewMockJobUsecase(ctrl),
+	}
+
+	svc := handler.NewJobHandler(
+		mocks.JobUsecase,
+	)
+
+	return svc, mocks
+}
+
+type MockAuthMiddleware struct {
+	// Usecase
+	AuthUsecase *mock_usecase.MockAuthUsecase
+}
+
+func NewAuthMiddleware(
+	ctrl *gomock.Controller,
+	publicPaths []string,
+) (*handler.AuthMiddleware, *MockAuthMiddleware) {
+	mocks := &MockAuthMiddleware{
+		AuthUsecase: mock_usecase.NewMockAuthUsecase(ctrl),
+	}
+
+	svc := handler.NewAuthMiddleware(
+		mocks.AuthUsecase,
+		publicPaths,
+	)
+
+	return svc, mocks
+}
+
+type MockGrantHandler struct {
+	// Usecase
+	GrantUsecase *mock_usecase.MockGrantUsecase
+}
+
+func NewGrantHandler(
+	ctrl *gomock.Controller,
+) (*handler.GrantHandler, *MockGrantHandler) {
+	mocks := &MockGrantHandler{
+		GrantUsecase: mock_usecase.NewMockGrantUsecase(ctrl),
+	}
+
+	svc := handler.NewGrantHandler(
+		mocks.GrantUsecase,
+	)
+
+	return svc, mocks
+}
+
+type MockLinkHandler struct {
+	// Usecase
+	LinkUsecase *mock_usecase.MockLinkUsecase
+}
+
+func NewLinkHandler(
+	ctrl *gomock.Controller,
+) (*handler.LinkHandler, *MockLinkHandler) {
+	mocks := &MockLinkHandler{
+		LinkUsecase: mock_usecase.NewMockLinkUsecase(ctrl),
+	}
+
+	svc := handler.NewLinkHandler(
+		mocks.LinkUsecase,
+	)
+
+	return svc, mocks
+}
+
+type MockUsageHandler struct {
+	// Usecase
+	UsageUsecase *mock_usecase.MockUsageUsecase
+}
+
+func NewUsageHandler(
+	ctrl *gomock.Controller,
+) (*handler.UsageHandler, *MockUsageHandler) {
+	mocks := &MockUsageHandler{
+		UsageUsecase: mock_usecase.NewMockUsageUsecase(ctrl),
+	}
+
+	svc := handler.NewUsageHandler(
+		mocks.UsageUsecase,
+	)
+
+	return svc, mocks
+}
+
+type MockHealthHandler struct {
+	// Usecase
+	HealthUsecase *mock_usecase.MockHealthUsecase
+}
+
+func NewHealthHandler(
+	ctrl *gomock.Controller,
+) (*handler.HealthHandler, *MockHealthHandler) {
+	mocks := &MockHealthHandler{
+		HealthUsecase: mock_usecase.NewMockHealthUsecase(ctrl),
+	}
+
+	svc := handler.NewHealthHandler(
+		mocks.HealthUsecase,
+	)
+
+	return svc, mocks
+}
@@ -17,3 +17,91 @@ func NewFileRepository() (repository.FileRepository, *MockFileRepository) {
 	repo := repository.NewFileRepository(db)
 	return repo, mocks
 }
+
+type MockBlobRepository struct {
+	SQLMock sqlmock.Sqlmock
+}
+
+func NewBlobRepository() (repository.BlobRepository, *MockBlobRepository) {
+	db, sqlMock := testutil.NewDatabase()
+	mocks := &MockBlobRepository{SQLMock: sqlMock}
+	repo := repository.NewBlobRepository(db)
+	return repo, mocks
+}
+
+type MockUploadRepository struct {
+	SQLMock sqlmock.Sqlmock
+}
+
+func NewUploadRepository() (repository.UploadRepository, *MockUploadRepository) {
+	db, sqlMock := testutil.NewDatabase()
+	mocks := &MockUploadRepository{SQLMock: sqlMock}
+	repo := repository.NewUploadRepository(db)
+	return repo, mocks
+}
+
+type MockMetadataRepository struct {
+	SQLMock sqlmock.Sqlmock
+}
+
+func NewMetadataRepository() (repository.MetadataRepository, *MockMetadataRepository) {
+	db, sqlMock := testutil.NewDatabase()
+	mocks := &MockMetadataRepository{SQLMock: sqlMock}
+	repo := repository.NewMetadataRepository(db)
+	return repo, mocks
+}
+
+type MockJobRepository struct {
+	SQLMock sqlmock.Sqlmock
+}
+
+func NewJobRepository() (repository.JobRepository, *MockJobRepository) {
+	db, sqlMock := testutil.NewDatabase()
+	mocks := &MockJobRepository{SQLMock: sqlMock}
+	repo := repository.NewJobRepository(db)
+	return repo, mocks
+}
+
+type MockAPIKeyRepository struct {
+	SQLMock sqlmock.Sqlmock
+}
+
+func NewAPIKeyRepository() (repository.APIKeyRepository, *MockAPIKeyRepository) {
+	db, sqlMock := testutil.NewDatabase()
+	mocks := &MockAPIKeyRepository{SQLMock: sqlMock}
+	repo := repository.NewAPIKeyRepository(db)
+	return repo, mocks
+}
+
+type MockGrantRepository struct {
+	SQLMock sqlmock.Sqlmock
+}
+
+func NewGrantRepository() (repository.GrantRepository, *MockGrantRepository) {
+	db, sqlMock := testutil.NewDatabase()
+	mocks := &MockGrantRepository{SQLMock: sqlMock}
+	repo := repository.NewGrantRepository(db)
+	return repo, mocks
+}
+
+type MockLinkRepository struct {
+	SQLMock sqlmock.Sqlmock
+}
+
+func NewLinkRepository() (repository.LinkRepository, *MockLinkRepository) {
+	db, sqlMock := testutil.NewDatabase()
+	mocks := &MockLinkRepository{SQLMock: sqlMock}
+	repo := repository.NewLinkRepository(db)
+	return repo, mocks
+}
+
+type MockHealthRepository struct {
+	SQLMock sqlmock.Sqlmock
+}
+
+func NewHealthRepository() (repository.HealthRepository, *MockHealthRepository) {
+	db, sqlMock := testutil.NewDatabase()
+	mocks := &MockHealthRepository{SQLMock: sqlMock}
+	repo := repository.NewHealthRepository(db)
+	return repo, mocks
+}
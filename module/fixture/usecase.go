@@ -3,19 +3,224 @@ package fixture
 import (
 	"github.com/golang/mock/gomock"
 
+	"video-server/internal/auth"
+	mock_storage "video-server/internal/storage/mock"
+	"video-server/internal/thumbnail"
 	mock_repository "video-server/module/internal/repository/mock"
 	"video-server/module/internal/usecase"
+	mock_usecase "video-server/module/internal/usecase/mock"
 )
 
 type MockFileUsecase struct {
 	// Repository
-	FileRepository *mock_repository.MockFileRepository
+	FileRepository     *mock_repository.MockFileRepository
+	BlobRepository     *mock_repository.MockBlobRepository
+	MetadataRepository *mock_repository.MockMetadataRepository
+	JobRepository      *mock_repository.MockJobRepository
+	GrantRepository    *mock_repository.MockGrantRepository
+
+	// Storage
+	BlobStore *mock_storage.MockBlobStore
 }
 
-func NewFileUsecase(ctrl *gomock.Controller) (usecase.FileUsecase, *MockFileUsecase) {
+func NewFileUsecase(ctrl *gomock.Controller, cfg usecase.FileConfig) (usecase.FileUsecase, *MockFileUsecase) {
 	mocks := &MockFileUsecase{
+		FileRepository:     mock_repository.NewMockFileRepository(ctrl),
+		BlobRepository:     mock_repository.NewMockBlobRepository(ctrl),
+		MetadataRepository: mock_repository.NewMockMetadataRepository(ctrl),
+		JobRepository:      mock_repository.NewMockJobRepository(ctrl),
+		GrantRepository:    mock_repository.NewMockGrantRepository(ctrl),
+		BlobStore:          mock_storage.NewMockBlobStore(ctrl),
+	}
+	ucs := usecase.NewFileUsecase(mocks.FileRepository, mocks.BlobRepository, mocks.MetadataRepository, mocks.JobRepository, mocks.GrantRepository, mocks.BlobStore, cfg)
+	return ucs, mocks
+}
+
+type MockUploadUsecase struct {
+	// Repository
+	UploadRepository *mock_repository.MockUploadRepository
+	FileRepository   *mock_repository.MockFileRepository
+
+	// Storage
+	BlobStore *mock_storage.MockBlobStore
+
+	// Usecase
+	FileUsecase *mock_usecase.MockFileUsecase
+}
+
+func NewUploadUsecase(ctrl *gomock.Controller, cfg usecase.UploadConfig) (usecase.UploadUsecase, *MockUploadUsecase) {
+	mocks := &MockUploadUsecase{
+		UploadRepository: mock_repository.NewMockUploadRepository(ctrl),
+		FileRepository:   mock_repository.NewMockFileRepository(ctrl),
+		BlobStore:        mock_storage.NewMockBlobStore(ctrl),
+		FileUsecase:      mock_usecase.NewMockFileUsecase(ctrl),
+	}
+	ucs := usecase.NewUploadUsecase(mocks.UploadRepository, mocks.FileRepository, mocks.BlobStore, mocks.FileUsecase, cfg)
+	return ucs, mocks
+}
+
+type MockStreamUsecase struct {
+	// Storage
+	BlobStore *mock_storage.MockBlobStore
+
+	// Usecase
+	FileUsecase *mock_usecase.MockFileUsecase
+}
+
+func NewStreamUsecase(ctrl *gomock.Controller) (usecase.StreamUsecase, *MockStreamUsecase) {
+	mocks := &MockStreamUsecase{
+		BlobStore:   mock_storage.NewMockBlobStore(ctrl),
+		FileUsecase: mock_usecase.NewMockFileUsecase(ctrl),
+	}
+	ucs := usecase.NewStreamUsecase(mocks.FileUsecase, mocks.BlobStore)
+	return ucs, mocks
+}
+
+type MockReconcileUsecase struct {
+	// Repository
+	FileRepository   *mock_repository.MockFileRepository
+	BlobRepository   *mock_repository.MockBlobRepository
+	UploadRepository *mock_repository.MockUploadRepository
+
+	// Storage
+	BlobStore *mock_storage.MockBlobStore
+}
+
+func NewReconcileUsecase(ctrl *gomock.Controller) (usecase.ReconcileUsecase, *MockReconcileUsecase) {
+	mocks := &MockReconcileUsecase{
+		FileRepository:   mock_repository.NewMockFileRepository(ctrl),
+		BlobRepository:   mock_repository.NewMockBlobRepository(ctrl),
+		UploadRepository: mock_repository.NewMockUploadRepository(ctrl),
+		BlobStore:        mock_storage.NewMockBlobStore(ctrl),
+	}
+	ucs := usecase.NewReconcileUsecase(mocks.FileRepository, mocks.BlobRepository, mocks.UploadRepository, mocks.BlobStore)
+	return ucs, mocks
+}
+
+type MockThumbnailUsecase struct {
+	// Repository
+	FileRepository  *mock_repository.MockFileRepository
+	GrantRepository *mock_repository.MockGrantRepository
+
+	// Storage
+	BlobStore *mock_storage.MockBlobStore
+}
+
+// NewThumbnailUsecase decodes MJPEG only, ffmpeg isn't looked up in tests
+func NewThumbnailUsecase(ctrl *gomock.Controller) (usecase.ThumbnailUsecase, *MockThumbnailUsecase) {
+	mocks := &MockThumbnailUsecase{
+		FileRepository:  mock_repository.NewMockFileRepository(ctrl),
+		GrantRepository: mock_repository.NewMockGrantRepository(ctrl),
+		BlobStore:       mock_storage.NewMockBlobStore(ctrl),
+	}
+	ucs := usecase.NewThumbnailUsecase(mocks.FileRepository, mocks.GrantRepository, mocks.BlobStore, thumbnail.Chain{thumbnail.JPEGExtractor{}})
+	return ucs, mocks
+}
+
+type MockJobUsecase struct {
+	// Repository
+	JobRepository  *mock_repository.MockJobRepository
+	FileRepository *mock_repository.MockFileRepository
+
+	// Storage
+	BlobStore *mock_storage.MockBlobStore
+
+	// Usecase
+	FileUsecase      *mock_usecase.MockFileUsecase
+	StreamUsecase    *mock_usecase.MockStreamUsecase
+	ThumbnailUsecase *mock_usecase.MockThumbnailUsecase
+}
+
+func NewJobUsecase(ctrl *gomock.Controller, cfg usecase.JobConfig) (usecase.JobUsecase, *MockJobUsecase) {
+	mocks := &MockJobUsecase{
+		JobRepository:    mock_repository.NewMockJobRepository(ctrl),
+		FileRepository:   mock_repository.NewMockFileRepository(ctrl),
+		BlobStore:        mock_storage.NewMockBlobStore(ctrl),
+		FileUsecase:      mock_usecase.NewMockFileUsecase(ctrl),
+		StreamUsecase:    mock_usecase.NewMockStreamUsecase(ctrl),
+		ThumbnailUsecase: mock_usecase.NewMockThumbnailUsecase(ctrl),
+	}
+	ucs := usecase.NewJobUsecase(mocks.JobRepository, mocks.FileRepository, mocks.BlobStore, mocks.FileUsecase, mocks.StreamUsecase, mocks.ThumbnailUsecase, cfg)
+	return ucs, mocks
+}
+
+type MockAuthUsecase struct {
+	// Repository
+	APIKeyRepository *mock_repository.MockAPIKeyRepository
+	LinkRepository   *mock_repository.MockLinkRepository
+}
+
+func NewAuthUsecase(ctrl *gomock.Controller, verifier auth.TokenVerifier, signer *auth.Signer) (usecase.AuthUsecase, *MockAuthUsecase) {
+	mocks := &MockAuthUsecase{
+		APIKeyRepository: mock_repository.NewMockAPIKeyRepository(ctrl),
+		LinkRepository:   mock_repository.NewMockLinkRepository(ctrl),
+	}
+	ucs := usecase.NewAuthUsecase(mocks.APIKeyRepository, mocks.LinkRepository, verifier, signer)
+	return ucs, mocks
+}
+
+type MockGrantUsecase struct {
+	// Repository
+	FileRepository  *mock_repository.MockFileRepository
+	GrantRepository *mock_repository.MockGrantRepository
+}
+
+func NewGrantUsecase(ctrl *gomock.Controller) (usecase.GrantUsecase, *MockGrantUsecase) {
+	mocks := &MockGrantUsecase{
+		FileRepository:  mock_repository.NewMockFileRepository(ctrl),
+		GrantRepository: mock_repository.NewMockGrantRepository(ctrl),
+	}
+	ucs := usecase.NewGrantUsecase(mocks.FileRepository, mocks.GrantRepository)
+	return ucs, mocks
+}
+
+type MockLinkUsecase struct {
+	// Repository
+	LinkRepository  *mock_repository.MockLinkRepository
+	GrantRepository *mock_repository.MockGrantRepository
+
+	// Usecase
+	FileUsecase *mock_usecase.MockFileUsecase
+}
+
+func NewLinkUsecase(ctrl *gomock.Controller, signer *auth.Signer, cfg usecase.LinkConfig) (usecase.LinkUsecase, *MockLinkUsecase) {
+	mocks := &MockLinkUsecase{
+		LinkRepository:  mock_repository.NewMockLinkRepository(ctrl),
+		GrantRepository: mock_repository.NewMockGrantRepository(ctrl),
+		FileUsecase:     mock_usecase.NewMockFileUsecase(ctrl),
+	}
+	ucs := usecase.NewLinkUsecase(mocks.LinkRepository, mocks.GrantRepository, mocks.FileUsecase, signer, cfg)
+	return ucs, mocks
+}
+
+type MockUsageUsecase struct {
+	// Repository
+	FileRepository *mock_repository.MockFileRepository
+}
+
+func NewUsageUsecase(ctrl *gomock.Controller, quota usecase.QuotaConfig) (usecase.UsageUsecase, *MockUsageUsecase) {
+	mocks := &MockUsageUsecase{
 		FileRepository: mock_repository.NewMockFileRepository(ctrl),
 	}
-	ucs := usecase.NewFileUsecase(mocks.FileRepository)
+	ucs := usecase.NewUsageUsecase(mocks.FileRepository, quota)
+	return ucs, mocks
+}
+
+type MockHealthUsecase struct {
+	// Repository
+	HealthRepository *mock_repository.MockHealthRepository
+
+	// Storage
+	BlobStore     *mock_storage.MockBlobStore
+	SpaceReporter *mock_storage.MockSpaceReporter
+}
+
+func NewHealthUsecase(ctrl *gomock.Controller, cfg usecase.HealthConfig) (usecase.HealthUsecase, *MockHealthUsecase) {
+	mocks := &MockHealthUsecase{
+		HealthRepository: mock_repository.NewMockHealthRepository(ctrl),
+		BlobStore:        mock_storage.NewMockBlobStore(ctrl),
+		SpaceReporter:    mock_storage.NewMockSpaceReporter(ctrl),
+	}
+	ucs := usecase.NewHealthUsecase(mocks.HealthRepository, mocks.BlobStore, mocks.SpaceReporter, cfg)
 	return ucs, mocks
 }
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"video-server/internal/dash"
+	"video-server/internal/hls"
+	"video-server/internal/logging"
+	"video-server/module/entity"
+	"video-server/module/internal/usecase"
+	"video-server/module/param"
+)
+
+// AuthMiddleware requires an API key or a bearer token on every request and
+// attaches the authenticated principal to the request context. Reading and
+// streaming a file may be authenticated by a signed link instead.
+type AuthMiddleware struct {
+	usecase usecase.AuthUsecase
+	public  map[string]bool
+}
+
+// NewAuthMiddleware lets requests to publicPaths through without
+// credentials.
+func NewAuthMiddleware(uc usecase.AuthUsecase, publicPaths []string) *AuthMiddleware {
+	public := make(map[string]bool, len(publicPaths))
+	for _, path := range publicPaths {
+		public[path] = true
+	}
+	return &AuthMiddleware{
+		usecase: uc,
+		public:  public,
+	}
+}
+
+func (m *AuthMiddleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// CORS preflights never carry credentials, OPTIONS only describes
+		// what the server supports
+		if r.Method == http.MethodOptions || m.public[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, err := m.usecase.Authenticate(r.Context(), credential(r))
+		if err != nil {
+			if errors.Is(err, entity.ErrorUnauthorized) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="video-server"`)
+			}
+			BuildErrorResponse(w, r, err)
+			return
+		}
+
+		logging.AddFields(r.Context(), logrus.Fields{
+			"principal":   principal.Subject,
+			"auth_method": principal.Method,
+			"tenant":      principal.Tenant,
+		})
+		next.ServeHTTP(w, r.WithContext(entity.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// credential reads the X-API-Key header or the Authorization bearer, which
+// may hold an API key too for clients that only support bearer auth, and
+// falls back to the signature of a download link.
+func credential(r *http.Request) *param.Credential {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return &param.Credential{APIKey: key}
+	}
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return &param.Credential{Link: linkCredential(r)}
+	}
+	token = strings.TrimSpace(token)
+	if strings.HasPrefix(token, usecase.APIKeyPrefix) {
+		return &param.Credential{APIKey: token}
+	}
+	return &param.Credential{Token: token}
+}
+
+// linkCredential reads a link signature from the query of a request reading
+// or streaming a file, nil for any other request.
+func linkCredential(r *http.Request) *param.LinkCredential {
+	query := r.URL.Query()
+	if query.Get(usecase.LinkParamSignature) == "" ||
+		(r.Method != http.MethodGet && r.Method != http.MethodHead) ||
+		!strings.HasPrefix(r.URL.Path, "/v1/files/") {
+		return nil
+	}
+
+	// route is what follows /v1/files/:fileid, empty for the file itself
+	id, route, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v1/files/"), "/")
+	fileID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil
+	}
+	dir, name, _ := strings.Cut(route, "/")
+	switch {
+	case route == "", route == dash.ManifestName:
+	case (dir == "hls" || dir == "dash") && name != "" && !strings.Contains(name, "/"):
+	default:
+		return nil
+	}
+
+	linkID, _ := strconv.Atoi(query.Get(usecase.LinkParamID))
+	expires, _ := strconv.ParseInt(query.Get(usecase.LinkParamExpires), 10, 64)
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	// a download starts with the whole file or its first bytes, a playback
+	// with its playlist or manifest, segments are not counted
+	download := r.Method == http.MethodGet && (route == dash.ManifestName ||
+		route == "hls/"+hls.MasterPlaylistName ||
+		(route == "" && (r.Header.Get("Range") == "" || strings.HasPrefix(r.Header.Get("Range"), "bytes=0-"))))
+	partial := r.Method == http.MethodGet && (route != "" || !download)
+
+	return &param.LinkCredential{
+		FileID:    fileID,
+		LinkID:    linkID,
+		Expires:   expires,
+		Signature: query.Get(usecase.LinkParamSignature),
+		IP:        ip,
+		Download:  download,
+		Partial:   partial,
+	}
+}
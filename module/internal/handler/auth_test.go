@@ -0,0 +1,244 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"video-server/internal/testutil"
+	"video-server/module/entity"
+	"video-server/module/fixture"
+	"video-server/module/param"
+)
+
+func TestAuthMiddleware_Wrap(t *testing.T) {
+	type Request struct {
+		method string
+		path   string
+		header map[string]string
+	}
+
+	type Response struct {
+		statusCode      int
+		subject         string
+		wwwAuthenticate string
+	}
+
+	principal := &entity.Principal{Subject: "alice", Method: entity.AuthMethodJWT}
+	link := func(download bool, partial bool) *param.Credential {
+		return &param.Credential{Link: &param.LinkCredential{
+			FileID:    1,
+			LinkID:    3,
+			Expires:   1700000000,
+			Signature: "abc",
+			IP:        "192.0.2.1",
+			Download:  download,
+			Partial:   partial,
+		}}
+	}
+	signed := "?link=3&expires=1700000000&sig=abc"
+
+	testcases := map[string]struct {
+		request  Request
+		response Response
+		mockFn   func(*fixture.MockAuthMiddleware)
+	}{
+		"bearer token": {
+			request: Request{method: http.MethodGet, path: "/v1/files", header: map[string]string{"Authorization": "Bearer token"}},
+			response: Response{
+				statusCode: 200,
+				subject:    "alice",
+			},
+			mockFn: func(m *fixture.MockAuthMiddleware) {
+				m.AuthUsecase.EXPECT().Authenticate(gomock.Any(), &param.Credential{Token: "token"}).
+					Return(principal, nil)
+			},
+		},
+		"api key header": {
+			request: Request{method: http.MethodGet, path: "/v1/files", header: map[string]string{"X-API-Key": "vsk_key"}},
+			response: Response{
+				statusCode: 200,
+				subject:    "alice",
+			},
+			mockFn: func(m *fixture.MockAuthMiddleware) {
+				m.AuthUsecase.EXPECT().Authenticate(gomock.Any(), &param.Credential{APIKey: "vsk_key"}).
+					Return(principal, nil)
+			},
+		},
+		"api key as bearer": {
+			request: Request{method: http.MethodGet, path: "/v1/files", header: map[string]string{"Authorization": "bearer vsk_key"}},
+			response: Response{
+				statusCode: 200,
+				subject:    "alice",
+			},
+			mockFn: func(m *fixture.MockAuthMiddleware) {
+				m.AuthUsecase.EXPECT().Authenticate(gomock.Any(), &param.Credential{APIKey: "vsk_key"}).
+					Return(principal, nil)
+			},
+		},
+		"other scheme": {
+			request: Request{method: http.MethodGet, path: "/v1/files", header: map[string]string{"Authorization": "Basic dXNlcjpwYXNz"}},
+			response: Response{
+				statusCode:      401,
+				wwwAuthenticate: `Bearer realm="video-server"`,
+			},
+			mockFn: func(m *fixture.MockAuthMiddleware) {
+				m.AuthUsecase.EXPECT().Authenticate(gomock.Any(), &param.Credential{}).
+					Return(nil, entity.WithReason(entity.ErrorUnauthorized, "missing credentials"))
+			},
+		},
+		"invalid credentials": {
+			request: Request{method: http.MethodGet, path: "/v1/files", header: map[string]string{"Authorization": "Bearer token"}},
+			response: Response{
+				statusCode:      401,
+				wwwAuthenticate: `Bearer realm="video-server"`,
+			},
+			mockFn: func(m *fixture.MockAuthMiddleware) {
+				m.AuthUsecase.EXPECT().Authenticate(gomock.Any(), &param.Credential{Token: "token"}).
+					Return(nil, entity.WithReason(entity.ErrorUnauthorized, "invalid bearer token"))
+			},
+		},
+		"db error": {
+			request: Request{method: http.MethodGet, path: "/v1/files", header: map[string]string{"X-API-Key": "vsk_key"}},
+			response: Response{
+				statusCode: 500,
+			},
+			mockFn: func(m *fixture.MockAuthMiddleware) {
+				m.AuthUsecase.EXPECT().Authenticate(gomock.Any(), &param.Credential{APIKey: "vsk_key"}).
+					Return(nil, testutil.ErrDB)
+			},
+		},
+		"signed link": {
+			request: Request{method: http.MethodGet, path: "/v1/files/1" + signed},
+			response: Response{
+				statusCode: 200,
+				subject:    "alice",
+			},
+			mockFn: func(m *fixture.MockAuthMiddleware) {
+				m.AuthUsecase.EXPECT().Authenticate(gomock.Any(), link(true, false)).
+					Return(principal, nil)
+			},
+		},
+		"signed link resumed": {
+			request: Request{method: http.MethodGet, path: "/v1/files/1" + signed, header: map[string]string{"Range": "bytes=1000-"}},
+			response: Response{
+				statusCode: 200,
+				subject:    "alice",
+			},
+			mockFn: func(m *fixture.MockAuthMiddleware) {
+				m.AuthUsecase.EXPECT().Authenticate(gomock.Any(), link(false, true)).
+					Return(principal, nil)
+			},
+		},
+		"signed link playlist": {
+			request: Request{method: http.MethodGet, path: "/v1/files/1/hls/master.m3u8" + signed},
+			response: Response{
+				statusCode: 200,
+				subject:    "alice",
+			},
+			mockFn: func(m *fixture.MockAuthMiddleware) {
+				m.AuthUsecase.EXPECT().Authenticate(gomock.Any(), link(true, true)).
+					Return(principal, nil)
+			},
+		},
+		"signed link segment": {
+			request: Request{method: http.MethodGet, path: "/v1/files/1/dash/segment-1-0.m4s" + signed},
+			response: Response{
+				statusCode: 200,
+				subject:    "alice",
+			},
+			mockFn: func(m *fixture.MockAuthMiddleware) {
+				m.AuthUsecase.EXPECT().Authenticate(gomock.Any(), link(false, true)).
+					Return(principal, nil)
+			},
+		},
+		"signed link media playlist": {
+			request: Request{method: http.MethodGet, path: "/v1/files/1/hls/media.m3u8" + signed},
+			response: Response{
+				statusCode: 200,
+				subject:    "alice",
+			},
+			mockFn: func(m *fixture.MockAuthMiddleware) {
+				m.AuthUsecase.EXPECT().Authenticate(gomock.Any(), link(false, true)).
+					Return(principal, nil)
+			},
+		},
+		"signed link head": {
+			request: Request{method: http.MethodHead, path: "/v1/files/1" + signed},
+			response: Response{
+				statusCode: 200,
+				subject:    "alice",
+			},
+			mockFn: func(m *fixture.MockAuthMiddleware) {
+				m.AuthUsecase.EXPECT().Authenticate(gomock.Any(), link(false, false)).
+					Return(principal, nil)
+			},
+		},
+		"signed link other route": {
+			request: Request{method: http.MethodGet, path: "/v1/files/1/grants" + signed},
+			response: Response{
+				statusCode:      401,
+				wwwAuthenticate: `Bearer realm="video-server"`,
+			},
+			mockFn: func(m *fixture.MockAuthMiddleware) {
+				m.AuthUsecase.EXPECT().Authenticate(gomock.Any(), &param.Credential{}).
+					Return(nil, entity.WithReason(entity.ErrorUnauthorized, "missing credentials"))
+			},
+		},
+		"signed link delete": {
+			request: Request{method: http.MethodDelete, path: "/v1/files/1" + signed},
+			response: Response{
+				statusCode:      401,
+				wwwAuthenticate: `Bearer realm="video-server"`,
+			},
+			mockFn: func(m *fixture.MockAuthMiddleware) {
+				m.AuthUsecase.EXPECT().Authenticate(gomock.Any(), &param.Credential{}).
+					Return(nil, entity.WithReason(entity.ErrorUnauthorized, "missing credentials"))
+			},
+		},
+		"public path": {
+			request: Request{method: http.MethodGet, path: "/v1/public"},
+			response: Response{
+				statusCode: 200,
+			},
+			mockFn: func(m *fixture.MockAuthMiddleware) {},
+		},
+		"preflight": {
+			request: Request{method: http.MethodOptions, path: "/v1/uploads"},
+			response: Response{
+				statusCode: 200,
+			},
+			mockFn: func(m *fixture.MockAuthMiddleware) {},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			middleware, mocks := fixture.NewAuthMiddleware(ctrl, []string{"/v1/public"})
+			tc.mockFn(mocks)
+
+			var subject string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if p := entity.PrincipalFromContext(r.Context()); p != nil {
+					subject = p.Subject
+				}
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(tc.request.method, "http://example.com"+tc.request.path, nil)
+			for key, value := range tc.request.header {
+				req.Header.Set(key, value)
+			}
+			responseWriter := httptest.NewRecorder()
+			middleware.Wrap(next).ServeHTTP(responseWriter, req)
+			assert.Equal(t, tc.response.statusCode, responseWriter.Code)
+			assert.Equal(t, tc.response.subject, subject)
+			assert.Equal(t, tc.response.wwwAuthenticate, responseWriter.Header().Get("WWW-Authenticate"))
+		})
+	}
+}
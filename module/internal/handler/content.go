@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"video-server/internal/storage"
+	"video-server/module/entity"
+)
+
+// maxRanges caps how many ranges a single request may ask for.
+const maxRanges = 32
+
+type byteRange struct {
+	start  int64
+	length int64
+}
+
+func (r byteRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.start, r.start+r.length-1, size)
+}
+
+// serveFileContent writes the blob of a file honouring conditional and range
+// requests. Validators come from the stored metadata rather than the backend,
+// so it behaves the same for every storage driver.
+func serveFileContent(w http.ResponseWriter, r *http.Request, file *entity.File, blob storage.Blob) {
+	etag := fileETag(file)
+	lastModified := file.CreatedAt.UTC().Truncate(time.Second)
+
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+
+	switch checkPreconditions(r, etag, lastModified) {
+	case http.StatusPreconditionFailed:
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	case http.StatusNotModified:
+		w.Header().Del("Content-Type")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	size := file.Size
+	rangeHeader := r.Header.Get("Range")
+	if rangeHeader != "" && !checkIfRange(r, etag, lastModified) {
+		rangeHeader = ""
+	}
+
+	ranges, err := parseRange(rangeHeader, size)
+	if err != nil {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+
+	switch len(ranges) {
+	case 0:
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+		w.WriteHeader(http.StatusOK)
+		if r.Method != http.MethodHead {
+			_, _ = io.Copy(w, io.NewSectionReader(blob, 0, size))
+		}
+	case 1:
+		w.Header().Set("Content-Range", ranges[0].contentRange(size))
+		w.Header().Set("Content-Length", strconv.FormatInt(ranges[0].length, 10))
+		w.WriteHeader(http.StatusPartialContent)
+		if r.Method != http.MethodHead {
+			_, _ = io.Copy(w, io.NewSectionReader(blob, ranges[0].start, ranges[0].length))
+		}
+	default:
+		writeMultiRange(w, r, file, blob, ranges)
+	}
+}
+
+func writeMultiRange(w http.ResponseWriter, r *http.Request, file *entity.File, blob storage.Blob, ranges []byteRange) {
+	mw := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", "multipart/byteranges; boundary="+mw.Boundary())
+	w.WriteHeader(http.StatusPartialContent)
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	for _, ra := range ranges {
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":  {file.MimeType},
+			"Content-Range": {ra.contentRange(file.Size)},
+		})
+		if err != nil {
+			return
+		}
+		if _, err := io.Copy(part, io.NewSectionReader(blob, ra.start, ra.length)); err != nil {
+			return
+		}
+	}
+	_ = mw.Close()
+}
+
+// fileETag is strong when the content digest is known, files stored before
+// content addressing only get a weak validator.
+func fileETag(file *entity.File) string {
+	if file.Digest != "" {
+		return fmt.Sprintf(`"%s"`, file.Digest)
+	}
+	return fmt.Sprintf(`W/"%d-%d-%d"`, file.ID, file.Size, file.CreatedAt.Unix())
+}
+
+// checkPreconditions evaluates the conditional headers in the order of
+// RFC 9110 section 13.2.2 and returns the status to short-circuit with, or 0.
+func checkPreconditions(r *http.Request, etag string, lastModified time.Time) int {
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		if !matchETag(ifMatch, etag, false) {
+			return http.StatusPreconditionFailed
+		}
+	} else if since, ok := parseHTTPDate(r.Header.Get("If-Unmodified-Since")); ok {
+		if lastModified.After(since) {
+			return http.StatusPreconditionFailed
+		}
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return 0
+	}
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		if matchETag(ifNoneMatch, etag, true) {
+			return http.StatusNotModified
+		}
+	} else if since, ok := parseHTTPDate(r.Header.Get("If-Modified-Since")); ok {
+		if !lastModified.After(since) {
+			return http.StatusNotModified
+		}
+	}
+
+	return 0
+}
+
+// checkIfRange reports whether the Range header should be honoured.
+func checkIfRange(r *http.Request, etag string, lastModified time.Time) bool {
+	ifRange := r.Header.Get("If-Range")
+	if ifRange == "" {
+		return true
+	}
+
+	if strings.HasPrefix(ifRange, `"`) || strings.HasPrefix(ifRange, "W/") {
+		return matchETag(ifRange, etag, false)
+	}
+	date, ok := parseHTTPDate(ifRange)
+	return ok && date.Equal(lastModified)
+}
+
+// matchETag checks a comma separated If-Match / If-None-Match list. Weak
+// comparison ignores the W/ prefix, strong comparison never matches weak tags.
+func matchETag(header string, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+			continue
+		}
+		if !strings.HasPrefix(candidate, "W/") && !strings.HasPrefix(etag, "W/") && candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func parseHTTPDate(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	date, err := http.ParseTime(value)
+	return date, err == nil
+}
+
+// parseRange parses a "bytes=" Range header. A malformed or unsupported
+// header yields no ranges so the full content is served, an error means
+// none of the ranges can be satisfied.
+func parseRange(header string, size int64) ([]byteRange, error) {
+	if !strings.HasPrefix(header, "bytes=") {
+		return nil, nil
+	}
+
+	specs := strings.Split(strings.TrimPrefix(header, "bytes="), ",")
+	if len(specs) > maxRanges {
+		return nil, nil
+	}
+
+	ranges := []byteRange{}
+	var total int64
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		startStr, endStr, found := strings.Cut(spec, "-")
+		if !found {
+			return nil, nil
+		}
+		startStr, endStr = strings.TrimSpace(startStr), strings.TrimSpace(endStr)
+
+		var ra byteRange
+		if startStr == "" {
+			// suffix range, the last N bytes
+			suffix, err := strconv.ParseInt(endStr, 10, 64)
+			if err != nil || suffix < 0 {
+				return nil, nil
+			}
+			if suffix == 0 {
+				continue
+			}
+			if suffix > size {
+				suffix = size
+			}
+			ra = byteRange{start: size - suffix, length: suffix}
+		} else {
+			start, err := strconv.ParseInt(startStr, 10, 64)
+			if err != nil || start < 0 {
+				return nil, nil
+			}
+			end := size - 1
+			if endStr != "" {
+				end, err = strconv.ParseInt(endStr, 10, 64)
+				if err != nil || end < start {
+					return nil, nil
+				}
+			}
+			if start >= size {
+				continue
+			}
+			if end >= size {
+				end = size - 1
+			}
+			ra = byteRange{start: start, length: end - start + 1}
+		}
+
+		ranges = append(ranges, ra)
+		total += ra.length
+	}
+
+	if len(ranges) == 0 {
+		return nil, entity.ErrorRangeNotSatisfiable
+	}
+	// asking for more than the whole file is not worth the multipart overhead
+	if total > size {
+		return nil, nil
+	}
+	return ranges, nil
+}
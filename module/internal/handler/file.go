@@ -2,14 +2,22 @@ package handler
 
 import (
 	"fmt"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"go.opentelemetry.io/otel/attribute"
 
+	"video-server/internal/metrics"
+	"video-server/internal/tracing"
 	"video-server/internal/util"
 	"video-server/module/entity"
 	"video-server/module/internal/usecase"
+	"video-server/module/param"
 	"video-server/module/response"
 )
 
@@ -17,6 +25,7 @@ type FileRepository interface {
 	CreateFile(w http.ResponseWriter, r *http.Request, params httprouter.Params)
 	ListFiles(w http.ResponseWriter, r *http.Request, params httprouter.Params)
 	GetFile(w http.ResponseWriter, r *http.Request, params httprouter.Params)
+	GetFileMetadata(w http.ResponseWriter, r *http.Request, params httprouter.Params)
 	DeleteFile(w http.ResponseWriter, r *http.Request, params httprouter.Params)
 }
 
@@ -34,62 +43,204 @@ func (h *FileHandler) Register(router *httprouter.Router) {
 	router.POST("/v1/files", h.CreateFile)
 	router.GET("/v1/files", h.ListFiles)
 	router.GET("/v1/files/:fileid", h.GetFile)
+	router.HEAD("/v1/files/:fileid", h.GetFile)
 	router.DELETE("/v1/files/:fileid", h.DeleteFile)
+	router.GET("/v1/files/:fileid/metadata", h.GetFileMetadata)
 }
 
 func (h *FileHandler) CreateFile(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
-	reqFile, reqFileHeader, err := r.FormFile("data")
+	ctx, span := tracing.Start(r.Context(), "handler.FileHandler.CreateFile")
+	defer span.End()
+
+	part, err := dataPart(r)
 	if err != nil {
-		BuildErrorResponse(w, entity.ErrorBadRequest)
+		BuildErrorResponse(w, r, err)
 		return
 	}
-	defer reqFile.Close()
+	fileReader := util.NewFileReader(part, part.FileName())
+	defer fileReader.Close()
 
-	result, err := h.usecase.CreateFile(r.Context(), util.NewFileReader(reqFile, reqFileHeader))
+	result, err := h.usecase.CreateFile(ctx, fileReader)
 	if err != nil {
-		BuildErrorResponse(w, err)
+		BuildErrorResponse(w, r, err)
 		return
 	}
+	span.SetAttributes(fileAttributes(result)...)
 
-	w.Header().Set("Location", fmt.Sprintf("%s/%d", util.StoragePath, result.ID))
+	w.Header().Set("Location", fmt.Sprintf("/v1/files/%d", result.ID))
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(http.StatusCreated)
 }
 
+// dataPart walks the multipart body up to the "data" file part, which is
+// then read straight off the request body instead of being spooled first.
+func dataPart(r *http.Request) (*multipart.Part, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, entity.ErrorBadRequest
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return nil, entity.ErrorBadRequest
+		}
+		if part.FormName() == "data" && part.FileName() != "" {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
 func (h *FileHandler) ListFiles(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
-	files, err := h.usecase.ListFiles(r.Context())
+	query, err := listFilesParams(r.URL.Query())
 	if err != nil {
-		BuildErrorResponse(w, err)
+		BuildErrorResponse(w, r, err)
 		return
 	}
 
-	result := []*response.File{}
+	files, pagination, err := h.usecase.ListFiles(r.Context(), query)
+	if err != nil {
+		BuildErrorResponse(w, r, err)
+		return
+	}
+
+	result := &response.FileList{
+		Data:       []*response.File{},
+		Pagination: pagination,
+	}
 	for _, obj := range files {
-		result = append(result, fileEntityToResponse(obj))
+		result.Data = append(result.Data, fileEntityToResponse(obj))
 	}
 
 	WriteHTTPResponse(w, result, http.StatusOK)
 }
 
+// listFilesParams reads the paging, filter and sort query parameters of a
+// file listing.
+func listFilesParams(values url.Values) (*param.ListFiles, error) {
+	query := &param.ListFiles{
+		Sort:   values.Get("sort"),
+		Cursor: values.Get("cursor"),
+		FileFilter: param.FileFilter{
+			NamePrefix: values.Get("name"),
+			MimeType:   values.Get("mime_type"),
+			Status:     values.Get("status"),
+		},
+	}
+
+	var err error
+	if query.Limit, err = intParam(values, "limit"); err != nil {
+		return nil, err
+	}
+	if query.Offset, err = intParam(values, "offset"); err != nil {
+		return nil, err
+	}
+	if query.MinSize, err = sizeParam(values, "min_size"); err != nil {
+		return nil, err
+	}
+	if query.MaxSize, err = sizeParam(values, "max_size"); err != nil {
+		return nil, err
+	}
+	if query.CreatedAfter, err = timeParam(values, "created_after"); err != nil {
+		return nil, err
+	}
+	if query.CreatedBefore, err = timeParam(values, "created_before"); err != nil {
+		return nil, err
+	}
+
+	switch values.Get("order") {
+	case "", "asc":
+	case "desc":
+		query.Desc = true
+	default:
+		return nil, entity.WithField(entity.ErrorBadRequest, "order", "order must be asc or desc")
+	}
+
+	return query, nil
+}
+
+func intParam(values url.Values, name string) (int, error) {
+	value := values.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, entity.WithField(entity.ErrorBadRequest, name, fmt.Sprintf("%s must be an integer", name))
+	}
+	return n, nil
+}
+
+func sizeParam(values url.Values, name string) (*int64, error) {
+	value := values.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return nil, entity.WithField(entity.ErrorBadRequest, name, fmt.Sprintf("%s must be a size in bytes", name))
+	}
+	return &n, nil
+}
+
+func timeParam(values url.Values, name string) (*time.Time, error) {
+	value := values.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, entity.WithField(entity.ErrorBadRequest, name, fmt.Sprintf("%s must be an RFC 3339 time", name))
+	}
+	return &t, nil
+}
+
 func (h *FileHandler) GetFile(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 	var id int
 	var err error
 
 	id, err = strconv.Atoi(params.ByName("fileid"))
 	if err != nil {
-		BuildErrorResponse(w, entity.ErrorFileNotFound)
+		BuildErrorResponse(w, r, entity.ErrorFileNotFound)
 		return
 	}
 
-	result, err := h.usecase.GetFile(r.Context(), id)
+	// the span lasts as long as the content is being sent
+	ctx, span := tracing.Start(r.Context(), "handler.FileHandler.GetFile", tracing.FileIDKey.Int(id))
+	defer span.End()
+
+	result, blob, err := h.usecase.OpenFile(ctx, id)
 	if err != nil {
-		BuildErrorResponse(w, err)
+		BuildErrorResponse(w, r, err)
 		return
 	}
+	defer blob.Close()
+	span.SetAttributes(fileAttributes(result)...)
+
+	streams := metrics.ActiveStreams.WithLabelValues(metrics.StreamDownload)
+	streams.Inc()
+	defer streams.Dec()
 
-	w.Header().Set("Content-Disposition", "attachment; filename="+result.Name)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": result.Name}))
 	w.Header().Set("Content-Type", result.MimeType)
-	http.ServeFile(w, r, fmt.Sprintf("%s/%s", util.StoragePath, result.Name))
+	serveFileContent(w, r, result, blob)
+}
+
+func (h *FileHandler) GetFileMetadata(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	id, err := strconv.Atoi(params.ByName("fileid"))
+	if err != nil {
+		BuildErrorResponse(w, r, entity.ErrorFileNotFound)
+		return
+	}
+
+	metadata, err := h.usecase.GetFileMetadata(r.Context(), id)
+	if err != nil {
+		BuildErrorResponse(w, r, err)
+		return
+	}
+
+	WriteHTTPResponse(w, metadataEntityToResponse(metadata), http.StatusOK)
 }
 
 func (h *FileHandler) DeleteFile(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
@@ -98,24 +249,50 @@ func (h *FileHandler) DeleteFile(w http.ResponseWriter, r *http.Request, params
 
 	id, err = strconv.Atoi(params.ByName("fileid"))
 	if err != nil {
-		BuildErrorResponse(w, entity.ErrorFileNotFound)
+		BuildErrorResponse(w, r, entity.ErrorFileNotFound)
 		return
 	}
 
 	err = h.usecase.DeleteFile(r.Context(), id)
 	if err != nil {
-		BuildErrorResponse(w, err)
+		BuildErrorResponse(w, r, err)
 		return
 	}
 
 	WriteHTTPResponse(w, nil, http.StatusNoContent)
 }
 
+func fileAttributes(file *entity.File) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		tracing.FileIDKey.Int(file.ID),
+		tracing.FileSizeKey.Int64(file.Size),
+		tracing.FileMimeTypeKey.String(file.MimeType),
+	}
+}
+
 func fileEntityToResponse(eObj *entity.File) *response.File {
-	return &response.File{
+	result := &response.File{
 		ID:        fmt.Sprint(eObj.ID),
 		Name:      eObj.Name,
 		Size:      eObj.Size,
 		CreatedAt: eObj.CreatedAt,
 	}
+	if eObj.Metadata != nil {
+		result.Metadata = metadataEntityToResponse(eObj.Metadata)
+	}
+	return result
+}
+
+func metadataEntityToResponse(eObj *entity.VideoMetadata) *response.Metadata {
+	return &response.Metadata{
+		Container:  eObj.Container,
+		Duration:   eObj.Duration,
+		Width:      eObj.Width,
+		Height:     eObj.Height,
+		FrameRate:  eObj.FrameRate,
+		Bitrate:    eObj.Bitrate,
+		VideoCodec: eObj.VideoCodec,
+		AudioCodec: eObj.AudioCodec,
+		Tracks:     eObj.Tracks,
+	}
 }
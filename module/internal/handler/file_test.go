@@ -1,11 +1,16 @@
 package handler_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -17,6 +22,7 @@ import (
 	"video-server/internal/util"
 	"video-server/module/entity"
 	"video-server/module/fixture"
+	"video-server/module/param"
 	"video-server/module/response"
 )
 
@@ -30,50 +36,92 @@ func TestFileHandler_CreateFile(t *testing.T) {
 		err        error
 	}
 
+	// the usecase is handed the data part as it arrives
+	readsSample := func(ctx context.Context, fileReader util.FileReader) {
+		assert.Equal(t, "sample.mp4", fileReader.GetName())
+		mimeType, err := fileReader.GetFileMimeType()
+		assert.NoError(t, err)
+		assert.Equal(t, "video/mp4", mimeType)
+	}
+
 	testcases := map[string]struct {
 		request  Request
 		response Response
 		mockFn   func(*fixture.MockFileHandler, Request)
 	}{
 		"success": {
+			request: Request{
+				req: testutil.RequestPayloadCreateFile("./../../../test/post_1/sample.mp4"),
+			},
 			response: Response{
 				statusCode: 201,
 				err:        nil,
 			},
 			mockFn: func(m *fixture.MockFileHandler, req Request) {
-				reqFile, reqFileHeader, _ := req.req.FormFile("data")
-				defer reqFile.Close()
-
-				m.FileUsecase.EXPECT().CreateFile(req.req.Context(), util.NewFileReader(reqFile, reqFileHeader)).
+				m.FileUsecase.EXPECT().CreateFile(req.req.Context(), gomock.Any()).
+					Do(readsSample).
 					Return(&entity.File{ID: 1}, nil)
 			},
 		},
 		"File exists": {
+			request: Request{
+				req: testutil.RequestPayloadCreateFile("./../../../test/post_1/sample.mp4"),
+			},
 			response: Response{
 				statusCode: 409,
 				err:        entity.ErrorFileExists,
 			},
 			mockFn: func(m *fixture.MockFileHandler, req Request) {
-				reqFile, reqFileHeader, _ := req.req.FormFile("data")
-				defer reqFile.Close()
-
-				m.FileUsecase.EXPECT().CreateFile(req.req.Context(), util.NewFileReader(reqFile, reqFileHeader)).
+				m.FileUsecase.EXPECT().CreateFile(req.req.Context(), gomock.Any()).
 					Return(nil, entity.ErrorFileExists)
 			},
 		},
 		"Unsupported File Type": {
+			request: Request{
+				req: testutil.RequestPayloadCreateFile("./../../../test/post_1/sample.mp4"),
+			},
 			response: Response{
 				statusCode: 415,
 				err:        entity.ErrorFileUnsupported,
 			},
 			mockFn: func(m *fixture.MockFileHandler, req Request) {
-				reqFile, reqFileHeader, _ := req.req.FormFile("data")
-				defer reqFile.Close()
-
-				m.FileUsecase.EXPECT().CreateFile(req.req.Context(), util.NewFileReader(reqFile, reqFileHeader)).
+				m.FileUsecase.EXPECT().CreateFile(req.req.Context(), gomock.Any()).
 					Return(nil, entity.ErrorFileUnsupported)
 			},
 		},
+		"File too large": {
+			request: Request{
+				req: testutil.RequestPayloadCreateFile("./../../../test/post_1/sample.mp4"),
+			},
+			response: Response{
+				statusCode: 413,
+				err:        entity.ErrorFileTooLarge,
+			},
+			mockFn: func(m *fixture.MockFileHandler, req Request) {
+				m.FileUsecase.EXPECT().CreateFile(req.req.Context(), gomock.Any()).
+					Return(nil, entity.ErrorFileTooLarge)
+			},
+		},
+		"Not multipart": {
+			request: Request{
+				req: httptest.NewRequest(http.MethodPost, "/v1/files", strings.NewReader("data")),
+			},
+			response: Response{
+				statusCode: 400,
+				err:        entity.ErrorBadRequest,
+			},
+			mockFn: func(m *fixture.MockFileHandler, req Request) {},
+		},
+		"Missing data part": {
+			request: Request{
+				req: multipartRequest("other", "sample.mp4"),
+			},
+			response: Response{
+				statusCode: 400,
+				err:        entity.ErrorBadRequest,
+			},
+			mockFn: func(m *fixture.MockFileHandler, req Request) {},
+		},
 	}
 
 	for name, tc := range testcases {
@@ -82,25 +130,37 @@ func TestFileHandler_CreateFile(t *testing.T) {
 			defer ctrl.Finish()
 
 			handler, mocks := fixture.NewFileHandler(ctrl)
-
-			req := testutil.RequestPayloadCreateFile("./../../../test/post_1/sample.mp4")
-			tc.mockFn(mocks, Request{req: req})
+			tc.mockFn(mocks, tc.request)
 
 			responseWriter := httptest.NewRecorder()
-			handler.CreateFile(responseWriter, req, nil)
-			assert.Equal(t, responseWriter.Code, tc.response.statusCode)
+			handler.CreateFile(responseWriter, tc.request.req, nil)
+			assert.Equal(t, tc.response.statusCode, responseWriter.Code)
 		})
 	}
 }
 
+// multipartRequest builds an upload whose only part is a file under field.
+func multipartRequest(field, filename string) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile(field, filename)
+	part.Write([]byte("some bytes"))
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/files", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
 func TestFileHandler_ListFiles(t *testing.T) {
 	type Request struct {
-		req *http.Request
-		ctx context.Context
+		req   *http.Request
+		query string
 	}
 
 	type Response struct {
-		body interface{}
+		statusCode int
+		body       interface{}
 	}
 
 	createdAt := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
@@ -119,33 +179,162 @@ func TestFileHandler_ListFiles(t *testing.T) {
 		CreatedAt: createdAt,
 	}
 
+	pagination := util.NewPagination(10, 0, 1, "")
+	minSize := int64(100)
+
 	testcases := map[string]struct {
 		request  Request
 		response Response
 		mockFn   func(*fixture.MockFileHandler, Request)
 	}{
 		"success": {
-			request: Request{
-				ctx: context.Background(),
+			request: Request{},
+			response: Response{
+				statusCode: http.StatusOK,
+				body:       &response.FileList{Data: []*response.File{resp}, Pagination: pagination},
 			},
+			mockFn: func(m *fixture.MockFileHandler, r Request) {
+				m.FileUsecase.EXPECT().ListFiles(r.req.Context(), &param.ListFiles{}).
+					Return([]*entity.File{file}, pagination, nil)
+			},
+		},
+		"success empty": {
+			request: Request{},
 			response: Response{
-				body: []*response.File{resp},
+				statusCode: http.StatusOK,
+				body:       map[string]interface{}{"data": []interface{}{}, "pagination": util.NewPagination(10, 0, 0, "")},
 			},
 			mockFn: func(m *fixture.MockFileHandler, r Request) {
-				m.FileUsecase.EXPECT().ListFiles(r.req.Context()).
-					Return([]*entity.File{file}, nil)
+				m.FileUsecase.EXPECT().ListFiles(r.req.Context(), &param.ListFiles{}).
+					Return(nil, util.NewPagination(10, 0, 0, ""), nil)
 			},
 		},
-		"ListFiles error": {
+		"success with query": {
 			request: Request{
-				ctx: context.Background(),
+				query: "limit=5&offset=10&cursor=abc&name=Some&mime_type=video%2Fmp4&min_size=100" +
+					"&created_after=2023-01-01T00:00:00Z&sort=size&order=desc",
+			},
+			response: Response{
+				statusCode: http.StatusOK,
+				body:       &response.FileList{Data: []*response.File{resp}, Pagination: util.NewPagination(5, 10, 11, "next")},
 			},
+			mockFn: func(m *fixture.MockFileHandler, r Request) {
+				m.FileUsecase.EXPECT().ListFiles(r.req.Context(), &param.ListFiles{
+					FileFilter: param.FileFilter{
+						NamePrefix:   "Some",
+						MimeType:     "video/mp4",
+						MinSize:      &minSize,
+						CreatedAfter: &createdAt,
+					},
+					Sort:   "size",
+					Desc:   true,
+					Limit:  5,
+					Offset: 10,
+					Cursor: "abc",
+				}).Return([]*entity.File{file}, util.NewPagination(5, 10, 11, "next"), nil)
+			},
+		},
+		"success with metadata": {
+			request: Request{},
 			response: Response{
-				body: map[string]interface{}{"message": "DB Error"},
+				statusCode: http.StatusOK,
+				body: &response.FileList{
+					Data: []*response.File{{
+						ID:        "1",
+						Name:      "Some Name",
+						Size:      100,
+						CreatedAt: createdAt,
+						Metadata: &response.Metadata{
+							Container:  "mp4",
+							Duration:   5.5,
+							Width:      1920,
+							Height:     1080,
+							FrameRate:  30,
+							Bitrate:    145,
+							VideoCodec: "avc1.640028",
+							Tracks:     1,
+						},
+					}},
+					Pagination: pagination,
+				},
 			},
 			mockFn: func(m *fixture.MockFileHandler, r Request) {
-				m.FileUsecase.EXPECT().ListFiles(r.req.Context()).
-					Return(nil, testutil.ErrDB)
+				withMetadata := *file
+				withMetadata.Metadata = &entity.VideoMetadata{
+					FileID:     1,
+					Container:  "mp4",
+					Duration:   5.5,
+					Width:      1920,
+					Height:     1080,
+					FrameRate:  30,
+					Bitrate:    145,
+					VideoCodec: "avc1.640028",
+					Tracks:     1,
+				}
+				m.FileUsecase.EXPECT().ListFiles(r.req.Context(), &param.ListFiles{}).
+					Return([]*entity.File{&withMetadata}, pagination, nil)
+			},
+		},
+		"invalid limit": {
+			request: Request{query: "limit=ten"},
+			response: Response{
+				statusCode: http.StatusBadRequest,
+				body: &response.Problem{
+					Type: "about:blank", Title: "Bad Request", Status: 400,
+					Detail: "Bad Request: limit must be an integer", Instance: "/v1/files", Code: "bad_request",
+					InvalidParams: []*response.InvalidParam{{Name: "limit", Reason: "limit must be an integer"}},
+				},
+			},
+			mockFn: func(m *fixture.MockFileHandler, r Request) {},
+		},
+		"invalid size": {
+			request: Request{query: "max_size=-1"},
+			response: Response{
+				statusCode: http.StatusBadRequest,
+				body: &response.Problem{
+					Type: "about:blank", Title: "Bad Request", Status: 400,
+					Detail: "Bad Request: max_size must be a size in bytes", Instance: "/v1/files", Code: "bad_request",
+					InvalidParams: []*response.InvalidParam{{Name: "max_size", Reason: "max_size must be a size in bytes"}},
+				},
+			},
+			mockFn: func(m *fixture.MockFileHandler, r Request) {},
+		},
+		"invalid time": {
+			request: Request{query: "created_before=yesterday"},
+			response: Response{
+				statusCode: http.StatusBadRequest,
+				body: &response.Problem{
+					Type: "about:blank", Title: "Bad Request", Status: 400,
+					Detail: "Bad Request: created_before must be an RFC 3339 time", Instance: "/v1/files", Code: "bad_request",
+					InvalidParams: []*response.InvalidParam{{Name: "created_before", Reason: "created_before must be an RFC 3339 time"}},
+				},
+			},
+			mockFn: func(m *fixture.MockFileHandler, r Request) {},
+		},
+		"invalid order": {
+			request: Request{query: "order=up"},
+			response: Response{
+				statusCode: http.StatusBadRequest,
+				body: &response.Problem{
+					Type: "about:blank", Title: "Bad Request", Status: 400,
+					Detail: "Bad Request: order must be asc or desc", Instance: "/v1/files", Code: "bad_request",
+					InvalidParams: []*response.InvalidParam{{Name: "order", Reason: "order must be asc or desc"}},
+				},
+			},
+			mockFn: func(m *fixture.MockFileHandler, r Request) {},
+		},
+		"ListFiles error": {
+			request: Request{},
+			response: Response{
+				statusCode: http.StatusInternalServerError,
+				body: &response.Problem{
+					Type: "about:blank", Title: "Internal Server Error", Status: 500,
+					Detail: "Internal server error", Instance: "/v1/files", Code: "internal_error",
+				},
+			},
+			mockFn: func(m *fixture.MockFileHandler, r Request) {
+				m.FileUsecase.EXPECT().ListFiles(r.req.Context(), &param.ListFiles{}).
+					Return(nil, nil, testutil.ErrDB)
 			},
 		},
 	}
@@ -154,7 +343,7 @@ func TestFileHandler_ListFiles(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			req, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+			req, _ := http.NewRequest(http.MethodGet, "http://example.com/v1/files?"+tc.request.query, nil)
 			tc.request.req = req
 			handler, mocks := fixture.NewFileHandler(ctrl)
 			tc.mockFn(mocks, tc.request)
@@ -163,6 +352,7 @@ func TestFileHandler_ListFiles(t *testing.T) {
 			handler.ListFiles(responseWriter, req, nil)
 			resultBody, _ := io.ReadAll(responseWriter.Body)
 			body, _ := json.Marshal(tc.response.body)
+			assert.Equal(t, tc.response.statusCode, responseWriter.Code)
 			assert.Equal(t, string(body)+"\n", string(resultBody))
 		})
 	}
@@ -177,15 +367,21 @@ func TestFileHandler_GetFile(t *testing.T) {
 	createdAt := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
 	file := &entity.File{
 		ID:        1,
-		Name:      "test/sample.mp4",
+		Name:      "clip ü.mp4",
 		MimeType:  "video/mp4",
 		Size:      100,
 		CreatedAt: createdAt,
 	}
 
+	type Response struct {
+		statusCode         int
+		contentDisposition string
+	}
+
 	testcases := map[string]struct {
-		request Request
-		mockFn  func(*fixture.MockFileHandler, Request)
+		request  Request
+		response Response
+		mockFn   func(*fixture.MockFileHandler, Request)
 	}{
 		"success": {
 			request: Request{
@@ -194,15 +390,23 @@ func TestFileHandler_GetFile(t *testing.T) {
 					Value: "123",
 				}},
 			},
+			response: Response{
+				statusCode:         200,
+				contentDisposition: "attachment; filename*=utf-8''clip%20%C3%BC.mp4",
+			},
 			mockFn: func(m *fixture.MockFileHandler, req Request) {
-				m.FileUsecase.EXPECT().GetFile(req.req.Context(), 123).
-					Return(file, nil)
+				blob, _ := os.Open("./../../../test/post_1/sample.mp4")
+				m.FileUsecase.EXPECT().OpenFile(req.req.Context(), 123).
+					Return(file, blob, nil)
 			},
 		},
 		"no request param": {
 			request: Request{
 				params: httprouter.Params{},
 			},
+			response: Response{
+				statusCode: 404,
+			},
 			mockFn: func(m *fixture.MockFileHandler, req Request) {},
 		},
 		"GetFile error": {
@@ -212,9 +416,12 @@ func TestFileHandler_GetFile(t *testing.T) {
 					Value: "123",
 				}},
 			},
+			response: Response{
+				statusCode: 404,
+			},
 			mockFn: func(m *fixture.MockFileHandler, req Request) {
-				m.FileUsecase.EXPECT().GetFile(req.req.Context(), 123).
-					Return(nil, entity.ErrorFileNotFound)
+				m.FileUsecase.EXPECT().OpenFile(req.req.Context(), 123).
+					Return(nil, nil, entity.ErrorFileNotFound)
 			},
 		},
 	}
@@ -230,6 +437,80 @@ func TestFileHandler_GetFile(t *testing.T) {
 
 			responseWriter := httptest.NewRecorder()
 			handler.GetFile(responseWriter, req, tc.request.params)
+			assert.Equal(t, tc.response.statusCode, responseWriter.Code)
+			assert.Equal(t, tc.response.contentDisposition, responseWriter.Header().Get("Content-Disposition"))
+		})
+	}
+}
+
+func TestFileHandler_GetFileMetadata(t *testing.T) {
+	type Response struct {
+		statusCode int
+		body       string
+	}
+
+	params := httprouter.Params{httprouter.Param{Key: "fileid", Value: "1"}}
+
+	testcases := map[string]struct {
+		params   httprouter.Params
+		response Response
+		mockFn   func(*fixture.MockFileHandler, *http.Request)
+	}{
+		"success": {
+			params: params,
+			response: Response{
+				statusCode: 200,
+				body:       `{"container":"webm","duration":12.5,"width":1280,"height":720,"frame_rate":25,"bitrate":800000,"video_codec":"vp9","audio_codec":"opus","tracks":2}` + "\n",
+			},
+			mockFn: func(m *fixture.MockFileHandler, req *http.Request) {
+				m.FileUsecase.EXPECT().GetFileMetadata(req.Context(), 1).
+					Return(&entity.VideoMetadata{
+						FileID:     1,
+						Container:  "webm",
+						Duration:   12.5,
+						Width:      1280,
+						Height:     720,
+						FrameRate:  25,
+						Bitrate:    800000,
+						VideoCodec: "vp9",
+						AudioCodec: "opus",
+						Tracks:     2,
+					}, nil)
+			},
+		},
+		"invalid file id": {
+			params: httprouter.Params{httprouter.Param{Key: "fileid", Value: "abc"}},
+			response: Response{
+				statusCode: 404,
+			},
+			mockFn: func(m *fixture.MockFileHandler, req *http.Request) {},
+		},
+		"metadata not found": {
+			params: params,
+			response: Response{
+				statusCode: 404,
+			},
+			mockFn: func(m *fixture.MockFileHandler, req *http.Request) {
+				m.FileUsecase.EXPECT().GetFileMetadata(req.Context(), 1).
+					Return(nil, entity.ErrorMetadataNotFound)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			req, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+			handler, mocks := fixture.NewFileHandler(ctrl)
+			tc.mockFn(mocks, req)
+
+			responseWriter := httptest.NewRecorder()
+			handler.GetFileMetadata(responseWriter, req, tc.params)
+			assert.Equal(t, tc.response.statusCode, responseWriter.Code)
+			if tc.response.body != "" {
+				assert.Equal(t, tc.response.body, responseWriter.Body.String())
+			}
 		})
 	}
 }
@@ -303,3 +584,194 @@ func TestFileHandler_DeleteFile(t *testing.T) {
 		})
 	}
 }
+
+func TestFileHandler_GetFile_Conditional(t *testing.T) {
+	type Request struct {
+		method  string
+		headers map[string]string
+	}
+
+	type Response struct {
+		statusCode   int
+		headers      map[string]string
+		body         string
+		bodyContains []string
+	}
+
+	samplePath := "./../../../test/post_1/sample.mp4"
+	content, _ := os.ReadFile(samplePath)
+	size := int64(len(content))
+	digest := "05bd857af7f70bf51b6aac1144046973bf3325c9101a554bc27dc9607dbbd8f5"
+	etag := `"` + digest + `"`
+	createdAt := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	lastModified := createdAt.Format(http.TimeFormat)
+
+	file := &entity.File{
+		ID:        1,
+		Name:      "sample.mp4",
+		MimeType:  "video/mp4",
+		Size:      size,
+		Digest:    digest,
+		CreatedAt: createdAt,
+	}
+
+	testcases := map[string]struct {
+		request  Request
+		response Response
+	}{
+		"full content": {
+			request: Request{method: http.MethodGet},
+			response: Response{
+				statusCode: 200,
+				headers: map[string]string{
+					"ETag":           etag,
+					"Last-Modified":  lastModified,
+					"Accept-Ranges":  "bytes",
+					"Content-Length": fmt.Sprint(size),
+				},
+				body: string(content),
+			},
+		},
+		"head": {
+			request: Request{method: http.MethodHead},
+			response: Response{
+				statusCode: 200,
+				headers:    map[string]string{"Content-Length": fmt.Sprint(size)},
+				body:       "",
+			},
+		},
+		"single range": {
+			request: Request{method: http.MethodGet, headers: map[string]string{"Range": "bytes=0-99"}},
+			response: Response{
+				statusCode: 206,
+				headers: map[string]string{
+					"Content-Range":  fmt.Sprintf("bytes 0-99/%d", size),
+					"Content-Length": "100",
+				},
+				body: string(content[:100]),
+			},
+		},
+		"suffix range": {
+			request: Request{method: http.MethodGet, headers: map[string]string{"Range": "bytes=-10"}},
+			response: Response{
+				statusCode: 206,
+				headers:    map[string]string{"Content-Range": fmt.Sprintf("bytes %d-%d/%d", size-10, size-1, size)},
+				body:       string(content[size-10:]),
+			},
+		},
+		"open ended range": {
+			request: Request{method: http.MethodGet, headers: map[string]string{"Range": fmt.Sprintf("bytes=%d-", size-5)}},
+			response: Response{
+				statusCode: 206,
+				headers:    map[string]string{"Content-Range": fmt.Sprintf("bytes %d-%d/%d", size-5, size-1, size)},
+				body:       string(content[size-5:]),
+			},
+		},
+		"multi range": {
+			request: Request{method: http.MethodGet, headers: map[string]string{"Range": "bytes=0-3, 8-11"}},
+			response: Response{
+				statusCode: 206,
+				bodyContains: []string{
+					fmt.Sprintf("Content-Range: bytes 0-3/%d", size),
+					fmt.Sprintf("Content-Range: bytes 8-11/%d", size),
+					"Content-Type: video/mp4",
+					string(content[8:12]),
+				},
+			},
+		},
+		"unsatisfiable range": {
+			request: Request{method: http.MethodGet, headers: map[string]string{"Range": fmt.Sprintf("bytes=%d-", size)}},
+			response: Response{
+				statusCode: 416,
+				headers:    map[string]string{"Content-Range": fmt.Sprintf("bytes */%d", size)},
+			},
+		},
+		"malformed range ignored": {
+			request: Request{method: http.MethodGet, headers: map[string]string{"Range": "bytes=abc"}},
+			response: Response{
+				statusCode: 200,
+				body:       string(content),
+			},
+		},
+		"if none match": {
+			request: Request{method: http.MethodGet, headers: map[string]string{"If-None-Match": `"other", ` + etag}},
+			response: Response{
+				statusCode: 304,
+				headers:    map[string]string{"ETag": etag},
+			},
+		},
+		"if none match changed": {
+			request: Request{method: http.MethodGet, headers: map[string]string{"If-None-Match": `"other"`}},
+			response: Response{
+				statusCode: 200,
+				body:       string(content),
+			},
+		},
+		"if modified since": {
+			request: Request{method: http.MethodGet, headers: map[string]string{"If-Modified-Since": lastModified}},
+			response: Response{
+				statusCode: 304,
+			},
+		},
+		"if modified since older": {
+			request: Request{method: http.MethodGet, headers: map[string]string{"If-Modified-Since": createdAt.Add(-time.Hour).Format(http.TimeFormat)}},
+			response: Response{
+				statusCode: 200,
+			},
+		},
+		"if match failed": {
+			request: Request{method: http.MethodGet, headers: map[string]string{"If-Match": `"other"`}},
+			response: Response{
+				statusCode: 412,
+			},
+		},
+		"if range matches": {
+			request: Request{method: http.MethodGet, headers: map[string]string{"Range": "bytes=0-9", "If-Range": etag}},
+			response: Response{
+				statusCode: 206,
+				body:       string(content[:10]),
+			},
+		},
+		"if range stale": {
+			request: Request{method: http.MethodGet, headers: map[string]string{"Range": "bytes=0-9", "If-Range": `"other"`}},
+			response: Response{
+				statusCode: 200,
+				body:       string(content),
+			},
+		},
+		"if range date": {
+			request: Request{method: http.MethodGet, headers: map[string]string{"Range": "bytes=0-9", "If-Range": lastModified}},
+			response: Response{
+				statusCode: 206,
+				body:       string(content[:10]),
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			req, _ := http.NewRequest(tc.request.method, "http://example.com/", nil)
+			for key, value := range tc.request.headers {
+				req.Header.Set(key, value)
+			}
+			handler, mocks := fixture.NewFileHandler(ctrl)
+			blob, _ := os.Open(samplePath)
+			mocks.FileUsecase.EXPECT().OpenFile(req.Context(), 1).Return(file, blob, nil)
+
+			responseWriter := httptest.NewRecorder()
+			handler.GetFile(responseWriter, req, httprouter.Params{{Key: "fileid", Value: "1"}})
+			assert.Equal(t, tc.response.statusCode, responseWriter.Code)
+			for key, value := range tc.response.headers {
+				assert.Equal(t, value, responseWriter.Header().Get(key), key)
+			}
+			if tc.response.body != "" || tc.request.method == http.MethodHead {
+				assert.Equal(t, tc.response.body, responseWriter.Body.String())
+			}
+			for _, part := range tc.response.bodyContains {
+				assert.Contains(t, responseWriter.Body.String(), part)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+
+	"video-server/module/entity"
+	"video-server/module/internal/usecase"
+	"video-server/module/response"
+)
+
+// GrantHandler shares files with other principals.
+type GrantHandler struct {
+	usecase usecase.GrantUsecase
+}
+
+func NewGrantHandler(uc usecase.GrantUsecase) *GrantHandler {
+	return &GrantHandler{
+		usecase: uc,
+	}
+}
+
+func (h *GrantHandler) Register(router *httprouter.Router) {
+	router.GET("/v1/files/:fileid/grants", h.ListGrants)
+	router.PUT("/v1/files/:fileid/grants/:subject", h.CreateGrant)
+	router.DELETE("/v1/files/:fileid/grants/:subject", h.DeleteGrant)
+}
+
+func (h *GrantHandler) ListGrants(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	id, err := strconv.Atoi(params.ByName("fileid"))
+	if err != nil {
+		BuildErrorResponse(w, r, entity.ErrorFileNotFound)
+		return
+	}
+
+	grants, err := h.usecase.ListGrants(r.Context(), id)
+	if err != nil {
+		BuildErrorResponse(w, r, err)
+		return
+	}
+
+	result := &response.GrantList{Data: make([]*response.Grant, len(grants))}
+	for i, grant := range grants {
+		result.Data[i] = grantEntityToResponse(grant)
+	}
+	WriteHTTPResponse(w, result, http.StatusOK)
+}
+
+// CreateGrant is idempotent, granting twice succeeds.
+func (h *GrantHandler) CreateGrant(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	id, err := strconv.Atoi(params.ByName("fileid"))
+	if err != nil {
+		BuildErrorResponse(w, r, entity.ErrorFileNotFound)
+		return
+	}
+
+	err = h.usecase.CreateGrant(r.Context(), id, params.ByName("subject"))
+	if err != nil {
+		BuildErrorResponse(w, r, err)
+		return
+	}
+
+	WriteHTTPResponse(w, nil, http.StatusNoContent)
+}
+
+func (h *GrantHandler) DeleteGrant(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	id, err := strconv.Atoi(params.ByName("fileid"))
+	if err != nil {
+		BuildErrorResponse(w, r, entity.ErrorFileNotFound)
+		return
+	}
+
+	err = h.usecase.DeleteGrant(r.Context(), id, params.ByName("subject"))
+	if err != nil {
+		BuildErrorResponse(w, r, err)
+		return
+	}
+
+	WriteHTTPResponse(w, nil, http.StatusNoContent)
+}
+
+func grantEntityToResponse(eObj *entity.FileGrant) *response.Grant {
+	return &response.Grant{
+		FileID:    fmt.Sprint(eObj.FileID),
+		Subject:   eObj.Subject,
+		GrantedBy: eObj.GrantedBy,
+		CreatedAt: eObj.CreatedAt,
+	}
+}
@@ -0,0 +1,109 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+
+	"video-server/module/entity"
+	"video-server/module/fixture"
+)
+
+func TestGrantHandler_ListGrants(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hdl, mocks := fixture.NewGrantHandler(ctrl)
+	req := httptest.NewRequest(http.MethodGet, "/v1/files/1/grants", nil)
+	mocks.GrantUsecase.EXPECT().ListGrants(req.Context(), 1).
+		Return([]*entity.FileGrant{{ID: 1, FileID: 1, Subject: "alice", GrantedBy: "bob", CreatedAt: jobTime}}, nil)
+
+	w := httptest.NewRecorder()
+	hdl.ListGrants(w, req, httprouter.Params{{Key: "fileid", Value: "1"}})
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "{\"data\":[{\"fileid\":\"1\",\"subject\":\"alice\",\"granted_by\":\"bob\",\"created_at\":\"2022-01-01T00:00:00Z\"}]}\n", w.Body.String())
+}
+
+func TestGrantHandler_CreateGrant(t *testing.T) {
+	type Response struct {
+		statusCode int
+		body       string
+	}
+
+	params := func(fileID string) httprouter.Params {
+		return httprouter.Params{
+			httprouter.Param{Key: "fileid", Value: fileID},
+			httprouter.Param{Key: "subject", Value: "alice"},
+		}
+	}
+
+	testcases := map[string]struct {
+		params   httprouter.Params
+		response Response
+		mockFn   func(*fixture.MockGrantHandler, *http.Request)
+	}{
+		"success": {
+			params:   params("1"),
+			response: Response{statusCode: 204},
+			mockFn: func(m *fixture.MockGrantHandler, req *http.Request) {
+				m.GrantUsecase.EXPECT().CreateGrant(req.Context(), 1, "alice").Return(nil)
+			},
+		},
+		"invalid file id": {
+			params: params("abc"),
+			response: Response{
+				statusCode: 404,
+				body:       "{\"type\":\"about:blank\",\"title\":\"Not Found\",\"status\":404,\"detail\":\"File not found\",\"instance\":\"/v1/files/1/grants/alice\",\"code\":\"file_not_found\"}\n",
+			},
+			mockFn: func(m *fixture.MockGrantHandler, req *http.Request) {},
+		},
+		"forbidden": {
+			params: params("1"),
+			response: Response{
+				statusCode: 403,
+				body:       "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"detail\":\"Forbidden: only the owner or an admin may change the file\",\"instance\":\"/v1/files/1/grants/alice\",\"code\":\"forbidden\"}\n",
+			},
+			mockFn: func(m *fixture.MockGrantHandler, req *http.Request) {
+				m.GrantUsecase.EXPECT().CreateGrant(req.Context(), 1, "alice").
+					Return(entity.WithReason(entity.ErrorForbidden, "only the owner or an admin may change the file"))
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			hdl, mocks := fixture.NewGrantHandler(ctrl)
+			req := httptest.NewRequest(http.MethodPut, "/v1/files/1/grants/alice", nil)
+			tc.mockFn(mocks, req)
+
+			w := httptest.NewRecorder()
+			hdl.CreateGrant(w, req, tc.params)
+
+			assert.Equal(t, tc.response.statusCode, w.Code)
+			assert.Equal(t, tc.response.body, w.Body.String())
+		})
+	}
+}
+
+func TestGrantHandler_DeleteGrant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hdl, mocks := fixture.NewGrantHandler(ctrl)
+	req := httptest.NewRequest(http.MethodDelete, "/v1/files/1/grants/alice", nil)
+	mocks.GrantUsecase.EXPECT().DeleteGrant(req.Context(), 1, "alice").
+		Return(entity.ErrorGrantNotFound)
+
+	w := httptest.NewRecorder()
+	hdl.DeleteGrant(w, req, httprouter.Params{{Key: "fileid", Value: "1"}, {Key: "subject", Value: "alice"}})
+
+	assert.Equal(t, 404, w.Code)
+	assert.Equal(t, "{\"type\":\"about:blank\",\"title\":\"Not Found\",\"status\":404,\"detail\":\"Grant not found\",\"instance\":\"/v1/files/1/grants/alice\",\"code\":\"grant_not_found\"}\n", w.Body.String())
+}
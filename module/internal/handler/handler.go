@@ -2,19 +2,66 @@ package handler
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 
+	"video-server/internal/logging"
 	"video-server/module/entity"
+	"video-server/module/response"
 )
 
-func BuildErrorResponse(w http.ResponseWriter, err error) {
+// ProblemContentType is the media type of RFC 7807 error bodies.
+const ProblemContentType = "application/problem+json"
+
+// codeInternal is the code of errors that aren't a RequestError, their
+// message is only logged.
+const codeInternal = "internal_error"
+
+// BuildErrorResponse answers r with an RFC 7807 problem. Errors other than
+// RequestError are logged with the request ID and masked, they may carry
+// details of the backend.
+func BuildErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	problem := &response.Problem{
+		Type:      "about:blank",
+		Instance:  r.URL.Path,
+		RequestID: entity.RequestIDFromContext(r.Context()),
+	}
+
 	e, ok := err.(entity.RequestError)
-	if !ok {
-		WriteHTTPResponse(w, map[string]string{"message": err.Error()}, http.StatusInternalServerError)
-		return
+	if ok {
+		problem.Status = e.StatusCode
+		problem.Code = e.Code
+		problem.Detail = e.Err.Error()
+		for _, field := range e.Fields {
+			problem.InvalidParams = append(problem.InvalidParams, &response.InvalidParam{Name: field.Name, Reason: field.Reason})
+		}
+	} else {
+		logging.FromContext(r.Context()).WithError(err).Error("internal error")
+		problem.Status = http.StatusInternalServerError
+		problem.Code = codeInternal
+		problem.Detail = "Internal server error"
 	}
+	problem.Title = http.StatusText(problem.Status)
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+	_ = json.NewEncoder(w).Encode(problem)
+}
+
+// NotFound answers requests no route matches.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	BuildErrorResponse(w, r, entity.ErrorNotFound)
+}
+
+// MethodNotAllowed answers requests to a route that doesn't support their
+// method, the router has set the Allow header.
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	BuildErrorResponse(w, r, entity.ErrorMethodNotAllowed)
+}
 
-	WriteHTTPResponse(w, nil, e.StatusCode)
+// Panic answers a request whose handler panicked as an internal error.
+func Panic(w http.ResponseWriter, r *http.Request, v interface{}) {
+	BuildErrorResponse(w, r, fmt.Errorf("panic: %v", v))
 }
 
 func WriteHTTPResponse(w http.ResponseWriter, body interface{}, code int) {
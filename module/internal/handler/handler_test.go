@@ -0,0 +1,53 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"video-server/internal/testutil"
+	"video-server/module/entity"
+	"video-server/module/internal/handler"
+)
+
+func TestBuildErrorResponse(t *testing.T) {
+	testcases := map[string]struct {
+		err        error
+		requestID  string
+		statusCode int
+		body       string
+	}{
+		"request error": {
+			err:        entity.ErrorFileNotFound,
+			requestID:  "req-123",
+			statusCode: 404,
+			body:       "{\"type\":\"about:blank\",\"title\":\"Not Found\",\"status\":404,\"detail\":\"File not found\",\"instance\":\"/v1/files\",\"code\":\"file_not_found\",\"request_id\":\"req-123\"}\n",
+		},
+		"field error": {
+			err:        entity.WithField(entity.ErrorBadRequest, "limit", "limit must be an integer"),
+			statusCode: 400,
+			body:       "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"Bad Request: limit must be an integer\",\"instance\":\"/v1/files\",\"code\":\"bad_request\",\"invalid_params\":[{\"name\":\"limit\",\"reason\":\"limit must be an integer\"}]}\n",
+		},
+		"internal error": {
+			err:        testutil.ErrDB,
+			requestID:  "req-123",
+			statusCode: 500,
+			body:       "{\"type\":\"about:blank\",\"title\":\"Internal Server Error\",\"status\":500,\"detail\":\"Internal server error\",\"instance\":\"/v1/files\",\"code\":\"internal_error\",\"request_id\":\"req-123\"}\n",
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/files", nil)
+			if tc.requestID != "" {
+				req = req.WithContext(entity.WithRequestID(req.Context(), tc.requestID))
+			}
+			responseWriter := httptest.NewRecorder()
+			handler.BuildErrorResponse(responseWriter, req, tc.err)
+			assert.Equal(t, tc.statusCode, responseWriter.Code)
+			assert.Equal(t, handler.ProblemContentType, responseWriter.Header().Get("Content-Type"))
+			assert.Equal(t, tc.body, responseWriter.Body.String())
+		})
+	}
+}
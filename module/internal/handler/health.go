@@ -5,20 +5,69 @@ import (
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
+
+	"video-server/module/entity"
+	"video-server/module/internal/usecase"
+	"video-server/module/response"
 )
 
-type HealthHandler struct{}
+type HealthHandler struct {
+	usecase usecase.HealthUsecase
+}
 
-func NewHealthHandler() *HealthHandler {
-	return &HealthHandler{}
+func NewHealthHandler(uc usecase.HealthUsecase) *HealthHandler {
+	return &HealthHandler{
+		usecase: uc,
+	}
 }
 
 func (h *HealthHandler) Register(router *httprouter.Router) {
 	router.GET("/v1/health", h.GetHealth)
+	router.GET("/v1/health/live", h.GetLive)
+	router.GET("/v1/health/ready", h.GetReady)
 }
 
+// GetHealth is kept for existing checks, it checks nothing. Probes should
+// use GetLive and GetReady.
 func (h *HealthHandler) GetHealth(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintln(w, "OK")
 }
+
+func (h *HealthHandler) GetLive(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	writeHealth(w, h.usecase.Live(r.Context()))
+}
+
+func (h *HealthHandler) GetReady(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	writeHealth(w, h.usecase.Ready(r.Context()))
+}
+
+// writeHealth answers 503 when the service is down, so probes needn't
+// parse the body.
+func writeHealth(w http.ResponseWriter, health *entity.Health) {
+	body := &response.Health{Status: string(health.Status)}
+	if len(health.Components) > 0 {
+		body.Components = make(map[string]*response.ComponentHealth, len(health.Components))
+	}
+	for name, component := range health.Components {
+		c := &response.ComponentHealth{
+			Status: string(component.Status),
+			Error:  component.Error,
+		}
+		if component.Space != nil {
+			c.FreeBytes = &component.Space.FreeBytes
+			c.TotalBytes = &component.Space.TotalBytes
+			c.MinFreeBytes = &component.MinFreeBytes
+		}
+		body.Components[name] = c
+	}
+
+	// probes run every few seconds, a cached answer would hide a change
+	w.Header().Set("Cache-Control", "no-store")
+	code := http.StatusOK
+	if health.Status != entity.HealthUp {
+		code = http.StatusServiceUnavailable
+	}
+	WriteHTTPResponse(w, body, code)
+}
@@ -0,0 +1,89 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"video-server/module/entity"
+	"video-server/module/fixture"
+)
+
+func TestHealthHandler_GetLive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hdl, mocks := fixture.NewHealthHandler(ctrl)
+	req := httptest.NewRequest(http.MethodGet, "/v1/health/live", nil)
+	mocks.HealthUsecase.EXPECT().Live(req.Context()).
+		Return(&entity.Health{Status: entity.HealthUp})
+
+	w := httptest.NewRecorder()
+	hdl.GetLive(w, req, nil)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "{\"status\":\"up\"}\n", w.Body.String())
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+}
+
+func TestHealthHandler_GetReady(t *testing.T) {
+	type Response struct {
+		statusCode int
+		body       string
+	}
+
+	testcases := map[string]struct {
+		health   *entity.Health
+		response Response
+	}{
+		"up": {
+			health: &entity.Health{
+				Status: entity.HealthUp,
+				Components: map[string]*entity.ComponentHealth{
+					entity.HealthDatabase: {Status: entity.HealthUp},
+					entity.HealthDisk: {
+						Status:       entity.HealthUp,
+						Space:        &entity.DiskSpace{FreeBytes: 5000, TotalBytes: 10000},
+						MinFreeBytes: 1000,
+					},
+				},
+			},
+			response: Response{
+				statusCode: 200,
+				body:       "{\"status\":\"up\",\"components\":{\"database\":{\"status\":\"up\"},\"disk\":{\"status\":\"up\",\"free_bytes\":5000,\"total_bytes\":10000,\"min_free_bytes\":1000}}}\n",
+			},
+		},
+		"down": {
+			health: &entity.Health{
+				Status: entity.HealthDown,
+				Components: map[string]*entity.ComponentHealth{
+					entity.HealthDatabase: {Status: entity.HealthUp},
+					entity.HealthStorage:  {Status: entity.HealthDown, Error: "write failed"},
+				},
+			},
+			response: Response{
+				statusCode: 503,
+				body:       "{\"status\":\"down\",\"components\":{\"database\":{\"status\":\"up\"},\"storage\":{\"status\":\"down\",\"error\":\"write failed\"}}}\n",
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			hdl, mocks := fixture.NewHealthHandler(ctrl)
+			req := httptest.NewRequest(http.MethodGet, "/v1/health/ready", nil)
+			mocks.HealthUsecase.EXPECT().Ready(req.Context()).Return(tc.health)
+
+			w := httptest.NewRecorder()
+			hdl.GetReady(w, req, nil)
+
+			assert.Equal(t, tc.response.statusCode, w.Code)
+			assert.Equal(t, tc.response.body, w.Body.String())
+		})
+	}
+}
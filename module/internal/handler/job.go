@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+
+	"video-server/module/entity"
+	"video-server/module/internal/usecase"
+	"video-server/module/response"
+)
+
+// JobHandler reports on the background processing of uploaded files.
+type JobHandler struct {
+	usecase usecase.JobUsecase
+}
+
+func NewJobHandler(uc usecase.JobUsecase) *JobHandler {
+	return &JobHandler{
+		usecase: uc,
+	}
+}
+
+func (h *JobHandler) Register(router *httprouter.Router) {
+	router.GET("/v1/jobs/:jobid", h.GetJob)
+	router.GET("/v1/files/:fileid/jobs", h.ListFileJobs)
+}
+
+func (h *JobHandler) GetJob(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	id, err := strconv.Atoi(params.ByName("jobid"))
+	if err != nil {
+		BuildErrorResponse(w, r, entity.ErrorJobNotFound)
+		return
+	}
+
+	job, err := h.usecase.GetJob(r.Context(), id)
+	if err != nil {
+		BuildErrorResponse(w, r, err)
+		return
+	}
+
+	WriteHTTPResponse(w, jobEntityToResponse(job), http.StatusOK)
+}
+
+func (h *JobHandler) ListFileJobs(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	id, err := strconv.Atoi(params.ByName("fileid"))
+	if err != nil {
+		BuildErrorResponse(w, r, entity.ErrorFileNotFound)
+		return
+	}
+
+	jobs, err := h.usecase.ListFileJobs(r.Context(), id)
+	if err != nil {
+		BuildErrorResponse(w, r, err)
+		return
+	}
+
+	result := &response.JobList{Data: make([]*response.Job, len(jobs))}
+	for i, job := range jobs {
+		result.Data[i] = jobEntityToResponse(job)
+	}
+	WriteHTTPResponse(w, result, http.StatusOK)
+}
+
+func jobEntityToResponse(eObj *entity.Job) *response.Job {
+	return &response.Job{
+		ID:         fmt.Sprint(eObj.ID),
+		FileID:     fmt.Sprint(eObj.FileID),
+		Type:       eObj.Type,
+		Status:     eObj.Status,
+		Attempts:   eObj.Attempts,
+		Progress:   eObj.Progress,
+		Error:      eObj.Error,
+		RunAt:      eObj.RunAt,
+		StartedAt:  eObj.StartedAt,
+		FinishedAt: eObj.FinishedAt,
+		CreatedAt:  eObj.CreatedAt,
+		UpdatedAt:  eObj.UpdatedAt,
+	}
+}
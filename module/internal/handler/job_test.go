@@ -0,0 +1,192 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+
+	"video-server/module/entity"
+	"video-server/module/fixture"
+)
+
+var jobTime = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestJobHandler_GetJob(t *testing.T) {
+	type Request struct {
+		req    *http.Request
+		params httprouter.Params
+	}
+
+	type Response struct {
+		statusCode int
+		body       string
+	}
+
+	params := func(jobID string) httprouter.Params {
+		return httprouter.Params{
+			httprouter.Param{Key: "jobid", Value: jobID},
+		}
+	}
+
+	testcases := map[string]struct {
+		request  Request
+		response Response
+		mockFn   func(*fixture.MockJobHandler, Request)
+	}{
+		"success": {
+			request: Request{params: params("1")},
+			response: Response{
+				statusCode: 200,
+				body:       "{\"jobid\":\"1\",\"fileid\":\"2\",\"type\":\"hash\",\"status\":\"queued\",\"attempts\":1,\"progress\":0,\"error\":\"storage error\",\"run_at\":\"2022-01-01T00:00:00Z\",\"created_at\":\"2022-01-01T00:00:00Z\",\"updated_at\":\"2022-01-01T00:00:00Z\"}\n",
+			},
+			mockFn: func(m *fixture.MockJobHandler, req Request) {
+				m.JobUsecase.EXPECT().GetJob(req.req.Context(), 1).
+					Return(&entity.Job{
+						ID:        1,
+						FileID:    2,
+						Type:      entity.JobTypeHash,
+						Status:    entity.JobStatusQueued,
+						Attempts:  1,
+						Error:     "storage error",
+						RunAt:     jobTime,
+						CreatedAt: jobTime,
+						UpdatedAt: jobTime,
+					}, nil)
+			},
+		},
+		"invalid job id": {
+			request: Request{params: params("abc")},
+			response: Response{
+				statusCode: 404,
+				body:       "{\"type\":\"about:blank\",\"title\":\"Not Found\",\"status\":404,\"detail\":\"Job not found\",\"instance\":\"/\",\"code\":\"job_not_found\"}\n",
+			},
+			mockFn: func(m *fixture.MockJobHandler, req Request) {},
+		},
+		"not found": {
+			request: Request{params: params("1")},
+			response: Response{
+				statusCode: 404,
+				body:       "{\"type\":\"about:blank\",\"title\":\"Not Found\",\"status\":404,\"detail\":\"Job not found\",\"instance\":\"/\",\"code\":\"job_not_found\"}\n",
+			},
+			mockFn: func(m *fixture.MockJobHandler, req Request) {
+				m.JobUsecase.EXPECT().GetJob(req.req.Context(), 1).
+					Return(nil, entity.ErrorJobNotFound)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			req, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+			handler, mocks := fixture.NewJobHandler(ctrl)
+			tc.request.req = req
+			tc.mockFn(mocks, tc.request)
+
+			responseWriter := httptest.NewRecorder()
+			handler.GetJob(responseWriter, req, tc.request.params)
+			assert.Equal(t, tc.response.statusCode, responseWriter.Code)
+			assert.Equal(t, tc.response.body, responseWriter.Body.String())
+		})
+	}
+}
+
+func TestJobHandler_ListFileJobs(t *testing.T) {
+	type Request struct {
+		req    *http.Request
+		params httprouter.Params
+	}
+
+	type Response struct {
+		statusCode int
+		body       string
+	}
+
+	params := func(fileID string) httprouter.Params {
+		return httprouter.Params{
+			httprouter.Param{Key: "fileid", Value: fileID},
+		}
+	}
+
+	testcases := map[string]struct {
+		request  Request
+		response Response
+		mockFn   func(*fixture.MockJobHandler, Request)
+	}{
+		"success": {
+			request: Request{params: params("2")},
+			response: Response{
+				statusCode: 200,
+				body:       "{\"data\":[{\"jobid\":\"1\",\"fileid\":\"2\",\"type\":\"probe\",\"status\":\"succeeded\",\"attempts\":1,\"progress\":100,\"run_at\":\"2022-01-01T00:00:00Z\",\"started_at\":\"2022-01-01T00:00:00Z\",\"finished_at\":\"2022-01-01T00:00:00Z\",\"created_at\":\"2022-01-01T00:00:00Z\",\"updated_at\":\"2022-01-01T00:00:00Z\"}]}\n",
+			},
+			mockFn: func(m *fixture.MockJobHandler, req Request) {
+				m.JobUsecase.EXPECT().ListFileJobs(req.req.Context(), 2).
+					Return([]*entity.Job{{
+						ID:         1,
+						FileID:     2,
+						Type:       entity.JobTypeProbe,
+						Status:     entity.JobStatusSucceeded,
+						Attempts:   1,
+						Progress:   100,
+						RunAt:      jobTime,
+						StartedAt:  &jobTime,
+						FinishedAt: &jobTime,
+						CreatedAt:  jobTime,
+						UpdatedAt:  jobTime,
+					}}, nil)
+			},
+		},
+		"no jobs": {
+			request: Request{params: params("2")},
+			response: Response{
+				statusCode: 200,
+				body:       "{\"data\":[]}\n",
+			},
+			mockFn: func(m *fixture.MockJobHandler, req Request) {
+				m.JobUsecase.EXPECT().ListFileJobs(req.req.Context(), 2).
+					Return([]*entity.Job{}, nil)
+			},
+		},
+		"invalid file id": {
+			request: Request{params: params("abc")},
+			response: Response{
+				statusCode: 404,
+				body:       "{\"type\":\"about:blank\",\"title\":\"Not Found\",\"status\":404,\"detail\":\"File not found\",\"instance\":\"/\",\"code\":\"file_not_found\"}\n",
+			},
+			mockFn: func(m *fixture.MockJobHandler, req Request) {},
+		},
+		"file not found": {
+			request: Request{params: params("2")},
+			response: Response{
+				statusCode: 404,
+				body:       "{\"type\":\"about:blank\",\"title\":\"Not Found\",\"status\":404,\"detail\":\"File not found\",\"instance\":\"/\",\"code\":\"file_not_found\"}\n",
+			},
+			mockFn: func(m *fixture.MockJobHandler, req Request) {
+				m.JobUsecase.EXPECT().ListFileJobs(req.req.Context(), 2).
+					Return(nil, entity.ErrorFileNotFound)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			req, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+			handler, mocks := fixture.NewJobHandler(ctrl)
+			tc.request.req = req
+			tc.mockFn(mocks, tc.request)
+
+			responseWriter := httptest.NewRecorder()
+			handler.ListFileJobs(responseWriter, req, tc.request.params)
+			assert.Equal(t, tc.response.statusCode, responseWriter.Code)
+			assert.Equal(t, tc.response.body, responseWriter.Body.String())
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"video-server/module/entity"
+	"video-server/module/internal/usecase"
+	"video-server/module/param"
+	"video-server/module/response"
+)
+
+// LinkHandler signs URLs for clients that can't send credentials.
+type LinkHandler struct {
+	usecase usecase.LinkUsecase
+}
+
+func NewLinkHandler(uc usecase.LinkUsecase) *LinkHandler {
+	return &LinkHandler{
+		usecase: uc,
+	}
+}
+
+func (h *LinkHandler) Register(router *httprouter.Router) {
+	router.POST("/v1/files/:fileid/links", h.CreateLink)
+}
+
+// maxExpiresIn is the longest expires_in, in seconds, a time.Duration holds.
+const maxExpiresIn = int64(math.MaxInt64 / time.Second)
+
+// createLinkRequest is the optional JSON body of CreateLink, ExpiresIn is
+// in seconds.
+type createLinkRequest struct {
+	ExpiresIn    int64  `json:"expires_in"`
+	IP           string `json:"ip"`
+	MaxDownloads int    `json:"max_downloads"`
+}
+
+func (h *LinkHandler) CreateLink(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	id, err := strconv.Atoi(params.ByName("fileid"))
+	if err != nil {
+		BuildErrorResponse(w, r, entity.ErrorFileNotFound)
+		return
+	}
+
+	body := createLinkRequest{}
+	err = json.NewDecoder(r.Body).Decode(&body)
+	if err != nil && !errors.Is(err, io.EOF) {
+		BuildErrorResponse(w, r, entity.WithReason(entity.ErrorBadRequest, "invalid JSON body"))
+		return
+	}
+	// checked before the conversion, which would overflow past the max TTL
+	if body.ExpiresIn < 0 || body.ExpiresIn > maxExpiresIn {
+		BuildErrorResponse(w, r, entity.WithField(entity.ErrorBadRequest, "expires_in", "expires_in is out of range"))
+		return
+	}
+
+	link, query, err := h.usecase.CreateLink(r.Context(), &param.CreateLink{
+		FileID:       id,
+		IP:           body.IP,
+		MaxDownloads: body.MaxDownloads,
+		ExpiresIn:    time.Duration(body.ExpiresIn) * time.Second,
+	})
+	if err != nil {
+		BuildErrorResponse(w, r, err)
+		return
+	}
+
+	WriteHTTPResponse(w, &response.Link{
+		ID:           fmt.Sprint(link.ID),
+		FileID:       fmt.Sprint(link.FileID),
+		URL:          fmt.Sprintf("%s/v1/files/%d?%s", baseURL(r), link.FileID, query),
+		IP:           link.IP,
+		MaxDownloads: link.MaxDownloads,
+		ExpiresAt:    link.ExpiresAt,
+	}, http.StatusCreated)
+}
+
+// baseURL is the scheme and host the request was sent to.
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,122 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+
+	"video-server/module/entity"
+	"video-server/module/fixture"
+	"video-server/module/param"
+)
+
+func TestLinkHandler_CreateLink(t *testing.T) {
+	type Request struct {
+		fileID string
+		body   string
+	}
+
+	type Response struct {
+		statusCode int
+		body       string
+	}
+
+	expiresAt := time.Date(2022, 1, 1, 1, 0, 0, 0, time.UTC)
+
+	testcases := map[string]struct {
+		request  Request
+		response Response
+		mockFn   func(*fixture.MockLinkHandler, *http.Request)
+	}{
+		"success": {
+			request: Request{fileID: "1", body: `{"expires_in":600,"ip":"203.0.113.7","max_downloads":2}`},
+			response: Response{
+				statusCode: 201,
+				body:       "{\"linkid\":\"3\",\"fileid\":\"1\",\"url\":\"http://example.com/v1/files/1?expires=1641000000\\u0026link=3\\u0026sig=abc\",\"ip\":\"203.0.113.7\",\"max_downloads\":2,\"expires_at\":\"2022-01-01T01:00:00Z\"}\n",
+			},
+			mockFn: func(m *fixture.MockLinkHandler, req *http.Request) {
+				m.LinkUsecase.EXPECT().CreateLink(req.Context(), &param.CreateLink{
+					FileID:       1,
+					IP:           "203.0.113.7",
+					MaxDownloads: 2,
+					ExpiresIn:    10 * time.Minute,
+				}).Return(&entity.DownloadLink{ID: 3, FileID: 1, IP: "203.0.113.7", MaxDownloads: 2, ExpiresAt: expiresAt}, "expires=1641000000&link=3&sig=abc", nil)
+			},
+		},
+		"empty body": {
+			request: Request{fileID: "1"},
+			response: Response{
+				statusCode: 201,
+				body:       "{\"linkid\":\"3\",\"fileid\":\"1\",\"url\":\"http://example.com/v1/files/1?sig=abc\",\"expires_at\":\"2022-01-01T01:00:00Z\"}\n",
+			},
+			mockFn: func(m *fixture.MockLinkHandler, req *http.Request) {
+				m.LinkUsecase.EXPECT().CreateLink(req.Context(), &param.CreateLink{FileID: 1}).
+					Return(&entity.DownloadLink{ID: 3, FileID: 1, ExpiresAt: expiresAt}, "sig=abc", nil)
+			},
+		},
+		"invalid body": {
+			request: Request{fileID: "1", body: `{"expires_in":"soon"}`},
+			response: Response{
+				statusCode: 400,
+				body:       "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"Bad Request: invalid JSON body\",\"instance\":\"/v1/files/1/links\",\"code\":\"bad_request\"}\n",
+			},
+			mockFn: func(m *fixture.MockLinkHandler, req *http.Request) {},
+		},
+		"expires_in out of range": {
+			request: Request{fileID: "1", body: `{"expires_in":9223372036854775807}`},
+			response: Response{
+				statusCode: 400,
+				body:       "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"Bad Request: expires_in is out of range\",\"instance\":\"/v1/files/1/links\",\"code\":\"bad_request\",\"invalid_params\":[{\"name\":\"expires_in\",\"reason\":\"expires_in is out of range\"}]}\n",
+			},
+			mockFn: func(m *fixture.MockLinkHandler, req *http.Request) {},
+		},
+		"negative expires_in": {
+			request: Request{fileID: "1", body: `{"expires_in":-9223372036854775807}`},
+			response: Response{
+				statusCode: 400,
+				body:       "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"Bad Request: expires_in is out of range\",\"instance\":\"/v1/files/1/links\",\"code\":\"bad_request\",\"invalid_params\":[{\"name\":\"expires_in\",\"reason\":\"expires_in is out of range\"}]}\n",
+			},
+			mockFn: func(m *fixture.MockLinkHandler, req *http.Request) {},
+		},
+		"invalid file id": {
+			request: Request{fileID: "abc"},
+			response: Response{
+				statusCode: 404,
+				body:       "{\"type\":\"about:blank\",\"title\":\"Not Found\",\"status\":404,\"detail\":\"File not found\",\"instance\":\"/v1/files/abc/links\",\"code\":\"file_not_found\"}\n",
+			},
+			mockFn: func(m *fixture.MockLinkHandler, req *http.Request) {},
+		},
+		"disabled": {
+			request: Request{fileID: "1"},
+			response: Response{
+				statusCode: 501,
+				body:       "{\"type\":\"about:blank\",\"title\":\"Not Implemented\",\"status\":501,\"detail\":\"Signed links are not enabled\",\"instance\":\"/v1/files/1/links\",\"code\":\"links_disabled\"}\n",
+			},
+			mockFn: func(m *fixture.MockLinkHandler, req *http.Request) {
+				m.LinkUsecase.EXPECT().CreateLink(req.Context(), &param.CreateLink{FileID: 1}).
+					Return(nil, "", entity.ErrorLinkDisabled)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			req := httptest.NewRequest(http.MethodPost, "http://example.com/v1/files/"+tc.request.fileID+"/links", strings.NewReader(tc.request.body))
+			handler, mocks := fixture.NewLinkHandler(ctrl)
+			tc.mockFn(mocks, req)
+
+			responseWriter := httptest.NewRecorder()
+			handler.CreateLink(responseWriter, req, httprouter.Params{{Key: "fileid", Value: tc.request.fileID}})
+			assert.Equal(t, tc.response.statusCode, responseWriter.Code)
+			assert.Equal(t, tc.response.body, responseWriter.Body.String())
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+
+	"video-server/internal/logging"
+	"video-server/module/entity"
+)
+
+// LoggingMiddleware passes a logger tagged with the request ID down the
+// request context and writes an access log line per request.
+type LoggingMiddleware struct {
+	router *httprouter.Router
+	logger *logrus.Logger
+}
+
+// NewLoggingMiddleware logs to logger, with the routes of router.
+func NewLoggingMiddleware(router *httprouter.Router, logger *logrus.Logger) *LoggingMiddleware {
+	return &LoggingMiddleware{
+		router: router,
+		logger: logger,
+	}
+}
+
+func (m *LoggingMiddleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		fields := logrus.Fields{"request_id": entity.RequestIDFromContext(r.Context())}
+		if span := trace.SpanContextFromContext(r.Context()); span.IsValid() {
+			fields["trace_id"] = span.TraceID().String()
+		}
+		ctx := logging.WithLogger(r.Context(), m.logger.WithFields(fields))
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		// the principal and tenant were added by the middleware further in
+		logger := logging.FromContext(ctx).WithFields(logrus.Fields{
+			"method":      r.Method,
+			"route":       routePattern(m.router, r),
+			"path":        r.URL.Path,
+			"status":      recorder.status,
+			"bytes":       recorder.n,
+			"duration_ms": logging.Milliseconds(time.Since(start)),
+			"remote_addr": r.RemoteAddr,
+		})
+		if recorder.status >= http.StatusInternalServerError {
+			logger.Error("request")
+			return
+		}
+		logger.Info("request")
+	})
+}
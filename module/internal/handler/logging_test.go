@@ -0,0 +1,105 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"video-server/internal/logging"
+	"video-server/module/internal/handler"
+)
+
+func TestLoggingMiddleware_Wrap(t *testing.T) {
+	testcases := map[string]struct {
+		path      string
+		status    int
+		principal string
+		expected  map[string]interface{}
+	}{
+		"success": {
+			path:      "/v1/files/1",
+			status:    http.StatusOK,
+			principal: "alice",
+			expected: map[string]interface{}{
+				"level":      "info",
+				"msg":        "request",
+				"request_id": "req-123",
+				"method":     "GET",
+				"route":      "/v1/files/:fileid",
+				"path":       "/v1/files/1",
+				"status":     float64(http.StatusOK),
+				"bytes":      float64(5),
+				"principal":  "alice",
+			},
+		},
+		"server error": {
+			path:   "/v1/files/1",
+			status: http.StatusInternalServerError,
+			expected: map[string]interface{}{
+				"level":  "error",
+				"route":  "/v1/files/:fileid",
+				"status": float64(http.StatusInternalServerError),
+			},
+		},
+		"unmatched": {
+			path:   "/nothing/here",
+			status: http.StatusNotFound,
+			expected: map[string]interface{}{
+				"level":  "info",
+				"route":  "unmatched",
+				"path":   "/nothing/here",
+				"status": float64(http.StatusNotFound),
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			logger := logrus.New()
+			logger.SetOutput(out)
+			logger.SetFormatter(&logrus.JSONFormatter{})
+
+			router := httprouter.New()
+			router.GET("/v1/files/:fileid", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+				// as the auth middleware does
+				if tc.principal != "" {
+					logging.AddFields(r.Context(), logrus.Fields{"principal": tc.principal})
+				}
+				logging.FromContext(r.Context()).Info("handled")
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte("hello"))
+			})
+			next := handler.NewLoggingMiddleware(router, logger).Wrap(router)
+			next = handler.NewRequestIDMiddleware().Wrap(next)
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set(handler.RequestIDHeader, "req-123")
+			next.ServeHTTP(httptest.NewRecorder(), req)
+
+			decoder := json.NewDecoder(out)
+			var entries []map[string]interface{}
+			for decoder.More() {
+				entry := map[string]interface{}{}
+				require.NoError(t, decoder.Decode(&entry))
+				entries = append(entries, entry)
+			}
+			require.NotEmpty(t, entries)
+			access := entries[len(entries)-1]
+			for key, value := range tc.expected {
+				assert.Equal(t, value, access[key], key)
+			}
+			assert.Contains(t, access, "duration_ms")
+			// whatever is logged during the request carries its ID
+			for _, entry := range entries {
+				assert.Equal(t, "req-123", entry["request_id"])
+			}
+		})
+	}
+}
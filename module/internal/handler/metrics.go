@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"video-server/internal/metrics"
+)
+
+// routeUnmatched labels requests no route matches, their paths would make
+// a series each.
+const routeUnmatched = "unmatched"
+
+// routeProbe stands in for one segment of a path to tell whether a
+// parameter matches it, no client sends a NUL in a path.
+const routeProbe = "\x00"
+
+type MetricsHandler struct {
+	handler http.Handler
+}
+
+func NewMetricsHandler(gatherer prometheus.Gatherer) *MetricsHandler {
+	return &MetricsHandler{
+		handler: promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}),
+	}
+}
+
+func (h *MetricsHandler) Register(router *httprouter.Router) {
+	router.Handler(http.MethodGet, "/metrics", h.handler)
+}
+
+// MetricsMiddleware counts requests and the bytes of their bodies by route.
+type MetricsMiddleware struct {
+	router *httprouter.Router
+}
+
+// NewMetricsMiddleware labels requests with the routes of router.
+func NewMetricsMiddleware(router *httprouter.Router) *MetricsMiddleware {
+	return &MetricsMiddleware{
+		router: router,
+	}
+}
+
+func (m *MetricsMiddleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		route := routePattern(m.router, r)
+		body := &countingReader{ReadCloser: r.Body}
+		if r.Body != nil {
+			r.Body = body
+		}
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, r)
+
+		metrics.HTTPRequests.WithLabelValues(r.Method, route, strconv.Itoa(recorder.status)).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+		metrics.ReceivedBytes.WithLabelValues(r.Method, route).Add(float64(body.n))
+		metrics.SentBytes.WithLabelValues(r.Method, route).Add(float64(recorder.n))
+	})
+}
+
+// routePattern returns the pattern of the route of router matching r.
+// httprouter only tells the values of its parameters, so each segment is
+// swapped for a probe: a parameter matches the probe where the route has
+// one, a static segment never does.
+func routePattern(router *httprouter.Router, r *http.Request) string {
+	handle, params, _ := router.Lookup(r.Method, r.URL.Path)
+	if handle == nil {
+		return routeUnmatched
+	}
+	if len(params) == 0 {
+		return r.URL.Path
+	}
+
+	segments := strings.Split(r.URL.Path, "/")
+	pattern := make([]string, len(segments))
+	copy(pattern, segments)
+	for i, segment := range segments {
+		if segment == "" {
+			continue
+		}
+		segments[i] = routeProbe
+		_, probed, _ := router.Lookup(r.Method, strings.Join(segments, "/"))
+		segments[i] = segment
+		for _, param := range probed {
+			if param.Value == routeProbe {
+				pattern[i] = ":" + param.Key
+			}
+		}
+	}
+	return strings.Join(pattern, "/")
+}
+
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// statusRecorder keeps the status and body size of a response. It passes
+// Flush through for streamed responses.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	n           int64
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+func (w *statusRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
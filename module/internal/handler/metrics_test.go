@@ -0,0 +1,96 @@
+package handler_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"video-server/internal/metrics"
+	"video-server/module/internal/handler"
+)
+
+func TestMetricsMiddleware_Wrap(t *testing.T) {
+	router := httprouter.New()
+	router.POST("/v1/files", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusCreated)
+	})
+	router.GET("/v1/files/:fileid/grants/:subject", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		_, _ = w.Write([]byte("grant"))
+	})
+
+	testcases := map[string]struct {
+		method   string
+		path     string
+		body     string
+		route    string
+		code     string
+		received float64
+		sent     float64
+	}{
+		"static route": {
+			method:   http.MethodPost,
+			path:     "/v1/files",
+			body:     "video",
+			route:    "/v1/files",
+			code:     "201",
+			received: 5,
+		},
+		"parameters": {
+			method: http.MethodGet,
+			path:   "/v1/files/42/grants/alice",
+			route:  "/v1/files/:fileid/grants/:subject",
+			code:   "200",
+			sent:   5,
+		},
+		"parameter like a static segment": {
+			method: http.MethodGet,
+			path:   "/v1/files/files/grants/grants",
+			route:  "/v1/files/:fileid/grants/:subject",
+			code:   "200",
+			sent:   5,
+		},
+		"unmatched": {
+			method: http.MethodGet,
+			path:   "/v1/unknown/42",
+			route:  "unmatched",
+			code:   "404",
+			sent:   19,
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			requests := metrics.HTTPRequests.WithLabelValues(tc.method, tc.route, tc.code)
+			received := metrics.ReceivedBytes.WithLabelValues(tc.method, tc.route)
+			sent := metrics.SentBytes.WithLabelValues(tc.method, tc.route)
+			requestsBefore := testutil.ToFloat64(requests)
+			receivedBefore := testutil.ToFloat64(received)
+			sentBefore := testutil.ToFloat64(sent)
+
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			handler.NewMetricsMiddleware(router).Wrap(router).ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, requestsBefore+1, testutil.ToFloat64(requests))
+			assert.Equal(t, receivedBefore+tc.received, testutil.ToFloat64(received))
+			assert.Equal(t, sentBefore+tc.sent, testutil.ToFloat64(sent))
+		})
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	router := httprouter.New()
+	handler.NewMetricsHandler(metrics.Registry).Register(router)
+	metrics.UploadRejections.WithLabelValues("file_unsupported").Inc()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `video_server_upload_rejections_total{reason="file_unsupported"}`)
+}
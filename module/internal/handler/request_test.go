@@ -0,0 +1,57 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"video-server/module/entity"
+	"video-server/module/internal/handler"
+)
+
+func TestRequestIDMiddleware_Wrap(t *testing.T) {
+	testcases := map[string]struct {
+		header    string
+		generated bool
+	}{
+		"no header": {
+			generated: true,
+		},
+		"client ID": {
+			header: "req-123",
+		},
+		"control characters": {
+			header:    "req\x01123",
+			generated: true,
+		},
+		"too long": {
+			header:    strings.Repeat("a", 129),
+			generated: true,
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			var id string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				id = entity.RequestIDFromContext(r.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/files", nil)
+			if tc.header != "" {
+				req.Header.Set(handler.RequestIDHeader, tc.header)
+			}
+			responseWriter := httptest.NewRecorder()
+			handler.NewRequestIDMiddleware().Wrap(next).ServeHTTP(responseWriter, req)
+			assert.NotEmpty(t, id)
+			assert.Equal(t, id, responseWriter.Header().Get(handler.RequestIDHeader))
+			if tc.generated {
+				assert.NotEqual(t, tc.header, id)
+			} else {
+				assert.Equal(t, tc.header, id)
+			}
+		})
+	}
+}
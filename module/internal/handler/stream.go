@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+
+	"video-server/internal/dash"
+	"video-server/internal/hls"
+	"video-server/internal/metrics"
+	"video-server/module/entity"
+	"video-server/module/internal/usecase"
+)
+
+const (
+	InitSegmentContentType  = "video/mp4"
+	MediaSegmentContentType = "video/iso.segment"
+)
+
+// StreamHandler serves stored MP4 files as HLS and MPEG-DASH with fMP4
+// segments, as stored by the package job or packaged on the fly until then.
+type StreamHandler struct {
+	usecase usecase.StreamUsecase
+}
+
+func NewStreamHandler(uc usecase.StreamUsecase) *StreamHandler {
+	return &StreamHandler{
+		usecase: uc,
+	}
+}
+
+func (h *StreamHandler) Register(router *httprouter.Router) {
+	router.GET("/v1/files/:fileid/hls/:name", h.GetHLS)
+	router.GET("/v1/files/:fileid/manifest.mpd", h.GetDASHManifest)
+	router.GET("/v1/files/:fileid/dash/:name", h.GetDASHSegment)
+}
+
+func (h *StreamHandler) GetHLS(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	id, err := strconv.Atoi(params.ByName("fileid"))
+	if err != nil {
+		BuildErrorResponse(w, r, entity.ErrorFileNotFound)
+		return
+	}
+
+	streams := metrics.ActiveStreams.WithLabelValues(metrics.StreamHLS)
+	streams.Inc()
+	defer streams.Dec()
+
+	name := params.ByName("name")
+	switch name {
+	case hls.MasterPlaylistName:
+		playlist, err := h.usecase.GetHLSMasterPlaylist(r.Context(), id)
+		writePlaylist(w, r, signPlaylist(r, playlist), err)
+	case hls.MediaPlaylistName:
+		playlist, err := h.usecase.GetHLSMediaPlaylist(r.Context(), id)
+		writePlaylist(w, r, signPlaylist(r, playlist), err)
+	case hls.InitSegmentName:
+		buf := &bytes.Buffer{}
+		err := h.usecase.WriteInitSegment(r.Context(), id, 0, buf)
+		writeSegment(w, r, InitSegmentContentType, buf, err)
+	default:
+		index, ok := hls.ParseSegmentName(name)
+		if !ok {
+			BuildErrorResponse(w, r, entity.ErrorSegmentNotFound)
+			return
+		}
+		buf := &bytes.Buffer{}
+		err := h.usecase.WriteMediaSegment(r.Context(), id, index, buf)
+		writeSegment(w, r, MediaSegmentContentType, buf, err)
+	}
+}
+
+func (h *StreamHandler) GetDASHManifest(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	id, err := strconv.Atoi(params.ByName("fileid"))
+	if err != nil {
+		BuildErrorResponse(w, r, entity.ErrorFileNotFound)
+		return
+	}
+
+	manifest, err := h.usecase.GetDASHManifest(r.Context(), id)
+	if err != nil {
+		BuildErrorResponse(w, r, err)
+		return
+	}
+	if query := linkQuery(r); query != "" {
+		manifest = dash.WithQuery(manifest, query)
+	}
+
+	w.Header().Set("Content-Type", dash.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(manifest)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(manifest)
+}
+
+// GetDASHSegment serves the per-track segments addressed by the manifest's
+// SegmentTemplate.
+func (h *StreamHandler) GetDASHSegment(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	id, err := strconv.Atoi(params.ByName("fileid"))
+	if err != nil {
+		BuildErrorResponse(w, r, entity.ErrorFileNotFound)
+		return
+	}
+
+	streams := metrics.ActiveStreams.WithLabelValues(metrics.StreamDASH)
+	streams.Inc()
+	defer streams.Dec()
+
+	name := params.ByName("name")
+	if trackID, ok := dash.ParseInitSegmentName(name); ok {
+		buf := &bytes.Buffer{}
+		err := h.usecase.WriteInitSegment(r.Context(), id, trackID, buf)
+		writeSegment(w, r, InitSegmentContentType, buf, err)
+		return
+	}
+
+	trackID, time, ok := dash.ParseMediaSegmentName(name)
+	if !ok {
+		BuildErrorResponse(w, r, entity.ErrorSegmentNotFound)
+		return
+	}
+	buf := &bytes.Buffer{}
+	err = h.usecase.WriteTrackSegment(r.Context(), id, trackID, time, buf)
+	writeSegment(w, r, MediaSegmentContentType, buf, err)
+}
+
+// linkQuery is the signature of the link a request was authenticated by,
+// which the segments it points to need as well.
+func linkQuery(r *http.Request) string {
+	principal := entity.PrincipalFromContext(r.Context())
+	if principal == nil || principal.Method != entity.AuthMethodLink {
+		return ""
+	}
+
+	values := r.URL.Query()
+	return url.Values{
+		usecase.LinkParamID:        {values.Get(usecase.LinkParamID)},
+		usecase.LinkParamExpires:   {values.Get(usecase.LinkParamExpires)},
+		usecase.LinkParamSignature: {values.Get(usecase.LinkParamSignature)},
+	}.Encode()
+}
+
+func signPlaylist(r *http.Request, playlist string) string {
+	if query := linkQuery(r); query != "" && playlist != "" {
+		return hls.WithQuery(playlist, query)
+	}
+	return playlist
+}
+
+func writePlaylist(w http.ResponseWriter, r *http.Request, playlist string, err error) {
+	if err != nil {
+		BuildErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", hls.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(playlist)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(playlist))
+}
+
+// writeSegment sends a segment rendered in memory, so a packaging error can
+// still be reported with a proper status.
+func writeSegment(w http.ResponseWriter, r *http.Request, contentType string, buf *bytes.Buffer, err error) {
+	if err != nil {
+		BuildErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	_, _ = buf.WriteTo(w)
+}
@@ -0,0 +1,327 @@
+package handler_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+
+	"video-server/internal/testutil"
+	"video-server/module/entity"
+	"video-server/module/fixture"
+)
+
+func TestStreamHandler_GetHLS(t *testing.T) {
+	type Request struct {
+		req    *http.Request
+		params httprouter.Params
+	}
+
+	type Response struct {
+		statusCode  int
+		contentType string
+		body        string
+	}
+
+	params := func(fileID, name string) httprouter.Params {
+		return httprouter.Params{
+			httprouter.Param{Key: "fileid", Value: fileID},
+			httprouter.Param{Key: "name", Value: name},
+		}
+	}
+
+	testcases := map[string]struct {
+		request  Request
+		response Response
+		mockFn   func(*fixture.MockStreamHandler, Request)
+	}{
+		"master playlist": {
+			request: Request{params: params("1", "master.m3u8")},
+			response: Response{
+				statusCode:  200,
+				contentType: "application/vnd.apple.mpegurl",
+				body:        "#EXTM3U\n",
+			},
+			mockFn: func(m *fixture.MockStreamHandler, req Request) {
+				m.StreamUsecase.EXPECT().GetHLSMasterPlaylist(req.req.Context(), 1).
+					Return("#EXTM3U\n", nil)
+			},
+		},
+		"media playlist": {
+			request: Request{params: params("1", "media.m3u8")},
+			response: Response{
+				statusCode:  200,
+				contentType: "application/vnd.apple.mpegurl",
+				body:        "#EXTM3U\n",
+			},
+			mockFn: func(m *fixture.MockStreamHandler, req Request) {
+				m.StreamUsecase.EXPECT().GetHLSMediaPlaylist(req.req.Context(), 1).
+					Return("#EXTM3U\n", nil)
+			},
+		},
+		"init segment": {
+			request: Request{params: params("1", "init.mp4")},
+			response: Response{
+				statusCode:  200,
+				contentType: "video/mp4",
+				body:        "init",
+			},
+			mockFn: func(m *fixture.MockStreamHandler, req Request) {
+				m.StreamUsecase.EXPECT().WriteInitSegment(req.req.Context(), 1, uint32(0), gomock.Any()).
+					DoAndReturn(func(_ interface{}, _ int, _ uint32, w io.Writer) error {
+						_, err := w.Write([]byte("init"))
+						return err
+					})
+			},
+		},
+		"media segment": {
+			request: Request{params: params("1", "segment-3.m4s")},
+			response: Response{
+				statusCode:  200,
+				contentType: "video/iso.segment",
+				body:        "segment",
+			},
+			mockFn: func(m *fixture.MockStreamHandler, req Request) {
+				m.StreamUsecase.EXPECT().WriteMediaSegment(req.req.Context(), 1, 3, gomock.Any()).
+					DoAndReturn(func(_ interface{}, _ int, _ int, w io.Writer) error {
+						_, err := w.Write([]byte("segment"))
+						return err
+					})
+			},
+		},
+		"segment not found": {
+			request: Request{params: params("1", "segment-9.m4s")},
+			response: Response{
+				statusCode:  404,
+				contentType: "application/problem+json",
+			},
+			mockFn: func(m *fixture.MockStreamHandler, req Request) {
+				m.StreamUsecase.EXPECT().WriteMediaSegment(req.req.Context(), 1, 9, gomock.Any()).
+					Return(entity.ErrorSegmentNotFound)
+			},
+		},
+		"unknown name": {
+			request: Request{params: params("1", "index.html")},
+			response: Response{
+				statusCode:  404,
+				contentType: "application/problem+json",
+			},
+			mockFn: func(m *fixture.MockStreamHandler, req Request) {},
+		},
+		"invalid file id": {
+			request: Request{params: params("abc", "master.m3u8")},
+			response: Response{
+				statusCode:  404,
+				contentType: "application/problem+json",
+			},
+			mockFn: func(m *fixture.MockStreamHandler, req Request) {},
+		},
+		"unsupported file": {
+			request: Request{params: params("1", "master.m3u8")},
+			response: Response{
+				statusCode:  415,
+				contentType: "application/problem+json",
+			},
+			mockFn: func(m *fixture.MockStreamHandler, req Request) {
+				m.StreamUsecase.EXPECT().GetHLSMasterPlaylist(req.req.Context(), 1).
+					Return("", entity.ErrorStreamUnsupported)
+			},
+		},
+		"storage error": {
+			request: Request{params: params("1", "init.mp4")},
+			response: Response{
+				statusCode:  500,
+				contentType: "application/problem+json",
+				body:        "{\"type\":\"about:blank\",\"title\":\"Internal Server Error\",\"status\":500,\"detail\":\"Internal server error\",\"instance\":\"/\",\"code\":\"internal_error\"}\n",
+			},
+			mockFn: func(m *fixture.MockStreamHandler, req Request) {
+				m.StreamUsecase.EXPECT().WriteInitSegment(req.req.Context(), 1, uint32(0), gomock.Any()).
+					Return(testutil.ErrStorage)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			req, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+			handler, mocks := fixture.NewStreamHandler(ctrl)
+			tc.request.req = req
+			tc.mockFn(mocks, tc.request)
+
+			responseWriter := httptest.NewRecorder()
+			handler.GetHLS(responseWriter, req, tc.request.params)
+			assert.Equal(t, tc.response.statusCode, responseWriter.Code)
+			assert.Equal(t, tc.response.contentType, responseWriter.Header().Get("Content-Type"))
+			if tc.response.body != "" {
+				assert.Equal(t, tc.response.body, responseWriter.Body.String())
+			}
+		})
+	}
+}
+
+func TestStreamHandler_SignedLink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	principal := &entity.Principal{Subject: "alice", Role: entity.RoleViewer, Method: entity.AuthMethodLink, KeyID: 3, FileID: 1}
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/v1/files/1/hls/media.m3u8?link=3&expires=1700000000&sig=abc&t=1", nil)
+	req = req.WithContext(entity.WithPrincipal(req.Context(), principal))
+	handler, mocks := fixture.NewStreamHandler(ctrl)
+	mocks.StreamUsecase.EXPECT().GetHLSMediaPlaylist(req.Context(), 1).
+		Return("#EXTM3U\n#EXT-X-MAP:URI=\"init.mp4\"\n#EXTINF:6.000,\nsegment-0.m4s\n", nil)
+	mocks.StreamUsecase.EXPECT().GetDASHManifest(req.Context(), 1).
+		Return([]byte(`<SegmentTemplate initialization="init-$RepresentationID$.mp4"></SegmentTemplate>`), nil)
+
+	responseWriter := httptest.NewRecorder()
+	handler.GetHLS(responseWriter, req, httprouter.Params{{Key: "fileid", Value: "1"}, {Key: "name", Value: "media.m3u8"}})
+	assert.Equal(t, "#EXTM3U\n"+
+		"#EXT-X-MAP:URI=\"init.mp4?expires=1700000000&link=3&sig=abc\"\n"+
+		"#EXTINF:6.000,\n"+
+		"segment-0.m4s?expires=1700000000&link=3&sig=abc\n", responseWriter.Body.String())
+
+	responseWriter = httptest.NewRecorder()
+	handler.GetDASHManifest(responseWriter, req, httprouter.Params{{Key: "fileid", Value: "1"}})
+	assert.Equal(t, `<SegmentTemplate initialization="init-$RepresentationID$.mp4?expires=1700000000&amp;link=3&amp;sig=abc"></SegmentTemplate>`, responseWriter.Body.String())
+}
+
+func TestStreamHandler_GetDASHManifest(t *testing.T) {
+	type Response struct {
+		statusCode  int
+		contentType string
+	}
+
+	testcases := map[string]struct {
+		params   httprouter.Params
+		response Response
+		mockFn   func(*fixture.MockStreamHandler, *http.Request)
+	}{
+		"success": {
+			params: httprouter.Params{httprouter.Param{Key: "fileid", Value: "1"}},
+			response: Response{
+				statusCode:  200,
+				contentType: "application/dash+xml",
+			},
+			mockFn: func(m *fixture.MockStreamHandler, req *http.Request) {
+				m.StreamUsecase.EXPECT().GetDASHManifest(req.Context(), 1).
+					Return([]byte("<MPD/>"), nil)
+			},
+		},
+		"invalid file id": {
+			params: httprouter.Params{httprouter.Param{Key: "fileid", Value: "abc"}},
+			response: Response{
+				statusCode:  404,
+				contentType: "application/problem+json",
+			},
+			mockFn: func(m *fixture.MockStreamHandler, req *http.Request) {},
+		},
+		"unsupported file": {
+			params: httprouter.Params{httprouter.Param{Key: "fileid", Value: "1"}},
+			response: Response{
+				statusCode:  415,
+				contentType: "application/problem+json",
+			},
+			mockFn: func(m *fixture.MockStreamHandler, req *http.Request) {
+				m.StreamUsecase.EXPECT().GetDASHManifest(req.Context(), 1).
+					Return(nil, entity.ErrorStreamUnsupported)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			req, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+			handler, mocks := fixture.NewStreamHandler(ctrl)
+			tc.mockFn(mocks, req)
+
+			responseWriter := httptest.NewRecorder()
+			handler.GetDASHManifest(responseWriter, req, tc.params)
+			assert.Equal(t, tc.response.statusCode, responseWriter.Code)
+			assert.Equal(t, tc.response.contentType, responseWriter.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestStreamHandler_GetDASHSegment(t *testing.T) {
+	type Response struct {
+		statusCode  int
+		contentType string
+	}
+
+	params := func(name string) httprouter.Params {
+		return httprouter.Params{
+			httprouter.Param{Key: "fileid", Value: "1"},
+			httprouter.Param{Key: "name", Value: name},
+		}
+	}
+
+	testcases := map[string]struct {
+		params   httprouter.Params
+		response Response
+		mockFn   func(*fixture.MockStreamHandler, *http.Request)
+	}{
+		"init segment": {
+			params: params("init-2.mp4"),
+			response: Response{
+				statusCode:  200,
+				contentType: "video/mp4",
+			},
+			mockFn: func(m *fixture.MockStreamHandler, req *http.Request) {
+				m.StreamUsecase.EXPECT().WriteInitSegment(req.Context(), 1, uint32(2), gomock.Any()).
+					Return(nil)
+			},
+		},
+		"media segment": {
+			params: params("segment-1-87552.m4s"),
+			response: Response{
+				statusCode:  200,
+				contentType: "video/iso.segment",
+			},
+			mockFn: func(m *fixture.MockStreamHandler, req *http.Request) {
+				m.StreamUsecase.EXPECT().WriteTrackSegment(req.Context(), 1, uint32(1), uint64(87552), gomock.Any()).
+					Return(nil)
+			},
+		},
+		"segment not found": {
+			params: params("segment-1-5.m4s"),
+			response: Response{
+				statusCode:  404,
+				contentType: "application/problem+json",
+			},
+			mockFn: func(m *fixture.MockStreamHandler, req *http.Request) {
+				m.StreamUsecase.EXPECT().WriteTrackSegment(req.Context(), 1, uint32(1), uint64(5), gomock.Any()).
+					Return(entity.ErrorSegmentNotFound)
+			},
+		},
+		"unknown name": {
+			params: params("init.mp4"),
+			response: Response{
+				statusCode:  404,
+				contentType: "application/problem+json",
+			},
+			mockFn: func(m *fixture.MockStreamHandler, req *http.Request) {},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			req, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+			handler, mocks := fixture.NewStreamHandler(ctrl)
+			tc.mockFn(mocks, req)
+
+			responseWriter := httptest.NewRecorder()
+			handler.GetDASHSegment(responseWriter, req, tc.params)
+			assert.Equal(t, tc.response.statusCode, responseWriter.Code)
+			assert.Equal(t, tc.response.contentType, responseWriter.Header().Get("Content-Type"))
+		})
+	}
+}
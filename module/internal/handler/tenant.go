@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+
+	"video-server/internal/logging"
+	"video-server/module/entity"
+)
+
+// TenantMiddleware scopes requests to the tenant named in a header. An
+// authenticated principal already belongs to a tenant, the header may only
+// repeat it. Without a principal, with auth disabled, the header alone
+// picks the tenant.
+type TenantMiddleware struct {
+	header string
+}
+
+func NewTenantMiddleware(header string) *TenantMiddleware {
+	return &TenantMiddleware{
+		header: header,
+	}
+}
+
+// Wrap has to run inside the auth middleware to see the principal.
+func (m *TenantMiddleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant := r.Header.Get(m.header)
+		if tenant == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !entity.IsTenant(tenant) {
+			BuildErrorResponse(w, r, entity.WithField(entity.ErrorBadRequest, m.header, "invalid tenant"))
+			return
+		}
+
+		principal := entity.PrincipalFromContext(r.Context())
+		if principal == nil {
+			logging.AddFields(r.Context(), logrus.Fields{"tenant": tenant})
+			next.ServeHTTP(w, r.WithContext(entity.WithTenant(r.Context(), tenant)))
+			return
+		}
+		if principal.Tenant != tenant {
+			BuildErrorResponse(w, r, entity.WithReason(entity.ErrorForbidden, "the credentials belong to another tenant"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,91 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"video-server/module/entity"
+	"video-server/module/internal/handler"
+)
+
+func TestTenantMiddleware_Wrap(t *testing.T) {
+	type Request struct {
+		principal *entity.Principal
+		tenant    string
+	}
+
+	type Response struct {
+		statusCode int
+		tenant     string
+		scoped     bool
+		body       string
+	}
+
+	acme := &entity.Principal{Subject: "alice", Tenant: "acme"}
+
+	testcases := map[string]struct {
+		request  Request
+		response Response
+	}{
+		"no header": {
+			request:  Request{},
+			response: Response{statusCode: 200},
+		},
+		"no header principal": {
+			request:  Request{principal: acme},
+			response: Response{statusCode: 200, tenant: "acme", scoped: true},
+		},
+		"header without principal": {
+			request:  Request{tenant: "globex"},
+			response: Response{statusCode: 200, tenant: "globex", scoped: true},
+		},
+		"header matching principal": {
+			request:  Request{principal: acme, tenant: "acme"},
+			response: Response{statusCode: 200, tenant: "acme", scoped: true},
+		},
+		"header of another tenant": {
+			request: Request{principal: acme, tenant: "globex"},
+			response: Response{
+				statusCode: 403,
+				body:       "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"detail\":\"Forbidden: the credentials belong to another tenant\",\"instance\":\"/v1/files\",\"code\":\"forbidden\"}\n",
+			},
+		},
+		"invalid header": {
+			request: Request{tenant: "../acme"},
+			response: Response{
+				statusCode: 400,
+				body:       "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"Bad Request: invalid tenant\",\"instance\":\"/v1/files\",\"code\":\"bad_request\",\"invalid_params\":[{\"name\":\"X-Tenant-ID\",\"reason\":\"invalid tenant\"}]}\n",
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			var tenant string
+			var scoped bool
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tenant, scoped = entity.TenantFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/files", nil)
+			if tc.request.principal != nil {
+				req = req.WithContext(entity.WithPrincipal(context.Background(), tc.request.principal))
+			}
+			if tc.request.tenant != "" {
+				req.Header.Set("X-Tenant-ID", tc.request.tenant)
+			}
+			responseWriter := httptest.NewRecorder()
+			handler.NewTenantMiddleware("X-Tenant-ID").Wrap(next).ServeHTTP(responseWriter, req)
+			assert.Equal(t, tc.response.statusCode, responseWriter.Code)
+			assert.Equal(t, tc.response.tenant, tenant)
+			assert.Equal(t, tc.response.scoped, scoped)
+			if tc.response.body != "" {
+				assert.Equal(t, tc.response.body, responseWriter.Body.String())
+			}
+		})
+	}
+}
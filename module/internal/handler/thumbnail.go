@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+
+	"video-server/internal/thumbnail"
+	"video-server/module/entity"
+	"video-server/module/internal/usecase"
+)
+
+// ThumbnailHandler serves preview images of stored videos.
+type ThumbnailHandler struct {
+	usecase usecase.ThumbnailUsecase
+}
+
+func NewThumbnailHandler(uc usecase.ThumbnailUsecase) *ThumbnailHandler {
+	return &ThumbnailHandler{
+		usecase: uc,
+	}
+}
+
+func (h *ThumbnailHandler) Register(router *httprouter.Router) {
+	router.GET("/v1/files/:fileid/thumbnail", h.GetThumbnail)
+}
+
+// GetThumbnail serves the poster frame, or with ?t= the key frame shown at
+// that many seconds into the video.
+func (h *ThumbnailHandler) GetThumbnail(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	id, err := strconv.Atoi(params.ByName("fileid"))
+	if err != nil {
+		BuildErrorResponse(w, r, entity.ErrorFileNotFound)
+		return
+	}
+
+	var time *float64
+	if value := r.URL.Query().Get("t"); value != "" {
+		t, err := strconv.ParseFloat(value, 64)
+		if err != nil || t < 0 {
+			BuildErrorResponse(w, r, entity.WithField(entity.ErrorBadRequest, "t", "t must be a non-negative number of seconds"))
+			return
+		}
+		time = &t
+	}
+
+	image, err := h.usecase.GetThumbnail(r.Context(), id, time)
+	if err != nil {
+		BuildErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", thumbnail.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(image)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(image)
+}
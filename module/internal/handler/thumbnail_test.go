@@ -0,0 +1,113 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+
+	"video-server/module/entity"
+	"video-server/module/fixture"
+)
+
+func TestThumbnailHandler_GetThumbnail(t *testing.T) {
+	type Request struct {
+		req    *http.Request
+		url    string
+		params httprouter.Params
+	}
+
+	type Response struct {
+		statusCode  int
+		contentType string
+		body        string
+	}
+
+	params := func(fileID string) httprouter.Params {
+		return httprouter.Params{
+			httprouter.Param{Key: "fileid", Value: fileID},
+		}
+	}
+	at := func(t float64) *float64 { return &t }
+
+	testcases := map[string]struct {
+		request  Request
+		response Response
+		mockFn   func(*fixture.MockThumbnailHandler, Request)
+	}{
+		"poster": {
+			request: Request{url: "http://example.com/", params: params("1")},
+			response: Response{
+				statusCode:  200,
+				contentType: "image/jpeg",
+				body:        "jpeg",
+			},
+			mockFn: func(m *fixture.MockThumbnailHandler, req Request) {
+				m.ThumbnailUsecase.EXPECT().GetThumbnail(req.req.Context(), 1, nil).
+					Return([]byte("jpeg"), nil)
+			},
+		},
+		"at time": {
+			request: Request{url: "http://example.com/?t=12.5", params: params("1")},
+			response: Response{
+				statusCode:  200,
+				contentType: "image/jpeg",
+				body:        "jpeg",
+			},
+			mockFn: func(m *fixture.MockThumbnailHandler, req Request) {
+				m.ThumbnailUsecase.EXPECT().GetThumbnail(req.req.Context(), 1, at(12.5)).
+					Return([]byte("jpeg"), nil)
+			},
+		},
+		"invalid time": {
+			request: Request{url: "http://example.com/?t=-1", params: params("1")},
+			response: Response{
+				statusCode:  400,
+				contentType: "application/problem+json",
+				body:        "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"Bad Request: t must be a non-negative number of seconds\",\"instance\":\"/\",\"code\":\"bad_request\",\"invalid_params\":[{\"name\":\"t\",\"reason\":\"t must be a non-negative number of seconds\"}]}\n",
+			},
+			mockFn: func(m *fixture.MockThumbnailHandler, req Request) {},
+		},
+		"invalid file id": {
+			request: Request{url: "http://example.com/", params: params("abc")},
+			response: Response{
+				statusCode:  404,
+				contentType: "application/problem+json",
+			},
+			mockFn: func(m *fixture.MockThumbnailHandler, req Request) {},
+		},
+		"unsupported file": {
+			request: Request{url: "http://example.com/", params: params("1")},
+			response: Response{
+				statusCode:  415,
+				contentType: "application/problem+json",
+			},
+			mockFn: func(m *fixture.MockThumbnailHandler, req Request) {
+				m.ThumbnailUsecase.EXPECT().GetThumbnail(req.req.Context(), 1, nil).
+					Return(nil, entity.ErrorThumbnailUnsupported)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			req, _ := http.NewRequest(http.MethodGet, tc.request.url, nil)
+			handler, mocks := fixture.NewThumbnailHandler(ctrl)
+			tc.request.req = req
+			tc.mockFn(mocks, tc.request)
+
+			responseWriter := httptest.NewRecorder()
+			handler.GetThumbnail(responseWriter, req, tc.request.params)
+			assert.Equal(t, tc.response.statusCode, responseWriter.Code)
+			assert.Equal(t, tc.response.contentType, responseWriter.Header().Get("Content-Type"))
+			if tc.response.body != "" {
+				assert.Equal(t, tc.response.body, responseWriter.Body.String())
+			}
+		})
+	}
+}
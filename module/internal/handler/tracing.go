@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"video-server/internal/tracing"
+	"video-server/module/entity"
+)
+
+// TracingMiddleware starts a server span per request, continuing the trace
+// of the W3C traceparent header when a client sends one.
+type TracingMiddleware struct {
+	router *httprouter.Router
+}
+
+// NewTracingMiddleware names spans after the routes of router.
+func NewTracingMiddleware(router *httprouter.Router) *TracingMiddleware {
+	return &TracingMiddleware{
+		router: router,
+	}
+}
+
+func (m *TracingMiddleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routePattern(m.router, r)
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Tracer().Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethodKey.String(r.Method),
+				semconv.HTTPRouteKey.String(route),
+				// the query of signed links carries credentials
+				semconv.HTTPTargetKey.String(r.URL.Path),
+			),
+		)
+		defer span.End()
+		if id := entity.RequestIDFromContext(ctx); id != "" {
+			span.SetAttributes(tracing.RequestIDKey.String(id))
+		}
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(recorder.status))
+		// client errors are answers, only the server failed on 5xx
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
+		}
+	})
+}
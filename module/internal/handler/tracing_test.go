@@ -0,0 +1,126 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"video-server/internal/tracing"
+	"video-server/module/internal/handler"
+)
+
+func TestTracingMiddleware_Wrap(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	const parentID = "00f067aa0ba902b7"
+
+	testcases := map[string]struct {
+		path        string
+		traceparent string
+		status      int
+		name        string
+		route       string
+		target      string
+		spanStatus  codes.Code
+	}{
+		"new trace": {
+			path:       "/v1/files/1",
+			status:     http.StatusOK,
+			name:       "GET /v1/files/:fileid",
+			route:      "/v1/files/:fileid",
+			target:     "/v1/files/1",
+			spanStatus: codes.Unset,
+		},
+		"signed link": {
+			path:       "/v1/files/1?link=2&expires=1700000000&sig=abc",
+			status:     http.StatusOK,
+			name:       "GET /v1/files/:fileid",
+			route:      "/v1/files/:fileid",
+			target:     "/v1/files/1",
+			spanStatus: codes.Unset,
+		},
+		"propagated trace": {
+			path:        "/v1/files/1",
+			traceparent: "00-" + traceID + "-" + parentID + "-01",
+			status:      http.StatusNotFound,
+			name:        "GET /v1/files/:fileid",
+			route:       "/v1/files/:fileid",
+			target:      "/v1/files/1",
+			spanStatus:  codes.Unset,
+		},
+		"server error": {
+			path:       "/v1/files/1",
+			status:     http.StatusInternalServerError,
+			name:       "GET /v1/files/:fileid",
+			route:      "/v1/files/:fileid",
+			target:     "/v1/files/1",
+			spanStatus: codes.Error,
+		},
+		"unmatched": {
+			path:       "/nothing/here",
+			status:     http.StatusNotFound,
+			name:       "GET unmatched",
+			route:      "unmatched",
+			target:     "/nothing/here",
+			spanStatus: codes.Unset,
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+			otel.SetTextMapPropagator(propagation.TraceContext{})
+			t.Cleanup(func() {
+				otel.SetTracerProvider(trace.NewNoopTracerProvider())
+				otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+			})
+
+			var inner trace.SpanContext
+			router := httprouter.New()
+			router.GET("/v1/files/:fileid", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+				inner = trace.SpanContextFromContext(r.Context())
+				w.WriteHeader(tc.status)
+			})
+			next := handler.NewTracingMiddleware(router).Wrap(router)
+			next = handler.NewRequestIDMiddleware().Wrap(next)
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set(handler.RequestIDHeader, "req-123")
+			if tc.traceparent != "" {
+				req.Header.Set("traceparent", tc.traceparent)
+			}
+			next.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			span := spans[0]
+			assert.Equal(t, tc.name, span.Name())
+			assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+			assert.Equal(t, tc.spanStatus, span.Status().Code)
+			assert.Contains(t, span.Attributes(), semconv.HTTPRouteKey.String(tc.route))
+			assert.Contains(t, span.Attributes(), semconv.HTTPTargetKey.String(tc.target))
+			assert.Contains(t, span.Attributes(), semconv.HTTPStatusCodeKey.Int(tc.status))
+			assert.Contains(t, span.Attributes(), tracing.RequestIDKey.String("req-123"))
+			if tc.route != "unmatched" {
+				assert.Equal(t, span.SpanContext(), inner)
+			}
+			if tc.traceparent != "" {
+				assert.Equal(t, traceID, span.SpanContext().TraceID().String())
+				assert.Equal(t, parentID, span.Parent().SpanID().String())
+				assert.True(t, span.Parent().IsRemote())
+			} else {
+				assert.False(t, span.Parent().IsValid())
+			}
+		})
+	}
+}
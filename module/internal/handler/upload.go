@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"video-server/module/entity"
+	"video-server/module/internal/usecase"
+	"video-server/module/param"
+)
+
+const (
+	TusVersion     = "1.0.0"
+	TusExtensions  = "creation,termination"
+	TusContentType = "application/offset+octet-stream"
+)
+
+// UploadHandler implements the tus 1.0 resumable upload protocol,
+// see https://tus.io/protocols/resumable-upload
+type UploadHandler struct {
+	usecase usecase.UploadUsecase
+}
+
+func NewUploadHandler(uc usecase.UploadUsecase) *UploadHandler {
+	return &UploadHandler{
+		usecase: uc,
+	}
+}
+
+func (h *UploadHandler) Register(router *httprouter.Router) {
+	router.OPTIONS("/v1/uploads", h.GetUploadOptions)
+	router.POST("/v1/uploads", h.CreateUpload)
+	router.HEAD("/v1/uploads/:uploadid", h.GetUploadOffset)
+	router.PATCH("/v1/uploads/:uploadid", h.AppendUpload)
+	router.DELETE("/v1/uploads/:uploadid", h.DeleteUpload)
+}
+
+func (h *UploadHandler) GetUploadOptions(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	w.Header().Set("Tus-Resumable", TusVersion)
+	w.Header().Set("Tus-Version", TusVersion)
+	w.Header().Set("Tus-Extension", TusExtensions)
+	if options := h.usecase.GetUploadOptions(r.Context()); options.MaxSize > 0 {
+		w.Header().Set("Tus-Max-Size", strconv.FormatInt(options.MaxSize, 10))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UploadHandler) CreateUpload(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	if !checkTusResumable(w, r) {
+		return
+	}
+
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length <= 0 {
+		BuildErrorResponse(w, r, entity.ErrorBadRequest)
+		return
+	}
+
+	rawMetadata := r.Header.Get("Upload-Metadata")
+	metadata, err := parseUploadMetadata(rawMetadata)
+	if err != nil {
+		BuildErrorResponse(w, r, entity.ErrorBadRequest)
+		return
+	}
+
+	result, err := h.usecase.CreateUpload(r.Context(), &param.CreateUpload{
+		Name:     metadata["filename"],
+		Length:   length,
+		Metadata: rawMetadata,
+	})
+	if err != nil {
+		BuildErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/v1/uploads/%s", result.ID))
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *UploadHandler) GetUploadOffset(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	if !checkTusResumable(w, r) {
+		return
+	}
+
+	result, err := h.usecase.GetUpload(r.Context(), params.ByName("uploadid"))
+	if err != nil {
+		BuildErrorResponse(w, r, err)
+		return
+	}
+
+	writeUploadHeaders(w, result)
+	if result.Metadata != "" {
+		w.Header().Set("Upload-Metadata", result.Metadata)
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *UploadHandler) AppendUpload(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	if !checkTusResumable(w, r) {
+		return
+	}
+
+	if r.Header.Get("Content-Type") != TusContentType {
+		BuildErrorResponse(w, r, entity.ErrorUploadContentType)
+		return
+	}
+
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		BuildErrorResponse(w, r, entity.ErrorBadRequest)
+		return
+	}
+
+	result, err := h.usecase.AppendUpload(r.Context(), params.ByName("uploadid"), offset, r.Body)
+	if err != nil {
+		BuildErrorResponse(w, r, err)
+		return
+	}
+
+	writeUploadHeaders(w, result)
+	if result.IsComplete() {
+		w.Header().Set("Location", fmt.Sprintf("/v1/files/%d", *result.FileID))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UploadHandler) DeleteUpload(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	if !checkTusResumable(w, r) {
+		return
+	}
+
+	err := h.usecase.DeleteUpload(r.Context(), params.ByName("uploadid"))
+	if err != nil {
+		BuildErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func checkTusResumable(w http.ResponseWriter, r *http.Request) bool {
+	w.Header().Set("Tus-Resumable", TusVersion)
+	if r.Header.Get("Tus-Resumable") != TusVersion {
+		w.Header().Set("Tus-Version", TusVersion)
+		BuildErrorResponse(w, r, entity.ErrorUploadVersion)
+		return false
+	}
+	return true
+}
+
+func writeUploadHeaders(w http.ResponseWriter, upload *entity.Upload) {
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(upload.Length, 10))
+}
+
+// parseUploadMetadata decodes "key base64value,key2 base64value2" pairs.
+func parseUploadMetadata(raw string) (map[string]string, error) {
+	metadata := map[string]string{}
+	if raw == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(raw, ",") {
+		fields := strings.Fields(pair)
+		switch len(fields) {
+		case 1:
+			metadata[fields[0]] = ""
+		case 2:
+			value, err := base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				return nil, err
+			}
+			metadata[fields[0]] = string(value)
+		default:
+			return nil, entity.ErrorBadRequest
+		}
+	}
+	return metadata, nil
+}
@@ -0,0 +1,303 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+
+	"video-server/module/entity"
+	"video-server/module/fixture"
+	"video-server/module/param"
+)
+
+func newTusRequest(method string, body string, headers map[string]string) *http.Request {
+	req, _ := http.NewRequest(method, "http://example.com/v1/uploads", strings.NewReader(body))
+	req.Header.Set("Tus-Resumable", "1.0.0")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	return req
+}
+
+func TestUploadHandler_GetUploadOptions(t *testing.T) {
+	testcases := map[string]struct {
+		options *entity.UploadOptions
+		maxSize string
+	}{
+		"limited": {
+			options: &entity.UploadOptions{MaxSize: 1000},
+			maxSize: "1000",
+		},
+		"unlimited": {
+			options: &entity.UploadOptions{},
+			maxSize: "",
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			handler, mocks := fixture.NewUploadHandler(ctrl)
+			req := newTusRequest(http.MethodOptions, "", nil)
+			mocks.UploadUsecase.EXPECT().GetUploadOptions(req.Context()).Return(tc.options)
+
+			responseWriter := httptest.NewRecorder()
+			handler.GetUploadOptions(responseWriter, req, nil)
+			assert.Equal(t, http.StatusNoContent, responseWriter.Code)
+			assert.Equal(t, "1.0.0", responseWriter.Header().Get("Tus-Version"))
+			assert.Equal(t, tc.maxSize, responseWriter.Header().Get("Tus-Max-Size"))
+		})
+	}
+}
+
+func TestUploadHandler_CreateUpload(t *testing.T) {
+	type Response struct {
+		statusCode int
+		location   string
+	}
+
+	testcases := map[string]struct {
+		headers  map[string]string
+		response Response
+		mockFn   func(*fixture.MockUploadHandler, *http.Request)
+	}{
+		"success": {
+			headers: map[string]string{
+				"Upload-Length":   "100",
+				"Upload-Metadata": "filename c2FtcGxlLm1wNA==,filetype",
+			},
+			response: Response{
+				statusCode: 201,
+				location:   "/v1/uploads/some-id",
+			},
+			mockFn: func(m *fixture.MockUploadHandler, req *http.Request) {
+				m.UploadUsecase.EXPECT().CreateUpload(req.Context(), &param.CreateUpload{
+					Name:     "sample.mp4",
+					Length:   100,
+					Metadata: "filename c2FtcGxlLm1wNA==,filetype",
+				}).Return(&entity.Upload{ID: "some-id"}, nil)
+			},
+		},
+		"missing length": {
+			headers: map[string]string{},
+			response: Response{
+				statusCode: 400,
+			},
+			mockFn: func(m *fixture.MockUploadHandler, req *http.Request) {},
+		},
+		"invalid metadata": {
+			headers: map[string]string{
+				"Upload-Length":   "100",
+				"Upload-Metadata": "filename not-base64!",
+			},
+			response: Response{
+				statusCode: 400,
+			},
+			mockFn: func(m *fixture.MockUploadHandler, req *http.Request) {},
+		},
+		"unsupported version": {
+			headers: map[string]string{
+				"Tus-Resumable": "0.2.2",
+				"Upload-Length": "100",
+			},
+			response: Response{
+				statusCode: 412,
+			},
+			mockFn: func(m *fixture.MockUploadHandler, req *http.Request) {},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			handler, mocks := fixture.NewUploadHandler(ctrl)
+			req := newTusRequest(http.MethodPost, "", tc.headers)
+			tc.mockFn(mocks, req)
+
+			responseWriter := httptest.NewRecorder()
+			handler.CreateUpload(responseWriter, req, nil)
+			assert.Equal(t, tc.response.statusCode, responseWriter.Code)
+			assert.Equal(t, tc.response.location, responseWriter.Header().Get("Location"))
+			assert.Equal(t, "1.0.0", responseWriter.Header().Get("Tus-Resumable"))
+		})
+	}
+}
+
+func TestUploadHandler_GetUploadOffset(t *testing.T) {
+	params := httprouter.Params{httprouter.Param{Key: "uploadid", Value: "some-id"}}
+
+	testcases := map[string]struct {
+		statusCode int
+		offset     string
+		mockFn     func(*fixture.MockUploadHandler, *http.Request)
+	}{
+		"success": {
+			statusCode: 200,
+			offset:     "50",
+			mockFn: func(m *fixture.MockUploadHandler, req *http.Request) {
+				m.UploadUsecase.EXPECT().GetUpload(req.Context(), "some-id").
+					Return(&entity.Upload{ID: "some-id", Length: 100, Offset: 50}, nil)
+			},
+		},
+		"not found": {
+			statusCode: 404,
+			mockFn: func(m *fixture.MockUploadHandler, req *http.Request) {
+				m.UploadUsecase.EXPECT().GetUpload(req.Context(), "some-id").
+					Return(nil, entity.ErrorUploadNotFound)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			handler, mocks := fixture.NewUploadHandler(ctrl)
+			req := newTusRequest(http.MethodHead, "", nil)
+			tc.mockFn(mocks, req)
+
+			responseWriter := httptest.NewRecorder()
+			handler.GetUploadOffset(responseWriter, req, params)
+			assert.Equal(t, tc.statusCode, responseWriter.Code)
+			assert.Equal(t, tc.offset, responseWriter.Header().Get("Upload-Offset"))
+		})
+	}
+}
+
+func TestUploadHandler_AppendUpload(t *testing.T) {
+	params := httprouter.Params{httprouter.Param{Key: "uploadid", Value: "some-id"}}
+	fileID := 7
+
+	type Response struct {
+		statusCode int
+		offset     string
+		location   string
+	}
+
+	testcases := map[string]struct {
+		headers  map[string]string
+		response Response
+		mockFn   func(*fixture.MockUploadHandler, *http.Request)
+	}{
+		"success": {
+			headers: map[string]string{
+				"Content-Type":  "application/offset+octet-stream",
+				"Upload-Offset": "0",
+			},
+			response: Response{
+				statusCode: 204,
+				offset:     "5",
+			},
+			mockFn: func(m *fixture.MockUploadHandler, req *http.Request) {
+				m.UploadUsecase.EXPECT().AppendUpload(req.Context(), "some-id", int64(0), req.Body).
+					Return(&entity.Upload{ID: "some-id", Length: 100, Offset: 5}, nil)
+			},
+		},
+		"completed": {
+			headers: map[string]string{
+				"Content-Type":  "application/offset+octet-stream",
+				"Upload-Offset": "95",
+			},
+			response: Response{
+				statusCode: 204,
+				offset:     "100",
+				location:   "/v1/files/7",
+			},
+			mockFn: func(m *fixture.MockUploadHandler, req *http.Request) {
+				m.UploadUsecase.EXPECT().AppendUpload(req.Context(), "some-id", int64(95), req.Body).
+					Return(&entity.Upload{ID: "some-id", Length: 100, Offset: 100, FileID: &fileID}, nil)
+			},
+		},
+		"wrong content type": {
+			headers: map[string]string{
+				"Content-Type":  "video/mp4",
+				"Upload-Offset": "0",
+			},
+			response: Response{
+				statusCode: 415,
+			},
+			mockFn: func(m *fixture.MockUploadHandler, req *http.Request) {},
+		},
+		"missing offset": {
+			headers: map[string]string{
+				"Content-Type": "application/offset+octet-stream",
+			},
+			response: Response{
+				statusCode: 400,
+			},
+			mockFn: func(m *fixture.MockUploadHandler, req *http.Request) {},
+		},
+		"offset mismatch": {
+			headers: map[string]string{
+				"Content-Type":  "application/offset+octet-stream",
+				"Upload-Offset": "3",
+			},
+			response: Response{
+				statusCode: 409,
+			},
+			mockFn: func(m *fixture.MockUploadHandler, req *http.Request) {
+				m.UploadUsecase.EXPECT().AppendUpload(req.Context(), "some-id", int64(3), req.Body).
+					Return(nil, entity.ErrorUploadOffsetMismatch)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			handler, mocks := fixture.NewUploadHandler(ctrl)
+			req := newTusRequest(http.MethodPatch, "hello", tc.headers)
+			tc.mockFn(mocks, req)
+
+			responseWriter := httptest.NewRecorder()
+			handler.AppendUpload(responseWriter, req, params)
+			assert.Equal(t, tc.response.statusCode, responseWriter.Code)
+			assert.Equal(t, tc.response.offset, responseWriter.Header().Get("Upload-Offset"))
+			assert.Equal(t, tc.response.location, responseWriter.Header().Get("Location"))
+		})
+	}
+}
+
+func TestUploadHandler_DeleteUpload(t *testing.T) {
+	params := httprouter.Params{httprouter.Param{Key: "uploadid", Value: "some-id"}}
+
+	testcases := map[string]struct {
+		statusCode int
+		mockFn     func(*fixture.MockUploadHandler, *http.Request)
+	}{
+		"success": {
+			statusCode: 204,
+			mockFn: func(m *fixture.MockUploadHandler, req *http.Request) {
+				m.UploadUsecase.EXPECT().DeleteUpload(req.Context(), "some-id").Return(nil)
+			},
+		},
+		"not found": {
+			statusCode: 404,
+			mockFn: func(m *fixture.MockUploadHandler, req *http.Request) {
+				m.UploadUsecase.EXPECT().DeleteUpload(req.Context(), "some-id").Return(entity.ErrorUploadNotFound)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			handler, mocks := fixture.NewUploadHandler(ctrl)
+			req := newTusRequest(http.MethodDelete, "", nil)
+			tc.mockFn(mocks, req)
+
+			responseWriter := httptest.NewRecorder()
+			handler.DeleteUpload(responseWriter, req, params)
+			assert.Equal(t, tc.statusCode, responseWriter.Code)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"video-server/module/internal/usecase"
+	"video-server/module/response"
+)
+
+// UsageHandler reports the consumption of the caller's tenant.
+type UsageHandler struct {
+	usecase usecase.UsageUsecase
+}
+
+func NewUsageHandler(uc usecase.UsageUsecase) *UsageHandler {
+	return &UsageHandler{
+		usecase: uc,
+	}
+}
+
+func (h *UsageHandler) Register(router *httprouter.Router) {
+	router.GET("/v1/usage", h.GetUsage)
+}
+
+func (h *UsageHandler) GetUsage(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	usage, err := h.usecase.GetUsage(r.Context())
+	if err != nil {
+		BuildErrorResponse(w, r, err)
+		return
+	}
+
+	WriteHTTPResponse(w, &response.Usage{
+		Tenant:   usage.TenantID,
+		Files:    usage.Files,
+		Bytes:    usage.Bytes,
+		MaxFiles: usage.MaxFiles,
+		MaxBytes: usage.MaxBytes,
+	}, http.StatusOK)
+}
@@ -0,0 +1,63 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"video-server/internal/testutil"
+	"video-server/module/entity"
+	"video-server/module/fixture"
+)
+
+func TestUsageHandler_GetUsage(t *testing.T) {
+	type Response struct {
+		statusCode int
+		body       string
+	}
+
+	testcases := map[string]struct {
+		response Response
+		mockFn   func(*fixture.MockUsageHandler, *http.Request)
+	}{
+		"success": {
+			response: Response{
+				statusCode: 200,
+				body:       "{\"tenant\":\"acme\",\"files\":2,\"bytes\":300,\"max_files\":0,\"max_bytes\":1000}\n",
+			},
+			mockFn: func(m *fixture.MockUsageHandler, req *http.Request) {
+				m.UsageUsecase.EXPECT().GetUsage(req.Context()).
+					Return(&entity.Usage{TenantID: "acme", Files: 2, Bytes: 300, MaxBytes: 1000}, nil)
+			},
+		},
+		"error": {
+			response: Response{
+				statusCode: 500,
+				body:       "{\"type\":\"about:blank\",\"title\":\"Internal Server Error\",\"status\":500,\"detail\":\"Internal server error\",\"instance\":\"/v1/usage\",\"code\":\"internal_error\"}\n",
+			},
+			mockFn: func(m *fixture.MockUsageHandler, req *http.Request) {
+				m.UsageUsecase.EXPECT().GetUsage(req.Context()).
+					Return(nil, testutil.ErrDB)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			hdl, mocks := fixture.NewUsageHandler(ctrl)
+			req := httptest.NewRequest(http.MethodGet, "/v1/usage", nil)
+			tc.mockFn(mocks, req)
+
+			w := httptest.NewRecorder()
+			hdl.GetUsage(w, req, nil)
+
+			assert.Equal(t, tc.response.statusCode, w.Code)
+			assert.Equal(t, tc.response.body, w.Body.String())
+		})
+	}
+}
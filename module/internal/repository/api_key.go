@@ -0,0 +1,107 @@
+package repository
+
+//go:generate mockgen -source api_key.go -destination mock/api_key.go
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"video-server/module/entity"
+	"video-server/module/param"
+)
+
+var (
+	APIKeyColumnsInsert = []string{
+		"name",
+		"subject",
+		"role",
+		"tenant",
+		"prefix",
+		"hash",
+		"expires_at",
+		"created_at",
+		"updated_at",
+	}
+	APIKeyColumns = append([]string{"id", "revoked_at"}, APIKeyColumnsInsert...)
+)
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, params *param.CreateAPIKey) (*entity.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (*entity.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]*entity.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+}
+
+type apiKeyRepository struct {
+	database *gorm.DB
+}
+
+func NewAPIKeyRepository(database *gorm.DB) *apiKeyRepository {
+	return &apiKeyRepository{
+		database: database,
+	}
+}
+
+func (r *apiKeyRepository) CreateAPIKey(ctx context.Context, params *param.CreateAPIKey) (*entity.APIKey, error) {
+	timeNow := time.Now()
+	key := &entity.APIKey{
+		Name:      params.Name,
+		Subject:   params.Subject,
+		Role:      params.Role,
+		Tenant:    params.Tenant,
+		Prefix:    params.Prefix,
+		Hash:      params.Hash,
+		ExpiresAt: params.ExpiresAt,
+		CreatedAt: timeNow,
+		UpdatedAt: timeNow,
+	}
+	err := withTrace(ctx, r.database).Select(APIKeyColumnsInsert).Create(key).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (r *apiKeyRepository) GetAPIKeyByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	key := &entity.APIKey{}
+	err := withTrace(ctx, r.database).Select(APIKeyColumns).Where("hash = ?", hash).First(key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrorAPIKeyNotFound
+		}
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (r *apiKeyRepository) ListAPIKeys(ctx context.Context) ([]*entity.APIKey, error) {
+	keys := []*entity.APIKey{}
+	err := withTrace(ctx, r.database).Select(APIKeyColumns).Order("id").Find(&keys).Error
+
+	return keys, err
+}
+
+// RevokeAPIKey keeps the row so the key stays listed, revoking twice keeps
+// the first revocation time.
+func (r *apiKeyRepository) RevokeAPIKey(ctx context.Context, id int) error {
+	timeNow := time.Now()
+	result := withTrace(ctx, r.database).Model(&entity.APIKey{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"revoked_at": gorm.Expr("COALESCE(revoked_at, ?)", timeNow),
+			"updated_at": timeNow,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return entity.ErrorAPIKeyNotFound
+	}
+
+	return nil
+}
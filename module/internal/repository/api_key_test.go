@@ -0,0 +1,181 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"video-server/internal/testutil"
+	"video-server/module/entity"
+	"video-server/module/fixture"
+	"video-server/module/param"
+)
+
+var (
+	apiKeyRowColumns = []string{"id", "revoked_at", "name", "subject", "role", "tenant", "prefix", "hash", "expires_at", "created_at", "updated_at"}
+	apiKeySelect     = "SELECT `id`,`revoked_at`,`name`,`subject`,`role`,`tenant`,`prefix`,`hash`,`expires_at`,`created_at`,`updated_at` FROM `api_keys`"
+)
+
+func apiKeyRow(id int, subject string) []driver.Value {
+	return []driver.Value{id, nil, "ci", subject, "uploader", "acme", "vsk_abcd", "hash", nil, testutil.CreatedAt, testutil.UpdatedAt}
+}
+
+func TestAPIKeyRepository_CreateAPIKey(t *testing.T) {
+	query := "INSERT INTO `api_keys` (`name`,`subject`,`role`,`tenant`,`prefix`,`hash`,`expires_at`,`created_at`,`updated_at`) VALUES (?,?,?,?,?,?,?,?,?)"
+	params := &param.CreateAPIKey{Name: "ci", Subject: "ci-bot", Role: "uploader", Tenant: "acme", Prefix: "vsk_abcd", Hash: "hash"}
+
+	type Response struct {
+		result interface{}
+		err    error
+	}
+
+	testcases := map[string]struct {
+		response Response
+		mockFn   func(*fixture.MockAPIKeyRepository)
+	}{
+		"success": {
+			response: Response{
+				result: map[string]interface{}{"ID": 1, "Name": "ci", "Subject": "ci-bot", "Prefix": "vsk_abcd", "Hash": "hash"},
+			},
+			mockFn: func(m *fixture.MockAPIKeyRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs("ci", "ci-bot", "uploader", "acme", "vsk_abcd", "hash", nil, testutil.AnyTime{}, testutil.AnyTime{}).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				m.SQLMock.ExpectCommit()
+			},
+		},
+		"db error": {
+			response: Response{
+				err: testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockAPIKeyRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WillReturnError(testutil.ErrDB)
+				m.SQLMock.ExpectRollback()
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewAPIKeyRepository()
+			tc.mockFn(mocks)
+			result, err := repo.CreateAPIKey(context.Background(), params)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			testutil.AssertStructExAc(t, tc.response.result, result)
+		})
+	}
+}
+
+func TestAPIKeyRepository_GetAPIKeyByHash(t *testing.T) {
+	query := apiKeySelect + " WHERE hash = ? ORDER BY `api_keys`.`id` LIMIT 1"
+
+	type Response struct {
+		result interface{}
+		err    error
+	}
+
+	testcases := map[string]struct {
+		response Response
+		mockFn   func(*fixture.MockAPIKeyRepository)
+	}{
+		"success": {
+			response: Response{
+				result: map[string]interface{}{"ID": 1, "Subject": "ci-bot", "Role": "uploader", "Tenant": "acme"},
+			},
+			mockFn: func(m *fixture.MockAPIKeyRepository) {
+				rows := m.SQLMock.NewRows(apiKeyRowColumns).AddRow(apiKeyRow(1, "ci-bot")...)
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("hash").WillReturnRows(rows)
+			},
+		},
+		"db error not found": {
+			response: Response{
+				err: entity.ErrorAPIKeyNotFound,
+			},
+			mockFn: func(m *fixture.MockAPIKeyRepository) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(gorm.ErrRecordNotFound)
+			},
+		},
+		"db error others": {
+			response: Response{
+				err: testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockAPIKeyRepository) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(testutil.ErrDB)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewAPIKeyRepository()
+			tc.mockFn(mocks)
+			result, err := repo.GetAPIKeyByHash(context.Background(), "hash")
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			testutil.AssertStructExAc(t, tc.response.result, result)
+		})
+	}
+}
+
+func TestAPIKeyRepository_ListAPIKeys(t *testing.T) {
+	repo, mocks := fixture.NewAPIKeyRepository()
+	rows := mocks.SQLMock.NewRows(apiKeyRowColumns).
+		AddRow(apiKeyRow(1, "ci-bot")...).
+		AddRow(apiKeyRow(2, "backup")...)
+	mocks.SQLMock.ExpectQuery(regexp.QuoteMeta(apiKeySelect + " ORDER BY id")).WillReturnRows(rows)
+
+	result, err := repo.ListAPIKeys(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, "backup", result[1].Subject)
+}
+
+func TestAPIKeyRepository_RevokeAPIKey(t *testing.T) {
+	query := "UPDATE `api_keys` SET `revoked_at`=COALESCE(revoked_at, ?),`updated_at`=? WHERE id = ?"
+
+	testcases := map[string]struct {
+		err    error
+		mockFn func(*fixture.MockAPIKeyRepository)
+	}{
+		"success": {
+			mockFn: func(m *fixture.MockAPIKeyRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs(testutil.AnyTime{}, testutil.AnyTime{}, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.SQLMock.ExpectCommit()
+			},
+		},
+		"not found": {
+			err: entity.ErrorAPIKeyNotFound,
+			mockFn: func(m *fixture.MockAPIKeyRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				m.SQLMock.ExpectCommit()
+			},
+		},
+		"db error": {
+			err: testutil.ErrDB,
+			mockFn: func(m *fixture.MockAPIKeyRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WillReturnError(testutil.ErrDB)
+				m.SQLMock.ExpectRollback()
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewAPIKeyRepository()
+			tc.mockFn(mocks)
+			err := repo.RevokeAPIKey(context.Background(), 1)
+			testutil.AssertErrorExAc(t, tc.err, err)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package repository
+
+//go:generate mockgen -source blob.go -destination mock/blob.go
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"video-server/module/entity"
+)
+
+type BlobRepository interface {
+	AcquireBlob(ctx context.Context, tenant, digest string, size int64) error
+	ReleaseBlob(ctx context.Context, tenant, digest string, deleteContent func() error) (int64, error)
+	DeleteBlob(ctx context.Context, tenant, digest string) error
+}
+
+type blobRepository struct {
+	database *gorm.DB
+}
+
+func NewBlobRepository(database *gorm.DB) *blobRepository {
+	return &blobRepository{
+		database: database,
+	}
+}
+
+// AcquireBlob records one more file referencing the blob, creating the row
+// on first use.
+func (r *blobRepository) AcquireBlob(ctx context.Context, tenant, digest string, size int64) error {
+	return acquireBlob(withTrace(ctx, r.database), tenant, digest, size)
+}
+
+func acquireBlob(db *gorm.DB, tenant, digest string, size int64) error {
+	blob := &entity.Blob{
+		TenantID:  tenant,
+		Digest:    digest,
+		Size:      size,
+		RefCount:  1,
+		CreatedAt: time.Now(),
+	}
+
+	return db.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"ref_count": gorm.Expr("ref_count + 1"),
+		}),
+	}).Create(blob).Error
+}
+
+// ReleaseBlob drops one reference and returns how many are left. When the
+// last reference goes deleteContent, unless nil, runs before the row is
+// removed and while it is locked: a file acquiring the blob meanwhile waits
+// for both and stores the content anew. The row stays if deleteContent
+// fails.
+func (r *blobRepository) ReleaseBlob(ctx context.Context, tenant, digest string, deleteContent func() error) (int64, error) {
+	var remaining int64
+	err := withTrace(ctx, r.database).Transaction(func(tx *gorm.DB) error {
+		blob := &entity.Blob{}
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("tenant_id = ? AND digest = ?", tenant, digest).
+			First(blob).Error
+		if err != nil {
+			return err
+		}
+
+		if blob.RefCount <= 1 {
+			if deleteContent != nil {
+				err = deleteContent()
+				if err != nil {
+					return err
+				}
+			}
+			return tx.Delete(blob).Error
+		}
+
+		remaining = blob.RefCount - 1
+		return tx.Model(blob).Update("ref_count", remaining).Error
+	})
+	// content stored before blobs were tracked
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if deleteContent != nil {
+			return 0, deleteContent()
+		}
+		return 0, nil
+	}
+
+	return remaining, err
+}
+
+// DeleteBlob drops the row of a blob whatever its reference count, used once
+// the content is known to be unreferenced.
+func (r *blobRepository) DeleteBlob(ctx context.Context, tenant, digest string) error {
+	return withTrace(ctx, r.database).Where("tenant_id = ? AND digest = ?", tenant, digest).Delete(&entity.Blob{}).Error
+}
@@ -0,0 +1,205 @@
+package repository_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"video-server/internal/testutil"
+	"video-server/module/fixture"
+)
+
+func TestBlobRepository_AcquireBlob(t *testing.T) {
+	query := "INSERT INTO `blobs` (`tenant_id`,`digest`,`size`,`ref_count`,`created_at`) VALUES (?,?,?,?,?) ON DUPLICATE KEY UPDATE `ref_count`=ref_count + 1"
+
+	testcases := map[string]struct {
+		err    error
+		mockFn func(*fixture.MockBlobRepository)
+	}{
+		"success": {
+			err: nil,
+			mockFn: func(m *fixture.MockBlobRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs("acme", "some-digest", 100, 1, testutil.AnyTime{}).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.SQLMock.ExpectCommit()
+			},
+		},
+		"db error": {
+			err: testutil.ErrDB,
+			mockFn: func(m *fixture.MockBlobRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WillReturnError(testutil.ErrDB)
+				m.SQLMock.ExpectRollback()
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewBlobRepository()
+			tc.mockFn(mocks)
+			err := repo.AcquireBlob(context.Background(), "acme", "some-digest", 100)
+			testutil.AssertErrorExAc(t, tc.err, err)
+		})
+	}
+}
+
+func TestBlobRepository_ReleaseBlob(t *testing.T) {
+	rowColumns := []string{"tenant_id", "digest", "size", "ref_count", "created_at"}
+	selectQuery := "SELECT * FROM `blobs` WHERE tenant_id = ? AND digest = ? ORDER BY `blobs`.`tenant_id` LIMIT 1 FOR UPDATE"
+	updateQuery := "UPDATE `blobs` SET `ref_count`=? WHERE `tenant_id` = ? AND `digest` = ?"
+	deleteQuery := "DELETE FROM `blobs` WHERE (`blobs`.`tenant_id`,`blobs`.`digest`) IN ((?,?))"
+
+	type Response struct {
+		remaining int64
+		deleted   bool
+		err       error
+	}
+
+	testcases := map[string]struct {
+		deleteErr error
+		response  Response
+		mockFn    func(*fixture.MockBlobRepository)
+	}{
+		"still referenced": {
+			response: Response{
+				remaining: 1,
+				err:       nil,
+			},
+			mockFn: func(m *fixture.MockBlobRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(selectQuery)).
+					WithArgs("acme", "some-digest").
+					WillReturnRows(m.SQLMock.NewRows(rowColumns).AddRow("acme", "some-digest", 100, 2, testutil.CreatedAt))
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(updateQuery)).
+					WithArgs(1, "acme", "some-digest").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.SQLMock.ExpectCommit()
+			},
+		},
+		"last reference": {
+			response: Response{
+				remaining: 0,
+				deleted:   true,
+				err:       nil,
+			},
+			mockFn: func(m *fixture.MockBlobRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(selectQuery)).
+					WithArgs("acme", "some-digest").
+					WillReturnRows(m.SQLMock.NewRows(rowColumns).AddRow("acme", "some-digest", 100, 1, testutil.CreatedAt))
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(deleteQuery)).
+					WithArgs("acme", "some-digest").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.SQLMock.ExpectCommit()
+			},
+		},
+		"content delete error": {
+			deleteErr: testutil.ErrStorage,
+			response: Response{
+				remaining: 0,
+				deleted:   true,
+				err:       testutil.ErrStorage,
+			},
+			mockFn: func(m *fixture.MockBlobRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(selectQuery)).
+					WithArgs("acme", "some-digest").
+					WillReturnRows(m.SQLMock.NewRows(rowColumns).AddRow("acme", "some-digest", 100, 1, testutil.CreatedAt))
+				m.SQLMock.ExpectRollback()
+			},
+		},
+		"not tracked": {
+			response: Response{
+				remaining: 0,
+				deleted:   true,
+				err:       nil,
+			},
+			mockFn: func(m *fixture.MockBlobRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(selectQuery)).
+					WillReturnError(gorm.ErrRecordNotFound)
+				m.SQLMock.ExpectRollback()
+			},
+		},
+		"db error": {
+			response: Response{
+				remaining: 0,
+				err:       testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockBlobRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(selectQuery)).
+					WillReturnError(testutil.ErrDB)
+				m.SQLMock.ExpectRollback()
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewBlobRepository()
+			tc.mockFn(mocks)
+			deleted := false
+			remaining, err := repo.ReleaseBlob(context.Background(), "acme", "some-digest", func() error {
+				deleted = true
+				return tc.deleteErr
+			})
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			assert.Equal(t, tc.response.remaining, remaining)
+			assert.Equal(t, tc.response.deleted, deleted)
+			assert.NoError(t, mocks.SQLMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestBlobRepository_DeleteBlob(t *testing.T) {
+	query := "DELETE FROM `blobs` WHERE tenant_id = ? AND digest = ?"
+
+	type Response struct {
+		err error
+	}
+
+	testcases := map[string]struct {
+		response Response
+		mockFn   func(*fixture.MockBlobRepository)
+	}{
+		"success": {
+			response: Response{
+				err: nil,
+			},
+			mockFn: func(m *fixture.MockBlobRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs("acme", "some-digest").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.SQLMock.ExpectCommit()
+			},
+		},
+		"db error": {
+			response: Response{
+				err: testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockBlobRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WillReturnError(testutil.ErrDB)
+				m.SQLMock.ExpectRollback()
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewBlobRepository()
+			tc.mockFn(mocks)
+			err := repo.DeleteBlob(context.Background(), "acme", "some-digest")
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			assert.NoError(t, mocks.SQLMock.ExpectationsWereMet())
+		})
+	}
+}
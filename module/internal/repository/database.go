@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"video-server/internal/tracing"
+)
+
+// withTrace returns database for a query made on behalf of ctx. The query
+// joins the trace of ctx but isn't cancelled with it, writes such as the
+// offset of an upload must land after the client went away.
+func withTrace(ctx context.Context, database *gorm.DB) *gorm.DB {
+	return database.WithContext(tracing.Detach(ctx))
+}
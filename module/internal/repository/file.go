@@ -5,9 +5,11 @@ package repository
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"video-server/module/entity"
 	"video-server/module/param"
@@ -20,15 +22,29 @@ var (
 		"name",
 		"size",
 		"mime_type",
+		"digest",
+		"status",
+		"owner_id",
+		"tenant_id",
 		"created_at",
 	}
 	FileColumns = append([]string{"id"}, FileColumnsInsert...)
+
+	// FileSortColumns are the columns a file listing can be sorted on
+	FileSortColumns = []string{"id", "name", "size", "mime_type", "created_at"}
 )
 
 type FileRepository interface {
 	CreateFile(ctx context.Context, params *param.CreateFile) (*entity.File, error)
-	ListFiles(ctx context.Context) ([]*entity.File, error)
-	GetFile(ctx context.Context, id int) (*entity.File, error)
+	ListFiles(ctx context.Context, params *param.ListFiles) ([]*entity.File, error)
+	CountFiles(ctx context.Context, filter *param.FileFilter) (int64, error)
+	GetFile(ctx context.Context, id int, statuses ...string) (*entity.File, error)
+	UpdateFileStatus(ctx context.Context, id int, status string) error
+	ScanFiles(ctx context.Context, afterID int, limit int) ([]*entity.File, error)
+	CountFilesByDigest(ctx context.Context, tenant, digest string) (int64, error)
+	GetUsage(ctx context.Context, tenant string) (*entity.Usage, error)
+	ListUsage(ctx context.Context) ([]*entity.Usage, error)
+	FindFileByName(ctx context.Context, tenant, name string) (*entity.File, error)
 	DeleteFile(ctx context.Context, id int) error
 }
 
@@ -42,15 +58,27 @@ func NewFileRepository(database *gorm.DB) *fileRepository {
 	}
 }
 
+// CreateFile inserts a pending file and takes its reference on the blob in
+// one transaction, the file becomes ready once its content is in place.
 func (r *fileRepository) CreateFile(ctx context.Context, params *param.CreateFile) (*entity.File, error) {
 	timeNow := time.Now()
 	file := &entity.File{
 		Name:      params.Name,
 		Size:      params.Size,
 		MimeType:  params.MimeType,
+		Digest:    params.Digest,
+		Status:    entity.FileStatusPending,
+		OwnerID:   params.OwnerID,
+		TenantID:  params.TenantID,
 		CreatedAt: timeNow,
 	}
-	err := r.database.Select(FileColumnsInsert).Create(file).Error
+	err := withTrace(ctx, r.database).Transaction(func(tx *gorm.DB) error {
+		err := tx.Select(FileColumnsInsert).Create(file).Error
+		if err != nil {
+			return err
+		}
+		return acquireBlob(tx, params.TenantID, params.Digest, params.Size)
+	})
 	if err != nil {
 		var mysqlErr *mysql.MySQLError
 		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
@@ -62,16 +90,115 @@ func (r *fileRepository) CreateFile(ctx context.Context, params *param.CreateFil
 	return file, err
 }
 
-func (r *fileRepository) ListFiles(ctx context.Context) ([]*entity.File, error) {
+func (r *fileRepository) ListFiles(ctx context.Context, params *param.ListFiles) ([]*entity.File, error) {
+	query := filterFiles(withTrace(ctx, r.database).Select(FileColumns), &params.FileFilter)
+
+	// id breaks ties so pages never overlap
+	sort := params.Sort
+	if sort == "" {
+		sort = "id"
+	}
+	query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: sort}, Desc: params.Desc})
+	if sort != "id" {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: params.Desc})
+	}
+
+	if params.After != nil {
+		query = query.Where(fileKeyset(sort, params.Desc, params.After))
+	} else if params.Offset > 0 {
+		query = query.Offset(params.Offset)
+	}
+	if params.Limit > 0 {
+		query = query.Limit(params.Limit)
+	}
+
 	files := []*entity.File{}
-	err := r.database.Select(FileColumns).Find(&files).Error
+	err := query.Preload("Metadata").Find(&files).Error
 
 	return files, err
 }
 
-func (r *fileRepository) GetFile(ctx context.Context, id int) (*entity.File, error) {
+func (r *fileRepository) CountFiles(ctx context.Context, filter *param.FileFilter) (int64, error) {
+	var total int64
+	err := filterFiles(withTrace(ctx, r.database).Model(&entity.File{}), filter).Count(&total).Error
+
+	return total, err
+}
+
+func filterFiles(query *gorm.DB, filter *param.FileFilter) *gorm.DB {
+	status := filter.Status
+	if status == "" {
+		status = entity.FileStatusReady
+	}
+	query = query.Where("status = ?", status)
+	if filter.NamePrefix != "" {
+		query = query.Where("name LIKE ?", likePrefix(filter.NamePrefix))
+	}
+	if filter.MimeType != "" {
+		query = query.Where("mime_type = ?", filter.MimeType)
+	}
+	if filter.MinSize != nil {
+		query = query.Where("size >= ?", *filter.MinSize)
+	}
+	if filter.MaxSize != nil {
+		query = query.Where("size <= ?", *filter.MaxSize)
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	if filter.Access != nil {
+		query = query.Where("tenant_id = ?", filter.Access.TenantID)
+		if !filter.Access.All {
+			query = query.Where("owner_id = ? OR id IN (SELECT file_id FROM file_grants WHERE subject = ?)",
+				filter.Access.Subject, filter.Access.Subject)
+		}
+	}
+	return query
+}
+
+// fileKeyset selects the rows sorting after the cursor row.
+func fileKeyset(sort string, desc bool, after *param.FileCursor) clause.Expression {
+	op := ">"
+	if desc {
+		op = "<"
+	}
+	if sort == "id" {
+		return clause.Expr{SQL: "id " + op + " ?", Vars: []interface{}{after.ID}}
+	}
+
+	var value interface{}
+	switch sort {
+	case "name":
+		value = after.Name
+	case "size":
+		value = after.Size
+	case "mime_type":
+		value = after.MimeType
+	case "created_at":
+		if after.CreatedAt != nil {
+			value = *after.CreatedAt
+		}
+	}
+	return clause.Expr{
+		SQL:  "(? " + op + " ? OR (? = ? AND id " + op + " ?))",
+		Vars: []interface{}{clause.Column{Name: sort}, value, clause.Column{Name: sort}, value, after.ID},
+	}
+}
+
+func likePrefix(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix) + "%"
+}
+
+// GetFile finds a file in one of statuses, ready files only without any.
+func (r *fileRepository) GetFile(ctx context.Context, id int, statuses ...string) (*entity.File, error) {
+	if len(statuses) == 0 {
+		statuses = []string{entity.FileStatusReady}
+	}
 	file := &entity.File{ID: id}
-	err := r.database.Select(FileColumns).First(&file).Error
+	err := withTrace(ctx, r.database).Select(FileColumns).Where("status IN ?", statuses).First(&file).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, entity.ErrorFileNotFound
@@ -82,8 +209,82 @@ func (r *fileRepository) GetFile(ctx context.Context, id int) (*entity.File, err
 	return file, err
 }
 
+func (r *fileRepository) FindFileByName(ctx context.Context, tenant, name string) (*entity.File, error) {
+	file := &entity.File{}
+	// pending uploads already hold their name, names are unique per tenant
+	err := withTrace(ctx, r.database).Select(FileColumns).
+		Where("tenant_id = ? AND name = ? AND status <> ?", tenant, name, entity.FileStatusFailed).
+		First(file).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrorFileNotFound
+		}
+		return nil, err
+	}
+
+	return file, err
+}
+
+func (r *fileRepository) UpdateFileStatus(ctx context.Context, id int, status string) error {
+	return withTrace(ctx, r.database).Model(&entity.File{ID: id}).Update("status", status).Error
+}
+
+// ScanFiles pages through every file whatever its status, in id order.
+func (r *fileRepository) ScanFiles(ctx context.Context, afterID int, limit int) ([]*entity.File, error) {
+	files := []*entity.File{}
+	err := withTrace(ctx, r.database).Select(FileColumns).
+		Where("id > ?", afterID).
+		Order("id").
+		Limit(limit).
+		Find(&files).Error
+
+	return files, err
+}
+
+// CountFilesByDigest counts the files of the tenant that still need the
+// blob.
+func (r *fileRepository) CountFilesByDigest(ctx context.Context, tenant, digest string) (int64, error) {
+	var total int64
+	err := withTrace(ctx, r.database).Model(&entity.File{}).
+		Where("tenant_id = ? AND digest = ? AND status <> ?", tenant, digest, entity.FileStatusFailed).
+		Count(&total).Error
+
+	return total, err
+}
+
+// GetUsage counts the files of the tenant and sums their sizes, failed
+// uploads don't count.
+func (r *fileRepository) GetUsage(ctx context.Context, tenant string) (*entity.Usage, error) {
+	usage := &entity.Usage{TenantID: tenant}
+	err := withTrace(ctx, r.database).Model(&entity.File{}).
+		Select("COUNT(*) AS files, COALESCE(SUM(size), 0) AS bytes").
+		Where("tenant_id = ? AND status <> ?", tenant, entity.FileStatusFailed).
+		Row().Scan(&usage.Files, &usage.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+// ListUsage is GetUsage of every tenant storing files.
+func (r *fileRepository) ListUsage(ctx context.Context) ([]*entity.Usage, error) {
+	var usages []*entity.Usage
+	err := withTrace(ctx, r.database).Model(&entity.File{}).
+		Select("tenant_id, COUNT(*) AS files, COALESCE(SUM(size), 0) AS bytes").
+		Where("status <> ?", entity.FileStatusFailed).
+		Group("tenant_id").
+		Order("tenant_id").
+		Scan(&usages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return usages, nil
+}
+
 func (r *fileRepository) DeleteFile(ctx context.Context, id int) error {
 	file := &entity.File{ID: id}
 
-	return r.database.Delete(&file).Error
+	return withTrace(ctx, r.database).Delete(&file).Error
 }
@@ -18,7 +18,8 @@ import (
 )
 
 func TestFileRepository_CreateFile(t *testing.T) {
-	query := "INSERT INTO `files` (`name`,`size`,`mime_type`,`created_at`) VALUES (?,?,?,?)"
+	query := "INSERT INTO `files` (`name`,`size`,`mime_type`,`digest`,`status`,`owner_id`,`tenant_id`,`created_at`) VALUES (?,?,?,?,?,?,?,?)"
+	blobQuery := "INSERT INTO `blobs` (`tenant_id`,`digest`,`size`,`ref_count`,`created_at`) VALUES (?,?,?,?,?) ON DUPLICATE KEY UPDATE `ref_count`=ref_count + 1"
 
 	type Request struct {
 		ctx    context.Context
@@ -42,6 +43,9 @@ func TestFileRepository_CreateFile(t *testing.T) {
 					MimeType: "video/mp4",
 					Name:     "Some Name",
 					Size:     100,
+					Digest:   "some-digest",
+					OwnerID:  "alice",
+					TenantID: "acme",
 				},
 			},
 			response: Response{
@@ -51,11 +55,40 @@ func TestFileRepository_CreateFile(t *testing.T) {
 			mockFn: func(m *fixture.MockFileRepository, req Request, res Response) {
 				m.SQLMock.ExpectBegin()
 				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
-					WithArgs("Some Name", 100, "video/mp4", testutil.AnyTime{}).
+					WithArgs("Some Name", 100, "video/mp4", "some-digest", "pending", "alice", "acme", testutil.AnyTime{}).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(blobQuery)).
+					WithArgs("acme", "some-digest", 100, 1, testutil.AnyTime{}).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 				m.SQLMock.ExpectCommit()
 			},
 		},
+		"blob db error": {
+			request: Request{
+				ctx: context.Background(),
+				params: &param.CreateFile{
+					MimeType: "video/mp4",
+					Name:     "Some Name",
+					Size:     100,
+					Digest:   "some-digest",
+					OwnerID:  "alice",
+					TenantID: "acme",
+				},
+			},
+			response: Response{
+				result: nil,
+				err:    testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockFileRepository, req Request, res Response) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs("Some Name", 100, "video/mp4", "some-digest", "pending", "alice", "acme", testutil.AnyTime{}).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(blobQuery)).
+					WillReturnError(testutil.ErrDB)
+				m.SQLMock.ExpectRollback()
+			},
+		},
 		"db error file exists": {
 			request: Request{
 				ctx: context.Background(),
@@ -63,6 +96,9 @@ func TestFileRepository_CreateFile(t *testing.T) {
 					MimeType: "video/mp4",
 					Name:     "Some Name",
 					Size:     100,
+					Digest:   "some-digest",
+					OwnerID:  "alice",
+					TenantID: "acme",
 				},
 			},
 			response: Response{
@@ -72,7 +108,7 @@ func TestFileRepository_CreateFile(t *testing.T) {
 			mockFn: func(m *fixture.MockFileRepository, req Request, res Response) {
 				m.SQLMock.ExpectBegin()
 				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
-					WithArgs("Some Name", 100, "video/mp4", testutil.AnyTime{}).
+					WithArgs("Some Name", 100, "video/mp4", "some-digest", "pending", "alice", "acme", testutil.AnyTime{}).
 					WillReturnResult(sqlmock.NewResult(0, 0)).
 					WillReturnError(&mysql.MySQLError{Number: 1062})
 				m.SQLMock.ExpectRollback()
@@ -85,6 +121,9 @@ func TestFileRepository_CreateFile(t *testing.T) {
 					MimeType: "video/mp4",
 					Name:     "Some Name",
 					Size:     100,
+					Digest:   "some-digest",
+					OwnerID:  "alice",
+					TenantID: "acme",
 				},
 			},
 			response: Response{
@@ -94,7 +133,7 @@ func TestFileRepository_CreateFile(t *testing.T) {
 			mockFn: func(m *fixture.MockFileRepository, req Request, res Response) {
 				m.SQLMock.ExpectBegin()
 				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
-					WithArgs("Some Name", 100, "video/mp4", testutil.AnyTime{}).
+					WithArgs("Some Name", 100, "video/mp4", "some-digest", "pending", "alice", "acme", testutil.AnyTime{}).
 					WillReturnResult(sqlmock.NewResult(0, 0)).
 					WillReturnError(testutil.ErrDB)
 				m.SQLMock.ExpectRollback()
@@ -115,12 +154,15 @@ func TestFileRepository_CreateFile(t *testing.T) {
 }
 
 func TestFileRepository_ListFiles(t *testing.T) {
-	rowColumns := []string{"id", "name", "size", "mime_type", "created_at"}
-	rowValues := []driver.Value{1, "Some Name", 100, "video/mp4", testutil.CreatedAt}
-	query := "SELECT `id`,`name`,`size`,`mime_type`,`created_at` FROM `files`"
+	rowColumns := []string{"id", "name", "size", "mime_type", "digest", "status", "created_at"}
+	rowValues := []driver.Value{1, "Some Name", 100, "video/mp4", "some-digest", "ready", testutil.CreatedAt}
+	query := "SELECT `id`,`name`,`size`,`mime_type`,`digest`,`status`,`owner_id`,`tenant_id`,`created_at` FROM `files`"
+	metadataQuery := "SELECT * FROM `video_metadata` WHERE `video_metadata`.`file_id` = ?"
+	minSize := int64(10)
 
 	type Request struct {
-		ctx context.Context
+		ctx    context.Context
+		params *param.ListFiles
 	}
 
 	type Response struct {
@@ -135,7 +177,8 @@ func TestFileRepository_ListFiles(t *testing.T) {
 	}{
 		"success": {
 			request: Request{
-				ctx: context.Background(),
+				ctx:    context.Background(),
+				params: &param.ListFiles{Limit: 11},
 			},
 			response: Response{
 				result: []*entity.File{
@@ -144,6 +187,8 @@ func TestFileRepository_ListFiles(t *testing.T) {
 						Name:      "Some Name",
 						Size:      100,
 						MimeType:  "video/mp4",
+						Digest:    "some-digest",
+						Status:    "ready",
 						CreatedAt: testutil.CreatedAt,
 					},
 				},
@@ -153,13 +198,114 @@ func TestFileRepository_ListFiles(t *testing.T) {
 
 				rows := m.SQLMock.NewRows(rowColumns)
 				rows.AddRow(rowValues...)
-				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(rows)
-
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query + " WHERE status = ? ORDER BY `id` LIMIT 11")).WillReturnRows(rows)
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(metadataQuery)).
+					WithArgs(1).
+					WillReturnRows(m.SQLMock.NewRows([]string{"file_id"}))
 			},
 		},
-		"db error": {
+		"success filtered by offset": {
+			request: Request{
+				ctx: context.Background(),
+				params: &param.ListFiles{
+					FileFilter: param.FileFilter{NamePrefix: "50%_off", MimeType: "video/mp4", MinSize: &minSize},
+					Sort:       "size",
+					Desc:       true,
+					Limit:      5,
+					Offset:     10,
+				},
+			},
+			response: Response{
+				result: nil,
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockFileRepository, req Request, res Response) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query+
+					" WHERE status = ? AND name LIKE ? AND mime_type = ? AND size >= ? ORDER BY `size` DESC,`id` DESC LIMIT 5 OFFSET 10")).
+					WithArgs("ready", `50\%\_off%`, "video/mp4", minSize).
+					WillReturnRows(m.SQLMock.NewRows(rowColumns))
+			},
+		},
+		"success after cursor": {
+			request: Request{
+				ctx: context.Background(),
+				params: &param.ListFiles{
+					Sort:   "created_at",
+					Limit:  5,
+					Offset: 10,
+					After:  &param.FileCursor{Sort: "created_at", ID: 7, CreatedAt: &testutil.CreatedAt},
+				},
+			},
+			response: Response{
+				result: nil,
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockFileRepository, req Request, res Response) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query+
+					" WHERE status = ? AND ((`created_at` > ? OR (`created_at` = ? AND id > ?))) ORDER BY `created_at`,`id` LIMIT 5")).
+					WithArgs("ready", testutil.CreatedAt, testutil.CreatedAt, 7).
+					WillReturnRows(m.SQLMock.NewRows(rowColumns))
+			},
+		},
+		"success scoped to caller": {
 			request: Request{
 				ctx: context.Background(),
+				params: &param.ListFiles{
+					FileFilter: param.FileFilter{Access: &param.FileAccess{TenantID: "acme", Subject: "alice"}},
+					Limit:      5,
+				},
+			},
+			response: Response{
+				result: nil,
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockFileRepository, req Request, res Response) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query+
+					" WHERE status = ? AND tenant_id = ? AND (owner_id = ? OR id IN (SELECT file_id FROM file_grants WHERE subject = ?)) ORDER BY `id` LIMIT 5")).
+					WithArgs("ready", "acme", "alice", "alice").
+					WillReturnRows(m.SQLMock.NewRows(rowColumns))
+			},
+		},
+		"success scoped to tenant": {
+			request: Request{
+				ctx: context.Background(),
+				params: &param.ListFiles{
+					FileFilter: param.FileFilter{Access: &param.FileAccess{TenantID: "acme", Subject: "root", All: true}},
+					Limit:      5,
+				},
+			},
+			response: Response{
+				result: nil,
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockFileRepository, req Request, res Response) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query+" WHERE status = ? AND tenant_id = ? ORDER BY `id` LIMIT 5")).
+					WithArgs("ready", "acme").
+					WillReturnRows(m.SQLMock.NewRows(rowColumns))
+			},
+		},
+		"success broken": {
+			request: Request{
+				ctx: context.Background(),
+				params: &param.ListFiles{
+					FileFilter: param.FileFilter{Status: "broken"},
+					Limit:      5,
+				},
+			},
+			response: Response{
+				result: nil,
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockFileRepository, req Request, res Response) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query + " WHERE status = ? ORDER BY `id` LIMIT 5")).
+					WithArgs("broken").
+					WillReturnRows(m.SQLMock.NewRows(rowColumns))
+			},
+		},
+		"db error": {
+			request: Request{
+				ctx:    context.Background(),
+				params: &param.ListFiles{},
 			},
 			response: Response{
 				result: nil,
@@ -175,23 +321,99 @@ func TestFileRepository_ListFiles(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			repo, mocks := fixture.NewFileRepository()
 			tc.mockFn(mocks, tc.request, tc.response)
-			result, err := repo.ListFiles(context.Background())
+			result, err := repo.ListFiles(tc.request.ctx, tc.request.params)
 			testutil.AssertErrorExAc(t, tc.response.err, err)
 			if len(tc.response.result) > 0 {
 				testutil.AssertStructExAc(t, tc.response.result[0], result[0])
 			}
+			assert.NoError(t, mocks.SQLMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestFileRepository_CountFiles(t *testing.T) {
+	query := "SELECT count(*) FROM `files`"
+	createdBefore := testutil.CreatedAt
+
+	type Request struct {
+		ctx    context.Context
+		filter *param.FileFilter
+	}
+
+	type Response struct {
+		result int64
+		err    error
+	}
+
+	testcases := map[string]struct {
+		request  Request
+		response Response
+		mockFn   func(*fixture.MockFileRepository, Request, Response)
+	}{
+		"success": {
+			request: Request{
+				ctx:    context.Background(),
+				filter: &param.FileFilter{},
+			},
+			response: Response{
+				result: 42,
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockFileRepository, req Request, res Response) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).
+					WillReturnRows(m.SQLMock.NewRows([]string{"count(*)"}).AddRow(42))
+			},
+		},
+		"success filtered": {
+			request: Request{
+				ctx:    context.Background(),
+				filter: &param.FileFilter{CreatedBefore: &createdBefore},
+			},
+			response: Response{
+				result: 3,
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockFileRepository, req Request, res Response) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query+" WHERE status = ? AND created_at < ?")).
+					WithArgs("ready", createdBefore).
+					WillReturnRows(m.SQLMock.NewRows([]string{"count(*)"}).AddRow(3))
+			},
+		},
+		"db error": {
+			request: Request{
+				ctx:    context.Background(),
+				filter: &param.FileFilter{},
+			},
+			response: Response{
+				result: 0,
+				err:    testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockFileRepository, req Request, res Response) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).
+					WillReturnError(testutil.ErrDB)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewFileRepository()
+			tc.mockFn(mocks, tc.request, tc.response)
+			result, err := repo.CountFiles(tc.request.ctx, tc.request.filter)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			assert.Equal(t, tc.response.result, result)
 		})
 	}
 }
 
 func TestFileRepository_GetFile(t *testing.T) {
-	rowColumns := []string{"id", "name", "size", "mime_type", "created_at"}
-	rowValues := []driver.Value{123, "Some Name", 100, "video/mp4", testutil.CreatedAt}
-	query := "SELECT `id`,`name`,`size`,`mime_type`,`created_at` FROM `files`"
+	rowColumns := []string{"id", "name", "size", "mime_type", "digest", "status", "created_at"}
+	rowValues := []driver.Value{123, "Some Name", 100, "video/mp4", "some-digest", "ready", testutil.CreatedAt}
+	query := "SELECT `id`,`name`,`size`,`mime_type`,`digest`,`status`,`owner_id`,`tenant_id`,`created_at` FROM `files`"
 
 	type Request struct {
-		ctx context.Context
-		id  int
+		ctx      context.Context
+		id       int
+		statuses []string
 	}
 
 	type Response struct {
@@ -216,7 +438,27 @@ func TestFileRepository_GetFile(t *testing.T) {
 			mockFn: func(m *fixture.MockFileRepository, req Request, res Response) {
 				rows := m.SQLMock.NewRows(rowColumns)
 				rows.AddRow(rowValues...)
-				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(rows)
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query+" WHERE status IN (?) AND `files`.`id` = ?")).
+					WithArgs(entity.FileStatusReady, 123).
+					WillReturnRows(rows)
+			},
+		},
+		"success broken": {
+			request: Request{
+				ctx:      context.Background(),
+				id:       123,
+				statuses: []string{entity.FileStatusReady, entity.FileStatusBroken},
+			},
+			response: Response{
+				result: map[string]interface{}{"ID": 123},
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockFileRepository, req Request, res Response) {
+				rows := m.SQLMock.NewRows(rowColumns)
+				rows.AddRow(123, "Some Name", 100, "video/mp4", "some-digest", "broken", testutil.CreatedAt)
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query+" WHERE status IN (?,?) AND `files`.`id` = ?")).
+					WithArgs(entity.FileStatusReady, entity.FileStatusBroken, 123).
+					WillReturnRows(rows)
 			},
 		},
 		"db error not found": {
@@ -254,7 +496,62 @@ func TestFileRepository_GetFile(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			repo, mocks := fixture.NewFileRepository()
 			tc.mockFn(mocks, tc.request, tc.response)
-			result, err := repo.GetFile(context.Background(), tc.request.id)
+			result, err := repo.GetFile(context.Background(), tc.request.id, tc.request.statuses...)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			testutil.AssertStructExAc(t, tc.response.result, result)
+		})
+	}
+}
+
+func TestFileRepository_FindFileByName(t *testing.T) {
+	rowColumns := []string{"id", "name", "size", "mime_type", "digest", "status", "created_at"}
+	rowValues := []driver.Value{123, "Some Name", 100, "video/mp4", "some-digest", "ready", testutil.CreatedAt}
+	query := "SELECT `id`,`name`,`size`,`mime_type`,`digest`,`status`,`owner_id`,`tenant_id`,`created_at` FROM `files` WHERE tenant_id = ? AND name = ? AND status <> ?"
+
+	type Response struct {
+		result interface{}
+		err    error
+	}
+
+	testcases := map[string]struct {
+		response Response
+		mockFn   func(*fixture.MockFileRepository)
+	}{
+		"success": {
+			response: Response{
+				result: map[string]interface{}{"ID": 123},
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockFileRepository) {
+				rows := m.SQLMock.NewRows(rowColumns)
+				rows.AddRow(rowValues...)
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("acme", "Some Name", "failed").WillReturnRows(rows)
+			},
+		},
+		"db error not found": {
+			response: Response{
+				result: nil,
+				err:    entity.ErrorFileNotFound,
+			},
+			mockFn: func(m *fixture.MockFileRepository) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(gorm.ErrRecordNotFound)
+			},
+		},
+		"db error others": {
+			response: Response{
+				result: nil,
+				err:    testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockFileRepository) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(testutil.ErrDB)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewFileRepository()
+			tc.mockFn(mocks)
+			result, err := repo.FindFileByName(context.Background(), "acme", "Some Name")
 			testutil.AssertErrorExAc(t, tc.response.err, err)
 			testutil.AssertStructExAc(t, tc.response.result, result)
 		})
@@ -304,3 +601,265 @@ func TestFileRepository_UpdateFile(t *testing.T) {
 		})
 	}
 }
+
+func TestFileRepository_UpdateFileStatus(t *testing.T) {
+	query := "UPDATE `files` SET `status`=? WHERE `id` = ?"
+
+	type Request struct {
+		ctx    context.Context
+		id     int
+		status string
+	}
+
+	type Response struct {
+		err error
+	}
+
+	testcases := map[string]struct {
+		request  Request
+		response Response
+		mockFn   func(*fixture.MockFileRepository, Request, Response)
+	}{
+		"success": {
+			request: Request{
+				ctx:    context.Background(),
+				id:     123,
+				status: entity.FileStatusReady,
+			},
+			response: Response{
+				err: nil,
+			},
+			mockFn: func(m *fixture.MockFileRepository, req Request, res Response) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs("ready", 123).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.SQLMock.ExpectCommit()
+			},
+		},
+		"db error": {
+			request: Request{
+				ctx:    context.Background(),
+				id:     123,
+				status: entity.FileStatusFailed,
+			},
+			response: Response{
+				err: testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockFileRepository, req Request, res Response) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs("failed", 123).
+					WillReturnError(testutil.ErrDB)
+				m.SQLMock.ExpectRollback()
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewFileRepository()
+			tc.mockFn(mocks, tc.request, tc.response)
+			err := repo.UpdateFileStatus(tc.request.ctx, tc.request.id, tc.request.status)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+		})
+	}
+}
+
+func TestFileRepository_ScanFiles(t *testing.T) {
+	rowColumns := []string{"id", "name", "size", "mime_type", "digest", "status", "created_at"}
+	rowValues := []driver.Value{124, "Some Name", 100, "video/mp4", "some-digest", "failed", testutil.CreatedAt}
+	query := "SELECT `id`,`name`,`size`,`mime_type`,`digest`,`status`,`owner_id`,`tenant_id`,`created_at` FROM `files` WHERE id > ? ORDER BY id LIMIT 50"
+
+	type Response struct {
+		result []*entity.File
+		err    error
+	}
+
+	testcases := map[string]struct {
+		response Response
+		mockFn   func(*fixture.MockFileRepository)
+	}{
+		"success": {
+			response: Response{
+				result: []*entity.File{
+					{
+						ID:        124,
+						Name:      "Some Name",
+						Size:      100,
+						MimeType:  "video/mp4",
+						Digest:    "some-digest",
+						Status:    "failed",
+						CreatedAt: testutil.CreatedAt,
+					},
+				},
+				err: nil,
+			},
+			mockFn: func(m *fixture.MockFileRepository) {
+				rows := m.SQLMock.NewRows(rowColumns)
+				rows.AddRow(rowValues...)
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs(123).
+					WillReturnRows(rows)
+			},
+		},
+		"db error": {
+			response: Response{
+				result: nil,
+				err:    testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockFileRepository) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).
+					WillReturnError(testutil.ErrDB)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewFileRepository()
+			tc.mockFn(mocks)
+			result, err := repo.ScanFiles(context.Background(), 123, 50)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			if len(tc.response.result) > 0 {
+				testutil.AssertStructExAc(t, tc.response.result[0], result[0])
+			}
+		})
+	}
+}
+
+func TestFileRepository_CountFilesByDigest(t *testing.T) {
+	query := "SELECT count(*) FROM `files` WHERE tenant_id = ? AND digest = ? AND status <> ?"
+
+	type Response struct {
+		result int64
+		err    error
+	}
+
+	testcases := map[string]struct {
+		response Response
+		mockFn   func(*fixture.MockFileRepository)
+	}{
+		"success": {
+			response: Response{
+				result: 2,
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockFileRepository) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs("acme", "some-digest", "failed").
+					WillReturnRows(m.SQLMock.NewRows([]string{"count(*)"}).AddRow(2))
+			},
+		},
+		"db error": {
+			response: Response{
+				result: 0,
+				err:    testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockFileRepository) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).
+					WillReturnError(testutil.ErrDB)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewFileRepository()
+			tc.mockFn(mocks)
+			result, err := repo.CountFilesByDigest(context.Background(), "acme", "some-digest")
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			assert.Equal(t, tc.response.result, result)
+		})
+	}
+}
+
+func TestFileRepository_GetUsage(t *testing.T) {
+	query := "SELECT COUNT(*) AS files, COALESCE(SUM(size), 0) AS bytes FROM `files` WHERE tenant_id = ? AND status <> ?"
+
+	type Response struct {
+		result interface{}
+		err    error
+	}
+
+	testcases := map[string]struct {
+		response Response
+		mockFn   func(*fixture.MockFileRepository)
+	}{
+		"success": {
+			response: Response{
+				result: map[string]interface{}{"TenantID": "acme", "Files": int64(2), "Bytes": int64(300)},
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockFileRepository) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs("acme", "failed").
+					WillReturnRows(m.SQLMock.NewRows([]string{"files", "bytes"}).AddRow(2, 300))
+			},
+		},
+		"db error": {
+			response: Response{
+				result: nil,
+				err:    testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockFileRepository) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).
+					WillReturnError(testutil.ErrDB)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewFileRepository()
+			tc.mockFn(mocks)
+			result, err := repo.GetUsage(context.Background(), "acme")
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			testutil.AssertStructExAc(t, tc.response.result, result)
+		})
+	}
+}
+
+func TestFileRepository_ListUsage(t *testing.T) {
+	query := "SELECT tenant_id, COUNT(*) AS files, COALESCE(SUM(size), 0) AS bytes FROM `files` WHERE status <> ? GROUP BY `tenant_id` ORDER BY tenant_id"
+
+	type Response struct {
+		result []*entity.Usage
+		err    error
+	}
+
+	testcases := map[string]struct {
+		response Response
+		mockFn   func(*fixture.MockFileRepository)
+	}{
+		"success": {
+			response: Response{
+				result: []*entity.Usage{
+					{TenantID: "", Files: 1, Bytes: 100},
+					{TenantID: "acme", Files: 2, Bytes: 300},
+				},
+			},
+			mockFn: func(m *fixture.MockFileRepository) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs("failed").
+					WillReturnRows(m.SQLMock.NewRows([]string{"tenant_id", "files", "bytes"}).
+						AddRow("", 1, 100).
+						AddRow("acme", 2, 300))
+			},
+		},
+		"db error": {
+			response: Response{
+				err: testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockFileRepository) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).
+					WillReturnError(testutil.ErrDB)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewFileRepository()
+			tc.mockFn(mocks)
+			result, err := repo.ListUsage(context.Background())
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			assert.Equal(t, tc.response.result, result)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package repository
+
+//go:generate mockgen -source grant.go -destination mock/grant.go
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"video-server/module/entity"
+	"video-server/module/param"
+)
+
+var (
+	GrantColumnsInsert = []string{
+		"file_id",
+		"subject",
+		"granted_by",
+		"created_at",
+	}
+	GrantColumns = append([]string{"id"}, GrantColumnsInsert...)
+)
+
+type GrantRepository interface {
+	CreateGrant(ctx context.Context, params *param.CreateGrant) error
+	ListGrants(ctx context.Context, fileID int) ([]*entity.FileGrant, error)
+	HasGrant(ctx context.Context, fileID int, subject string) (bool, error)
+	DeleteGrant(ctx context.Context, fileID int, subject string) error
+}
+
+type grantRepository struct {
+	database *gorm.DB
+}
+
+func NewGrantRepository(database *gorm.DB) *grantRepository {
+	return &grantRepository{
+		database: database,
+	}
+}
+
+// CreateGrant is idempotent, granting a file twice keeps the first grant.
+func (r *grantRepository) CreateGrant(ctx context.Context, params *param.CreateGrant) error {
+	grant := &entity.FileGrant{
+		FileID:    params.FileID,
+		Subject:   params.Subject,
+		GrantedBy: params.GrantedBy,
+		CreatedAt: time.Now(),
+	}
+
+	return withTrace(ctx, r.database).Select(GrantColumnsInsert).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(grant).Error
+}
+
+func (r *grantRepository) ListGrants(ctx context.Context, fileID int) ([]*entity.FileGrant, error) {
+	grants := []*entity.FileGrant{}
+	err := withTrace(ctx, r.database).Select(GrantColumns).Where("file_id = ?", fileID).Order("id").Find(&grants).Error
+
+	return grants, err
+}
+
+func (r *grantRepository) HasGrant(ctx context.Context, fileID int, subject string) (bool, error) {
+	var total int64
+	err := withTrace(ctx, r.database).Model(&entity.FileGrant{}).
+		Where("file_id = ? AND subject = ?", fileID, subject).
+		Count(&total).Error
+
+	return total > 0, err
+}
+
+func (r *grantRepository) DeleteGrant(ctx context.Context, fileID int, subject string) error {
+	result := withTrace(ctx, r.database).Where("file_id = ? AND subject = ?", fileID, subject).Delete(&entity.FileGrant{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return entity.ErrorGrantNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package repository_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"video-server/internal/testutil"
+	"video-server/module/entity"
+	"video-server/module/fixture"
+	"video-server/module/param"
+)
+
+func TestGrantRepository_CreateGrant(t *testing.T) {
+	query := "INSERT INTO `file_grants` (`file_id`,`subject`,`granted_by`,`created_at`) VALUES (?,?,?,?) ON DUPLICATE KEY UPDATE `id`=`id`"
+
+	testcases := map[string]struct {
+		err    error
+		mockFn func(*fixture.MockGrantRepository)
+	}{
+		"success": {
+			mockFn: func(m *fixture.MockGrantRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs(1, "bob", "alice", testutil.AnyTime{}).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				m.SQLMock.ExpectCommit()
+			},
+		},
+		"db error": {
+			err: testutil.ErrDB,
+			mockFn: func(m *fixture.MockGrantRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WillReturnError(testutil.ErrDB)
+				m.SQLMock.ExpectRollback()
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewGrantRepository()
+			tc.mockFn(mocks)
+			err := repo.CreateGrant(context.Background(), &param.CreateGrant{FileID: 1, Subject: "bob", GrantedBy: "alice"})
+			testutil.AssertErrorExAc(t, tc.err, err)
+		})
+	}
+}
+
+func TestGrantRepository_ListGrants(t *testing.T) {
+	repo, mocks := fixture.NewGrantRepository()
+	rows := mocks.SQLMock.NewRows([]string{"id", "file_id", "subject", "granted_by", "created_at"}).
+		AddRow(1, 1, "bob", "alice", testutil.CreatedAt).
+		AddRow(2, 1, "carol", "alice", testutil.CreatedAt)
+	mocks.SQLMock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`file_id`,`subject`,`granted_by`,`created_at` FROM `file_grants` WHERE file_id = ? ORDER BY id")).
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	result, err := repo.ListGrants(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, "carol", result[1].Subject)
+}
+
+func TestGrantRepository_HasGrant(t *testing.T) {
+	query := "SELECT count(*) FROM `file_grants` WHERE file_id = ? AND subject = ?"
+
+	testcases := map[string]struct {
+		count  int
+		result bool
+		err    error
+	}{
+		"granted":     {count: 1, result: true},
+		"not granted": {count: 0, result: false},
+		"db error":    {err: testutil.ErrDB},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewGrantRepository()
+			expect := mocks.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(1, "bob")
+			if tc.err != nil {
+				expect.WillReturnError(tc.err)
+			} else {
+				expect.WillReturnRows(mocks.SQLMock.NewRows([]string{"count"}).AddRow(tc.count))
+			}
+
+			result, err := repo.HasGrant(context.Background(), 1, "bob")
+			testutil.AssertErrorExAc(t, tc.err, err)
+			assert.Equal(t, tc.result, result)
+		})
+	}
+}
+
+func TestGrantRepository_DeleteGrant(t *testing.T) {
+	query := "DELETE FROM `file_grants` WHERE file_id = ? AND subject = ?"
+
+	testcases := map[string]struct {
+		err    error
+		mockFn func(*fixture.MockGrantRepository)
+	}{
+		"success": {
+			mockFn: func(m *fixture.MockGrantRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs(1, "bob").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.SQLMock.ExpectCommit()
+			},
+		},
+		"not found": {
+			err: entity.ErrorGrantNotFound,
+			mockFn: func(m *fixture.MockGrantRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				m.SQLMock.ExpectCommit()
+			},
+		},
+		"db error": {
+			err: testutil.ErrDB,
+			mockFn: func(m *fixture.MockGrantRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WillReturnError(testutil.ErrDB)
+				m.SQLMock.ExpectRollback()
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewGrantRepository()
+			tc.mockFn(mocks)
+			err := repo.DeleteGrant(context.Background(), 1, "bob")
+			testutil.AssertErrorExAc(t, tc.err, err)
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package repository
+
+//go:generate mockgen -source health.go -destination mock/health.go
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type HealthRepository interface {
+	// Ping runs a trivial query, it fails when the database can't be
+	// reached or doesn't answer
+	Ping(ctx context.Context) error
+}
+
+type healthRepository struct {
+	database *gorm.DB
+}
+
+func NewHealthRepository(database *gorm.DB) *healthRepository {
+	return &healthRepository{
+		database: database,
+	}
+}
+
+func (r *healthRepository) Ping(ctx context.Context) error {
+	return r.database.WithContext(ctx).Exec("SELECT 1").Error
+}
@@ -0,0 +1,37 @@
+package repository_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"video-server/internal/testutil"
+	"video-server/module/fixture"
+)
+
+func TestHealthRepository_Ping(t *testing.T) {
+	query := "SELECT 1"
+
+	testcases := map[string]struct {
+		err error
+	}{
+		"up":       {},
+		"db error": {err: testutil.ErrDB},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewHealthRepository()
+			if tc.err != nil {
+				mocks.SQLMock.ExpectExec(regexp.QuoteMeta(query)).WillReturnError(tc.err)
+			} else {
+				mocks.SQLMock.ExpectExec(regexp.QuoteMeta(query)).WillReturnResult(sqlmock.NewResult(0, 0))
+			}
+
+			err := repo.Ping(context.Background())
+			testutil.AssertErrorExAc(t, tc.err, err)
+			testutil.AssertErrorExAc(t, nil, mocks.SQLMock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package repository
+
+//go:generate mockgen -source job.go -destination mock/job.go
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"video-server/module/entity"
+	"video-server/module/param"
+)
+
+var (
+	JobColumnsInsert = []string{
+		"file_id",
+		"type",
+		"status",
+		"attempts",
+		"progress",
+		"run_at",
+		"created_at",
+		"updated_at",
+	}
+	JobColumns = append([]string{
+		"id",
+		"error",
+		"locked_by",
+		"locked_until",
+		"started_at",
+		"finished_at",
+	}, JobColumnsInsert...)
+)
+
+type JobRepository interface {
+	CreateJobs(ctx context.Context, params []*param.CreateJob) ([]*entity.Job, error)
+	GetJob(ctx context.Context, id int) (*entity.Job, error)
+	ListJobsByFile(ctx context.Context, fileID int) ([]*entity.Job, error)
+	ClaimJob(ctx context.Context, lease time.Duration) (*entity.Job, error)
+	UpdateJobProgress(ctx context.Context, job *entity.Job, progress int) error
+	FinishJob(ctx context.Context, job *entity.Job, params *param.FinishJob) error
+}
+
+type jobRepository struct {
+	database *gorm.DB
+}
+
+func NewJobRepository(database *gorm.DB) *jobRepository {
+	return &jobRepository{
+		database: database,
+	}
+}
+
+func (r *jobRepository) CreateJobs(ctx context.Context, params []*param.CreateJob) ([]*entity.Job, error) {
+	timeNow := time.Now()
+	jobs := make([]*entity.Job, len(params))
+	for i, p := range params {
+		jobs[i] = &entity.Job{
+			FileID:    p.FileID,
+			Type:      p.Type,
+			Status:    entity.JobStatusQueued,
+			RunAt:     timeNow,
+			CreatedAt: timeNow,
+			UpdatedAt: timeNow,
+		}
+	}
+	err := withTrace(ctx, r.database).Select(JobColumnsInsert).Create(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+func (r *jobRepository) GetJob(ctx context.Context, id int) (*entity.Job, error) {
+	job := &entity.Job{}
+	err := withTrace(ctx, r.database).Select(JobColumns).Where("id = ?", id).First(job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrorJobNotFound
+		}
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (r *jobRepository) ListJobsByFile(ctx context.Context, fileID int) ([]*entity.Job, error) {
+	jobs := []*entity.Job{}
+	err := withTrace(ctx, r.database).Select(JobColumns).Where("file_id = ?", fileID).Order("id").Find(&jobs).Error
+
+	return jobs, err
+}
+
+// ClaimJob takes the oldest due job, or a running one whose worker let its
+// lease expire, in a single UPDATE so two workers never get the same job.
+// It returns ErrorJobNotFound when nothing is due.
+func (r *jobRepository) ClaimJob(ctx context.Context, lease time.Duration) (*entity.Job, error) {
+	timeNow := time.Now()
+	token := uuid.NewString()
+	result := withTrace(ctx, r.database).Model(&entity.Job{}).
+		Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_until < ?)",
+			entity.JobStatusQueued, timeNow, entity.JobStatusRunning, timeNow).
+		Order("id").
+		Limit(1).
+		Updates(map[string]interface{}{
+			"status":       entity.JobStatusRunning,
+			"attempts":     gorm.Expr("attempts + 1"),
+			"progress":     0,
+			"locked_by":    token,
+			"locked_until": timeNow.Add(lease),
+			"started_at":   timeNow,
+			"updated_at":   timeNow,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, entity.ErrorJobNotFound
+	}
+
+	job := &entity.Job{}
+	err := withTrace(ctx, r.database).Select(JobColumns).Where("locked_by = ?", token).First(job).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// UpdateJobProgress only touches the job while the caller still holds it.
+func (r *jobRepository) UpdateJobProgress(ctx context.Context, job *entity.Job, progress int) error {
+	return withTrace(ctx, r.database).Model(&entity.Job{}).
+		Where("id = ? AND locked_by = ?", job.ID, job.LockedBy).
+		Updates(map[string]interface{}{"progress": progress, "updated_at": time.Now()}).Error
+}
+
+// FinishJob records the outcome of an attempt and releases the lease, a job
+// going back to queued waits until params.RunAt.
+func (r *jobRepository) FinishJob(ctx context.Context, job *entity.Job, params *param.FinishJob) error {
+	timeNow := time.Now()
+	values := map[string]interface{}{
+		"status":       params.Status,
+		"error":        params.Error,
+		"locked_by":    "",
+		"locked_until": nil,
+		"updated_at":   timeNow,
+	}
+	switch params.Status {
+	case entity.JobStatusQueued:
+		values["run_at"] = params.RunAt
+	case entity.JobStatusSucceeded:
+		values["progress"] = 100
+		values["finished_at"] = timeNow
+	default:
+		values["finished_at"] = timeNow
+	}
+
+	return withTrace(ctx, r.database).Model(&entity.Job{}).
+		Where("id = ? AND locked_by = ?", job.ID, job.LockedBy).
+		Updates(values).Error
+}
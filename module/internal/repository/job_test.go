@@ -0,0 +1,272 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"video-server/internal/testutil"
+	"video-server/module/entity"
+	"video-server/module/fixture"
+	"video-server/module/param"
+)
+
+var (
+	jobRowColumns = []string{"id", "error", "locked_by", "locked_until", "started_at", "finished_at", "file_id", "type", "status", "attempts", "progress", "run_at", "created_at", "updated_at"}
+	jobSelect     = "SELECT `id`,`error`,`locked_by`,`locked_until`,`started_at`,`finished_at`,`file_id`,`type`,`status`,`attempts`,`progress`,`run_at`,`created_at`,`updated_at` FROM `jobs`"
+)
+
+func jobRow(id int, typ string, status string) []driver.Value {
+	return []driver.Value{id, "", "", nil, nil, nil, 1, typ, status, 0, 0, testutil.CreatedAt, testutil.CreatedAt, testutil.UpdatedAt}
+}
+
+func TestJobRepository_CreateJobs(t *testing.T) {
+	query := "INSERT INTO `jobs` (`file_id`,`type`,`status`,`attempts`,`progress`,`run_at`,`created_at`,`updated_at`) VALUES (?,?,?,?,?,?,?,?),(?,?,?,?,?,?,?,?)"
+	params := []*param.CreateJob{
+		{FileID: 1, Type: entity.JobTypeProbe},
+		{FileID: 1, Type: entity.JobTypeHash},
+	}
+
+	type Response struct {
+		ids []int
+		err error
+	}
+
+	testcases := map[string]struct {
+		response Response
+		mockFn   func(*fixture.MockJobRepository)
+	}{
+		"success": {
+			response: Response{
+				ids: []int{1, 2},
+			},
+			mockFn: func(m *fixture.MockJobRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs(
+						1, "probe", "queued", 0, 0, testutil.AnyTime{}, testutil.AnyTime{}, testutil.AnyTime{},
+						1, "hash", "queued", 0, 0, testutil.AnyTime{}, testutil.AnyTime{}, testutil.AnyTime{},
+					).
+					WillReturnResult(sqlmock.NewResult(1, 2))
+				m.SQLMock.ExpectCommit()
+			},
+		},
+		"db error": {
+			response: Response{
+				err: testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockJobRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WillReturnError(testutil.ErrDB)
+				m.SQLMock.ExpectRollback()
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewJobRepository()
+			tc.mockFn(mocks)
+			result, err := repo.CreateJobs(context.Background(), params)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			for i, id := range tc.response.ids {
+				assert.Equal(t, id, result[i].ID)
+				assert.Equal(t, entity.JobStatusQueued, result[i].Status)
+			}
+		})
+	}
+}
+
+func TestJobRepository_GetJob(t *testing.T) {
+	query := jobSelect + " WHERE id = ? ORDER BY `jobs`.`id` LIMIT 1"
+
+	type Response struct {
+		result interface{}
+		err    error
+	}
+
+	testcases := map[string]struct {
+		response Response
+		mockFn   func(*fixture.MockJobRepository)
+	}{
+		"success": {
+			response: Response{
+				result: map[string]interface{}{"ID": 1, "Type": "probe", "Status": "queued"},
+			},
+			mockFn: func(m *fixture.MockJobRepository) {
+				rows := m.SQLMock.NewRows(jobRowColumns).AddRow(jobRow(1, "probe", "queued")...)
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(1).WillReturnRows(rows)
+			},
+		},
+		"db error not found": {
+			response: Response{
+				err: entity.ErrorJobNotFound,
+			},
+			mockFn: func(m *fixture.MockJobRepository) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(gorm.ErrRecordNotFound)
+			},
+		},
+		"db error others": {
+			response: Response{
+				err: testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockJobRepository) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(testutil.ErrDB)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewJobRepository()
+			tc.mockFn(mocks)
+			result, err := repo.GetJob(context.Background(), 1)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			testutil.AssertStructExAc(t, tc.response.result, result)
+		})
+	}
+}
+
+func TestJobRepository_ListJobsByFile(t *testing.T) {
+	repo, mocks := fixture.NewJobRepository()
+	rows := mocks.SQLMock.NewRows(jobRowColumns).
+		AddRow(jobRow(1, "probe", "succeeded")...).
+		AddRow(jobRow(2, "hash", "running")...)
+	mocks.SQLMock.ExpectQuery(regexp.QuoteMeta(jobSelect + " WHERE file_id = ? ORDER BY id")).
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	result, err := repo.ListJobsByFile(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, "running", result[1].Status)
+}
+
+func TestJobRepository_ClaimJob(t *testing.T) {
+	update := "UPDATE `jobs` SET `attempts`=attempts + 1,`locked_by`=?,`locked_until`=?,`progress`=?,`started_at`=?,`status`=?,`updated_at`=? " +
+		"WHERE (status = ? AND run_at <= ?) OR (status = ? AND locked_until < ?) ORDER BY id LIMIT 1"
+	query := jobSelect + " WHERE locked_by = ? ORDER BY `jobs`.`id` LIMIT 1"
+
+	type Response struct {
+		result interface{}
+		err    error
+	}
+
+	testcases := map[string]struct {
+		response Response
+		mockFn   func(*fixture.MockJobRepository)
+	}{
+		"success": {
+			response: Response{
+				result: map[string]interface{}{"ID": 3, "Status": "running"},
+			},
+			mockFn: func(m *fixture.MockJobRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(update)).
+					WithArgs(testutil.AnyString{}, testutil.AnyTime{}, 0, testutil.AnyTime{}, "running", testutil.AnyTime{},
+						"queued", testutil.AnyTime{}, "running", testutil.AnyTime{}).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.SQLMock.ExpectCommit()
+				rows := m.SQLMock.NewRows(jobRowColumns).AddRow(jobRow(3, "hash", "running")...)
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(testutil.AnyString{}).WillReturnRows(rows)
+			},
+		},
+		"nothing due": {
+			response: Response{
+				err: entity.ErrorJobNotFound,
+			},
+			mockFn: func(m *fixture.MockJobRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(update)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				m.SQLMock.ExpectCommit()
+			},
+		},
+		"db error": {
+			response: Response{
+				err: testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockJobRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(update)).
+					WillReturnError(testutil.ErrDB)
+				m.SQLMock.ExpectRollback()
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewJobRepository()
+			tc.mockFn(mocks)
+			result, err := repo.ClaimJob(context.Background(), time.Minute)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			testutil.AssertStructExAc(t, tc.response.result, result)
+		})
+	}
+}
+
+func TestJobRepository_UpdateJobProgress(t *testing.T) {
+	repo, mocks := fixture.NewJobRepository()
+	mocks.SQLMock.ExpectBegin()
+	mocks.SQLMock.ExpectExec(regexp.QuoteMeta("UPDATE `jobs` SET `progress`=?,`updated_at`=? WHERE id = ? AND locked_by = ?")).
+		WithArgs(40, testutil.AnyTime{}, 1, "token").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mocks.SQLMock.ExpectCommit()
+
+	err := repo.UpdateJobProgress(context.Background(), &entity.Job{ID: 1, LockedBy: "token"}, 40)
+	assert.NoError(t, err)
+}
+
+func TestJobRepository_FinishJob(t *testing.T) {
+	runAt := time.Now().Add(time.Minute)
+
+	testcases := map[string]struct {
+		params *param.FinishJob
+		mockFn func(*fixture.MockJobRepository)
+	}{
+		"succeeded": {
+			params: &param.FinishJob{Status: entity.JobStatusSucceeded},
+			mockFn: func(m *fixture.MockJobRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta("UPDATE `jobs` SET `error`=?,`finished_at`=?,`locked_by`=?,`locked_until`=?,`progress`=?,`status`=?,`updated_at`=? WHERE id = ? AND locked_by = ?")).
+					WithArgs("", testutil.AnyTime{}, "", nil, 100, "succeeded", testutil.AnyTime{}, 1, "token").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.SQLMock.ExpectCommit()
+			},
+		},
+		"dead": {
+			params: &param.FinishJob{Status: entity.JobStatusDead, Error: "storage error"},
+			mockFn: func(m *fixture.MockJobRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta("UPDATE `jobs` SET `error`=?,`finished_at`=?,`locked_by`=?,`locked_until`=?,`status`=?,`updated_at`=? WHERE id = ? AND locked_by = ?")).
+					WithArgs("storage error", testutil.AnyTime{}, "", nil, "dead", testutil.AnyTime{}, 1, "token").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.SQLMock.ExpectCommit()
+			},
+		},
+		"retry": {
+			params: &param.FinishJob{Status: entity.JobStatusQueued, Error: "storage error", RunAt: runAt},
+			mockFn: func(m *fixture.MockJobRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta("UPDATE `jobs` SET `error`=?,`locked_by`=?,`locked_until`=?,`run_at`=?,`status`=?,`updated_at`=? WHERE id = ? AND locked_by = ?")).
+					WithArgs("storage error", "", nil, runAt, "queued", testutil.AnyTime{}, 1, "token").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.SQLMock.ExpectCommit()
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewJobRepository()
+			tc.mockFn(mocks)
+			err := repo.FinishJob(context.Background(), &entity.Job{ID: 1, LockedBy: "token"}, tc.params)
+			assert.NoError(t, err)
+			assert.NoError(t, mocks.SQLMock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package repository
+
+//go:generate mockgen -source link.go -destination mock/link.go
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"video-server/module/entity"
+	"video-server/module/param"
+)
+
+var (
+	LinkColumnsInsert = []string{
+		"file_id",
+		"tenant_id",
+		"created_by",
+		"ip",
+		"max_downloads",
+		"expires_at",
+		"created_at",
+	}
+	LinkColumns = append([]string{"id", "downloads"}, LinkColumnsInsert...)
+)
+
+type LinkRepository interface {
+	CreateLink(ctx context.Context, params *param.CreateLink) (*entity.DownloadLink, error)
+	GetLink(ctx context.Context, id int) (*entity.DownloadLink, error)
+	// CountDownload adds a download to the link, it returns false without
+	// counting when the link has no downloads left
+	CountDownload(ctx context.Context, id int) (bool, error)
+	// CountPlayback counts a download for client unless its playback is
+	// still open, and keeps the playback open until until. It returns false
+	// when a new download would go over the limit.
+	CountPlayback(ctx context.Context, id int, client string, until time.Time) (bool, error)
+}
+
+type linkRepository struct {
+	database *gorm.DB
+}
+
+func NewLinkRepository(database *gorm.DB) *linkRepository {
+	return &linkRepository{
+		database: database,
+	}
+}
+
+func (r *linkRepository) CreateLink(ctx context.Context, params *param.CreateLink) (*entity.DownloadLink, error) {
+	link := &entity.DownloadLink{
+		FileID:       params.FileID,
+		TenantID:     params.TenantID,
+		CreatedBy:    params.CreatedBy,
+		IP:           params.IP,
+		MaxDownloads: params.MaxDownloads,
+		ExpiresAt:    params.ExpiresAt,
+		CreatedAt:    time.Now(),
+	}
+
+	err := withTrace(ctx, r.database).Select(LinkColumnsInsert).Create(link).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+func (r *linkRepository) GetLink(ctx context.Context, id int) (*entity.DownloadLink, error) {
+	link := &entity.DownloadLink{}
+	err := withTrace(ctx, r.database).Select(LinkColumns).Where("id = ?", id).First(link).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrorLinkNotFound
+		}
+		return nil, err
+	}
+
+	return link, nil
+}
+
+// CountDownload checks the limit in the update itself, so concurrent
+// requests can't go over it.
+func (r *linkRepository) CountDownload(ctx context.Context, id int) (bool, error) {
+	result := withTrace(ctx, r.database).Model(&entity.DownloadLink{}).
+		Where("id = ? AND (max_downloads = 0 OR downloads < max_downloads)", id).
+		Update("downloads", gorm.Expr("downloads + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// CountPlayback holds the row of the link while it looks at the playback,
+// so concurrent first requests of a client count once.
+func (r *linkRepository) CountPlayback(ctx context.Context, id int, client string, until time.Time) (bool, error) {
+	counted := false
+	err := withTrace(ctx, r.database).Transaction(func(tx *gorm.DB) error {
+		link := &entity.DownloadLink{}
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", id).First(link).Error
+		if err != nil {
+			return err
+		}
+
+		playback := &entity.LinkPlayback{}
+		err = tx.Where("link_id = ? AND client = ?", id, client).First(playback).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err != nil || !playback.ExpiresAt.After(time.Now()) {
+			result := tx.Model(&entity.DownloadLink{}).
+				Where("id = ? AND (max_downloads = 0 OR downloads < max_downloads)", id).
+				Update("downloads", gorm.Expr("downloads + 1"))
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+		}
+
+		counted = true
+		return tx.Clauses(clause.OnConflict{DoUpdates: clause.AssignmentColumns([]string{"expires_at"})}).
+			Create(&entity.LinkPlayback{LinkID: id, Client: client, ExpiresAt: until}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, entity.ErrorLinkNotFound
+	}
+
+	return counted, err
+}
@@ -0,0 +1,241 @@
+package repository_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"video-server/internal/testutil"
+	"video-server/module/entity"
+	"video-server/module/fixture"
+	"video-server/module/param"
+)
+
+func TestLinkRepository_CreateLink(t *testing.T) {
+	query := "INSERT INTO `download_links` (`file_id`,`tenant_id`,`created_by`,`ip`,`max_downloads`,`expires_at`,`created_at`) VALUES (?,?,?,?,?,?,?)"
+	expiresAt := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testcases := map[string]struct {
+		result interface{}
+		err    error
+		mockFn func(*fixture.MockLinkRepository)
+	}{
+		"success": {
+			result: map[string]interface{}{"ID": 3, "FileID": 1, "TenantID": "acme", "CreatedBy": "alice", "IP": "203.0.113.7", "MaxDownloads": 2, "ExpiresAt": expiresAt},
+			mockFn: func(m *fixture.MockLinkRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs(1, "acme", "alice", "203.0.113.7", 2, expiresAt, testutil.AnyTime{}).
+					WillReturnResult(sqlmock.NewResult(3, 1))
+				m.SQLMock.ExpectCommit()
+			},
+		},
+		"db error": {
+			err: testutil.ErrDB,
+			mockFn: func(m *fixture.MockLinkRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WillReturnError(testutil.ErrDB)
+				m.SQLMock.ExpectRollback()
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewLinkRepository()
+			tc.mockFn(mocks)
+			result, err := repo.CreateLink(context.Background(), &param.CreateLink{
+				FileID:       1,
+				TenantID:     "acme",
+				CreatedBy:    "alice",
+				IP:           "203.0.113.7",
+				MaxDownloads: 2,
+				ExpiresAt:    expiresAt,
+			})
+			testutil.AssertErrorExAc(t, tc.err, err)
+			testutil.AssertStructExAc(t, tc.result, result)
+		})
+	}
+}
+
+func TestLinkRepository_GetLink(t *testing.T) {
+	query := "SELECT `id`,`downloads`,`file_id`,`tenant_id`,`created_by`,`ip`,`max_downloads`,`expires_at`,`created_at` FROM `download_links` WHERE id = ? ORDER BY `download_links`.`id` LIMIT 1"
+
+	testcases := map[string]struct {
+		err    error
+		mockFn func(*fixture.MockLinkRepository)
+	}{
+		"success": {
+			mockFn: func(m *fixture.MockLinkRepository) {
+				rows := m.SQLMock.NewRows([]string{"id", "downloads", "file_id", "tenant_id", "created_by", "ip", "max_downloads", "expires_at", "created_at"}).
+					AddRow(3, 1, 1, "acme", "alice", "", 2, testutil.CreatedAt, testutil.CreatedAt)
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(3).WillReturnRows(rows)
+			},
+		},
+		"not found": {
+			err: entity.ErrorLinkNotFound,
+			mockFn: func(m *fixture.MockLinkRepository) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(3).
+					WillReturnRows(m.SQLMock.NewRows([]string{"id"}))
+			},
+		},
+		"db error": {
+			err: testutil.ErrDB,
+			mockFn: func(m *fixture.MockLinkRepository) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(3).WillReturnError(testutil.ErrDB)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewLinkRepository()
+			tc.mockFn(mocks)
+			result, err := repo.GetLink(context.Background(), 3)
+			testutil.AssertErrorExAc(t, tc.err, err)
+			if tc.err == nil {
+				assert.Equal(t, 1, result.Downloads)
+				assert.Equal(t, 2, result.MaxDownloads)
+			}
+		})
+	}
+}
+
+func TestLinkRepository_CountDownload(t *testing.T) {
+	query := "UPDATE `download_links` SET `downloads`=downloads + 1 WHERE id = ? AND (max_downloads = 0 OR downloads < max_downloads)"
+
+	testcases := map[string]struct {
+		rows   int64
+		result bool
+		err    error
+	}{
+		"counted":   {rows: 1, result: true},
+		"exhausted": {rows: 0, result: false},
+		"db error":  {err: testutil.ErrDB},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewLinkRepository()
+			mocks.SQLMock.ExpectBegin()
+			if tc.err != nil {
+				mocks.SQLMock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(3).WillReturnError(tc.err)
+				mocks.SQLMock.ExpectRollback()
+			} else {
+				mocks.SQLMock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(3).
+					WillReturnResult(sqlmock.NewResult(0, tc.rows))
+				mocks.SQLMock.ExpectCommit()
+			}
+
+			result, err := repo.CountDownload(context.Background(), 3)
+			testutil.AssertErrorExAc(t, tc.err, err)
+			assert.Equal(t, tc.result, result)
+		})
+	}
+}
+
+func TestLinkRepository_CountPlayback(t *testing.T) {
+	lockQuery := "SELECT `id` FROM `download_links` WHERE id = ? ORDER BY `download_links`.`id` LIMIT 1 FOR UPDATE"
+	playbackQuery := "SELECT * FROM `link_playbacks` WHERE link_id = ? AND client = ?"
+	countQuery := "UPDATE `download_links` SET `downloads`=downloads + 1 WHERE id = ? AND (max_downloads = 0 OR downloads < max_downloads)"
+	upsertQuery := "INSERT INTO `link_playbacks` (`link_id`,`client`,`expires_at`) VALUES (?,?,?) ON DUPLICATE KEY UPDATE `expires_at`=VALUES(`expires_at`)"
+	playbackColumns := []string{"link_id", "client", "expires_at"}
+	until := time.Now().Add(15 * time.Minute)
+
+	testcases := map[string]struct {
+		result bool
+		err    error
+		mockFn func(sqlmock.Sqlmock)
+	}{
+		"new playback": {
+			result: true,
+			mockFn: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectQuery(regexp.QuoteMeta(lockQuery)).WithArgs(3).
+					WillReturnRows(m.NewRows([]string{"id"}).AddRow(3))
+				m.ExpectQuery(regexp.QuoteMeta(playbackQuery)).WithArgs(3, "203.0.113.7").
+					WillReturnRows(m.NewRows(playbackColumns))
+				m.ExpectExec(regexp.QuoteMeta(countQuery)).WithArgs(3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectExec(regexp.QuoteMeta(upsertQuery)).WithArgs(3, "203.0.113.7", until).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectCommit()
+			},
+		},
+		"open playback": {
+			result: true,
+			mockFn: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectQuery(regexp.QuoteMeta(lockQuery)).WithArgs(3).
+					WillReturnRows(m.NewRows([]string{"id"}).AddRow(3))
+				m.ExpectQuery(regexp.QuoteMeta(playbackQuery)).WithArgs(3, "203.0.113.7").
+					WillReturnRows(m.NewRows(playbackColumns).AddRow(3, "203.0.113.7", time.Now().Add(time.Minute)))
+				m.ExpectExec(regexp.QuoteMeta(upsertQuery)).WithArgs(3, "203.0.113.7", until).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				m.ExpectCommit()
+			},
+		},
+		"expired playback": {
+			result: true,
+			mockFn: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectQuery(regexp.QuoteMeta(lockQuery)).WithArgs(3).
+					WillReturnRows(m.NewRows([]string{"id"}).AddRow(3))
+				m.ExpectQuery(regexp.QuoteMeta(playbackQuery)).WithArgs(3, "203.0.113.7").
+					WillReturnRows(m.NewRows(playbackColumns).AddRow(3, "203.0.113.7", time.Now().Add(-time.Minute)))
+				m.ExpectExec(regexp.QuoteMeta(countQuery)).WithArgs(3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectExec(regexp.QuoteMeta(upsertQuery)).WithArgs(3, "203.0.113.7", until).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				m.ExpectCommit()
+			},
+		},
+		"exhausted": {
+			result: false,
+			mockFn: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectQuery(regexp.QuoteMeta(lockQuery)).WithArgs(3).
+					WillReturnRows(m.NewRows([]string{"id"}).AddRow(3))
+				m.ExpectQuery(regexp.QuoteMeta(playbackQuery)).WithArgs(3, "203.0.113.7").
+					WillReturnRows(m.NewRows(playbackColumns))
+				m.ExpectExec(regexp.QuoteMeta(countQuery)).WithArgs(3).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				m.ExpectCommit()
+			},
+		},
+		"link not found": {
+			err: entity.ErrorLinkNotFound,
+			mockFn: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectQuery(regexp.QuoteMeta(lockQuery)).WithArgs(3).
+					WillReturnError(gorm.ErrRecordNotFound)
+				m.ExpectRollback()
+			},
+		},
+		"db error": {
+			err: testutil.ErrDB,
+			mockFn: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectQuery(regexp.QuoteMeta(lockQuery)).WithArgs(3).
+					WillReturnRows(m.NewRows([]string{"id"}).AddRow(3))
+				m.ExpectQuery(regexp.QuoteMeta(playbackQuery)).WithArgs(3, "203.0.113.7").
+					WillReturnError(testutil.ErrDB)
+				m.ExpectRollback()
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewLinkRepository()
+			tc.mockFn(mocks.SQLMock)
+
+			result, err := repo.CountPlayback(context.Background(), 3, "203.0.113.7", until)
+			testutil.AssertErrorExAc(t, tc.err, err)
+			assert.Equal(t, tc.result, result)
+			assert.NoError(t, mocks.SQLMock.ExpectationsWereMet())
+		})
+	}
+}
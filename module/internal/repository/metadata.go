@@ -0,0 +1,67 @@
+package repository
+
+//go:generate mockgen -source metadata.go -destination mock/metadata.go
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"video-server/module/entity"
+	"video-server/module/param"
+)
+
+type MetadataRepository interface {
+	CreateMetadata(ctx context.Context, params *param.CreateMetadata) (*entity.VideoMetadata, error)
+	GetMetadata(ctx context.Context, fileID int) (*entity.VideoMetadata, error)
+}
+
+type metadataRepository struct {
+	database *gorm.DB
+}
+
+func NewMetadataRepository(database *gorm.DB) *metadataRepository {
+	return &metadataRepository{
+		database: database,
+	}
+}
+
+// CreateMetadata stores the probe result of a file, replacing a previous one
+// so probing again is harmless.
+func (r *metadataRepository) CreateMetadata(ctx context.Context, params *param.CreateMetadata) (*entity.VideoMetadata, error) {
+	metadata := &entity.VideoMetadata{
+		FileID:     params.FileID,
+		Container:  params.Container,
+		Duration:   params.Duration,
+		Width:      params.Width,
+		Height:     params.Height,
+		FrameRate:  params.FrameRate,
+		Bitrate:    params.Bitrate,
+		VideoCodec: params.VideoCodec,
+		AudioCodec: params.AudioCodec,
+		Tracks:     params.Tracks,
+		CreatedAt:  time.Now(),
+	}
+	err := withTrace(ctx, r.database).Clauses(clause.OnConflict{UpdateAll: true}).Create(metadata).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+func (r *metadataRepository) GetMetadata(ctx context.Context, fileID int) (*entity.VideoMetadata, error) {
+	metadata := &entity.VideoMetadata{}
+	err := withTrace(ctx, r.database).Where("file_id = ?", fileID).First(metadata).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrorMetadataNotFound
+		}
+		return nil, err
+	}
+
+	return metadata, nil
+}
@@ -0,0 +1,134 @@
+package repository_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"video-server/internal/testutil"
+	"video-server/module/entity"
+	"video-server/module/fixture"
+	"video-server/module/param"
+)
+
+func TestMetadataRepository_CreateMetadata(t *testing.T) {
+	query := "INSERT INTO `video_metadata` (`file_id`,`container`,`duration`,`width`,`height`,`frame_rate`,`bitrate`,`video_codec`,`audio_codec`,`tracks`,`created_at`) VALUES (?,?,?,?,?,?,?,?,?,?,?) ON DUPLICATE KEY UPDATE `container`=VALUES(`container`),`duration`=VALUES(`duration`),`width`=VALUES(`width`),`height`=VALUES(`height`),`frame_rate`=VALUES(`frame_rate`),`bitrate`=VALUES(`bitrate`),`video_codec`=VALUES(`video_codec`),`audio_codec`=VALUES(`audio_codec`),`tracks`=VALUES(`tracks`)"
+
+	params := &param.CreateMetadata{
+		FileID:     1,
+		Container:  "mp4",
+		Duration:   5.5,
+		Width:      1920,
+		Height:     1080,
+		FrameRate:  30,
+		Bitrate:    4000000,
+		VideoCodec: "avc1.640028",
+		AudioCodec: "mp4a.40.2",
+		Tracks:     2,
+	}
+
+	type Response struct {
+		result interface{}
+		err    error
+	}
+
+	testcases := map[string]struct {
+		response Response
+		mockFn   func(*fixture.MockMetadataRepository)
+	}{
+		"success": {
+			response: Response{
+				result: map[string]interface{}{"FileID": 1, "Container": "mp4", "Width": 1920},
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockMetadataRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs(1, "mp4", 5.5, 1920, 1080, 30.0, 4000000, "avc1.640028", "mp4a.40.2", 2, testutil.AnyTime{}).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.SQLMock.ExpectCommit()
+			},
+		},
+		"db error": {
+			response: Response{
+				result: nil,
+				err:    testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockMetadataRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WillReturnError(testutil.ErrDB)
+				m.SQLMock.ExpectRollback()
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewMetadataRepository()
+			tc.mockFn(mocks)
+			result, err := repo.CreateMetadata(context.Background(), params)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			testutil.AssertStructExAc(t, tc.response.result, result)
+		})
+	}
+}
+
+func TestMetadataRepository_GetMetadata(t *testing.T) {
+	rowColumns := []string{"file_id", "container", "duration", "width", "height", "frame_rate", "bitrate", "video_codec", "audio_codec", "tracks", "created_at"}
+	query := "SELECT * FROM `video_metadata` WHERE file_id = ? ORDER BY `video_metadata`.`file_id` LIMIT 1"
+
+	type Response struct {
+		result interface{}
+		err    error
+	}
+
+	testcases := map[string]struct {
+		response Response
+		mockFn   func(*fixture.MockMetadataRepository)
+	}{
+		"success": {
+			response: Response{
+				result: map[string]interface{}{"FileID": 1, "VideoCodec": "avc1.640028", "Bitrate": int64(4000000)},
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockMetadataRepository) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs(1).
+					WillReturnRows(m.SQLMock.NewRows(rowColumns).
+						AddRow(1, "mp4", 5.5, 1920, 1080, 30.0, 4000000, "avc1.640028", "mp4a.40.2", 2, testutil.CreatedAt))
+			},
+		},
+		"not found": {
+			response: Response{
+				result: nil,
+				err:    entity.ErrorMetadataNotFound,
+			},
+			mockFn: func(m *fixture.MockMetadataRepository) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs(1).
+					WillReturnRows(m.SQLMock.NewRows(rowColumns))
+			},
+		},
+		"db error": {
+			response: Response{
+				result: nil,
+				err:    testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockMetadataRepository) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).
+					WillReturnError(testutil.ErrDB)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewMetadataRepository()
+			tc.mockFn(mocks)
+			result, err := repo.GetMetadata(context.Background(), 1)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			testutil.AssertStructExAc(t, tc.response.result, result)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: blob.go
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockBlobRepository is a mock of BlobRepository interface.
+type MockBlobRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockBlobRepositoryMockRecorder
+}
+
+// MockBlobRepositoryMockRecorder is the mock recorder for MockBlobRepository.
+type MockBlobRepositoryMockRecorder struct {
+	mock *MockBlobRepository
+}
+
+// NewMockBlobRepository creates a new mock instance.
+func NewMockBlobRepository(ctrl *gomock.Controller) *MockBlobRepository {
+	mock := &MockBlobRepository{ctrl: ctrl}
+	mock.recorder = &MockBlobRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBlobRepository) EXPECT() *MockBlobRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AcquireBlob mocks base method.
+func (m *MockBlobRepository) AcquireBlob(ctx context.Context, tenant, digest string, size int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcquireBlob", ctx, tenant, digest, size)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AcquireBlob indicates an expected call of AcquireBlob.
+func (mr *MockBlobRepositoryMockRecorder) AcquireBlob(ctx, tenant, digest, size interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcquireBlob", reflect.TypeOf((*MockBlobRepository)(nil).AcquireBlob), ctx, tenant, digest, size)
+}
+
+// DeleteBlob mocks base method.
+func (m *MockBlobRepository) DeleteBlob(ctx context.Context, tenant, digest string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBlob", ctx, tenant, digest)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBlob indicates an expected call of DeleteBlob.
+func (mr *MockBlobRepositoryMockRecorder) DeleteBlob(ctx, tenant, digest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBlob", reflect.TypeOf((*MockBlobRepository)(nil).DeleteBlob), ctx, tenant, digest)
+}
+
+// ReleaseBlob mocks base method.
+func (m *MockBlobRepository) ReleaseBlob(ctx context.Context, tenant, digest string, deleteContent func() error) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseBlob", ctx, tenant, digest, deleteContent)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseBlob indicates an expected call of ReleaseBlob.
+func (mr *MockBlobRepositoryMockRecorder) ReleaseBlob(ctx, tenant, digest, deleteContent interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseBlob", reflect.TypeOf((*MockBlobRepository)(nil).ReleaseBlob), ctx, tenant, digest, deleteContent)
+}
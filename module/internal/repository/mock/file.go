@@ -7,9 +7,9 @@ package mock_repository
 import (
 	context "context"
 	reflect "reflect"
-
 	entity "video-server/module/entity"
 	param "video-server/module/param"
+
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -36,6 +36,36 @@ func (m *MockFileRepository) EXPECT() *MockFileRepositoryMockRecorder {
 	return m.recorder
 }
 
+// CountFiles mocks base method.
+func (m *MockFileRepository) CountFiles(ctx context.Context, filter *param.FileFilter) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountFiles", ctx, filter)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountFiles indicates an expected call of CountFiles.
+func (mr *MockFileRepositoryMockRecorder) CountFiles(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountFiles", reflect.TypeOf((*MockFileRepository)(nil).CountFiles), ctx, filter)
+}
+
+// CountFilesByDigest mocks base method.
+func (m *MockFileRepository) CountFilesByDigest(ctx context.Context, tenant, digest string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountFilesByDigest", ctx, tenant, digest)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountFilesByDigest indicates an expected call of CountFilesByDigest.
+func (mr *MockFileRepositoryMockRecorder) CountFilesByDigest(ctx, tenant, digest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountFilesByDigest", reflect.TypeOf((*MockFileRepository)(nil).CountFilesByDigest), ctx, tenant, digest)
+}
+
 // CreateFile mocks base method.
 func (m *MockFileRepository) CreateFile(ctx context.Context, params *param.CreateFile) (*entity.File, error) {
 	m.ctrl.T.Helper()
@@ -65,32 +95,111 @@ func (mr *MockFileRepositoryMockRecorder) DeleteFile(ctx, id interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFile", reflect.TypeOf((*MockFileRepository)(nil).DeleteFile), ctx, id)
 }
 
+// FindFileByName mocks base method.
+func (m *MockFileRepository) FindFileByName(ctx context.Context, tenant, name string) (*entity.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindFileByName", ctx, tenant, name)
+	ret0, _ := ret[0].(*entity.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindFileByName indicates an expected call of FindFileByName.
+func (mr *MockFileRepositoryMockRecorder) FindFileByName(ctx, tenant, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindFileByName", reflect.TypeOf((*MockFileRepository)(nil).FindFileByName), ctx, tenant, name)
+}
+
 // GetFile mocks base method.
-func (m *MockFileRepository) GetFile(ctx context.Context, id int) (*entity.File, error) {
+func (m *MockFileRepository) GetFile(ctx context.Context, id int, statuses ...string) (*entity.File, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetFile", ctx, id)
+	varargs := []interface{}{ctx, id}
+	for _, a := range statuses {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetFile", varargs...)
 	ret0, _ := ret[0].(*entity.File)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetFile indicates an expected call of GetFile.
-func (mr *MockFileRepositoryMockRecorder) GetFile(ctx, id interface{}) *gomock.Call {
+func (mr *MockFileRepositoryMockRecorder) GetFile(ctx, id interface{}, statuses ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, id}, statuses...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFile", reflect.TypeOf((*MockFileRepository)(nil).GetFile), varargs...)
+}
+
+// GetUsage mocks base method.
+func (m *MockFileRepository) GetUsage(ctx context.Context, tenant string) (*entity.Usage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsage", ctx, tenant)
+	ret0, _ := ret[0].(*entity.Usage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsage indicates an expected call of GetUsage.
+func (mr *MockFileRepositoryMockRecorder) GetUsage(ctx, tenant interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFile", reflect.TypeOf((*MockFileRepository)(nil).GetFile), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsage", reflect.TypeOf((*MockFileRepository)(nil).GetUsage), ctx, tenant)
 }
 
 // ListFiles mocks base method.
-func (m *MockFileRepository) ListFiles(ctx context.Context) ([]*entity.File, error) {
+func (m *MockFileRepository) ListFiles(ctx context.Context, params *param.ListFiles) ([]*entity.File, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListFiles", ctx)
+	ret := m.ctrl.Call(m, "ListFiles", ctx, params)
 	ret0, _ := ret[0].([]*entity.File)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListFiles indicates an expected call of ListFiles.
-func (mr *MockFileRepositoryMockRecorder) ListFiles(ctx interface{}) *gomock.Call {
+func (mr *MockFileRepositoryMockRecorder) ListFiles(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFiles", reflect.TypeOf((*MockFileRepository)(nil).ListFiles), ctx, params)
+}
+
+// ListUsage mocks base method.
+func (m *MockFileRepository) ListUsage(ctx context.Context) ([]*entity.Usage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsage", ctx)
+	ret0, _ := ret[0].([]*entity.Usage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsage indicates an expected call of ListUsage.
+func (mr *MockFileRepositoryMockRecorder) ListUsage(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsage", reflect.TypeOf((*MockFileRepository)(nil).ListUsage), ctx)
+}
+
+// ScanFiles mocks base method.
+func (m *MockFileRepository) ScanFiles(ctx context.Context, afterID, limit int) ([]*entity.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScanFiles", ctx, afterID, limit)
+	ret0, _ := ret[0].([]*entity.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScanFiles indicates an expected call of ScanFiles.
+func (mr *MockFileRepositoryMockRecorder) ScanFiles(ctx, afterID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScanFiles", reflect.TypeOf((*MockFileRepository)(nil).ScanFiles), ctx, afterID, limit)
+}
+
+// UpdateFileStatus mocks base method.
+func (m *MockFileRepository) UpdateFileStatus(ctx context.Context, id int, status string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFileStatus", ctx, id, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateFileStatus indicates an expected call of UpdateFileStatus.
+func (mr *MockFileRepositoryMockRecorder) UpdateFileStatus(ctx, id, status interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFiles", reflect.TypeOf((*MockFileRepository)(nil).ListFiles), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFileStatus", reflect.TypeOf((*MockFileRepository)(nil).UpdateFileStatus), ctx, id, status)
 }
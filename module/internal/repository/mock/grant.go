@@ -0,0 +1,95 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: grant.go
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	context "context"
+	reflect "reflect"
+	entity "video-server/module/entity"
+	param "video-server/module/param"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockGrantRepository is a mock of GrantRepository interface.
+type MockGrantRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockGrantRepositoryMockRecorder
+}
+
+// MockGrantRepositoryMockRecorder is the mock recorder for MockGrantRepository.
+type MockGrantRepositoryMockRecorder struct {
+	mock *MockGrantRepository
+}
+
+// NewMockGrantRepository creates a new mock instance.
+func NewMockGrantRepository(ctrl *gomock.Controller) *MockGrantRepository {
+	mock := &MockGrantRepository{ctrl: ctrl}
+	mock.recorder = &MockGrantRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGrantRepository) EXPECT() *MockGrantRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateGrant mocks base method.
+func (m *MockGrantRepository) CreateGrant(ctx context.Context, params *param.CreateGrant) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGrant", ctx, params)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateGrant indicates an expected call of CreateGrant.
+func (mr *MockGrantRepositoryMockRecorder) CreateGrant(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGrant", reflect.TypeOf((*MockGrantRepository)(nil).CreateGrant), ctx, params)
+}
+
+// DeleteGrant mocks base method.
+func (m *MockGrantRepository) DeleteGrant(ctx context.Context, fileID int, subject string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGrant", ctx, fileID, subject)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGrant indicates an expected call of DeleteGrant.
+func (mr *MockGrantRepositoryMockRecorder) DeleteGrant(ctx, fileID, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGrant", reflect.TypeOf((*MockGrantRepository)(nil).DeleteGrant), ctx, fileID, subject)
+}
+
+// HasGrant mocks base method.
+func (m *MockGrantRepository) HasGrant(ctx context.Context, fileID int, subject string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasGrant", ctx, fileID, subject)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasGrant indicates an expected call of HasGrant.
+func (mr *MockGrantRepositoryMockRecorder) HasGrant(ctx, fileID, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasGrant", reflect.TypeOf((*MockGrantRepository)(nil).HasGrant), ctx, fileID, subject)
+}
+
+// ListGrants mocks base method.
+func (m *MockGrantRepository) ListGrants(ctx context.Context, fileID int) ([]*entity.FileGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGrants", ctx, fileID)
+	ret0, _ := ret[0].([]*entity.FileGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGrants indicates an expected call of ListGrants.
+func (mr *MockGrantRepositoryMockRecorder) ListGrants(ctx, fileID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGrants", reflect.TypeOf((*MockGrantRepository)(nil).ListGrants), ctx, fileID)
+}
@@ -0,0 +1,98 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: link.go
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+	entity "video-server/module/entity"
+	param "video-server/module/param"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockLinkRepository is a mock of LinkRepository interface.
+type MockLinkRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockLinkRepositoryMockRecorder
+}
+
+// MockLinkRepositoryMockRecorder is the mock recorder for MockLinkRepository.
+type MockLinkRepositoryMockRecorder struct {
+	mock *MockLinkRepository
+}
+
+// NewMockLinkRepository creates a new mock instance.
+func NewMockLinkRepository(ctrl *gomock.Controller) *MockLinkRepository {
+	mock := &MockLinkRepository{ctrl: ctrl}
+	mock.recorder = &MockLinkRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLinkRepository) EXPECT() *MockLinkRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CountDownload mocks base method.
+func (m *MockLinkRepository) CountDownload(ctx context.Context, id int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountDownload", ctx, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountDownload indicates an expected call of CountDownload.
+func (mr *MockLinkRepositoryMockRecorder) CountDownload(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountDownload", reflect.TypeOf((*MockLinkRepository)(nil).CountDownload), ctx, id)
+}
+
+// CountPlayback mocks base method.
+func (m *MockLinkRepository) CountPlayback(ctx context.Context, id int, client string, until time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountPlayback", ctx, id, client, until)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountPlayback indicates an expected call of CountPlayback.
+func (mr *MockLinkRepositoryMockRecorder) CountPlayback(ctx, id, client, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPlayback", reflect.TypeOf((*MockLinkRepository)(nil).CountPlayback), ctx, id, client, until)
+}
+
+// CreateLink mocks base method.
+func (m *MockLinkRepository) CreateLink(ctx context.Context, params *param.CreateLink) (*entity.DownloadLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLink", ctx, params)
+	ret0, _ := ret[0].(*entity.DownloadLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateLink indicates an expected call of CreateLink.
+func (mr *MockLinkRepositoryMockRecorder) CreateLink(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLink", reflect.TypeOf((*MockLinkRepository)(nil).CreateLink), ctx, params)
+}
+
+// GetLink mocks base method.
+func (m *MockLinkRepository) GetLink(ctx context.Context, id int) (*entity.DownloadLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLink", ctx, id)
+	ret0, _ := ret[0].(*entity.DownloadLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLink indicates an expected call of GetLink.
+func (mr *MockLinkRepositoryMockRecorder) GetLink(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLink", reflect.TypeOf((*MockLinkRepository)(nil).GetLink), ctx, id)
+}
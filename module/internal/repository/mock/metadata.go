@@ -0,0 +1,67 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: metadata.go
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	context "context"
+	reflect "reflect"
+	entity "video-server/module/entity"
+	param "video-server/module/param"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockMetadataRepository is a mock of MetadataRepository interface.
+type MockMetadataRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockMetadataRepositoryMockRecorder
+}
+
+// MockMetadataRepositoryMockRecorder is the mock recorder for MockMetadataRepository.
+type MockMetadataRepositoryMockRecorder struct {
+	mock *MockMetadataRepository
+}
+
+// NewMockMetadataRepository creates a new mock instance.
+func NewMockMetadataRepository(ctrl *gomock.Controller) *MockMetadataRepository {
+	mock := &MockMetadataRepository{ctrl: ctrl}
+	mock.recorder = &MockMetadataRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMetadataRepository) EXPECT() *MockMetadataRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateMetadata mocks base method.
+func (m *MockMetadataRepository) CreateMetadata(ctx context.Context, params *param.CreateMetadata) (*entity.VideoMetadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMetadata", ctx, params)
+	ret0, _ := ret[0].(*entity.VideoMetadata)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMetadata indicates an expected call of CreateMetadata.
+func (mr *MockMetadataRepositoryMockRecorder) CreateMetadata(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMetadata", reflect.TypeOf((*MockMetadataRepository)(nil).CreateMetadata), ctx, params)
+}
+
+// GetMetadata mocks base method.
+func (m *MockMetadataRepository) GetMetadata(ctx context.Context, fileID int) (*entity.VideoMetadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMetadata", ctx, fileID)
+	ret0, _ := ret[0].(*entity.VideoMetadata)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMetadata indicates an expected call of GetMetadata.
+func (mr *MockMetadataRepositoryMockRecorder) GetMetadata(ctx, fileID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetadata", reflect.TypeOf((*MockMetadataRepository)(nil).GetMetadata), ctx, fileID)
+}
@@ -0,0 +1,153 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: upload.go
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+	entity "video-server/module/entity"
+	param "video-server/module/param"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockUploadRepository is a mock of UploadRepository interface.
+type MockUploadRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockUploadRepositoryMockRecorder
+}
+
+// MockUploadRepositoryMockRecorder is the mock recorder for MockUploadRepository.
+type MockUploadRepositoryMockRecorder struct {
+	mock *MockUploadRepository
+}
+
+// NewMockUploadRepository creates a new mock instance.
+func NewMockUploadRepository(ctrl *gomock.Controller) *MockUploadRepository {
+	mock := &MockUploadRepository{ctrl: ctrl}
+	mock.recorder = &MockUploadRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUploadRepository) EXPECT() *MockUploadRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ClaimUpload mocks base method.
+func (m *MockUploadRepository) ClaimUpload(ctx context.Context, id string, staleBefore time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimUpload", ctx, id, staleBefore)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClaimUpload indicates an expected call of ClaimUpload.
+func (mr *MockUploadRepositoryMockRecorder) ClaimUpload(ctx, id, staleBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimUpload", reflect.TypeOf((*MockUploadRepository)(nil).ClaimUpload), ctx, id, staleBefore)
+}
+
+// CompleteUpload mocks base method.
+func (m *MockUploadRepository) CompleteUpload(ctx context.Context, id string, fileID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteUpload", ctx, id, fileID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteUpload indicates an expected call of CompleteUpload.
+func (mr *MockUploadRepositoryMockRecorder) CompleteUpload(ctx, id, fileID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteUpload", reflect.TypeOf((*MockUploadRepository)(nil).CompleteUpload), ctx, id, fileID)
+}
+
+// CreateUpload mocks base method.
+func (m *MockUploadRepository) CreateUpload(ctx context.Context, params *param.CreateUpload) (*entity.Upload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUpload", ctx, params)
+	ret0, _ := ret[0].(*entity.Upload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUpload indicates an expected call of CreateUpload.
+func (mr *MockUploadRepositoryMockRecorder) CreateUpload(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUpload", reflect.TypeOf((*MockUploadRepository)(nil).CreateUpload), ctx, params)
+}
+
+// DeleteUpload mocks base method.
+func (m *MockUploadRepository) DeleteUpload(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUpload", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUpload indicates an expected call of DeleteUpload.
+func (mr *MockUploadRepositoryMockRecorder) DeleteUpload(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUpload", reflect.TypeOf((*MockUploadRepository)(nil).DeleteUpload), ctx, id)
+}
+
+// GetUpload mocks base method.
+func (m *MockUploadRepository) GetUpload(ctx context.Context, id string) (*entity.Upload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUpload", ctx, id)
+	ret0, _ := ret[0].(*entity.Upload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUpload indicates an expected call of GetUpload.
+func (mr *MockUploadRepositoryMockRecorder) GetUpload(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUpload", reflect.TypeOf((*MockUploadRepository)(nil).GetUpload), ctx, id)
+}
+
+// ReleaseUpload mocks base method.
+func (m *MockUploadRepository) ReleaseUpload(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseUpload", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseUpload indicates an expected call of ReleaseUpload.
+func (mr *MockUploadRepositoryMockRecorder) ReleaseUpload(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseUpload", reflect.TypeOf((*MockUploadRepository)(nil).ReleaseUpload), ctx, id)
+}
+
+// ScanUploads mocks base method.
+func (m *MockUploadRepository) ScanUploads(ctx context.Context, afterID string, limit int) ([]*entity.Upload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScanUploads", ctx, afterID, limit)
+	ret0, _ := ret[0].([]*entity.Upload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScanUploads indicates an expected call of ScanUploads.
+func (mr *MockUploadRepositoryMockRecorder) ScanUploads(ctx, afterID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScanUploads", reflect.TypeOf((*MockUploadRepository)(nil).ScanUploads), ctx, afterID, limit)
+}
+
+// UpdateUploadOffset mocks base method.
+func (m *MockUploadRepository) UpdateUploadOffset(ctx context.Context, id string, from, to int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUploadOffset", ctx, id, from, to)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUploadOffset indicates an expected call of UpdateUploadOffset.
+func (mr *MockUploadRepositoryMockRecorder) UpdateUploadOffset(ctx, id, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUploadOffset", reflect.TypeOf((*MockUploadRepository)(nil).UpdateUploadOffset), ctx, id, from, to)
+}
@@ -0,0 +1,151 @@
+package repository
+
+//go:generate mockgen -source upload.go -destination mock/upload.go
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"video-server/module/entity"
+	"video-server/module/param"
+)
+
+var (
+	UploadColumnsInsert = []string{
+		"id",
+		"name",
+		"length",
+		"offset",
+		"metadata",
+		"owner_id",
+		"tenant_id",
+		"created_at",
+		"updated_at",
+	}
+	UploadColumns = append([]string{"file_id"}, UploadColumnsInsert...)
+)
+
+type UploadRepository interface {
+	CreateUpload(ctx context.Context, params *param.CreateUpload) (*entity.Upload, error)
+	GetUpload(ctx context.Context, id string) (*entity.Upload, error)
+	UpdateUploadOffset(ctx context.Context, id string, from int64, to int64) error
+	ClaimUpload(ctx context.Context, id string, staleBefore time.Time) error
+	ReleaseUpload(ctx context.Context, id string) error
+	CompleteUpload(ctx context.Context, id string, fileID int) error
+	DeleteUpload(ctx context.Context, id string) error
+	ScanUploads(ctx context.Context, afterID string, limit int) ([]*entity.Upload, error)
+}
+
+type uploadRepository struct {
+	database *gorm.DB
+}
+
+func NewUploadRepository(database *gorm.DB) *uploadRepository {
+	return &uploadRepository{
+		database: database,
+	}
+}
+
+func (r *uploadRepository) CreateUpload(ctx context.Context, params *param.CreateUpload) (*entity.Upload, error) {
+	timeNow := time.Now()
+	upload := &entity.Upload{
+		ID:        uuid.NewString(),
+		Name:      params.Name,
+		Length:    params.Length,
+		Offset:    0,
+		Metadata:  params.Metadata,
+		OwnerID:   params.OwnerID,
+		TenantID:  params.TenantID,
+		CreatedAt: timeNow,
+		UpdatedAt: timeNow,
+	}
+	err := withTrace(ctx, r.database).Select(UploadColumnsInsert).Create(upload).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+func (r *uploadRepository) GetUpload(ctx context.Context, id string) (*entity.Upload, error) {
+	upload := &entity.Upload{}
+	err := withTrace(ctx, r.database).Select(UploadColumns).Where("id = ?", id).First(upload).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrorUploadNotFound
+		}
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+// UpdateUploadOffset moves the offset forward only if nobody else did first,
+// so concurrent PATCH requests for the same upload cannot both succeed.
+func (r *uploadRepository) UpdateUploadOffset(ctx context.Context, id string, from int64, to int64) error {
+	result := withTrace(ctx, r.database).Model(&entity.Upload{}).
+		Where("id = ? AND `offset` = ?", id, from).
+		Updates(map[string]interface{}{"offset": to, "updated_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return entity.ErrorUploadOffsetMismatch
+	}
+
+	return nil
+}
+
+// ClaimUpload lets a single request create the file of an upload. It fails
+// with ErrorUploadCompleting once the file exists or while another claim
+// made after staleBefore holds the upload.
+func (r *uploadRepository) ClaimUpload(ctx context.Context, id string, staleBefore time.Time) error {
+	timeNow := time.Now()
+	result := withTrace(ctx, r.database).Model(&entity.Upload{}).
+		Where("id = ? AND file_id IS NULL AND (completing_at IS NULL OR completing_at < ?)", id, staleBefore).
+		Updates(map[string]interface{}{"completing_at": timeNow, "updated_at": timeNow})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return entity.ErrorUploadCompleting
+	}
+
+	return nil
+}
+
+// ReleaseUpload drops the claim of a completion that failed, for the client
+// to retry.
+func (r *uploadRepository) ReleaseUpload(ctx context.Context, id string) error {
+	return withTrace(ctx, r.database).Model(&entity.Upload{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"completing_at": nil, "updated_at": time.Now()}).Error
+}
+
+func (r *uploadRepository) CompleteUpload(ctx context.Context, id string, fileID int) error {
+	return withTrace(ctx, r.database).Model(&entity.Upload{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"file_id": fileID, "updated_at": time.Now()}).Error
+}
+
+func (r *uploadRepository) DeleteUpload(ctx context.Context, id string) error {
+	upload := &entity.Upload{ID: id}
+
+	return withTrace(ctx, r.database).Delete(upload).Error
+}
+
+// ScanUploads pages through every upload, complete or not, in id order.
+func (r *uploadRepository) ScanUploads(ctx context.Context, afterID string, limit int) ([]*entity.Upload, error) {
+	uploads := []*entity.Upload{}
+	err := withTrace(ctx, r.database).Select(UploadColumns).
+		Where("id > ?", afterID).
+		Order("id").
+		Limit(limit).
+		Find(&uploads).Error
+
+	return uploads, err
+}
@@ -0,0 +1,322 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/gorm"
+
+	"video-server/internal/testutil"
+	"video-server/module/entity"
+	"video-server/module/fixture"
+	"video-server/module/param"
+)
+
+func TestUploadRepository_CreateUpload(t *testing.T) {
+	query := "INSERT INTO `uploads` (`id`,`name`,`length`,`offset`,`metadata`,`owner_id`,`tenant_id`,`created_at`,`updated_at`) VALUES (?,?,?,?,?,?,?,?,?)"
+
+	type Request struct {
+		params *param.CreateUpload
+	}
+
+	type Response struct {
+		result interface{}
+		err    error
+	}
+
+	testcases := map[string]struct {
+		request  Request
+		response Response
+		mockFn   func(*fixture.MockUploadRepository, Request)
+	}{
+		"success": {
+			request: Request{
+				params: &param.CreateUpload{
+					Name:     "sample.mp4",
+					Length:   100,
+					Metadata: "filename c2FtcGxlLm1wNA==",
+				},
+			},
+			response: Response{
+				result: map[string]interface{}{"Name": "sample.mp4", "Length": int64(100), "Offset": int64(0)},
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockUploadRepository, req Request) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs(testutil.AnyString{}, "sample.mp4", 100, 0, "filename c2FtcGxlLm1wNA==", "", "", testutil.AnyTime{}, testutil.AnyTime{}).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				m.SQLMock.ExpectCommit()
+			},
+		},
+		"db error": {
+			request: Request{
+				params: &param.CreateUpload{
+					Name:   "sample.mp4",
+					Length: 100,
+				},
+			},
+			response: Response{
+				result: nil,
+				err:    testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockUploadRepository, req Request) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WillReturnError(testutil.ErrDB)
+				m.SQLMock.ExpectRollback()
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewUploadRepository()
+			tc.mockFn(mocks, tc.request)
+			result, err := repo.CreateUpload(context.Background(), tc.request.params)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			testutil.AssertStructExAc(t, tc.response.result, result)
+		})
+	}
+}
+
+func TestUploadRepository_GetUpload(t *testing.T) {
+	rowColumns := []string{"file_id", "id", "name", "length", "offset", "metadata", "created_at", "updated_at"}
+	rowValues := []driver.Value{nil, "some-id", "sample.mp4", 100, 50, "", testutil.CreatedAt, testutil.UpdatedAt}
+	query := "SELECT `file_id`,`id`,`name`,`length`,`offset`,`metadata`,`owner_id`,`tenant_id`,`created_at`,`updated_at` FROM `uploads` WHERE id = ?"
+
+	type Response struct {
+		result interface{}
+		err    error
+	}
+
+	testcases := map[string]struct {
+		response Response
+		mockFn   func(*fixture.MockUploadRepository)
+	}{
+		"success": {
+			response: Response{
+				result: map[string]interface{}{"ID": "some-id", "Offset": int64(50)},
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockUploadRepository) {
+				rows := m.SQLMock.NewRows(rowColumns)
+				rows.AddRow(rowValues...)
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("some-id").WillReturnRows(rows)
+			},
+		},
+		"db error not found": {
+			response: Response{
+				result: nil,
+				err:    entity.ErrorUploadNotFound,
+			},
+			mockFn: func(m *fixture.MockUploadRepository) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(gorm.ErrRecordNotFound)
+			},
+		},
+		"db error others": {
+			response: Response{
+				result: nil,
+				err:    testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockUploadRepository) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(testutil.ErrDB)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewUploadRepository()
+			tc.mockFn(mocks)
+			result, err := repo.GetUpload(context.Background(), "some-id")
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			testutil.AssertStructExAc(t, tc.response.result, result)
+		})
+	}
+}
+
+func TestUploadRepository_UpdateUploadOffset(t *testing.T) {
+	query := "UPDATE `uploads` SET `offset`=?,`updated_at`=? WHERE id = ? AND `offset` = ?"
+
+	testcases := map[string]struct {
+		err    error
+		mockFn func(*fixture.MockUploadRepository)
+	}{
+		"success": {
+			err: nil,
+			mockFn: func(m *fixture.MockUploadRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs(100, testutil.AnyTime{}, "some-id", 50).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.SQLMock.ExpectCommit()
+			},
+		},
+		"offset moved": {
+			err: entity.ErrorUploadOffsetMismatch,
+			mockFn: func(m *fixture.MockUploadRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs(100, testutil.AnyTime{}, "some-id", 50).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				m.SQLMock.ExpectCommit()
+			},
+		},
+		"db error": {
+			err: testutil.ErrDB,
+			mockFn: func(m *fixture.MockUploadRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WillReturnError(testutil.ErrDB)
+				m.SQLMock.ExpectRollback()
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewUploadRepository()
+			tc.mockFn(mocks)
+			err := repo.UpdateUploadOffset(context.Background(), "some-id", 50, 100)
+			testutil.AssertErrorExAc(t, tc.err, err)
+		})
+	}
+}
+
+func TestUploadRepository_ClaimUpload(t *testing.T) {
+	query := "UPDATE `uploads` SET `completing_at`=?,`updated_at`=? WHERE id = ? AND file_id IS NULL AND (completing_at IS NULL OR completing_at < ?)"
+	staleBefore := time.Now().Add(-time.Hour)
+
+	testcases := map[string]struct {
+		err    error
+		mockFn func(*fixture.MockUploadRepository)
+	}{
+		"success": {
+			err: nil,
+			mockFn: func(m *fixture.MockUploadRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs(testutil.AnyTime{}, testutil.AnyTime{}, "some-id", staleBefore).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.SQLMock.ExpectCommit()
+			},
+		},
+		"claimed": {
+			err: entity.ErrorUploadCompleting,
+			mockFn: func(m *fixture.MockUploadRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs(testutil.AnyTime{}, testutil.AnyTime{}, "some-id", staleBefore).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				m.SQLMock.ExpectCommit()
+			},
+		},
+		"db error": {
+			err: testutil.ErrDB,
+			mockFn: func(m *fixture.MockUploadRepository) {
+				m.SQLMock.ExpectBegin()
+				m.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+					WillReturnError(testutil.ErrDB)
+				m.SQLMock.ExpectRollback()
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewUploadRepository()
+			tc.mockFn(mocks)
+			err := repo.ClaimUpload(context.Background(), "some-id", staleBefore)
+			testutil.AssertErrorExAc(t, tc.err, err)
+		})
+	}
+}
+
+func TestUploadRepository_ReleaseUpload(t *testing.T) {
+	query := "UPDATE `uploads` SET `completing_at`=?,`updated_at`=? WHERE id = ?"
+
+	repo, mocks := fixture.NewUploadRepository()
+	mocks.SQLMock.ExpectBegin()
+	mocks.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs(nil, testutil.AnyTime{}, "some-id").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mocks.SQLMock.ExpectCommit()
+
+	err := repo.ReleaseUpload(context.Background(), "some-id")
+	testutil.AssertErrorExAc(t, nil, err)
+}
+
+func TestUploadRepository_DeleteUpload(t *testing.T) {
+	query := "DELETE FROM `uploads` WHERE `uploads`.`id` = ?"
+
+	repo, mocks := fixture.NewUploadRepository()
+	mocks.SQLMock.ExpectBegin()
+	mocks.SQLMock.ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs("some-id").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mocks.SQLMock.ExpectCommit()
+
+	err := repo.DeleteUpload(context.Background(), "some-id")
+	testutil.AssertErrorExAc(t, nil, err)
+}
+
+func TestUploadRepository_ScanUploads(t *testing.T) {
+	rowColumns := []string{"file_id", "id", "name", "length", "offset", "metadata", "created_at", "updated_at"}
+	rowValues := []driver.Value{nil, "some-id", "sample.mp4", 100, 50, "", testutil.CreatedAt, testutil.UpdatedAt}
+	query := "SELECT `file_id`,`id`,`name`,`length`,`offset`,`metadata`,`owner_id`,`tenant_id`,`created_at`,`updated_at` FROM `uploads` WHERE id > ? ORDER BY id LIMIT 50"
+
+	type Response struct {
+		result []*entity.Upload
+		err    error
+	}
+
+	testcases := map[string]struct {
+		response Response
+		mockFn   func(*fixture.MockUploadRepository)
+	}{
+		"success": {
+			response: Response{
+				result: []*entity.Upload{
+					{
+						ID:        "some-id",
+						Name:      "sample.mp4",
+						Length:    100,
+						Offset:    50,
+						CreatedAt: testutil.CreatedAt,
+						UpdatedAt: testutil.UpdatedAt,
+					},
+				},
+				err: nil,
+			},
+			mockFn: func(m *fixture.MockUploadRepository) {
+				rows := m.SQLMock.NewRows(rowColumns)
+				rows.AddRow(rowValues...)
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs("after-id").
+					WillReturnRows(rows)
+			},
+		},
+		"db error": {
+			response: Response{
+				result: nil,
+				err:    testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockUploadRepository) {
+				m.SQLMock.ExpectQuery(regexp.QuoteMeta(query)).
+					WillReturnError(testutil.ErrDB)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			repo, mocks := fixture.NewUploadRepository()
+			tc.mockFn(mocks)
+			result, err := repo.ScanUploads(context.Background(), "after-id", 50)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			if len(tc.response.result) > 0 {
+				testutil.AssertStructExAc(t, tc.response.result[0], result[0])
+			}
+		})
+	}
+}
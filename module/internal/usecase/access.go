@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"context"
+
+	"video-server/module/entity"
+	repository "video-server/module/internal/repository"
+	"video-server/module/param"
+)
+
+// fileAccess enforces who may see and act on a file. Files never cross
+// tenants, within one admins reach every file while others reach the files
+// they own or were granted, and download links the file they were signed
+// for. Contexts without a principal, with auth disabled or in background
+// jobs, may do anything within the tenant they were scoped to, if any.
+type fileAccess struct {
+	grant repository.GrantRepository
+}
+
+// read returns ErrorFileNotFound for a file the caller may not see, so its
+// existence doesn't leak.
+func (a fileAccess) read(ctx context.Context, file *entity.File) error {
+	if tenant, ok := entity.TenantFromContext(ctx); ok && tenant != file.TenantID {
+		return entity.ErrorFileNotFound
+	}
+	principal := entity.PrincipalFromContext(ctx)
+	if principal == nil {
+		return nil
+	}
+	if principal.FileID != 0 {
+		if principal.FileID != file.ID {
+			return entity.ErrorFileNotFound
+		}
+		return nil
+	}
+	if principal.HasRole(entity.RoleAdmin) || (file.OwnerID != "" && file.OwnerID == principal.Subject) {
+		return nil
+	}
+
+	granted, err := a.grant.HasGrant(ctx, file.ID, principal.Subject)
+	if err != nil {
+		return err
+	}
+	if !granted {
+		return entity.ErrorFileNotFound
+	}
+	return nil
+}
+
+// write allows owners holding the uploader role and admins to delete and
+// share a file. Callers that may only see the file get ErrorForbidden.
+func (a fileAccess) write(ctx context.Context, file *entity.File) error {
+	err := a.read(ctx, file)
+	if err != nil {
+		return err
+	}
+
+	principal := entity.PrincipalFromContext(ctx)
+	if principal == nil || principal.HasRole(entity.RoleAdmin) ||
+		(file.OwnerID == principal.Subject && principal.HasRole(entity.RoleUploader)) {
+		return nil
+	}
+	return entity.WithReason(entity.ErrorForbidden, "only the owner or an admin may change the file")
+}
+
+// admin allows admins only, and callers without credentials when auth is
+// disabled.
+func (a fileAccess) admin(ctx context.Context, reason string) error {
+	principal := entity.PrincipalFromContext(ctx)
+	if principal == nil || principal.HasRole(entity.RoleAdmin) {
+		return nil
+	}
+	return entity.WithReason(entity.ErrorForbidden, reason)
+}
+
+// create allows uploaders and admins to add files.
+func (a fileAccess) create(ctx context.Context) error {
+	principal := entity.PrincipalFromContext(ctx)
+	if principal == nil || principal.HasRole(entity.RoleUploader) {
+		return nil
+	}
+	return entity.WithReason(entity.ErrorForbidden, "uploading requires the uploader role")
+}
+
+// upload lets only the principal that started an upload, or an admin of
+// its tenant, see and continue it. Download links never do.
+func (a fileAccess) upload(ctx context.Context, upload *entity.Upload) error {
+	if tenant, ok := entity.TenantFromContext(ctx); ok && tenant != upload.TenantID {
+		return entity.ErrorUploadNotFound
+	}
+	principal := entity.PrincipalFromContext(ctx)
+	if principal == nil {
+		return nil
+	}
+	if principal.FileID != 0 ||
+		(upload.OwnerID != principal.Subject && !principal.HasRole(entity.RoleAdmin)) {
+		return entity.ErrorUploadNotFound
+	}
+	return nil
+}
+
+// filter narrows listings to the files read allows.
+func (a fileAccess) filter(ctx context.Context) *param.FileAccess {
+	principal := entity.PrincipalFromContext(ctx)
+	if principal == nil {
+		if tenant, ok := entity.TenantFromContext(ctx); ok {
+			return &param.FileAccess{TenantID: tenant, All: true}
+		}
+		return nil
+	}
+	return &param.FileAccess{
+		TenantID: principal.Tenant,
+		Subject:  principal.Subject,
+		All:      principal.HasRole(entity.RoleAdmin),
+	}
+}
+
+// owner returns the owner and tenant of files created in ctx.
+func (a fileAccess) owner(ctx context.Context) (string, string) {
+	principal := entity.PrincipalFromContext(ctx)
+	if principal == nil {
+		tenant, _ := entity.TenantFromContext(ctx)
+		return "", tenant
+	}
+	return principal.Subject, principal.Tenant
+}
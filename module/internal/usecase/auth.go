@@ -0,0 +1,242 @@
+package usecase
+
+//go:generate mockgen -source auth.go -destination mock/auth.go
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"video-server/internal/auth"
+	"video-server/module/entity"
+	repository "video-server/module/internal/repository"
+	"video-server/module/param"
+)
+
+const (
+	// APIKeyPrefix starts every generated key, so leaked keys are easy to
+	// spot in logs and by secret scanners
+	APIKeyPrefix = "vsk_"
+	// apiKeyListedLength is how much of a key is kept to tell keys apart
+	apiKeyListedLength = len(APIKeyPrefix) + 8
+	// linkPlaybackWindow is how long a client may pause between requests
+	// through a link with a download limit before its next one counts as a
+	// new download
+	linkPlaybackWindow = 15 * time.Minute
+)
+
+type AuthUsecase interface {
+	// Authenticate returns the principal of a credential, ErrorUnauthorized
+	// when it is missing or not valid
+	Authenticate(ctx context.Context, credential *param.Credential) (*entity.Principal, error)
+	// CreateAPIKey returns the new key along with its row, the key can't be
+	// recovered later
+	CreateAPIKey(ctx context.Context, params *param.CreateAPIKey) (*entity.APIKey, string, error)
+	ListAPIKeys(ctx context.Context) ([]*entity.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+}
+
+type authUsecaseRepository struct {
+	apiKey repository.APIKeyRepository
+	link   repository.LinkRepository
+}
+
+type authUsecase struct {
+	repository authUsecaseRepository
+	verifier   auth.TokenVerifier
+	signer     *auth.Signer
+}
+
+// NewAuthUsecase rejects every bearer token when verifier is nil, and every
+// signed link when signer is nil.
+func NewAuthUsecase(
+	apiKeyRepository repository.APIKeyRepository,
+	linkRepository repository.LinkRepository,
+	verifier auth.TokenVerifier,
+	signer *auth.Signer,
+) *authUsecase {
+	return &authUsecase{
+		repository: authUsecaseRepository{
+			apiKey: apiKeyRepository,
+			link:   linkRepository,
+		},
+		verifier: verifier,
+		signer:   signer,
+	}
+}
+
+func (u *authUsecase) Authenticate(ctx context.Context, credential *param.Credential) (*entity.Principal, error) {
+	switch {
+	case credential.APIKey != "":
+		return u.authenticateAPIKey(ctx, credential.APIKey)
+	case credential.Token != "":
+		return u.authenticateToken(credential.Token)
+	case credential.Link != nil:
+		return u.authenticateLink(ctx, credential.Link)
+	default:
+		return nil, entity.WithReason(entity.ErrorUnauthorized, "missing credentials")
+	}
+}
+
+func (u *authUsecase) authenticateAPIKey(ctx context.Context, key string) (*entity.Principal, error) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return nil, entity.WithReason(entity.ErrorUnauthorized, "invalid API key")
+	}
+
+	apiKey, err := u.repository.apiKey.GetAPIKeyByHash(ctx, hashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, entity.ErrorAPIKeyNotFound) {
+			return nil, entity.WithReason(entity.ErrorUnauthorized, "invalid API key")
+		}
+		return nil, err
+	}
+	if !apiKey.IsActive(time.Now()) {
+		return nil, entity.WithReason(entity.ErrorUnauthorized, "API key expired or revoked")
+	}
+	if !entity.IsRole(apiKey.Role) {
+		return nil, entity.WithReason(entity.ErrorUnauthorized, "unknown role")
+	}
+
+	return &entity.Principal{
+		Subject: apiKey.Subject,
+		Role:    apiKey.Role,
+		Tenant:  apiKey.Tenant,
+		Method:  entity.AuthMethodAPIKey,
+		KeyID:   apiKey.ID,
+	}, nil
+}
+
+func (u *authUsecase) authenticateToken(token string) (*entity.Principal, error) {
+	if u.verifier == nil {
+		return nil, entity.WithReason(entity.ErrorUnauthorized, "bearer tokens are not accepted")
+	}
+
+	claims, err := u.verifier.Verify(token)
+	if err != nil {
+		return nil, entity.WithReason(entity.ErrorUnauthorized, "invalid bearer token")
+	}
+
+	// tokens without a role claim get the least privileges
+	role := claims.Role
+	if role == "" {
+		role = entity.RoleViewer
+	}
+	if !entity.IsRole(role) {
+		return nil, entity.WithReason(entity.ErrorUnauthorized, "unknown role")
+	}
+	if !entity.IsTenant(claims.Tenant) {
+		return nil, entity.WithReason(entity.ErrorUnauthorized, "invalid tenant")
+	}
+
+	return &entity.Principal{
+		Subject: claims.Subject,
+		Role:    role,
+		Tenant:  claims.Tenant,
+		Method:  entity.AuthMethodJWT,
+	}, nil
+}
+
+// authenticateLink checks the signature before looking the link up, so
+// forged links cost no query. A link acts as a viewer of its file only.
+func (u *authUsecase) authenticateLink(ctx context.Context, credential *param.LinkCredential) (*entity.Principal, error) {
+	if u.signer == nil {
+		return nil, entity.WithReason(entity.ErrorUnauthorized, "signed links are not accepted")
+	}
+	if !u.signer.Verify(linkMessage(credential.LinkID, credential.FileID, credential.Expires), credential.Signature) {
+		return nil, entity.WithReason(entity.ErrorUnauthorized, "invalid link signature")
+	}
+	if time.Now().Unix() >= credential.Expires {
+		return nil, entity.WithReason(entity.ErrorUnauthorized, "link expired")
+	}
+
+	link, err := u.repository.link.GetLink(ctx, credential.LinkID)
+	if err != nil {
+		if errors.Is(err, entity.ErrorLinkNotFound) {
+			return nil, entity.WithReason(entity.ErrorUnauthorized, "invalid link signature")
+		}
+		return nil, err
+	}
+	if link.IP != "" && !net.ParseIP(link.IP).Equal(net.ParseIP(credential.IP)) {
+		return nil, entity.WithReason(entity.ErrorUnauthorized, "link is bound to another address")
+	}
+	// the requests of a download or playback can't be told from new ones,
+	// on limited links each client counts once per playback instead
+	if link.MaxDownloads > 0 && (credential.Download || credential.Partial) {
+		counted, err := u.repository.link.CountPlayback(ctx, link.ID, credential.IP, time.Now().Add(linkPlaybackWindow))
+		if err != nil {
+			return nil, err
+		}
+		if !counted {
+			return nil, entity.WithReason(entity.ErrorForbidden, "link download limit reached")
+		}
+	} else if credential.Download {
+		counted, err := u.repository.link.CountDownload(ctx, link.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !counted {
+			return nil, entity.WithReason(entity.ErrorForbidden, "link download limit reached")
+		}
+	}
+
+	return &entity.Principal{
+		Subject: link.CreatedBy,
+		Role:    entity.RoleViewer,
+		Tenant:  link.TenantID,
+		Method:  entity.AuthMethodLink,
+		KeyID:   link.ID,
+		FileID:  link.FileID,
+	}, nil
+}
+
+func (u *authUsecase) CreateAPIKey(ctx context.Context, params *param.CreateAPIKey) (*entity.APIKey, string, error) {
+	if params.Name == "" || params.Subject == "" {
+		return nil, "", entity.WithReason(entity.ErrorBadRequest, "an API key needs a name and a subject")
+	}
+	if params.Role == "" {
+		params.Role = entity.RoleUploader
+	}
+	if !entity.IsRole(params.Role) {
+		return nil, "", entity.WithField(entity.ErrorBadRequest, "role", fmt.Sprintf("unknown role %q", params.Role))
+	}
+	if !entity.IsTenant(params.Tenant) {
+		return nil, "", entity.WithField(entity.ErrorBadRequest, "tenant", fmt.Sprintf("invalid tenant %q", params.Tenant))
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	key := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	params.Prefix = key[:apiKeyListedLength]
+	params.Hash = hashAPIKey(key)
+	apiKey, err := u.repository.apiKey.CreateAPIKey(ctx, params)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return apiKey, key, nil
+}
+
+func (u *authUsecase) ListAPIKeys(ctx context.Context) ([]*entity.APIKey, error) {
+	return u.repository.apiKey.ListAPIKeys(ctx)
+}
+
+func (u *authUsecase) RevokeAPIKey(ctx context.Context, id int) error {
+	return u.repository.apiKey.RevokeAPIKey(ctx, id)
+}
+
+// hashAPIKey is a plain SHA-256, keys are random enough that a slow hash
+// adds nothing and it lets the key be looked up by its hash.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
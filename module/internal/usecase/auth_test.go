@@ -0,0 +1,381 @@
+package usecase_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"video-server/internal/auth"
+	"video-server/internal/testutil"
+	"video-server/module/entity"
+	"video-server/module/fixture"
+	"video-server/module/internal/usecase"
+	"video-server/module/param"
+)
+
+const testAPIKey = usecase.APIKeyPrefix + "0123456789abcdef"
+
+var tokenSecret = []byte("secret")
+
+func apiKeyHash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func signToken(t *testing.T, subject string, expiresIn time.Duration) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   subject,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+	}).SignedString(tokenSecret)
+	require.NoError(t, err)
+	return token
+}
+
+func TestAuthUsecase_Authenticate(t *testing.T) {
+	type Response struct {
+		result interface{}
+		err    interface{}
+	}
+
+	past := time.Now().Add(-time.Hour)
+	verifier := auth.NewVerifier(auth.Config{HMACSecrets: [][]byte{tokenSecret}})
+	signer := auth.NewSigner(tokenSecret)
+	expires := time.Now().Add(time.Hour).Unix()
+	link := func(fileID int, expires int64, ip string, download bool) *param.Credential {
+		return &param.Credential{Link: &param.LinkCredential{
+			FileID:    fileID,
+			LinkID:    3,
+			Expires:   expires,
+			Signature: signer.Sign(fmt.Sprintf("3.1.%d", expires)),
+			IP:        ip,
+			Download:  download,
+		}}
+	}
+	partial := func(credential *param.Credential) *param.Credential {
+		credential.Link.Partial = true
+		return credential
+	}
+
+	testcases := map[string]struct {
+		credential *param.Credential
+		verifier   auth.TokenVerifier
+		signer     *auth.Signer
+		response   Response
+		mockFn     func(*fixture.MockAuthUsecase)
+	}{
+		"api key": {
+			credential: &param.Credential{APIKey: testAPIKey},
+			response: Response{
+				result: &entity.Principal{Subject: "ci-bot", Role: entity.RoleUploader, Tenant: "acme", Method: entity.AuthMethodAPIKey, KeyID: 1},
+			},
+			mockFn: func(m *fixture.MockAuthUsecase) {
+				m.APIKeyRepository.EXPECT().GetAPIKeyByHash(gomock.Any(), apiKeyHash(testAPIKey)).
+					Return(&entity.APIKey{ID: 1, Subject: "ci-bot", Role: entity.RoleUploader, Tenant: "acme"}, nil)
+			},
+		},
+		"api key unknown role": {
+			credential: &param.Credential{APIKey: testAPIKey},
+			response: Response{
+				err: "status 401: err Unauthorized: unknown role",
+			},
+			mockFn: func(m *fixture.MockAuthUsecase) {
+				m.APIKeyRepository.EXPECT().GetAPIKeyByHash(gomock.Any(), apiKeyHash(testAPIKey)).
+					Return(&entity.APIKey{ID: 1, Subject: "ci-bot", Role: "root"}, nil)
+			},
+		},
+		"api key unknown": {
+			credential: &param.Credential{APIKey: testAPIKey},
+			response: Response{
+				err: "status 401: err Unauthorized: invalid API key",
+			},
+			mockFn: func(m *fixture.MockAuthUsecase) {
+				m.APIKeyRepository.EXPECT().GetAPIKeyByHash(gomock.Any(), apiKeyHash(testAPIKey)).
+					Return(nil, entity.ErrorAPIKeyNotFound)
+			},
+		},
+		"api key without prefix": {
+			credential: &param.Credential{APIKey: "0123456789abcdef"},
+			response: Response{
+				err: "status 401: err Unauthorized: invalid API key",
+			},
+			mockFn: func(m *fixture.MockAuthUsecase) {},
+		},
+		"api key revoked": {
+			credential: &param.Credential{APIKey: testAPIKey},
+			response: Response{
+				err: "status 401: err Unauthorized: API key expired or revoked",
+			},
+			mockFn: func(m *fixture.MockAuthUsecase) {
+				m.APIKeyRepository.EXPECT().GetAPIKeyByHash(gomock.Any(), apiKeyHash(testAPIKey)).
+					Return(&entity.APIKey{ID: 1, Subject: "ci-bot", RevokedAt: &past}, nil)
+			},
+		},
+		"api key expired": {
+			credential: &param.Credential{APIKey: testAPIKey},
+			response: Response{
+				err: "status 401: err Unauthorized: API key expired or revoked",
+			},
+			mockFn: func(m *fixture.MockAuthUsecase) {
+				m.APIKeyRepository.EXPECT().GetAPIKeyByHash(gomock.Any(), apiKeyHash(testAPIKey)).
+					Return(&entity.APIKey{ID: 1, Subject: "ci-bot", ExpiresAt: &past}, nil)
+			},
+		},
+		"api key db error": {
+			credential: &param.Credential{APIKey: testAPIKey},
+			response: Response{
+				err: testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockAuthUsecase) {
+				m.APIKeyRepository.EXPECT().GetAPIKeyByHash(gomock.Any(), apiKeyHash(testAPIKey)).
+					Return(nil, testutil.ErrDB)
+			},
+		},
+		"token": {
+			credential: &param.Credential{Token: signToken(t, "alice", time.Hour)},
+			verifier:   verifier,
+			response: Response{
+				result: &entity.Principal{Subject: "alice", Role: entity.RoleViewer, Method: entity.AuthMethodJWT},
+			},
+			mockFn: func(m *fixture.MockAuthUsecase) {},
+		},
+		"token expired": {
+			credential: &param.Credential{Token: signToken(t, "alice", -time.Hour)},
+			verifier:   verifier,
+			response: Response{
+				err: "status 401: err Unauthorized: invalid bearer token",
+			},
+			mockFn: func(m *fixture.MockAuthUsecase) {},
+		},
+		"token not accepted": {
+			credential: &param.Credential{Token: signToken(t, "alice", time.Hour)},
+			response: Response{
+				err: "status 401: err Unauthorized: bearer tokens are not accepted",
+			},
+			mockFn: func(m *fixture.MockAuthUsecase) {},
+		},
+		"link": {
+			credential: link(1, expires, "203.0.113.7", true),
+			signer:     signer,
+			response: Response{
+				result: &entity.Principal{Subject: "alice", Role: entity.RoleViewer, Tenant: "acme", Method: entity.AuthMethodLink, KeyID: 3, FileID: 1},
+			},
+			mockFn: func(m *fixture.MockAuthUsecase) {
+				m.LinkRepository.EXPECT().GetLink(gomock.Any(), 3).
+					Return(&entity.DownloadLink{ID: 3, FileID: 1, TenantID: "acme", CreatedBy: "alice", IP: "203.0.113.7", MaxDownloads: 2}, nil)
+				m.LinkRepository.EXPECT().CountPlayback(gomock.Any(), 3, "203.0.113.7", gomock.Any()).Return(true, nil)
+			},
+		},
+		"link unlimited": {
+			credential: link(1, expires, "203.0.113.7", true),
+			signer:     signer,
+			response: Response{
+				result: &entity.Principal{Subject: "alice", Role: entity.RoleViewer, Tenant: "acme", Method: entity.AuthMethodLink, KeyID: 3, FileID: 1},
+			},
+			mockFn: func(m *fixture.MockAuthUsecase) {
+				m.LinkRepository.EXPECT().GetLink(gomock.Any(), 3).
+					Return(&entity.DownloadLink{ID: 3, FileID: 1, TenantID: "acme", CreatedBy: "alice"}, nil)
+				m.LinkRepository.EXPECT().CountDownload(gomock.Any(), 3).Return(true, nil)
+			},
+		},
+		"link head": {
+			credential: link(1, expires, "203.0.113.7", false),
+			signer:     signer,
+			response: Response{
+				result: &entity.Principal{Subject: "alice", Role: entity.RoleViewer, Tenant: "acme", Method: entity.AuthMethodLink, KeyID: 3, FileID: 1},
+			},
+			mockFn: func(m *fixture.MockAuthUsecase) {
+				m.LinkRepository.EXPECT().GetLink(gomock.Any(), 3).
+					Return(&entity.DownloadLink{ID: 3, FileID: 1, TenantID: "acme", CreatedBy: "alice", MaxDownloads: 2, Downloads: 2}, nil)
+			},
+		},
+		"link segment": {
+			credential: partial(link(1, expires, "203.0.113.7", false)),
+			signer:     signer,
+			response: Response{
+				result: &entity.Principal{Subject: "alice", Role: entity.RoleViewer, Tenant: "acme", Method: entity.AuthMethodLink, KeyID: 3, FileID: 1},
+			},
+			mockFn: func(m *fixture.MockAuthUsecase) {
+				m.LinkRepository.EXPECT().GetLink(gomock.Any(), 3).
+					Return(&entity.DownloadLink{ID: 3, FileID: 1, TenantID: "acme", CreatedBy: "alice", Downloads: 2}, nil)
+			},
+		},
+		"link segment limited": {
+			credential: partial(link(1, expires, "203.0.113.7", false)),
+			signer:     signer,
+			response: Response{
+				result: &entity.Principal{Subject: "alice", Role: entity.RoleViewer, Tenant: "acme", Method: entity.AuthMethodLink, KeyID: 3, FileID: 1},
+			},
+			mockFn: func(m *fixture.MockAuthUsecase) {
+				m.LinkRepository.EXPECT().GetLink(gomock.Any(), 3).
+					Return(&entity.DownloadLink{ID: 3, FileID: 1, TenantID: "acme", CreatedBy: "alice", MaxDownloads: 2, Downloads: 2}, nil)
+				m.LinkRepository.EXPECT().CountPlayback(gomock.Any(), 3, "203.0.113.7", gomock.Any()).Return(true, nil)
+			},
+		},
+		"link playback error": {
+			credential: partial(link(1, expires, "203.0.113.7", false)),
+			signer:     signer,
+			response: Response{
+				err: testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockAuthUsecase) {
+				m.LinkRepository.EXPECT().GetLink(gomock.Any(), 3).
+					Return(&entity.DownloadLink{ID: 3, FileID: 1, MaxDownloads: 2}, nil)
+				m.LinkRepository.EXPECT().CountPlayback(gomock.Any(), 3, "203.0.113.7", gomock.Any()).Return(false, testutil.ErrDB)
+			},
+		},
+		"link limit reached": {
+			credential: link(1, expires, "203.0.113.7", true),
+			signer:     signer,
+			response: Response{
+				err: "status 403: err Forbidden: link download limit reached",
+			},
+			mockFn: func(m *fixture.MockAuthUsecase) {
+				m.LinkRepository.EXPECT().GetLink(gomock.Any(), 3).
+					Return(&entity.DownloadLink{ID: 3, FileID: 1, MaxDownloads: 2, Downloads: 2}, nil)
+				m.LinkRepository.EXPECT().CountPlayback(gomock.Any(), 3, "203.0.113.7", gomock.Any()).Return(false, nil)
+			},
+		},
+		"link other address": {
+			credential: link(1, expires, "198.51.100.1", true),
+			signer:     signer,
+			response: Response{
+				err: "status 401: err Unauthorized: link is bound to another address",
+			},
+			mockFn: func(m *fixture.MockAuthUsecase) {
+				m.LinkRepository.EXPECT().GetLink(gomock.Any(), 3).
+					Return(&entity.DownloadLink{ID: 3, FileID: 1, IP: "203.0.113.7"}, nil)
+			},
+		},
+		"link other file": {
+			credential: link(2, expires, "203.0.113.7", true),
+			signer:     signer,
+			response: Response{
+				err: "status 401: err Unauthorized: invalid link signature",
+			},
+			mockFn: func(m *fixture.MockAuthUsecase) {},
+		},
+		"link expired": {
+			credential: link(1, past.Unix(), "203.0.113.7", true),
+			signer:     signer,
+			response: Response{
+				err: "status 401: err Unauthorized: link expired",
+			},
+			mockFn: func(m *fixture.MockAuthUsecase) {},
+		},
+		"link deleted": {
+			credential: link(1, expires, "203.0.113.7", true),
+			signer:     signer,
+			response: Response{
+				err: "status 401: err Unauthorized: invalid link signature",
+			},
+			mockFn: func(m *fixture.MockAuthUsecase) {
+				m.LinkRepository.EXPECT().GetLink(gomock.Any(), 3).Return(nil, entity.ErrorLinkNotFound)
+			},
+		},
+		"link not accepted": {
+			credential: link(1, expires, "203.0.113.7", true),
+			response: Response{
+				err: "status 401: err Unauthorized: signed links are not accepted",
+			},
+			mockFn: func(m *fixture.MockAuthUsecase) {},
+		},
+		"missing credentials": {
+			credential: &param.Credential{},
+			response: Response{
+				err: "status 401: err Unauthorized: missing credentials",
+			},
+			mockFn: func(m *fixture.MockAuthUsecase) {},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ucs, mocks := fixture.NewAuthUsecase(ctrl, tc.verifier, tc.signer)
+			tc.mockFn(mocks)
+			result, err := ucs.Authenticate(context.Background(), tc.credential)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			if tc.response.err == nil {
+				assert.Equal(t, tc.response.result, result)
+			}
+		})
+	}
+}
+
+// createAPIKeyMatcher matches the params of a new key, whose prefix and hash
+// derive from a random key.
+type createAPIKeyMatcher struct {
+	name string
+}
+
+func (c createAPIKeyMatcher) Matches(x interface{}) bool {
+	params, ok := x.(*param.CreateAPIKey)
+	return ok && params.Name == c.name &&
+		strings.HasPrefix(params.Prefix, usecase.APIKeyPrefix) &&
+		len(params.Hash) == sha256.Size*2
+}
+
+func (c createAPIKeyMatcher) String() string {
+	return fmt.Sprintf("creates API key %q", c.name)
+}
+
+func TestAuthUsecase_CreateAPIKey(t *testing.T) {
+	testcases := map[string]struct {
+		params *param.CreateAPIKey
+		err    interface{}
+		mockFn func(*fixture.MockAuthUsecase)
+	}{
+		"success": {
+			params: &param.CreateAPIKey{Name: "ci", Subject: "ci-bot"},
+			mockFn: func(m *fixture.MockAuthUsecase) {
+				m.APIKeyRepository.EXPECT().CreateAPIKey(gomock.Any(), createAPIKeyMatcher{name: "ci"}).
+					DoAndReturn(func(ctx context.Context, params *param.CreateAPIKey) (*entity.APIKey, error) {
+						return &entity.APIKey{ID: 1, Name: params.Name, Prefix: params.Prefix, Hash: params.Hash}, nil
+					})
+			},
+		},
+		"missing subject": {
+			params: &param.CreateAPIKey{Name: "ci"},
+			err:    "status 400: err Bad Request: an API key needs a name and a subject",
+			mockFn: func(m *fixture.MockAuthUsecase) {},
+		},
+		"invalid tenant": {
+			params: &param.CreateAPIKey{Name: "ci", Subject: "ci-bot", Tenant: "acme/ops"},
+			err:    "status 400: err Bad Request: invalid tenant \"acme/ops\"",
+			mockFn: func(m *fixture.MockAuthUsecase) {},
+		},
+		"db error": {
+			params: &param.CreateAPIKey{Name: "ci", Subject: "ci-bot"},
+			err:    testutil.ErrDB,
+			mockFn: func(m *fixture.MockAuthUsecase) {
+				m.APIKeyRepository.EXPECT().CreateAPIKey(gomock.Any(), createAPIKeyMatcher{name: "ci"}).
+					Return(nil, testutil.ErrDB)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ucs, mocks := fixture.NewAuthUsecase(ctrl, nil, nil)
+			tc.mockFn(mocks)
+			apiKey, key, err := ucs.CreateAPIKey(context.Background(), tc.params)
+			testutil.AssertErrorExAc(t, tc.err, err)
+			if tc.err == nil {
+				assert.True(t, strings.HasPrefix(key, apiKey.Prefix))
+				assert.Equal(t, apiKeyHash(key), apiKey.Hash)
+			}
+		})
+	}
+}
@@ -4,10 +4,19 @@ package usecase
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
 	"strings"
 
+	"go.opentelemetry.io/otel/attribute"
+
+	"video-server/internal/metrics"
+	"video-server/internal/mp4"
+	"video-server/internal/probe"
+	"video-server/internal/storage"
+	"video-server/internal/tracing"
 	"video-server/internal/util"
 	"video-server/module/entity"
 	repository "video-server/module/internal/repository"
@@ -16,30 +25,96 @@ import (
 
 type FileUsecase interface {
 	CreateFile(ctx context.Context, filereader util.FileReader) (*entity.File, error)
-	ListFiles(ctx context.Context) ([]*entity.File, error)
+	ListFiles(ctx context.Context, params *param.ListFiles) ([]*entity.File, *util.Pagination, error)
 	GetFile(ctx context.Context, id int) (*entity.File, error)
+	OpenFile(ctx context.Context, id int) (*entity.File, storage.Blob, error)
+	GetFileMetadata(ctx context.Context, id int) (*entity.VideoMetadata, error)
 	DeleteFile(ctx context.Context, id int) error
 }
 
 type fileUsecaseRepository struct {
-	file repository.FileRepository
+	file     repository.FileRepository
+	blob     repository.BlobRepository
+	metadata repository.MetadataRepository
+	job      repository.JobRepository
+	grant    repository.GrantRepository
+}
+
+type FileConfig struct {
+	// UniqueNames rejects uploads whose name is already taken
+	UniqueNames bool
+
+	// MaxSize is the largest upload in bytes, 0 doesn't limit
+	MaxSize int64
+
+	// AllowedContainers and the codec lists restrict what uploads may
+	// contain, codecs match on their RFC 6381 prefix. Empty lists allow
+	// anything the probe understands.
+	AllowedContainers  []string
+	AllowedVideoCodecs []string
+	AllowedAudioCodecs []string
+
+	// Quota limits what each tenant may store
+	Quota QuotaConfig
 }
 
 type fileUsecase struct {
 	repository fileUsecaseRepository
+	access     fileAccess
+	limits     uploadLimits
+	storage    storage.BlobStore
+	config     FileConfig
 }
 
 func NewFileUsecase(
 	fileRepository repository.FileRepository,
+	blobRepository repository.BlobRepository,
+	metadataRepository repository.MetadataRepository,
+	jobRepository repository.JobRepository,
+	grantRepository repository.GrantRepository,
+	blobStore storage.BlobStore,
+	config FileConfig,
 ) *fileUsecase {
 	return &fileUsecase{
 		repository: fileUsecaseRepository{
-			file: fileRepository,
+			file:     fileRepository,
+			blob:     blobRepository,
+			metadata: metadataRepository,
+			job:      jobRepository,
+			grant:    grantRepository,
 		},
+		access:  fileAccess{grant: grantRepository},
+		limits:  uploadLimits{file: fileRepository, maxSize: config.MaxSize, quota: config.Quota},
+		storage: blobStore,
+		config:  config,
 	}
 }
 
 func (u *fileUsecase) CreateFile(ctx context.Context, fileReader util.FileReader) (*entity.File, error) {
+	ctx, span := startSpan(ctx, "FileUsecase.CreateFile")
+	file, err := u.createFile(ctx, fileReader)
+	setFileAttributes(span, file)
+	endSpan(span, err)
+	if err != nil {
+		// only errors answered to the client are rejections, the others
+		// are failures of the service
+		if e, ok := err.(entity.RequestError); ok {
+			metrics.UploadRejections.WithLabelValues(e.Code).Inc()
+		}
+		return nil, err
+	}
+
+	metrics.UploadedFiles.Inc()
+	metrics.UploadedBytes.Add(float64(file.Size))
+	return file, nil
+}
+
+func (u *fileUsecase) createFile(ctx context.Context, fileReader util.FileReader) (*entity.File, error) {
+	err := u.access.create(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	fileMimeType, err := fileReader.GetFileMimeType()
 	if err != nil {
 		return nil, err
@@ -49,34 +124,398 @@ func (u *fileUsecase) CreateFile(ctx context.Context, fileReader util.FileReader
 		return nil, entity.ErrorFileUnsupported
 	}
 
+	owner, tenant := u.access.owner(ctx)
+	name := util.SanitizeFileName(fileReader.GetName())
+	if u.config.UniqueNames {
+		_, err = u.repository.file.FindFileByName(ctx, tenant, name)
+		if err == nil {
+			return nil, entity.ErrorFileExists
+		}
+		if !errors.Is(err, entity.ErrorFileNotFound) {
+			return nil, err
+		}
+	}
+
+	limit, quotaBound, err := u.limits.limit(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	// the content is streamed into storage once, validated from there and
+	// only then moved under its digest
+	err = fileReader.Store(ctx, tenantStore(u.storage, tenant), limit)
+	if err != nil {
+		if errors.Is(err, util.ErrFileTooLarge) && quotaBound {
+			return nil, entity.WithReason(entity.ErrorQuotaExceeded, fmt.Sprintf("the upload exceeds the %d bytes left to the tenant", limit))
+		}
+		if errors.Is(err, util.ErrFileTooLarge) {
+			return nil, entity.WithReason(entity.ErrorFileTooLarge, fmt.Sprintf("files are limited to %d bytes", u.config.MaxSize))
+		}
+		return nil, err
+	}
+
+	err = u.validate(fileReader)
+	if err != nil {
+		_ = fileReader.Discard(ctx)
+		return nil, err
+	}
+
+	// the row goes in pending together with its blob reference, the file
+	// only becomes visible once its content is in place
 	file, err := u.repository.file.CreateFile(ctx, &param.CreateFile{
-		Name:     fileReader.GetName(),
+		Name:     name,
 		Size:     fileReader.GetSize(),
 		MimeType: fileMimeType,
+		Digest:   fileReader.GetDigest(),
+		OwnerID:  owner,
+		TenantID: tenant,
 	})
 	if err != nil {
+		_ = fileReader.Discard(ctx)
 		return nil, err
 	}
 
-	err = fileReader.Store()
-	return file, err
+	err = fileReader.Commit(ctx)
+	if err != nil {
+		_ = fileReader.Discard(ctx)
+		u.failFile(ctx, file)
+		return nil, err
+	}
+
+	err = u.repository.file.UpdateFileStatus(ctx, file.ID, entity.FileStatusReady)
+	if err != nil {
+		u.failFile(ctx, file)
+		return nil, err
+	}
+	file.Status = entity.FileStatusReady
+
+	// the rest of the processing happens in the background, metadata and
+	// poster are still produced on first request if enqueueing failed
+	jobs := make([]*param.CreateJob, len(entity.FileJobTypes))
+	for i, typ := range entity.FileJobTypes {
+		jobs[i] = &param.CreateJob{FileID: file.ID, Type: typ}
+	}
+	_, _ = u.repository.job.CreateJobs(ctx, jobs)
+
+	return file, nil
+}
+
+// validate walks the whole container of a staged upload and checks it
+// against the allow-lists.
+func (u *fileUsecase) validate(fileReader util.FileReader) error {
+	metadata, err := probe.Validate(fileReader, fileReader.GetSize())
+	switch {
+	case errors.Is(err, probe.ErrUnknownFormat):
+		return entity.WithReason(entity.ErrorFileUnsupported, "container format not recognized")
+	case errors.Is(err, probe.ErrInvalidFile), errors.Is(err, mp4.ErrInvalidBox), errors.Is(err, mp4.ErrNoMovie):
+		return entity.WithReason(entity.ErrorFileInvalid, err.Error())
+	case err != nil:
+		return err
+	}
+
+	if !allowed(u.config.AllowedContainers, metadata.Container) {
+		return entity.WithReason(entity.ErrorFileUnsupported, fmt.Sprintf("container %s is not allowed", metadata.Container))
+	}
+	if metadata.VideoCodec == "" {
+		return entity.WithReason(entity.ErrorFileUnsupported, "no video track")
+	}
+	if !allowed(u.config.AllowedVideoCodecs, metadata.VideoCodec) {
+		return entity.WithReason(entity.ErrorFileUnsupported, fmt.Sprintf("video codec %s is not allowed", metadata.VideoCodec))
+	}
+	if metadata.AudioCodec != "" && !allowed(u.config.AllowedAudioCodecs, metadata.AudioCodec) {
+		return entity.WithReason(entity.ErrorFileUnsupported, fmt.Sprintf("audio codec %s is not allowed", metadata.AudioCodec))
+	}
+
+	return nil
+}
+
+// allowed matches a container or codec against an allow-list, an entry
+// matches the whole value or its prefix up to a dot.
+func allowed(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, entry := range list {
+		entry = strings.TrimSpace(entry)
+		if strings.EqualFold(entry, value) || strings.HasPrefix(strings.ToLower(value), strings.ToLower(entry)+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// ListFiles returns a page of files, paged by offset or by the cursor
+// handed out with the previous page.
+func (u *fileUsecase) ListFiles(ctx context.Context, params *param.ListFiles) ([]*entity.File, *util.Pagination, error) {
+	ctx, span := startSpan(ctx, "FileUsecase.ListFiles")
+	files, pagination, err := u.listFiles(ctx, params)
+	span.SetAttributes(attribute.Int("file.count", len(files)))
+	endSpan(span, err)
+	return files, pagination, err
+}
+
+func (u *fileUsecase) listFiles(ctx context.Context, params *param.ListFiles) ([]*entity.File, *util.Pagination, error) {
+	query := *params
+	if query.Limit == 0 {
+		query.Limit = util.DefaultLimit
+	}
+	if query.Limit < 0 || query.Limit > util.MaxLimit {
+		return nil, nil, entity.WithField(entity.ErrorBadRequest, "limit", fmt.Sprintf("limit must be between 1 and %d", util.MaxLimit))
+	}
+	if query.Offset < 0 {
+		return nil, nil, entity.WithField(entity.ErrorBadRequest, "offset", "offset must not be negative")
+	}
+	if query.Sort == "" {
+		query.Sort = "id"
+	}
+	query.Access = u.access.filter(ctx)
+	if !containsString(repository.FileSortColumns, query.Sort) {
+		return nil, nil, entity.WithField(entity.ErrorBadRequest, "sort", fmt.Sprintf("files can't be sorted on %q", query.Sort))
+	}
+	// broken files are listed for admins to clean up
+	switch query.Status {
+	case "", entity.FileStatusReady:
+	case entity.FileStatusBroken:
+		err := u.access.admin(ctx, "listing broken files requires the admin role")
+		if err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, entity.WithField(entity.ErrorBadRequest, "status", "status must be ready or broken")
+	}
+
+	if query.Cursor != "" {
+		after, err := decodeFileCursor(query.Cursor)
+		if err != nil || after.Sort != query.Sort || after.Desc != query.Desc {
+			return nil, nil, entity.WithField(entity.ErrorBadRequest, "cursor", "invalid cursor")
+		}
+		query.After = after
+		query.Offset = 0
+	}
+
+	total, err := u.repository.file.CountFiles(ctx, &query.FileFilter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// one extra row tells whether there is a next page
+	limit := query.Limit
+	query.Limit++
+	files, err := u.repository.file.ListFiles(ctx, &query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var next string
+	if len(files) > limit {
+		files = files[:limit]
+		next = encodeFileCursor(files[limit-1], query.Sort, query.Desc)
+	}
+
+	return files, util.NewPagination(limit, query.Offset, int(total), next), nil
 }
 
-func (u *fileUsecase) ListFiles(ctx context.Context) ([]*entity.File, error) {
-	return u.repository.file.ListFiles(ctx)
+func encodeFileCursor(file *entity.File, sort string, desc bool) string {
+	cursor := param.FileCursor{Sort: sort, Desc: desc, ID: file.ID}
+	switch sort {
+	case "name":
+		cursor.Name = file.Name
+	case "size":
+		cursor.Size = file.Size
+	case "mime_type":
+		cursor.MimeType = file.MimeType
+	case "created_at":
+		cursor.CreatedAt = &file.CreatedAt
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeFileCursor(cursor string) (*param.FileCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	after := &param.FileCursor{}
+	if err := json.Unmarshal(data, after); err != nil {
+		return nil, err
+	}
+	return after, nil
 }
 
+func containsString(list []string, value string) bool {
+	for _, entry := range list {
+		if entry == value {
+			return true
+		}
+	}
+	return false
+}
+
+// GetFile returns a file the caller may see, others are not found.
 func (u *fileUsecase) GetFile(ctx context.Context, id int) (*entity.File, error) {
-	return u.repository.file.GetFile(ctx, id)
+	ctx, span := startSpan(ctx, "FileUsecase.GetFile", tracing.FileIDKey.Int(id))
+	file, err := u.getFile(ctx, id)
+	setFileAttributes(span, file)
+	endSpan(span, err)
+	return file, err
+}
+
+func (u *fileUsecase) getFile(ctx context.Context, id int) (*entity.File, error) {
+	file, err := u.repository.file.GetFile(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.access.read(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func (u *fileUsecase) OpenFile(ctx context.Context, id int) (*entity.File, storage.Blob, error) {
+	ctx, span := startSpan(ctx, "FileUsecase.OpenFile", tracing.FileIDKey.Int(id))
+	file, blob, err := u.openFile(ctx, id)
+	setFileAttributes(span, file)
+	endSpan(span, err)
+	return file, blob, err
+}
+
+func (u *fileUsecase) openFile(ctx context.Context, id int) (*entity.File, storage.Blob, error) {
+	file, err := u.GetFile(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	blob, err := u.openContent(ctx, file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return file, blob, nil
+}
+
+func (u *fileUsecase) openContent(ctx context.Context, file *entity.File) (storage.Blob, error) {
+	blob, err := tenantStore(u.storage, file.TenantID).Get(ctx, fileStorageKey(file))
+	if err != nil {
+		if errors.Is(err, storage.ErrBlobNotFound) {
+			return nil, entity.ErrorFileNotFound
+		}
+		return nil, err
+	}
 
+	return blob, nil
+}
+
+// GetFileMetadata returns the probed metadata of a file, probing the stored
+// content of files whose metadata isn't recorded yet.
+func (u *fileUsecase) GetFileMetadata(ctx context.Context, id int) (*entity.VideoMetadata, error) {
+	file, err := u.GetFile(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := u.repository.metadata.GetMetadata(ctx, id)
+	if !errors.Is(err, entity.ErrorMetadataNotFound) {
+		return metadata, err
+	}
+
+	blob, err := u.openContent(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	defer blob.Close()
+
+	probed, err := probe.Probe(blob, file.Size)
+	if err != nil {
+		return nil, entity.ErrorMetadataNotFound
+	}
+
+	return u.repository.metadata.CreateMetadata(ctx, metadataParams(file.ID, probed))
 }
 
 func (u *fileUsecase) DeleteFile(ctx context.Context, id int) error {
-	file, err := u.repository.file.GetFile(ctx, id)
+	ctx, span := startSpan(ctx, "FileUsecase.DeleteFile", tracing.FileIDKey.Int(id))
+	err := u.deleteFile(ctx, id)
+	endSpan(span, err)
+	return err
+}
+
+// deleteFile also finds broken files, which are gone from every other route
+// but still hold their blob reference.
+func (u *fileUsecase) deleteFile(ctx context.Context, id int) error {
+	file, err := u.repository.file.GetFile(ctx, id, entity.FileStatusReady, entity.FileStatusBroken)
+	if err != nil {
+		return err
+	}
+
+	err = u.access.write(ctx, file)
+	if err != nil {
+		return err
+	}
+
+	// the row goes first, content left behind by a failed delete is an
+	// orphan for reconcile rather than a row pointing at nothing
+	err = u.repository.file.DeleteFile(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	os.Remove(fmt.Sprintf("%s/%s", util.StoragePath, file.Name))
-	return u.repository.file.DeleteFile(ctx, id)
+	if file.Digest == "" {
+		err = tenantStore(u.storage, file.TenantID).Delete(ctx, fileStorageKey(file))
+		if err != nil && !errors.Is(err, storage.ErrBlobNotFound) {
+			return err
+		}
+		return nil
+	}
+	return u.releaseBlob(ctx, file)
+}
+
+// failFile rolls back a pending file whose content never made it into
+// place. The row is kept as failed, anything left over is for reconcile.
+func (u *fileUsecase) failFile(ctx context.Context, file *entity.File) {
+	_ = u.repository.file.UpdateFileStatus(ctx, file.ID, entity.FileStatusFailed)
+	_ = u.releaseBlob(ctx, file)
+}
+
+// releaseBlob drops the reference of file to its content and removes the
+// content from storage once no file of the tenant points at it anymore. The
+// content goes under the lock of the blob row, a file sharing it again
+// meanwhile cannot find it in place and lose it right after.
+func (u *fileUsecase) releaseBlob(ctx context.Context, file *entity.File) error {
+	_, err := u.repository.blob.ReleaseBlob(ctx, file.TenantID, file.Digest, func() error {
+		err := tenantStore(u.storage, file.TenantID).Delete(ctx, storage.DigestKey(file.Digest))
+		if err != nil && !errors.Is(err, storage.ErrBlobNotFound) {
+			return err
+		}
+		return nil
+	})
+	return err
+}
+
+// fileStorageKey locates the content of a file. The name is display metadata
+// only, except for files uploaded before content addressing which are still
+// stored under it.
+func fileStorageKey(file *entity.File) string {
+	if file.Digest == "" {
+		return file.Name
+	}
+	return storage.DigestKey(file.Digest)
+}
+
+func metadataParams(fileID int, metadata *probe.Metadata) *param.CreateMetadata {
+	return &param.CreateMetadata{
+		FileID:     fileID,
+		Container:  metadata.Container,
+		Duration:   metadata.Duration,
+		Width:      metadata.Width,
+		Height:     metadata.Height,
+		FrameRate:  metadata.FrameRate,
+		Bitrate:    metadata.Bitrate,
+		VideoCodec: metadata.VideoCodec,
+		AudioCodec: metadata.AudioCodec,
+		Tracks:     metadata.Tracks,
+	}
 }
@@ -2,18 +2,124 @@ package usecase_test
 
 import (
 	"context"
+	"io"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
 
+	"video-server/internal/storage"
 	"video-server/internal/testutil"
 	"video-server/internal/util"
 	"video-server/module/entity"
 	"video-server/module/fixture"
+	"video-server/module/internal/usecase"
 	"video-server/module/param"
 )
 
+const (
+	sampleSize   = int64(2848208)
+	sampleDigest = "05bd857af7f70bf51b6aac1144046973bf3325c9101a554bc27dc9607dbbd8f5"
+	sampleKey    = "blobs/sha256/05/" + sampleDigest
+)
+
+// sampleMetadata is what the probe reads from the sample movie, its audio
+// track being the longest
+func fileJobs(fileID int) []*param.CreateJob {
+	return []*param.CreateJob{
+		{FileID: fileID, Type: entity.JobTypeProbe},
+		{FileID: fileID, Type: entity.JobTypeHash},
+		{FileID: fileID, Type: entity.JobTypePackage},
+		{FileID: fileID, Type: entity.JobTypeThumbnail},
+	}
+}
+
+func sampleMetadata(fileID int) *param.CreateMetadata {
+	return &param.CreateMetadata{
+		FileID:     fileID,
+		Container:  "mp4",
+		Duration:   253952.0 / 44100,
+		Width:      1920,
+		Height:     1080,
+		FrameRate:  30,
+		Bitrate:    3956841,
+		VideoCodec: "avc1.640028",
+		AudioCodec: "mp4a.40.2",
+		Tracks:     2,
+	}
+}
+
+// truncatedSample writes the sample movie without its last bytes, cutting
+// into the moov box at its end
+func truncatedSample(t *testing.T) string {
+	data, err := os.ReadFile("./../../../test/post_1/sample.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := t.TempDir() + "/truncated.mp4"
+	if err := os.WriteFile(path, data[:2846000], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// viewerCtx, adminCtx, uploaderCtx and linkCtx carry principals of the acme
+// tenant, the link one signed by alice for file 1
+var (
+	viewerCtx   = entity.WithPrincipal(context.Background(), &entity.Principal{Subject: "alice", Role: entity.RoleViewer, Tenant: "acme"})
+	adminCtx    = entity.WithPrincipal(context.Background(), &entity.Principal{Subject: "root", Role: entity.RoleAdmin, Tenant: "acme"})
+	uploaderCtx = entity.WithPrincipal(context.Background(), &entity.Principal{Subject: "bob", Role: entity.RoleUploader, Tenant: "acme"})
+	linkCtx     = entity.WithPrincipal(context.Background(), &entity.Principal{Subject: "alice", Role: entity.RoleViewer, Tenant: "acme", Method: entity.AuthMethodLink, FileID: 1})
+)
+
+// acmeSampleKey is where the sample content of the acme tenant is stored
+const acmeSampleKey = "tenants/acme/" + sampleKey
+
+// releaseLastBlob releases the last reference to a blob, deleting its
+// content as the repository does under the row lock
+func releaseLastBlob(_ context.Context, _, _ string, deleteContent func() error) (int64, error) {
+	return 0, deleteContent()
+}
+
+// stagingKey and acmeStagingKey match the key an upload is staged under
+// before validation
+var (
+	stagingKey     = stagingKeyMatcher{prefix: "staging/"}
+	acmeStagingKey = stagingKeyMatcher{prefix: "tenants/acme/staging/"}
+)
+
+type stagingKeyMatcher struct {
+	prefix string
+}
+
+func (m stagingKeyMatcher) Matches(x interface{}) bool {
+	key, ok := x.(string)
+	return ok && strings.HasPrefix(key, m.prefix)
+}
+
+func (m stagingKeyMatcher) String() string {
+	return "is a staging key under " + m.prefix
+}
+
+// expectStage expects the upload to be streamed to a staging key and read
+// back from the file at path.
+func expectStage(m *fixture.MockFileUsecase, ctx context.Context, path string) {
+	m.BlobStore.EXPECT().Put(ctx, stagingKey, gomock.Any(), int64(-1)).
+		DoAndReturn(func(_ context.Context, _ string, r io.Reader, _ int64) error {
+			_, err := io.Copy(io.Discard, r)
+			return err
+		})
+	m.BlobStore.EXPECT().Get(ctx, stagingKey).
+		DoAndReturn(func(context.Context, string) (storage.Blob, error) {
+			return os.Open(path)
+		})
+}
+
 func TestFileUsecase_CreateFile(t *testing.T) {
+	truncated := truncatedSample(t)
+
 	type Request struct {
 		ctx      context.Context
 		filePath string
@@ -21,57 +127,856 @@ func TestFileUsecase_CreateFile(t *testing.T) {
 
 	type Response struct {
 		result interface{}
-		err    error
+		err    interface{}
 	}
 
 	testcases := map[string]struct {
+		config   usecase.FileConfig
 		request  Request
 		response Response
-		mockFn   func(*fixture.MockFileUsecase, context.Context, util.FileReader)
+		mockFn   func(*fixture.MockFileUsecase, Request)
 	}{
+		"viewer forbidden": {
+			request: Request{
+				ctx:      viewerCtx,
+				filePath: samplePath,
+			},
+			response: Response{
+				result: nil,
+				err:    "status 403: err Forbidden: uploading requires the uploader role",
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {},
+		},
 		"success": {
 			request: Request{
 				ctx:      context.Background(),
-				filePath: "./../../../test/post_1/sample.mp4",
+				filePath: samplePath,
+			},
+			response: Response{
+				result: map[string]interface{}{"ID": 1},
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				expectStage(m, req.ctx, req.filePath)
+				m.BlobStore.EXPECT().Stat(req.ctx, sampleKey).
+					Return(nil, storage.ErrBlobNotFound)
+				m.BlobStore.EXPECT().Move(req.ctx, stagingKey, sampleKey).
+					Return(nil)
+				m.FileRepository.EXPECT().CreateFile(req.ctx, &param.CreateFile{
+					Name:     "sample.mp4",
+					Size:     sampleSize,
+					MimeType: "video/mp4",
+					Digest:   sampleDigest,
+				}).Return(&entity.File{ID: 1, Digest: sampleDigest}, nil)
+				m.FileRepository.EXPECT().UpdateFileStatus(req.ctx, 1, entity.FileStatusReady).
+					Return(nil)
+				m.JobRepository.EXPECT().CreateJobs(req.ctx, fileJobs(1)).
+					Return([]*entity.Job{}, nil)
+			},
+		},
+		"success duplicate content": {
+			request: Request{
+				ctx:      context.Background(),
+				filePath: samplePath,
+			},
+			response: Response{
+				result: map[string]interface{}{"ID": 2},
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				expectStage(m, req.ctx, req.filePath)
+				m.BlobStore.EXPECT().Stat(req.ctx, sampleKey).
+					Return(&storage.BlobInfo{Key: sampleKey}, nil)
+				m.BlobStore.EXPECT().Delete(req.ctx, stagingKey).
+					Return(nil)
+				m.FileRepository.EXPECT().CreateFile(req.ctx, &param.CreateFile{
+					Name:     "sample.mp4",
+					Size:     sampleSize,
+					MimeType: "video/mp4",
+					Digest:   sampleDigest,
+				}).Return(&entity.File{ID: 2, Digest: sampleDigest}, nil)
+				m.FileRepository.EXPECT().UpdateFileStatus(req.ctx, 2, entity.FileStatusReady).
+					Return(nil)
+				// processing is queued best effort
+				m.JobRepository.EXPECT().CreateJobs(req.ctx, fileJobs(2)).
+					Return(nil, testutil.ErrDB)
+			},
+		},
+		"success tenant": {
+			config: usecase.FileConfig{Quota: usecase.QuotaConfig{
+				Default: usecase.Quota{MaxFiles: 1},
+				Tenants: map[string]usecase.Quota{"acme": {}},
+			}},
+			request: Request{
+				ctx:      uploaderCtx,
+				filePath: samplePath,
+			},
+			response: Response{
+				result: map[string]interface{}{"ID": 1, "TenantID": "acme"},
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.BlobStore.EXPECT().Put(req.ctx, acmeStagingKey, gomock.Any(), int64(-1)).
+					DoAndReturn(func(_ context.Context, _ string, r io.Reader, _ int64) error {
+						_, err := io.Copy(io.Discard, r)
+						return err
+					})
+				m.BlobStore.EXPECT().Get(req.ctx, acmeStagingKey).
+					DoAndReturn(func(context.Context, string) (storage.Blob, error) {
+						return os.Open(req.filePath)
+					})
+				m.BlobStore.EXPECT().Stat(req.ctx, acmeSampleKey).
+					Return(nil, storage.ErrBlobNotFound)
+				m.BlobStore.EXPECT().Move(req.ctx, acmeStagingKey, acmeSampleKey).
+					Return(nil)
+				m.FileRepository.EXPECT().CreateFile(req.ctx, &param.CreateFile{
+					Name:     "sample.mp4",
+					Size:     sampleSize,
+					MimeType: "video/mp4",
+					Digest:   sampleDigest,
+					OwnerID:  "bob",
+					TenantID: "acme",
+				}).Return(&entity.File{ID: 1, Digest: sampleDigest, TenantID: "acme"}, nil)
+				m.FileRepository.EXPECT().UpdateFileStatus(req.ctx, 1, entity.FileStatusReady).
+					Return(nil)
+				m.JobRepository.EXPECT().CreateJobs(req.ctx, fileJobs(1)).
+					Return([]*entity.Job{}, nil)
+			},
+		},
+		"quota files exceeded": {
+			config: usecase.FileConfig{Quota: usecase.QuotaConfig{Default: usecase.Quota{MaxFiles: 2}}},
+			request: Request{
+				ctx:      context.Background(),
+				filePath: samplePath,
+			},
+			response: Response{
+				result: nil,
+				err:    "status 507: err Quota exceeded: the tenant is limited to 2 files",
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().GetUsage(req.ctx, "").
+					Return(&entity.Usage{Files: 2, Bytes: 100}, nil)
+			},
+		},
+		"quota bytes exceeded": {
+			config: usecase.FileConfig{Quota: usecase.QuotaConfig{Default: usecase.Quota{MaxBytes: 1000, MaxFiles: 10}}},
+			request: Request{
+				ctx:      context.Background(),
+				filePath: samplePath,
+			},
+			response: Response{
+				result: nil,
+				err:    "status 507: err Quota exceeded: the tenant is limited to 1000 bytes",
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().GetUsage(req.ctx, "").
+					Return(&entity.Usage{Files: 2, Bytes: 1000}, nil)
+			},
+		},
+		"quota left exceeded": {
+			config: usecase.FileConfig{
+				MaxSize: 1 << 30,
+				Quota:   usecase.QuotaConfig{Default: usecase.Quota{MaxBytes: 1<<20 + 100}},
+			},
+			request: Request{
+				ctx:      context.Background(),
+				filePath: samplePath,
+			},
+			response: Response{
+				result: nil,
+				err:    "status 507: err Quota exceeded: the upload exceeds the 1048576 bytes left to the tenant",
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().GetUsage(req.ctx, "").
+					Return(&entity.Usage{Files: 2, Bytes: 100}, nil)
+				m.BlobStore.EXPECT().Put(req.ctx, stagingKey, gomock.Any(), int64(-1)).
+					DoAndReturn(func(_ context.Context, _ string, r io.Reader, _ int64) error {
+						_, err := io.Copy(io.Discard, r)
+						return err
+					})
+				m.BlobStore.EXPECT().Delete(req.ctx, stagingKey).
+					Return(nil)
+			},
+		},
+		"quota above size limit": {
+			config: usecase.FileConfig{
+				MaxSize: 1 << 20,
+				Quota:   usecase.QuotaConfig{Default: usecase.Quota{MaxBytes: 1 << 30}},
+			},
+			request: Request{
+				ctx:      context.Background(),
+				filePath: samplePath,
+			},
+			response: Response{
+				result: nil,
+				err:    "status 413: err File too large: files are limited to 1048576 bytes",
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().GetUsage(req.ctx, "").
+					Return(&entity.Usage{Files: 2, Bytes: 100}, nil)
+				m.BlobStore.EXPECT().Put(req.ctx, stagingKey, gomock.Any(), int64(-1)).
+					DoAndReturn(func(_ context.Context, _ string, r io.Reader, _ int64) error {
+						_, err := io.Copy(io.Discard, r)
+						return err
+					})
+				m.BlobStore.EXPECT().Delete(req.ctx, stagingKey).
+					Return(nil)
+			},
+		},
+		"GetUsage error": {
+			config: usecase.FileConfig{Quota: usecase.QuotaConfig{Default: usecase.Quota{MaxFiles: 2}}},
+			request: Request{
+				ctx:      context.Background(),
+				filePath: samplePath,
+			},
+			response: Response{
+				result: nil,
+				err:    testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().GetUsage(req.ctx, "").
+					Return(nil, testutil.ErrDB)
+			},
+		},
+		"unique names name taken": {
+			config: usecase.FileConfig{UniqueNames: true},
+			request: Request{
+				ctx:      context.Background(),
+				filePath: samplePath,
+			},
+			response: Response{
+				result: nil,
+				err:    entity.ErrorFileExists,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().FindFileByName(req.ctx, "", "sample.mp4").
+					Return(&entity.File{ID: 1, Name: "sample.mp4"}, nil)
+			},
+		},
+		"unique names name free": {
+			config: usecase.FileConfig{UniqueNames: true},
+			request: Request{
+				ctx:      context.Background(),
+				filePath: samplePath,
+			},
+			response: Response{
+				result: map[string]interface{}{"ID": 1},
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().FindFileByName(req.ctx, "", "sample.mp4").
+					Return(nil, entity.ErrorFileNotFound)
+				expectStage(m, req.ctx, req.filePath)
+				m.BlobStore.EXPECT().Stat(req.ctx, sampleKey).
+					Return(&storage.BlobInfo{Key: sampleKey}, nil)
+				m.BlobStore.EXPECT().Delete(req.ctx, stagingKey).
+					Return(nil)
+				m.FileRepository.EXPECT().CreateFile(req.ctx, &param.CreateFile{
+					Name:     "sample.mp4",
+					Size:     sampleSize,
+					MimeType: "video/mp4",
+					Digest:   sampleDigest,
+				}).Return(&entity.File{ID: 1, Digest: sampleDigest}, nil)
+				m.FileRepository.EXPECT().UpdateFileStatus(req.ctx, 1, entity.FileStatusReady).
+					Return(nil)
+				m.JobRepository.EXPECT().CreateJobs(req.ctx, fileJobs(1)).
+					Return([]*entity.Job{}, nil)
+			},
+		},
+		"Store error": {
+			request: Request{
+				ctx:      context.Background(),
+				filePath: samplePath,
+			},
+			response: Response{
+				result: nil,
+				err:    testutil.ErrStorage,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.BlobStore.EXPECT().Put(req.ctx, stagingKey, gomock.Any(), int64(-1)).
+					Return(testutil.ErrStorage)
+				m.BlobStore.EXPECT().Delete(req.ctx, stagingKey).
+					Return(storage.ErrBlobNotFound)
+			},
+		},
+		"Too large error": {
+			config: usecase.FileConfig{MaxSize: 1 << 20},
+			request: Request{
+				ctx:      context.Background(),
+				filePath: samplePath,
+			},
+			response: Response{
+				result: nil,
+				err:    "status 413: err File too large: files are limited to 1048576 bytes",
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.BlobStore.EXPECT().Put(req.ctx, stagingKey, gomock.Any(), int64(-1)).
+					DoAndReturn(func(_ context.Context, _ string, r io.Reader, _ int64) error {
+						_, err := io.Copy(io.Discard, r)
+						return err
+					})
+				m.BlobStore.EXPECT().Delete(req.ctx, stagingKey).
+					Return(nil)
+			},
+		},
+		"Unsupported type error": {
+			request: Request{
+				ctx:      context.Background(),
+				filePath: "./../../../test/post_4/test.txt",
+			},
+			response: Response{
+				result: nil,
+				err:    entity.ErrorFileUnsupported,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {},
+		},
+		"Invalid container error": {
+			request: Request{
+				ctx:      context.Background(),
+				filePath: truncated,
+			},
+			response: Response{
+				result: nil,
+				err:    `status 422: err File invalid: mp4: invalid box: "moov" at offset 2840583 has size 7625, 5417 bytes available`,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				expectStage(m, req.ctx, req.filePath)
+				m.BlobStore.EXPECT().Delete(req.ctx, stagingKey).
+					Return(nil)
+			},
+		},
+		"Container not allowed error": {
+			config: usecase.FileConfig{AllowedContainers: []string{"webm", "matroska"}},
+			request: Request{
+				ctx:      context.Background(),
+				filePath: samplePath,
+			},
+			response: Response{
+				result: nil,
+				err:    "status 415: err File unsupported: container mp4 is not allowed",
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				expectStage(m, req.ctx, req.filePath)
+				m.BlobStore.EXPECT().Delete(req.ctx, stagingKey).
+					Return(nil)
+			},
+		},
+		"Video codec not allowed error": {
+			config: usecase.FileConfig{AllowedVideoCodecs: []string{"hvc1", "avc1.42"}},
+			request: Request{
+				ctx:      context.Background(),
+				filePath: samplePath,
+			},
+			response: Response{
+				result: nil,
+				err:    "status 415: err File unsupported: video codec avc1.640028 is not allowed",
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				expectStage(m, req.ctx, req.filePath)
+				m.BlobStore.EXPECT().Delete(req.ctx, stagingKey).
+					Return(nil)
+			},
+		},
+		"Audio codec not allowed error": {
+			config: usecase.FileConfig{
+				AllowedVideoCodecs: []string{"AVC1"},
+				AllowedAudioCodecs: []string{"opus"},
+			},
+			request: Request{
+				ctx:      context.Background(),
+				filePath: samplePath,
+			},
+			response: Response{
+				result: nil,
+				err:    entity.ErrorFileUnsupported,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				expectStage(m, req.ctx, req.filePath)
+				m.BlobStore.EXPECT().Delete(req.ctx, stagingKey).
+					Return(nil)
+			},
+		},
+		"Commit error": {
+			request: Request{
+				ctx:      context.Background(),
+				filePath: samplePath,
+			},
+			response: Response{
+				result: nil,
+				err:    testutil.ErrStorage,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				expectStage(m, req.ctx, req.filePath)
+				m.FileRepository.EXPECT().CreateFile(req.ctx, &param.CreateFile{
+					Name:     "sample.mp4",
+					Size:     sampleSize,
+					MimeType: "video/mp4",
+					Digest:   sampleDigest,
+				}).Return(&entity.File{ID: 1, Digest: sampleDigest}, nil)
+				m.BlobStore.EXPECT().Stat(req.ctx, sampleKey).
+					Return(nil, storage.ErrBlobNotFound)
+				m.BlobStore.EXPECT().Move(req.ctx, stagingKey, sampleKey).
+					Return(testutil.ErrStorage)
+				m.BlobStore.EXPECT().Delete(req.ctx, stagingKey).
+					Return(nil)
+				m.FileRepository.EXPECT().UpdateFileStatus(req.ctx, 1, entity.FileStatusFailed).
+					Return(nil)
+				m.BlobRepository.EXPECT().ReleaseBlob(req.ctx, "", sampleDigest, gomock.Any()).
+					DoAndReturn(releaseLastBlob)
+				m.BlobStore.EXPECT().Delete(req.ctx, sampleKey).
+					Return(storage.ErrBlobNotFound)
+			},
+		},
+		"CreateFile error": {
+			request: Request{
+				ctx:      context.Background(),
+				filePath: samplePath,
+			},
+			response: Response{
+				result: nil,
+				err:    testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				expectStage(m, req.ctx, req.filePath)
+				m.FileRepository.EXPECT().CreateFile(req.ctx, &param.CreateFile{
+					Name:     "sample.mp4",
+					Size:     sampleSize,
+					MimeType: "video/mp4",
+					Digest:   sampleDigest,
+				}).Return(nil, testutil.ErrDB)
+				m.BlobStore.EXPECT().Delete(req.ctx, stagingKey).
+					Return(nil)
+			},
+		},
+		"UpdateFileStatus error": {
+			request: Request{
+				ctx:      context.Background(),
+				filePath: samplePath,
+			},
+			response: Response{
+				result: nil,
+				err:    testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				expectStage(m, req.ctx, req.filePath)
+				m.FileRepository.EXPECT().CreateFile(req.ctx, &param.CreateFile{
+					Name:     "sample.mp4",
+					Size:     sampleSize,
+					MimeType: "video/mp4",
+					Digest:   sampleDigest,
+				}).Return(&entity.File{ID: 1, Digest: sampleDigest}, nil)
+				m.BlobStore.EXPECT().Stat(req.ctx, sampleKey).
+					Return(nil, storage.ErrBlobNotFound)
+				m.BlobStore.EXPECT().Move(req.ctx, stagingKey, sampleKey).
+					Return(nil)
+				m.FileRepository.EXPECT().UpdateFileStatus(req.ctx, 1, entity.FileStatusReady).
+					Return(testutil.ErrDB)
+				m.FileRepository.EXPECT().UpdateFileStatus(req.ctx, 1, entity.FileStatusFailed).
+					Return(nil)
+				m.BlobRepository.EXPECT().ReleaseBlob(req.ctx, "", sampleDigest, gomock.Any()).
+					DoAndReturn(releaseLastBlob)
+				m.BlobStore.EXPECT().Delete(req.ctx, sampleKey).
+					Return(nil)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ucs, mocks := fixture.NewFileUsecase(ctrl, tc.config)
+			tc.mockFn(mocks, tc.request)
+
+			file, err := os.Open(tc.request.filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fileReader := util.NewFileReader(file, "sample.mp4")
+			defer fileReader.Close()
+
+			result, err := ucs.CreateFile(tc.request.ctx, fileReader)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			testutil.AssertStructExAc(t, tc.response.result, result)
+		})
+	}
+}
+
+func TestFileUsecase_ListFiles(t *testing.T) {
+	type Request struct {
+		ctx    context.Context
+		params *param.ListFiles
+	}
+
+	type Response struct {
+		result     []*entity.File
+		pagination *util.Pagination
+		err        interface{}
+	}
+
+	file := &entity.File{
+		ID:       1,
+		Name:     "Some Name",
+		Size:     100,
+		MimeType: "video/mp4",
+	}
+	next := &entity.File{
+		ID:       2,
+		Name:     "Other Name",
+		Size:     200,
+		MimeType: "video/mp4",
+	}
+	// {"s":"size","d":true,"i":2,"z":200}
+	cursor := "eyJzIjoic2l6ZSIsImQiOnRydWUsImkiOjIsInoiOjIwMH0"
+
+	testcases := map[string]struct {
+		request  Request
+		response Response
+		mockFn   func(*fixture.MockFileUsecase, Request)
+	}{
+		"success": {
+			request: Request{
+				ctx:    context.Background(),
+				params: &param.ListFiles{},
+			},
+			response: Response{
+				result:     []*entity.File{file},
+				pagination: util.NewPagination(util.DefaultLimit, 0, 1, ""),
+				err:        nil,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().CountFiles(req.ctx, &param.FileFilter{}).
+					Return(int64(1), nil)
+				m.FileRepository.EXPECT().ListFiles(req.ctx, &param.ListFiles{Sort: "id", Limit: util.DefaultLimit + 1}).
+					Return([]*entity.File{file}, nil)
+			},
+		},
+		"success next page": {
+			request: Request{
+				ctx:    context.Background(),
+				params: &param.ListFiles{Sort: "size", Desc: true, Limit: 2, Offset: 4},
+			},
+			response: Response{
+				result:     []*entity.File{file, next},
+				pagination: util.NewPagination(2, 4, 9, cursor),
+				err:        nil,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().CountFiles(req.ctx, &param.FileFilter{}).
+					Return(int64(9), nil)
+				m.FileRepository.EXPECT().ListFiles(req.ctx, &param.ListFiles{Sort: "size", Desc: true, Limit: 3, Offset: 4}).
+					Return([]*entity.File{file, next, {ID: 3}}, nil)
+			},
+		},
+		"success after cursor": {
+			request: Request{
+				ctx:    context.Background(),
+				params: &param.ListFiles{Sort: "size", Desc: true, Limit: 2, Offset: 4, Cursor: cursor},
+			},
+			response: Response{
+				result:     []*entity.File{file},
+				pagination: util.NewPagination(2, 0, 9, ""),
+				err:        nil,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().CountFiles(req.ctx, &param.FileFilter{}).
+					Return(int64(9), nil)
+				m.FileRepository.EXPECT().ListFiles(req.ctx, &param.ListFiles{
+					Sort:   "size",
+					Desc:   true,
+					Limit:  3,
+					Cursor: cursor,
+					After:  &param.FileCursor{Sort: "size", Desc: true, ID: 2, Size: 200},
+				}).Return([]*entity.File{file}, nil)
+			},
+		},
+		"limit error": {
+			request: Request{
+				ctx:    context.Background(),
+				params: &param.ListFiles{Limit: 1000},
+			},
+			response: Response{
+				err: "status 400: err Bad Request: limit must be between 1 and 100",
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {},
+		},
+		"sort error": {
+			request: Request{
+				ctx:    context.Background(),
+				params: &param.ListFiles{Sort: "digest"},
+			},
+			response: Response{
+				err: `status 400: err Bad Request: files can't be sorted on "digest"`,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {},
+		},
+		"broken for admin": {
+			request: Request{
+				ctx:    adminCtx,
+				params: &param.ListFiles{FileFilter: param.FileFilter{Status: entity.FileStatusBroken}},
+			},
+			response: Response{
+				result:     []*entity.File{file},
+				pagination: util.NewPagination(util.DefaultLimit, 0, 1, ""),
+				err:        nil,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				filter := &param.FileFilter{
+					Status: entity.FileStatusBroken,
+					Access: &param.FileAccess{TenantID: "acme", Subject: "root", All: true},
+				}
+				m.FileRepository.EXPECT().CountFiles(req.ctx, filter).
+					Return(int64(1), nil)
+				m.FileRepository.EXPECT().ListFiles(req.ctx, &param.ListFiles{FileFilter: *filter, Sort: "id", Limit: util.DefaultLimit + 1}).
+					Return([]*entity.File{file}, nil)
+			},
+		},
+		"broken for viewer error": {
+			request: Request{
+				ctx:    viewerCtx,
+				params: &param.ListFiles{FileFilter: param.FileFilter{Status: entity.FileStatusBroken}},
+			},
+			response: Response{
+				err: "status 403: err Forbidden: listing broken files requires the admin role",
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {},
+		},
+		"status error": {
+			request: Request{
+				ctx:    context.Background(),
+				params: &param.ListFiles{FileFilter: param.FileFilter{Status: entity.FileStatusFailed}},
+			},
+			response: Response{
+				err: "status 400: err Bad Request: status must be ready or broken",
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {},
+		},
+		"cursor sort mismatch error": {
+			request: Request{
+				ctx:    context.Background(),
+				params: &param.ListFiles{Sort: "size", Cursor: cursor},
+			},
+			response: Response{
+				err: entity.ErrorBadRequest,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {},
+		},
+		"cursor error": {
+			request: Request{
+				ctx:    context.Background(),
+				params: &param.ListFiles{Cursor: "not a cursor"},
+			},
+			response: Response{
+				err: "status 400: err Bad Request: invalid cursor",
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {},
+		},
+		"CountFiles error": {
+			request: Request{
+				ctx:    context.Background(),
+				params: &param.ListFiles{},
+			},
+			response: Response{
+				err: testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().CountFiles(req.ctx, &param.FileFilter{}).
+					Return(int64(0), testutil.ErrDB)
+			},
+		},
+		"ListFiles error": {
+			request: Request{
+				ctx:    context.Background(),
+				params: &param.ListFiles{},
+			},
+			response: Response{
+				err: testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().CountFiles(req.ctx, &param.FileFilter{}).
+					Return(int64(1), nil)
+				m.FileRepository.EXPECT().ListFiles(req.ctx, gomock.Any()).
+					Return(nil, testutil.ErrDB)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ucs, mocks := fixture.NewFileUsecase(ctrl, usecase.FileConfig{})
+			tc.mockFn(mocks, tc.request)
+
+			result, pagination, err := ucs.ListFiles(tc.request.ctx, tc.request.params)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			assert.Equal(t, len(tc.response.result), len(result))
+			if len(tc.response.result) > 0 {
+				testutil.AssertStructExAc(t, tc.response.result[0], result[0])
+			}
+			assert.Equal(t, tc.response.pagination, pagination)
+		})
+	}
+}
+
+func TestFileUsecase_GetFile(t *testing.T) {
+	type Request struct {
+		ctx context.Context
+		id  int
+	}
+
+	type Response struct {
+		result interface{}
+		err    error
+	}
+
+	testcases := map[string]struct {
+		request  Request
+		response Response
+		mockFn   func(*fixture.MockFileUsecase, Request)
+	}{
+		"success": {
+			request: Request{
+				ctx: context.Background(),
+				id:  1,
 			},
 			response: Response{
 				result: map[string]interface{}{"ID": 1},
 				err:    nil,
 			},
-			mockFn: func(m *fixture.MockFileUsecase, ctx context.Context, fileReader util.FileReader) {
-				m.FileRepository.EXPECT().CreateFile(ctx, &param.CreateFile{
-					Name:     fileReader.GetName(),
-					Size:     fileReader.GetSize(),
-					MimeType: "video/mp4",
-				}).Return(&entity.File{ID: 1}, nil)
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id).
+					Return(&entity.File{ID: 1}, nil)
 			},
 		},
-		"Unsupported type error": {
+		"owner": {
 			request: Request{
-				ctx:      context.Background(),
-				filePath: "./../../../test/post_4/test.txt",
+				ctx: viewerCtx,
+				id:  1,
+			},
+			response: Response{
+				result: map[string]interface{}{"ID": 1, "OwnerID": "alice", "TenantID": "acme"},
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id).
+					Return(&entity.File{ID: 1, OwnerID: "alice", TenantID: "acme"}, nil)
+			},
+		},
+		"granted": {
+			request: Request{
+				ctx: viewerCtx,
+				id:  1,
+			},
+			response: Response{
+				result: map[string]interface{}{"ID": 1, "OwnerID": "bob", "TenantID": "acme"},
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id).
+					Return(&entity.File{ID: 1, OwnerID: "bob", TenantID: "acme"}, nil)
+				m.GrantRepository.EXPECT().HasGrant(req.ctx, 1, "alice").
+					Return(true, nil)
+			},
+		},
+		"not granted": {
+			request: Request{
+				ctx: viewerCtx,
+				id:  1,
 			},
 			response: Response{
 				result: nil,
-				err:    entity.ErrorFileUnsupported,
+				err:    entity.ErrorFileNotFound,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id).
+					Return(&entity.File{ID: 1, OwnerID: "bob", TenantID: "acme"}, nil)
+				m.GrantRepository.EXPECT().HasGrant(req.ctx, 1, "alice").
+					Return(false, nil)
 			},
-			mockFn: func(m *fixture.MockFileUsecase, ctx context.Context, fileReader util.FileReader) {},
 		},
-		"CreateFile error": {
+		"admin": {
 			request: Request{
-				ctx:      context.Background(),
-				filePath: "./../../../test/post_1/sample.mp4",
+				ctx: adminCtx,
+				id:  1,
+			},
+			response: Response{
+				result: map[string]interface{}{"ID": 1, "OwnerID": "bob", "TenantID": "acme"},
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id).
+					Return(&entity.File{ID: 1, OwnerID: "bob", TenantID: "acme"}, nil)
+			},
+		},
+		"link": {
+			request: Request{
+				ctx: linkCtx,
+				id:  1,
+			},
+			response: Response{
+				result: map[string]interface{}{"ID": 1, "OwnerID": "bob", "TenantID": "acme"},
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id).
+					Return(&entity.File{ID: 1, OwnerID: "bob", TenantID: "acme"}, nil)
+			},
+		},
+		"link other file": {
+			request: Request{
+				ctx: linkCtx,
+				id:  2,
 			},
 			response: Response{
 				result: nil,
-				err:    testutil.ErrDB,
+				err:    entity.ErrorFileNotFound,
 			},
-			mockFn: func(m *fixture.MockFileUsecase, ctx context.Context, fileReader util.FileReader) {
-				m.FileRepository.EXPECT().CreateFile(ctx, &param.CreateFile{
-					Name:     fileReader.GetName(),
-					Size:     fileReader.GetSize(),
-					MimeType: "video/mp4",
-				}).Return(nil, testutil.ErrDB)
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id).
+					Return(&entity.File{ID: 2, OwnerID: "alice", TenantID: "acme"}, nil)
+			},
+		},
+		"other tenant": {
+			request: Request{
+				ctx: adminCtx,
+				id:  1,
+			},
+			response: Response{
+				result: nil,
+				err:    entity.ErrorFileNotFound,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id).
+					Return(&entity.File{ID: 1, OwnerID: "alice", TenantID: "globex"}, nil)
+			},
+		},
+		"scoped tenant": {
+			request: Request{
+				ctx: entity.WithTenant(context.Background(), "acme"),
+				id:  1,
+			},
+			response: Response{
+				result: map[string]interface{}{"ID": 1, "OwnerID": "bob", "TenantID": "acme"},
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id).
+					Return(&entity.File{ID: 1, OwnerID: "bob", TenantID: "acme"}, nil)
+			},
+		},
+		"scoped other tenant": {
+			request: Request{
+				ctx: entity.WithTenant(context.Background(), "acme"),
+				id:  1,
+			},
+			response: Response{
+				result: nil,
+				err:    entity.ErrorFileNotFound,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id).
+					Return(&entity.File{ID: 1, OwnerID: "alice", TenantID: "globex"}, nil)
 			},
 		},
 	}
@@ -80,34 +985,30 @@ func TestFileUsecase_CreateFile(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			ucs, mocks := fixture.NewFileUsecase(ctrl)
-
-			httpRequest := testutil.RequestPayloadCreateFile(tc.request.filePath)
-			reqFile, reqFileHeader, _ := httpRequest.FormFile("data")
-			fileReader := util.NewFileReader(reqFile, reqFileHeader)
-			defer reqFile.Close()
-			tc.mockFn(mocks, tc.request.ctx, fileReader)
+			ucs, mocks := fixture.NewFileUsecase(ctrl, usecase.FileConfig{})
+			tc.mockFn(mocks, tc.request)
 
-			result, err := ucs.CreateFile(tc.request.ctx, fileReader)
+			result, err := ucs.GetFile(tc.request.ctx, tc.request.id)
 			testutil.AssertErrorExAc(t, tc.response.err, err)
 			testutil.AssertStructExAc(t, tc.response.result, result)
 		})
 	}
 }
 
-func TestFileUsecase_ListFiles(t *testing.T) {
+func TestFileUsecase_OpenFile(t *testing.T) {
 	type Request struct {
 		ctx context.Context
+		id  int
 	}
 
 	type Response struct {
-		result []*entity.File
+		result interface{}
 		err    error
 	}
 
 	file := &entity.File{
 		ID:       1,
-		Name:     "Some Name",
+		Name:     "The Name",
 		Size:     100,
 		MimeType: "video/mp4",
 	}
@@ -120,27 +1021,63 @@ func TestFileUsecase_ListFiles(t *testing.T) {
 		"success": {
 			request: Request{
 				ctx: context.Background(),
+				id:  1,
 			},
 			response: Response{
-				result: []*entity.File{file},
+				result: map[string]interface{}{"ID": 1},
 				err:    nil,
 			},
 			mockFn: func(m *fixture.MockFileUsecase, req Request) {
-				m.FileRepository.EXPECT().ListFiles(req.ctx).
-					Return([]*entity.File{file}, nil)
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id).
+					Return(file, nil)
+				m.BlobStore.EXPECT().Get(req.ctx, "The Name").
+					Return(nil, nil)
 			},
 		},
-		"ListFiles error": {
+		"GetFile error": {
 			request: Request{
 				ctx: context.Background(),
+				id:  1,
 			},
 			response: Response{
 				result: nil,
-				err:    testutil.ErrDB,
+				err:    entity.ErrorFileNotFound,
 			},
 			mockFn: func(m *fixture.MockFileUsecase, req Request) {
-				m.FileRepository.EXPECT().ListFiles(req.ctx).
-					Return(nil, testutil.ErrDB)
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id).
+					Return(nil, entity.ErrorFileNotFound)
+			},
+		},
+		"blob not found": {
+			request: Request{
+				ctx: context.Background(),
+				id:  1,
+			},
+			response: Response{
+				result: nil,
+				err:    entity.ErrorFileNotFound,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id).
+					Return(file, nil)
+				m.BlobStore.EXPECT().Get(req.ctx, "The Name").
+					Return(nil, storage.ErrBlobNotFound)
+			},
+		},
+		"storage error": {
+			request: Request{
+				ctx: context.Background(),
+				id:  1,
+			},
+			response: Response{
+				result: nil,
+				err:    testutil.ErrStorage,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id).
+					Return(file, nil)
+				m.BlobStore.EXPECT().Get(req.ctx, "The Name").
+					Return(nil, testutil.ErrStorage)
 			},
 		},
 	}
@@ -149,19 +1086,17 @@ func TestFileUsecase_ListFiles(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			ucs, mocks := fixture.NewFileUsecase(ctrl)
+			ucs, mocks := fixture.NewFileUsecase(ctrl, usecase.FileConfig{})
 			tc.mockFn(mocks, tc.request)
 
-			result, err := ucs.ListFiles(tc.request.ctx)
+			result, _, err := ucs.OpenFile(tc.request.ctx, tc.request.id)
 			testutil.AssertErrorExAc(t, tc.response.err, err)
-			if len(tc.response.result) > 0 {
-				testutil.AssertStructExAc(t, tc.response.result[0], result[0])
-			}
+			testutil.AssertStructExAc(t, tc.response.result, result)
 		})
 	}
 }
 
-func TestFileUsecase_GetFile(t *testing.T) {
+func TestFileUsecase_GetFileMetadata(t *testing.T) {
 	type Request struct {
 		ctx context.Context
 		id  int
@@ -172,6 +1107,14 @@ func TestFileUsecase_GetFile(t *testing.T) {
 		err    error
 	}
 
+	file := &entity.File{
+		ID:       1,
+		Name:     "sample.mp4",
+		Size:     2848208,
+		MimeType: "video/mp4",
+		Digest:   sampleDigest,
+	}
+
 	testcases := map[string]struct {
 		request  Request
 		response Response
@@ -183,12 +1126,84 @@ func TestFileUsecase_GetFile(t *testing.T) {
 				id:  1,
 			},
 			response: Response{
-				result: map[string]interface{}{"ID": 1},
+				result: map[string]interface{}{"FileID": 1, "Width": 1920},
 				err:    nil,
 			},
 			mockFn: func(m *fixture.MockFileUsecase, req Request) {
 				m.FileRepository.EXPECT().GetFile(req.ctx, req.id).
-					Return(&entity.File{ID: 1}, nil)
+					Return(file, nil)
+				m.MetadataRepository.EXPECT().GetMetadata(req.ctx, req.id).
+					Return(&entity.VideoMetadata{FileID: 1, Width: 1920}, nil)
+			},
+		},
+		"probe file uploaded before metadata": {
+			request: Request{
+				ctx: context.Background(),
+				id:  1,
+			},
+			response: Response{
+				result: map[string]interface{}{"FileID": 1, "Width": 1920},
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				blob, _ := os.Open("./../../../test/post_1/sample.mp4")
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id).
+					Return(file, nil)
+				m.MetadataRepository.EXPECT().GetMetadata(req.ctx, req.id).
+					Return(nil, entity.ErrorMetadataNotFound)
+				m.BlobStore.EXPECT().Get(req.ctx, sampleKey).
+					Return(blob, nil)
+				m.MetadataRepository.EXPECT().CreateMetadata(req.ctx, sampleMetadata(1)).
+					Return(&entity.VideoMetadata{FileID: 1, Width: 1920}, nil)
+			},
+		},
+		"unreadable content": {
+			request: Request{
+				ctx: context.Background(),
+				id:  1,
+			},
+			response: Response{
+				result: nil,
+				err:    entity.ErrorMetadataNotFound,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				blob, _ := os.Open("./../../../test/post_4/test.txt")
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id).
+					Return(&entity.File{ID: 1, Size: 19, Digest: sampleDigest}, nil)
+				m.MetadataRepository.EXPECT().GetMetadata(req.ctx, req.id).
+					Return(nil, entity.ErrorMetadataNotFound)
+				m.BlobStore.EXPECT().Get(req.ctx, sampleKey).
+					Return(blob, nil)
+			},
+		},
+		"file not found": {
+			request: Request{
+				ctx: context.Background(),
+				id:  1,
+			},
+			response: Response{
+				result: nil,
+				err:    entity.ErrorFileNotFound,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id).
+					Return(nil, entity.ErrorFileNotFound)
+			},
+		},
+		"db error": {
+			request: Request{
+				ctx: context.Background(),
+				id:  1,
+			},
+			response: Response{
+				result: nil,
+				err:    testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id).
+					Return(file, nil)
+				m.MetadataRepository.EXPECT().GetMetadata(req.ctx, req.id).
+					Return(nil, testutil.ErrDB)
 			},
 		},
 	}
@@ -197,10 +1212,10 @@ func TestFileUsecase_GetFile(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			ucs, mocks := fixture.NewFileUsecase(ctrl)
+			ucs, mocks := fixture.NewFileUsecase(ctrl, usecase.FileConfig{})
 			tc.mockFn(mocks, tc.request)
 
-			result, err := ucs.GetFile(tc.request.ctx, tc.request.id)
+			result, err := ucs.GetFileMetadata(tc.request.ctx, tc.request.id)
 			testutil.AssertErrorExAc(t, tc.response.err, err)
 			testutil.AssertStructExAc(t, tc.response.result, result)
 		})
@@ -238,10 +1253,95 @@ func TestFileUsecase_UpdateFile(t *testing.T) {
 				err: nil,
 			},
 			mockFn: func(m *fixture.MockFileUsecase, req Request) {
-				m.FileRepository.EXPECT().GetFile(req.ctx, req.id).
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id, entity.FileStatusReady, entity.FileStatusBroken).
+					Return(file, nil)
+				m.BlobStore.EXPECT().Delete(req.ctx, "The Name").
+					Return(nil)
+				m.FileRepository.EXPECT().DeleteFile(req.ctx, req.id).
+					Return(nil)
+			},
+		},
+		"viewer forbidden": {
+			request: Request{
+				ctx: viewerCtx,
+				id:  1,
+			},
+			response: Response{
+				err: entity.ErrorForbidden,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id, entity.FileStatusReady, entity.FileStatusBroken).
+					Return(&entity.File{ID: 1, OwnerID: "alice", TenantID: "acme"}, nil)
+			},
+		},
+		"success content addressed": {
+			request: Request{
+				ctx: context.Background(),
+				id:  2,
+			},
+			response: Response{
+				err: nil,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id, entity.FileStatusReady, entity.FileStatusBroken).
+					Return(&entity.File{ID: 2, Digest: sampleDigest}, nil)
+				m.FileRepository.EXPECT().DeleteFile(req.ctx, req.id).
+					Return(nil)
+				m.BlobRepository.EXPECT().ReleaseBlob(req.ctx, "", sampleDigest, gomock.Any()).
+					DoAndReturn(releaseLastBlob)
+				m.BlobStore.EXPECT().Delete(req.ctx, sampleKey).
+					Return(nil)
+			},
+		},
+		"success content still referenced": {
+			request: Request{
+				ctx: context.Background(),
+				id:  2,
+			},
+			response: Response{
+				err: nil,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id, entity.FileStatusReady, entity.FileStatusBroken).
+					Return(&entity.File{ID: 2, Digest: sampleDigest}, nil)
+				m.FileRepository.EXPECT().DeleteFile(req.ctx, req.id).
+					Return(nil)
+				m.BlobRepository.EXPECT().ReleaseBlob(req.ctx, "", sampleDigest, gomock.Any()).
+					Return(int64(1), nil)
+			},
+		},
+		"blob already missing": {
+			request: Request{
+				ctx: context.Background(),
+				id:  1,
+			},
+			response: Response{
+				err: nil,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id, entity.FileStatusReady, entity.FileStatusBroken).
+					Return(file, nil)
+				m.BlobStore.EXPECT().Delete(req.ctx, "The Name").
+					Return(storage.ErrBlobNotFound)
+				m.FileRepository.EXPECT().DeleteFile(req.ctx, req.id).
+					Return(nil)
+			},
+		},
+		"Delete blob error": {
+			request: Request{
+				ctx: context.Background(),
+				id:  1,
+			},
+			response: Response{
+				err: testutil.ErrStorage,
+			},
+			mockFn: func(m *fixture.MockFileUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id, entity.FileStatusReady, entity.FileStatusBroken).
 					Return(file, nil)
 				m.FileRepository.EXPECT().DeleteFile(req.ctx, req.id).
 					Return(nil)
+				m.BlobStore.EXPECT().Delete(req.ctx, "The Name").
+					Return(testutil.ErrStorage)
 			},
 		},
 		"GetFile error": {
@@ -253,7 +1353,7 @@ func TestFileUsecase_UpdateFile(t *testing.T) {
 				err: testutil.ErrDB,
 			},
 			mockFn: func(m *fixture.MockFileUsecase, req Request) {
-				m.FileRepository.EXPECT().GetFile(req.ctx, req.id).
+				m.FileRepository.EXPECT().GetFile(req.ctx, req.id, entity.FileStatusReady, entity.FileStatusBroken).
 					Return(nil, testutil.ErrDB)
 			},
 		},
@@ -263,7 +1363,7 @@ func TestFileUsecase_UpdateFile(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			ucs, mocks := fixture.NewFileUsecase(ctrl)
+			ucs, mocks := fixture.NewFileUsecase(ctrl, usecase.FileConfig{})
 			tc.mockFn(mocks, tc.request)
 
 			err := ucs.DeleteFile(tc.request.ctx, tc.request.id)
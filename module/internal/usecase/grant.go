@@ -0,0 +1,95 @@
+package usecase
+
+//go:generate mockgen -source grant.go -destination mock/grant.go
+
+import (
+	"context"
+
+	"video-server/module/entity"
+	repository "video-server/module/internal/repository"
+	"video-server/module/param"
+)
+
+// GrantUsecase shares files with other principals of their tenant. Only
+// those who may change a file, its owner and admins, manage its grants.
+type GrantUsecase interface {
+	ListGrants(ctx context.Context, fileID int) ([]*entity.FileGrant, error)
+	CreateGrant(ctx context.Context, fileID int, subject string) error
+	DeleteGrant(ctx context.Context, fileID int, subject string) error
+}
+
+type grantUsecaseRepository struct {
+	file  repository.FileRepository
+	grant repository.GrantRepository
+}
+
+type grantUsecase struct {
+	repository grantUsecaseRepository
+	access     fileAccess
+}
+
+func NewGrantUsecase(
+	fileRepository repository.FileRepository,
+	grantRepository repository.GrantRepository,
+) *grantUsecase {
+	return &grantUsecase{
+		repository: grantUsecaseRepository{
+			file:  fileRepository,
+			grant: grantRepository,
+		},
+		access: fileAccess{grant: grantRepository},
+	}
+}
+
+func (u *grantUsecase) ListGrants(ctx context.Context, fileID int) ([]*entity.FileGrant, error) {
+	_, err := u.writableFile(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.repository.grant.ListGrants(ctx, fileID)
+}
+
+func (u *grantUsecase) CreateGrant(ctx context.Context, fileID int, subject string) error {
+	if subject == "" {
+		return entity.WithField(entity.ErrorBadRequest, "subject", "subject must not be empty")
+	}
+
+	file, err := u.writableFile(ctx, fileID)
+	if err != nil {
+		return err
+	}
+	if subject == file.OwnerID {
+		return entity.WithReason(entity.ErrorBadRequest, "the owner already has access")
+	}
+
+	grantedBy, _ := u.access.owner(ctx)
+	return u.repository.grant.CreateGrant(ctx, &param.CreateGrant{
+		FileID:    fileID,
+		Subject:   subject,
+		GrantedBy: grantedBy,
+	})
+}
+
+func (u *grantUsecase) DeleteGrant(ctx context.Context, fileID int, subject string) error {
+	_, err := u.writableFile(ctx, fileID)
+	if err != nil {
+		return err
+	}
+
+	return u.repository.grant.DeleteGrant(ctx, fileID, subject)
+}
+
+func (u *grantUsecase) writableFile(ctx context.Context, fileID int) (*entity.File, error) {
+	file, err := u.repository.file.GetFile(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.access.write(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
@@ -0,0 +1,129 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"video-server/internal/testutil"
+	"video-server/module/entity"
+	"video-server/module/fixture"
+	"video-server/module/param"
+)
+
+func TestGrantUsecase_CreateGrant(t *testing.T) {
+	type Request struct {
+		ctx     context.Context
+		subject string
+	}
+
+	ownerCtx := entity.WithPrincipal(context.Background(), &entity.Principal{Subject: "bob", Role: entity.RoleUploader, Tenant: "acme"})
+	file := &entity.File{ID: 1, OwnerID: "bob", TenantID: "acme"}
+
+	testcases := map[string]struct {
+		request Request
+		err     interface{}
+		mockFn  func(*fixture.MockGrantUsecase, Request)
+	}{
+		"owner": {
+			request: Request{ctx: ownerCtx, subject: "alice"},
+			mockFn: func(m *fixture.MockGrantUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, 1).Return(file, nil)
+				m.GrantRepository.EXPECT().CreateGrant(req.ctx, &param.CreateGrant{
+					FileID:    1,
+					Subject:   "alice",
+					GrantedBy: "bob",
+				}).Return(nil)
+			},
+		},
+		"admin": {
+			request: Request{ctx: adminCtx, subject: "alice"},
+			mockFn: func(m *fixture.MockGrantUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, 1).Return(file, nil)
+				m.GrantRepository.EXPECT().CreateGrant(req.ctx, &param.CreateGrant{
+					FileID:    1,
+					Subject:   "alice",
+					GrantedBy: "root",
+				}).Return(nil)
+			},
+		},
+		"granted viewer": {
+			request: Request{ctx: viewerCtx, subject: "carol"},
+			err:     "status 403: err Forbidden: only the owner or an admin may change the file",
+			mockFn: func(m *fixture.MockGrantUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, 1).Return(file, nil)
+				m.GrantRepository.EXPECT().HasGrant(req.ctx, 1, "alice").Return(true, nil)
+			},
+		},
+		"hidden file": {
+			request: Request{ctx: viewerCtx, subject: "carol"},
+			err:     entity.ErrorFileNotFound,
+			mockFn: func(m *fixture.MockGrantUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, 1).Return(file, nil)
+				m.GrantRepository.EXPECT().HasGrant(req.ctx, 1, "alice").Return(false, nil)
+			},
+		},
+		"owner subject": {
+			request: Request{ctx: ownerCtx, subject: "bob"},
+			err:     "status 400: err Bad Request: the owner already has access",
+			mockFn: func(m *fixture.MockGrantUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, 1).Return(file, nil)
+			},
+		},
+		"empty subject": {
+			request: Request{ctx: ownerCtx, subject: ""},
+			err:     "status 400: err Bad Request: subject must not be empty",
+			mockFn:  func(m *fixture.MockGrantUsecase, req Request) {},
+		},
+		"db error": {
+			request: Request{ctx: ownerCtx, subject: "alice"},
+			err:     testutil.ErrDB,
+			mockFn: func(m *fixture.MockGrantUsecase, req Request) {
+				m.FileRepository.EXPECT().GetFile(req.ctx, 1).Return(nil, testutil.ErrDB)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ucs, mocks := fixture.NewGrantUsecase(ctrl)
+			tc.mockFn(mocks, tc.request)
+
+			err := ucs.CreateGrant(tc.request.ctx, 1, tc.request.subject)
+			testutil.AssertErrorExAc(t, tc.err, err)
+		})
+	}
+}
+
+func TestGrantUsecase_ListGrants(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ucs, mocks := fixture.NewGrantUsecase(ctrl)
+	mocks.FileRepository.EXPECT().GetFile(adminCtx, 1).
+		Return(&entity.File{ID: 1, OwnerID: "bob", TenantID: "acme"}, nil)
+	mocks.GrantRepository.EXPECT().ListGrants(adminCtx, 1).
+		Return([]*entity.FileGrant{{ID: 1, FileID: 1, Subject: "alice"}}, nil)
+
+	grants, err := ucs.ListGrants(adminCtx, 1)
+	assert.NoError(t, err)
+	assert.Len(t, grants, 1)
+}
+
+func TestGrantUsecase_DeleteGrant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ucs, mocks := fixture.NewGrantUsecase(ctrl)
+	mocks.FileRepository.EXPECT().GetFile(adminCtx, 1).
+		Return(&entity.File{ID: 1, OwnerID: "bob", TenantID: "acme"}, nil).Times(2)
+	mocks.GrantRepository.EXPECT().DeleteGrant(adminCtx, 1, "alice").Return(nil)
+	mocks.GrantRepository.EXPECT().DeleteGrant(adminCtx, 1, "carol").Return(entity.ErrorGrantNotFound)
+
+	assert.NoError(t, ucs.DeleteGrant(adminCtx, 1, "alice"))
+	assert.ErrorIs(t, ucs.DeleteGrant(adminCtx, 1, "carol"), entity.ErrorGrantNotFound)
+}
@@ -0,0 +1,170 @@
+package usecase
+
+//go:generate mockgen -source health.go -destination mock/health.go
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"video-server/internal/logging"
+	"video-server/internal/storage"
+	"video-server/module/entity"
+	repository "video-server/module/internal/repository"
+)
+
+// healthProbePrefix holds the blobs written by the storage check, they are
+// deleted right away.
+const healthProbePrefix = "health/"
+
+type HealthConfig struct {
+	// MinFreeBytes is the free space below which the disk is down, 0
+	// doesn't check
+	MinFreeBytes int64
+	// Timeout bounds every check
+	Timeout time.Duration
+}
+
+type HealthUsecase interface {
+	// Live tells the process is able to serve, it checks no dependency.
+	Live(ctx context.Context) *entity.Health
+	// Ready checks every dependency a request may need.
+	Ready(ctx context.Context) *entity.Health
+}
+
+type healthUsecaseRepository struct {
+	health repository.HealthRepository
+}
+
+type healthUsecase struct {
+	repository healthUsecaseRepository
+	blobStore  storage.BlobStore
+	space      storage.SpaceReporter
+	config     HealthConfig
+}
+
+// NewHealthUsecase skips the disk check when space is nil.
+func NewHealthUsecase(healthRepository repository.HealthRepository, blobStore storage.BlobStore, space storage.SpaceReporter, cfg HealthConfig) *healthUsecase {
+	return &healthUsecase{
+		repository: healthUsecaseRepository{
+			health: healthRepository,
+		},
+		blobStore: blobStore,
+		space:     space,
+		config:    cfg,
+	}
+}
+
+func (u *healthUsecase) Live(ctx context.Context) *entity.Health {
+	return &entity.Health{Status: entity.HealthUp}
+}
+
+func (u *healthUsecase) Ready(ctx context.Context) *entity.Health {
+	checks := map[string]func(context.Context) *entity.ComponentHealth{
+		entity.HealthDatabase: u.checkDatabase,
+		entity.HealthStorage:  u.checkStorage,
+	}
+	if u.space != nil {
+		checks[entity.HealthDisk] = u.checkDisk
+	}
+
+	health := &entity.Health{
+		Status:     entity.HealthUp,
+		Components: make(map[string]*entity.ComponentHealth, len(checks)),
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) *entity.ComponentHealth) {
+			defer wg.Done()
+			checkCtx, cancel := u.withTimeout(ctx)
+			defer cancel()
+			component := check(checkCtx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			health.Components[name] = component
+			if component.Status != entity.HealthUp {
+				health.Status = entity.HealthDown
+			}
+		}(name, check)
+	}
+	wg.Wait()
+	return health
+}
+
+func (u *healthUsecase) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if u.config.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, u.config.Timeout)
+}
+
+func (u *healthUsecase) checkDatabase(ctx context.Context) *entity.ComponentHealth {
+	if err := u.repository.health.Ping(ctx); err != nil {
+		return componentDown(ctx, "ping failed", err)
+	}
+	return &entity.ComponentHealth{Status: entity.HealthUp}
+}
+
+// checkStorage writes, reads back and deletes a probe blob, a store that
+// is reachable but read-only or full is down.
+func (u *healthUsecase) checkStorage(ctx context.Context) *entity.ComponentHealth {
+	key := healthProbePrefix + uuid.NewString()
+	content := []byte(key)
+
+	if err := u.blobStore.Put(ctx, key, bytes.NewReader(content), int64(len(content))); err != nil {
+		return componentDown(ctx, "write failed", err)
+	}
+	// the probe is removed even when reading it fails
+	defer func() {
+		if err := u.blobStore.Delete(ctx, key); err != nil {
+			logging.FromContext(ctx).WithError(err).WithField("key", key).Warn("health check: delete probe blob")
+		}
+	}()
+
+	blob, err := u.blobStore.Get(ctx, key)
+	if err != nil {
+		return componentDown(ctx, "read failed", err)
+	}
+	defer blob.Close()
+	read, err := io.ReadAll(blob)
+	if err != nil {
+		return componentDown(ctx, "read failed", err)
+	}
+	if !bytes.Equal(read, content) {
+		return componentDown(ctx, "read back mismatch", fmt.Errorf("read %d bytes, wrote %d", len(read), len(content)))
+	}
+	return &entity.ComponentHealth{Status: entity.HealthUp}
+}
+
+func (u *healthUsecase) checkDisk(ctx context.Context) *entity.ComponentHealth {
+	space, err := u.space.Space(ctx)
+	if err != nil {
+		return componentDown(ctx, "space unavailable", err)
+	}
+
+	component := &entity.ComponentHealth{
+		Status:       entity.HealthUp,
+		Space:        &entity.DiskSpace{FreeBytes: space.Free, TotalBytes: space.Total},
+		MinFreeBytes: u.config.MinFreeBytes,
+	}
+	if space.Free < u.config.MinFreeBytes {
+		component.Status = entity.HealthDown
+		component.Error = "free space below threshold"
+	}
+	return component
+}
+
+// componentDown logs err, the probe is public and only names the step
+// that failed.
+func componentDown(ctx context.Context, reason string, err error) *entity.ComponentHealth {
+	logging.FromContext(ctx).WithError(err).WithField("reason", reason).Warn("health check failed")
+	return &entity.ComponentHealth{Status: entity.HealthDown, Error: reason}
+}
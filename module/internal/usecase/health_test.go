@@ -0,0 +1,216 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"video-server/internal/storage"
+	"video-server/internal/testutil"
+	"video-server/module/entity"
+	"video-server/module/fixture"
+	mock_repository "video-server/module/internal/repository/mock"
+	"video-server/module/internal/usecase"
+)
+
+// expectProbe expects the storage probe to be written and deleted, the read
+// returns what was written unless corrupt is set.
+func expectProbe(t *testing.T, m *fixture.MockHealthUsecase, corrupt bool) {
+	path := filepath.Join(t.TempDir(), "probe")
+	m.BlobStore.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, r io.Reader, _ int64) error {
+			content, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			if corrupt {
+				content = content[1:]
+			}
+			return os.WriteFile(path, content, 0o600)
+		})
+	m.BlobStore.EXPECT().Get(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(context.Context, string) (storage.Blob, error) {
+			return os.Open(path)
+		})
+	m.BlobStore.EXPECT().Delete(gomock.Any(), gomock.Any()).
+		Return(nil)
+}
+
+func TestHealthUsecase_Ready(t *testing.T) {
+	cfg := usecase.HealthConfig{MinFreeBytes: 1000}
+	space := &storage.Space{Free: 5000, Total: 10000}
+	disk := &entity.ComponentHealth{
+		Status:       entity.HealthUp,
+		Space:        &entity.DiskSpace{FreeBytes: 5000, TotalBytes: 10000},
+		MinFreeBytes: 1000,
+	}
+	up := &entity.ComponentHealth{Status: entity.HealthUp}
+
+	testcases := map[string]struct {
+		expected *entity.Health
+		mockFn   func(*testing.T, *fixture.MockHealthUsecase)
+	}{
+		"up": {
+			expected: &entity.Health{
+				Status: entity.HealthUp,
+				Components: map[string]*entity.ComponentHealth{
+					entity.HealthDatabase: up,
+					entity.HealthStorage:  up,
+					entity.HealthDisk:     disk,
+				},
+			},
+			mockFn: func(t *testing.T, m *fixture.MockHealthUsecase) {
+				m.HealthRepository.EXPECT().Ping(gomock.Any()).Return(nil)
+				expectProbe(t, m, false)
+				m.SpaceReporter.EXPECT().Space(gomock.Any()).Return(space, nil)
+			},
+		},
+		"database down": {
+			expected: &entity.Health{
+				Status: entity.HealthDown,
+				Components: map[string]*entity.ComponentHealth{
+					entity.HealthDatabase: {Status: entity.HealthDown, Error: "ping failed"},
+					entity.HealthStorage:  up,
+					entity.HealthDisk:     disk,
+				},
+			},
+			mockFn: func(t *testing.T, m *fixture.MockHealthUsecase) {
+				m.HealthRepository.EXPECT().Ping(gomock.Any()).Return(testutil.ErrDB)
+				expectProbe(t, m, false)
+				m.SpaceReporter.EXPECT().Space(gomock.Any()).Return(space, nil)
+			},
+		},
+		"storage unwritable": {
+			expected: &entity.Health{
+				Status: entity.HealthDown,
+				Components: map[string]*entity.ComponentHealth{
+					entity.HealthDatabase: up,
+					entity.HealthStorage:  {Status: entity.HealthDown, Error: "write failed"},
+					entity.HealthDisk:     disk,
+				},
+			},
+			mockFn: func(t *testing.T, m *fixture.MockHealthUsecase) {
+				m.HealthRepository.EXPECT().Ping(gomock.Any()).Return(nil)
+				m.BlobStore.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(errors.New("read-only file system"))
+				m.SpaceReporter.EXPECT().Space(gomock.Any()).Return(space, nil)
+			},
+		},
+		"storage unreadable": {
+			expected: &entity.Health{
+				Status: entity.HealthDown,
+				Components: map[string]*entity.ComponentHealth{
+					entity.HealthDatabase: up,
+					entity.HealthStorage:  {Status: entity.HealthDown, Error: "read failed"},
+					entity.HealthDisk:     disk,
+				},
+			},
+			mockFn: func(t *testing.T, m *fixture.MockHealthUsecase) {
+				m.HealthRepository.EXPECT().Ping(gomock.Any()).Return(nil)
+				m.BlobStore.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil)
+				m.BlobStore.EXPECT().Get(gomock.Any(), gomock.Any()).
+					Return(nil, storage.ErrBlobNotFound)
+				m.BlobStore.EXPECT().Delete(gomock.Any(), gomock.Any()).
+					Return(nil)
+				m.SpaceReporter.EXPECT().Space(gomock.Any()).Return(space, nil)
+			},
+		},
+		"storage corrupt": {
+			expected: &entity.Health{
+				Status: entity.HealthDown,
+				Components: map[string]*entity.ComponentHealth{
+					entity.HealthDatabase: up,
+					entity.HealthStorage:  {Status: entity.HealthDown, Error: "read back mismatch"},
+					entity.HealthDisk:     disk,
+				},
+			},
+			mockFn: func(t *testing.T, m *fixture.MockHealthUsecase) {
+				m.HealthRepository.EXPECT().Ping(gomock.Any()).Return(nil)
+				expectProbe(t, m, true)
+				m.SpaceReporter.EXPECT().Space(gomock.Any()).Return(space, nil)
+			},
+		},
+		"disk full": {
+			expected: &entity.Health{
+				Status: entity.HealthDown,
+				Components: map[string]*entity.ComponentHealth{
+					entity.HealthDatabase: up,
+					entity.HealthStorage:  up,
+					entity.HealthDisk: {
+						Status:       entity.HealthDown,
+						Error:        "free space below threshold",
+						Space:        &entity.DiskSpace{FreeBytes: 999, TotalBytes: 10000},
+						MinFreeBytes: 1000,
+					},
+				},
+			},
+			mockFn: func(t *testing.T, m *fixture.MockHealthUsecase) {
+				m.HealthRepository.EXPECT().Ping(gomock.Any()).Return(nil)
+				expectProbe(t, m, false)
+				m.SpaceReporter.EXPECT().Space(gomock.Any()).Return(&storage.Space{Free: 999, Total: 10000}, nil)
+			},
+		},
+		"space error": {
+			expected: &entity.Health{
+				Status: entity.HealthDown,
+				Components: map[string]*entity.ComponentHealth{
+					entity.HealthDatabase: up,
+					entity.HealthStorage:  up,
+					entity.HealthDisk:     {Status: entity.HealthDown, Error: "space unavailable"},
+				},
+			},
+			mockFn: func(t *testing.T, m *fixture.MockHealthUsecase) {
+				m.HealthRepository.EXPECT().Ping(gomock.Any()).Return(nil)
+				expectProbe(t, m, false)
+				m.SpaceReporter.EXPECT().Space(gomock.Any()).Return(nil, errors.New("no such file or directory"))
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ucs, mocks := fixture.NewHealthUsecase(ctrl, cfg)
+			tc.mockFn(t, mocks)
+
+			assert.Equal(t, tc.expected, ucs.Ready(context.Background()))
+		})
+	}
+}
+
+func TestHealthUsecase_Ready_NoSpace(t *testing.T) {
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mock_repository.NewMockHealthRepository(ctrl)
+	repo.EXPECT().Ping(gomock.Any()).Return(nil)
+	ucs := usecase.NewHealthUsecase(repo, store, nil, usecase.HealthConfig{})
+
+	health := ucs.Ready(context.Background())
+	assert.Equal(t, entity.HealthUp, health.Status)
+	assert.NotContains(t, health.Components, entity.HealthDisk)
+
+	// the probe is gone
+	blobs, err := store.List(context.Background(), "health/")
+	require.NoError(t, err)
+	assert.Empty(t, blobs)
+}
+
+func TestHealthUsecase_Live(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ucs, _ := fixture.NewHealthUsecase(ctrl, usecase.HealthConfig{})
+	assert.Equal(t, &entity.Health{Status: entity.HealthUp}, ucs.Live(context.Background()))
+}
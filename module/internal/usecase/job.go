@@ -0,0 +1,315 @@
+package usecase
+
+//go:generate mockgen -source job.go -destination mock/job.go
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+
+	"video-server/internal/logging"
+	"video-server/internal/storage"
+	"video-server/internal/tracing"
+	"video-server/module/entity"
+	repository "video-server/module/internal/repository"
+	"video-server/module/param"
+)
+
+var (
+	errUnknownJobType = errors.New("unknown job type")
+	errDigestMismatch = errors.New("stored content doesn't match its digest")
+)
+
+type JobConfig struct {
+	// MaxAttempts before a job is dead
+	MaxAttempts int
+
+	// Backoff is the delay before the first retry, it doubles with every
+	// further attempt up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// Lease bounds how long an attempt may run, a job whose worker died is
+	// taken over once it expires
+	Lease time.Duration
+}
+
+type JobUsecase interface {
+	GetJob(ctx context.Context, id int) (*entity.Job, error)
+	ListFileJobs(ctx context.Context, fileID int) ([]*entity.Job, error)
+	// RunNext claims the next due job and runs it, it returns false when no
+	// job was due
+	RunNext(ctx context.Context) (bool, error)
+}
+
+type jobUsecaseRepository struct {
+	job  repository.JobRepository
+	file repository.FileRepository
+}
+
+type jobUsecase struct {
+	repository jobUsecaseRepository
+	storage    storage.BlobStore
+	file       FileUsecase
+	stream     StreamUsecase
+	thumbnail  ThumbnailUsecase
+	config     JobConfig
+}
+
+// jobFunc runs one job, reporting progress in percent as it goes.
+type jobFunc func(ctx context.Context, job *entity.Job, progress func(int)) error
+
+func NewJobUsecase(
+	jobRepository repository.JobRepository,
+	fileRepository repository.FileRepository,
+	blobStore storage.BlobStore,
+	fileUsecase FileUsecase,
+	streamUsecase StreamUsecase,
+	thumbnailUsecase ThumbnailUsecase,
+	config JobConfig,
+) *jobUsecase {
+	return &jobUsecase{
+		repository: jobUsecaseRepository{
+			job:  jobRepository,
+			file: fileRepository,
+		},
+		storage:   blobStore,
+		file:      fileUsecase,
+		stream:    streamUsecase,
+		thumbnail: thumbnailUsecase,
+		config:    config,
+	}
+}
+
+// GetJob returns a job of a file the caller may see.
+func (u *jobUsecase) GetJob(ctx context.Context, id int) (*entity.Job, error) {
+	job, err := u.repository.job.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = u.file.GetFile(ctx, job.FileID)
+	if err != nil {
+		if errors.Is(err, entity.ErrorFileNotFound) {
+			return nil, entity.ErrorJobNotFound
+		}
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (u *jobUsecase) ListFileJobs(ctx context.Context, fileID int) ([]*entity.Job, error) {
+	_, err := u.file.GetFile(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.repository.job.ListJobsByFile(ctx, fileID)
+}
+
+func (u *jobUsecase) RunNext(ctx context.Context) (bool, error) {
+	job, err := u.repository.job.ClaimJob(ctx, u.config.Lease)
+	if err != nil {
+		if errors.Is(err, entity.ErrorJobNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	// jobs run outside of any request, each attempt starts a trace
+	ctx, span := startSpan(ctx, "JobUsecase.RunNext",
+		attribute.Int("job.id", job.ID),
+		attribute.String("job.type", job.Type),
+		tracing.FileIDKey.Int(job.FileID),
+	)
+	defer span.End()
+	ctx = logging.WithLogger(ctx, logging.FromContext(ctx).WithFields(logrus.Fields{
+		"job_id":   job.ID,
+		"job_type": job.Type,
+		"file_id":  job.FileID,
+		"attempt":  job.Attempts,
+	}))
+
+	// the attempt must end before someone else may take the job over
+	runCtx, cancel := context.WithTimeout(ctx, u.config.Lease)
+	defer cancel()
+
+	run := u.jobFunc(job.Type)
+	if run == nil {
+		tracing.RecordError(span, errUnknownJobType)
+		return true, u.finish(ctx, job, errUnknownJobType)
+	}
+	progress := func(percent int) {
+		_ = u.repository.job.UpdateJobProgress(ctx, job, percent)
+	}
+
+	err = run(runCtx, job, progress)
+	tracing.RecordError(span, err)
+	return true, u.finish(ctx, job, err)
+}
+
+func (u *jobUsecase) jobFunc(typ string) jobFunc {
+	switch typ {
+	case entity.JobTypeProbe:
+		return u.probe
+	case entity.JobTypeHash:
+		return u.hash
+	case entity.JobTypePackage:
+		return u.createPackage
+	case entity.JobTypeThumbnail:
+		return u.createThumbnail
+	}
+	return nil
+}
+
+// finish records the outcome of an attempt. Jobs that don't apply to their
+// file are skipped, broken content and unknown types are dead at once and
+// anything else is retried until the attempts run out.
+func (u *jobUsecase) finish(ctx context.Context, job *entity.Job, err error) error {
+	params := &param.FinishJob{Status: entity.JobStatusSucceeded}
+	logger := logging.FromContext(ctx)
+	if err != nil {
+		params.Error = err.Error()
+		logger = logger.WithError(err)
+		switch {
+		case skipJob(err):
+			params.Status = entity.JobStatusSkipped
+		case errors.Is(err, errDigestMismatch), errors.Is(err, errUnknownJobType), job.Attempts >= u.config.MaxAttempts:
+			params.Status = entity.JobStatusDead
+		default:
+			params.Status = entity.JobStatusQueued
+			params.RunAt = time.Now().Add(u.backoff(job.Attempts))
+		}
+	}
+
+	logger = logger.WithField("status", params.Status)
+	switch params.Status {
+	case entity.JobStatusDead:
+		logger.Error("job failed")
+	case entity.JobStatusQueued:
+		logger.WithField("run_at", params.RunAt).Warn("job failed, retrying")
+	default:
+		logger.Info("job finished")
+	}
+	return u.repository.job.FinishJob(ctx, job, params)
+}
+
+func skipJob(err error) bool {
+	for _, target := range []error{
+		entity.ErrorFileNotFound,
+		entity.ErrorMetadataNotFound,
+		entity.ErrorStreamUnsupported,
+		entity.ErrorThumbnailUnsupported,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff is the delay before the attempt after the given one.
+func (u *jobUsecase) backoff(attempts int) time.Duration {
+	delay := u.config.Backoff
+	for i := 1; i < attempts && delay < u.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if u.config.MaxBackoff > 0 && delay > u.config.MaxBackoff {
+		delay = u.config.MaxBackoff
+	}
+	return delay
+}
+
+func (u *jobUsecase) probe(ctx context.Context, job *entity.Job, progress func(int)) error {
+	_, err := u.file.GetFileMetadata(ctx, job.FileID)
+	return err
+}
+
+// hash reads the stored content back and checks it still matches the
+// digest it is addressed by, a mismatching file is marked broken.
+func (u *jobUsecase) hash(ctx context.Context, job *entity.Job, progress func(int)) error {
+	file, err := u.repository.file.GetFile(ctx, job.FileID)
+	if err != nil {
+		return err
+	}
+	if file.Digest == "" {
+		// stored under its name before content addressing
+		return nil
+	}
+
+	blob, err := tenantStore(u.storage, file.TenantID).Get(ctx, fileStorageKey(file))
+	if err != nil {
+		if errors.Is(err, storage.ErrBlobNotFound) {
+			return entity.ErrorFileNotFound
+		}
+		return err
+	}
+	defer blob.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, &progressReader{ctx: ctx, r: blob, size: file.Size, progress: progress})
+	if err != nil {
+		return err
+	}
+
+	digest := hex.EncodeToString(hash.Sum(nil))
+	if digest != file.Digest {
+		_ = u.repository.file.UpdateFileStatus(ctx, file.ID, entity.FileStatusBroken)
+		return fmt.Errorf("%w: got %s", errDigestMismatch, digest)
+	}
+	return nil
+}
+
+func (u *jobUsecase) createPackage(ctx context.Context, job *entity.Job, progress func(int)) error {
+	file, err := u.repository.file.GetFile(ctx, job.FileID)
+	if err != nil {
+		return err
+	}
+
+	return u.stream.CreatePackage(ctx, file)
+}
+
+func (u *jobUsecase) createThumbnail(ctx context.Context, job *entity.Job, progress func(int)) error {
+	file, err := u.repository.file.GetFile(ctx, job.FileID)
+	if err != nil {
+		return err
+	}
+
+	return u.thumbnail.CreatePoster(ctx, file)
+}
+
+// progressReader reports every tenth of size read and stops once ctx is
+// done.
+type progressReader struct {
+	ctx      context.Context
+	r        io.Reader
+	size     int64
+	read     int64
+	reported int
+	progress func(int)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	n, err := r.r.Read(p)
+	r.read += int64(n)
+	if r.size > 0 {
+		percent := int(r.read * 100 / r.size)
+		if percent >= r.reported+10 && percent < 100 {
+			r.reported = percent - percent%10
+			r.progress(r.reported)
+		}
+	}
+	return n, err
+}
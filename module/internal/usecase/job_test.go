@@ -0,0 +1,365 @@
+package usecase_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"video-server/internal/storage"
+	"video-server/internal/testutil"
+	"video-server/module/entity"
+	"video-server/module/fixture"
+	"video-server/module/internal/usecase"
+	"video-server/module/param"
+)
+
+var jobConfig = usecase.JobConfig{
+	MaxAttempts: 3,
+	Backoff:     time.Minute,
+	MaxBackoff:  time.Hour,
+	Lease:       time.Minute,
+}
+
+// finishMatcher matches the outcome of an attempt, a retry must be due
+// after the given delay.
+type finishMatcher struct {
+	status string
+	delay  time.Duration
+}
+
+func (f finishMatcher) Matches(x interface{}) bool {
+	params, ok := x.(*param.FinishJob)
+	if !ok || params.Status != f.status {
+		return false
+	}
+	if f.status != entity.JobStatusQueued {
+		return params.RunAt.IsZero()
+	}
+	delay := time.Until(params.RunAt)
+	return delay > f.delay-time.Second && delay <= f.delay
+}
+
+func (f finishMatcher) String() string {
+	return fmt.Sprintf("finishes %s after %s", f.status, f.delay)
+}
+
+func TestJobUsecase_RunNext(t *testing.T) {
+	type Response struct {
+		ran bool
+		err error
+	}
+
+	testcases := map[string]struct {
+		response Response
+		mockFn   func(*testing.T, *fixture.MockJobUsecase)
+	}{
+		"nothing due": {
+			response: Response{ran: false},
+			mockFn: func(t *testing.T, m *fixture.MockJobUsecase) {
+				m.JobRepository.EXPECT().ClaimJob(gomock.Any(), time.Minute).
+					Return(nil, entity.ErrorJobNotFound)
+			},
+		},
+		"ClaimJob error": {
+			response: Response{err: testutil.ErrDB},
+			mockFn: func(t *testing.T, m *fixture.MockJobUsecase) {
+				m.JobRepository.EXPECT().ClaimJob(gomock.Any(), time.Minute).
+					Return(nil, testutil.ErrDB)
+			},
+		},
+		"probe": {
+			response: Response{ran: true},
+			mockFn: func(t *testing.T, m *fixture.MockJobUsecase) {
+				job := &entity.Job{ID: 1, FileID: 1, Type: entity.JobTypeProbe, Attempts: 1}
+				m.JobRepository.EXPECT().ClaimJob(gomock.Any(), time.Minute).Return(job, nil)
+				m.FileUsecase.EXPECT().GetFileMetadata(gomock.Any(), 1).
+					Return(&entity.VideoMetadata{FileID: 1}, nil)
+				m.JobRepository.EXPECT().FinishJob(gomock.Any(), job, finishMatcher{status: entity.JobStatusSucceeded}).
+					Return(nil)
+			},
+		},
+		"probe not a video": {
+			response: Response{ran: true},
+			mockFn: func(t *testing.T, m *fixture.MockJobUsecase) {
+				job := &entity.Job{ID: 1, FileID: 1, Type: entity.JobTypeProbe, Attempts: 1}
+				m.JobRepository.EXPECT().ClaimJob(gomock.Any(), time.Minute).Return(job, nil)
+				m.FileUsecase.EXPECT().GetFileMetadata(gomock.Any(), 1).
+					Return(nil, entity.ErrorMetadataNotFound)
+				m.JobRepository.EXPECT().FinishJob(gomock.Any(), job, finishMatcher{status: entity.JobStatusSkipped}).
+					Return(nil)
+			},
+		},
+		"hash": {
+			response: Response{ran: true},
+			mockFn: func(t *testing.T, m *fixture.MockJobUsecase) {
+				job := &entity.Job{ID: 1, FileID: 1, Type: entity.JobTypeHash, Attempts: 1}
+				file, blob := sampleFile(t)
+				m.JobRepository.EXPECT().ClaimJob(gomock.Any(), time.Minute).Return(job, nil)
+				m.FileRepository.EXPECT().GetFile(gomock.Any(), 1).Return(file, nil)
+				m.BlobStore.EXPECT().Get(gomock.Any(), sampleKey).Return(blob, nil)
+				m.JobRepository.EXPECT().UpdateJobProgress(gomock.Any(), job, gomock.Any()).
+					Return(nil).MinTimes(1)
+				m.JobRepository.EXPECT().FinishJob(gomock.Any(), job, finishMatcher{status: entity.JobStatusSucceeded}).
+					Return(nil)
+			},
+		},
+		"hash mismatch": {
+			response: Response{ran: true},
+			mockFn: func(t *testing.T, m *fixture.MockJobUsecase) {
+				job := &entity.Job{ID: 1, FileID: 1, Type: entity.JobTypeHash, Attempts: 1}
+				file, blob := sampleFile(t)
+				file.Digest = "ab" + sampleDigest[2:]
+				m.JobRepository.EXPECT().ClaimJob(gomock.Any(), time.Minute).Return(job, nil)
+				m.FileRepository.EXPECT().GetFile(gomock.Any(), 1).Return(file, nil)
+				m.BlobStore.EXPECT().Get(gomock.Any(), storage.DigestKey(file.Digest)).Return(blob, nil)
+				m.JobRepository.EXPECT().UpdateJobProgress(gomock.Any(), job, gomock.Any()).
+					Return(nil).AnyTimes()
+				m.FileRepository.EXPECT().UpdateFileStatus(gomock.Any(), 1, entity.FileStatusBroken).
+					Return(nil)
+				m.JobRepository.EXPECT().FinishJob(gomock.Any(), job, finishMatcher{status: entity.JobStatusDead}).
+					Return(nil)
+			},
+		},
+		"hash legacy file": {
+			response: Response{ran: true},
+			mockFn: func(t *testing.T, m *fixture.MockJobUsecase) {
+				job := &entity.Job{ID: 1, FileID: 1, Type: entity.JobTypeHash, Attempts: 1}
+				m.JobRepository.EXPECT().ClaimJob(gomock.Any(), time.Minute).Return(job, nil)
+				m.FileRepository.EXPECT().GetFile(gomock.Any(), 1).
+					Return(&entity.File{ID: 1, Name: "legacy.mp4"}, nil)
+				m.JobRepository.EXPECT().FinishJob(gomock.Any(), job, finishMatcher{status: entity.JobStatusSucceeded}).
+					Return(nil)
+			},
+		},
+		"package": {
+			response: Response{ran: true},
+			mockFn: func(t *testing.T, m *fixture.MockJobUsecase) {
+				job := &entity.Job{ID: 1, FileID: 1, Type: entity.JobTypePackage, Attempts: 1}
+				file := &entity.File{ID: 1, Digest: sampleDigest}
+				m.JobRepository.EXPECT().ClaimJob(gomock.Any(), time.Minute).Return(job, nil)
+				m.FileRepository.EXPECT().GetFile(gomock.Any(), 1).Return(file, nil)
+				m.StreamUsecase.EXPECT().CreatePackage(gomock.Any(), file).Return(nil)
+				m.JobRepository.EXPECT().FinishJob(gomock.Any(), job, finishMatcher{status: entity.JobStatusSucceeded}).
+					Return(nil)
+			},
+		},
+		"package unsupported": {
+			response: Response{ran: true},
+			mockFn: func(t *testing.T, m *fixture.MockJobUsecase) {
+				job := &entity.Job{ID: 1, FileID: 1, Type: entity.JobTypePackage, Attempts: 1}
+				file := &entity.File{ID: 1, Digest: sampleDigest}
+				m.JobRepository.EXPECT().ClaimJob(gomock.Any(), time.Minute).Return(job, nil)
+				m.FileRepository.EXPECT().GetFile(gomock.Any(), 1).Return(file, nil)
+				m.StreamUsecase.EXPECT().CreatePackage(gomock.Any(), file).Return(entity.ErrorStreamUnsupported)
+				m.JobRepository.EXPECT().FinishJob(gomock.Any(), job, finishMatcher{status: entity.JobStatusSkipped}).
+					Return(nil)
+			},
+		},
+		"thumbnail unsupported": {
+			response: Response{ran: true},
+			mockFn: func(t *testing.T, m *fixture.MockJobUsecase) {
+				job := &entity.Job{ID: 1, FileID: 1, Type: entity.JobTypeThumbnail, Attempts: 1}
+				file := &entity.File{ID: 1, Digest: sampleDigest}
+				m.JobRepository.EXPECT().ClaimJob(gomock.Any(), time.Minute).Return(job, nil)
+				m.FileRepository.EXPECT().GetFile(gomock.Any(), 1).Return(file, nil)
+				m.ThumbnailUsecase.EXPECT().CreatePoster(gomock.Any(), file).Return(entity.ErrorThumbnailUnsupported)
+				m.JobRepository.EXPECT().FinishJob(gomock.Any(), job, finishMatcher{status: entity.JobStatusSkipped}).
+					Return(nil)
+			},
+		},
+		"thumbnail": {
+			response: Response{ran: true},
+			mockFn: func(t *testing.T, m *fixture.MockJobUsecase) {
+				job := &entity.Job{ID: 1, FileID: 1, Type: entity.JobTypeThumbnail, Attempts: 1}
+				file := &entity.File{ID: 1, Digest: sampleDigest}
+				m.JobRepository.EXPECT().ClaimJob(gomock.Any(), time.Minute).Return(job, nil)
+				m.FileRepository.EXPECT().GetFile(gomock.Any(), 1).Return(file, nil)
+				m.ThumbnailUsecase.EXPECT().CreatePoster(gomock.Any(), file).Return(nil)
+				m.JobRepository.EXPECT().FinishJob(gomock.Any(), job, finishMatcher{status: entity.JobStatusSucceeded}).
+					Return(nil)
+			},
+		},
+		"file deleted": {
+			response: Response{ran: true},
+			mockFn: func(t *testing.T, m *fixture.MockJobUsecase) {
+				job := &entity.Job{ID: 1, FileID: 1, Type: entity.JobTypeThumbnail, Attempts: 1}
+				m.JobRepository.EXPECT().ClaimJob(gomock.Any(), time.Minute).Return(job, nil)
+				m.FileRepository.EXPECT().GetFile(gomock.Any(), 1).Return(nil, entity.ErrorFileNotFound)
+				m.JobRepository.EXPECT().FinishJob(gomock.Any(), job, finishMatcher{status: entity.JobStatusSkipped}).
+					Return(nil)
+			},
+		},
+		"retry with backoff": {
+			response: Response{ran: true},
+			mockFn: func(t *testing.T, m *fixture.MockJobUsecase) {
+				job := &entity.Job{ID: 1, FileID: 1, Type: entity.JobTypeThumbnail, Attempts: 2}
+				file := &entity.File{ID: 1, Digest: sampleDigest}
+				m.JobRepository.EXPECT().ClaimJob(gomock.Any(), time.Minute).Return(job, nil)
+				m.FileRepository.EXPECT().GetFile(gomock.Any(), 1).Return(file, nil)
+				m.ThumbnailUsecase.EXPECT().CreatePoster(gomock.Any(), file).Return(testutil.ErrStorage)
+				m.JobRepository.EXPECT().FinishJob(gomock.Any(), job, finishMatcher{status: entity.JobStatusQueued, delay: 2 * time.Minute}).
+					Return(nil)
+			},
+		},
+		"out of attempts": {
+			response: Response{ran: true},
+			mockFn: func(t *testing.T, m *fixture.MockJobUsecase) {
+				job := &entity.Job{ID: 1, FileID: 1, Type: entity.JobTypeThumbnail, Attempts: 3}
+				file := &entity.File{ID: 1, Digest: sampleDigest}
+				m.JobRepository.EXPECT().ClaimJob(gomock.Any(), time.Minute).Return(job, nil)
+				m.FileRepository.EXPECT().GetFile(gomock.Any(), 1).Return(file, nil)
+				m.ThumbnailUsecase.EXPECT().CreatePoster(gomock.Any(), file).Return(testutil.ErrStorage)
+				m.JobRepository.EXPECT().FinishJob(gomock.Any(), job, finishMatcher{status: entity.JobStatusDead}).
+					Return(nil)
+			},
+		},
+		"unknown type": {
+			response: Response{ran: true},
+			mockFn: func(t *testing.T, m *fixture.MockJobUsecase) {
+				job := &entity.Job{ID: 1, FileID: 1, Type: "transcode", Attempts: 1}
+				m.JobRepository.EXPECT().ClaimJob(gomock.Any(), time.Minute).Return(job, nil)
+				m.JobRepository.EXPECT().FinishJob(gomock.Any(), job, finishMatcher{status: entity.JobStatusDead}).
+					Return(nil)
+			},
+		},
+		"FinishJob error": {
+			response: Response{ran: true, err: testutil.ErrDB},
+			mockFn: func(t *testing.T, m *fixture.MockJobUsecase) {
+				job := &entity.Job{ID: 1, FileID: 1, Type: entity.JobTypeThumbnail, Attempts: 1}
+				file := &entity.File{ID: 1, Digest: sampleDigest}
+				m.JobRepository.EXPECT().ClaimJob(gomock.Any(), time.Minute).Return(job, nil)
+				m.FileRepository.EXPECT().GetFile(gomock.Any(), 1).Return(file, nil)
+				m.ThumbnailUsecase.EXPECT().CreatePoster(gomock.Any(), file).Return(nil)
+				m.JobRepository.EXPECT().FinishJob(gomock.Any(), job, gomock.Any()).Return(testutil.ErrDB)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ucs, mocks := fixture.NewJobUsecase(ctrl, jobConfig)
+			tc.mockFn(t, mocks)
+
+			ran, err := ucs.RunNext(context.Background())
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			assert.Equal(t, tc.response.ran, ran)
+		})
+	}
+}
+
+func TestJobUsecase_GetJob(t *testing.T) {
+	// without a principal the file is still looked up, scoped to the tenant
+	// of the request
+	tenantCtx := entity.WithTenant(context.Background(), "acme")
+
+	type Response struct {
+		id  int
+		err error
+	}
+
+	testcases := map[string]struct {
+		ctx      context.Context
+		response Response
+		mockFn   func(*fixture.MockJobUsecase)
+	}{
+		"success": {
+			ctx:      tenantCtx,
+			response: Response{id: 1},
+			mockFn: func(m *fixture.MockJobUsecase) {
+				m.JobRepository.EXPECT().GetJob(gomock.Any(), 1).Return(&entity.Job{ID: 1, FileID: 7}, nil)
+				m.FileUsecase.EXPECT().GetFile(tenantCtx, 7).Return(&entity.File{ID: 7}, nil)
+			},
+		},
+		"job not found": {
+			ctx:      tenantCtx,
+			response: Response{err: entity.ErrorJobNotFound},
+			mockFn: func(m *fixture.MockJobUsecase) {
+				m.JobRepository.EXPECT().GetJob(gomock.Any(), 1).Return(nil, entity.ErrorJobNotFound)
+			},
+		},
+		"file of another tenant": {
+			ctx:      tenantCtx,
+			response: Response{err: entity.ErrorJobNotFound},
+			mockFn: func(m *fixture.MockJobUsecase) {
+				m.JobRepository.EXPECT().GetJob(gomock.Any(), 1).Return(&entity.Job{ID: 1, FileID: 7}, nil)
+				m.FileUsecase.EXPECT().GetFile(tenantCtx, 7).Return(nil, entity.ErrorFileNotFound)
+			},
+		},
+		"file error": {
+			ctx:      viewerCtx,
+			response: Response{err: testutil.ErrDB},
+			mockFn: func(m *fixture.MockJobUsecase) {
+				m.JobRepository.EXPECT().GetJob(gomock.Any(), 1).Return(&entity.Job{ID: 1, FileID: 7}, nil)
+				m.FileUsecase.EXPECT().GetFile(viewerCtx, 7).Return(nil, testutil.ErrDB)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ucs, mocks := fixture.NewJobUsecase(ctrl, jobConfig)
+			tc.mockFn(mocks)
+
+			job, err := ucs.GetJob(tc.ctx, 1)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			if tc.response.err == nil {
+				assert.Equal(t, tc.response.id, job.ID)
+			}
+		})
+	}
+}
+
+func TestJobUsecase_ListFileJobs(t *testing.T) {
+	type Response struct {
+		count int
+		err   error
+	}
+
+	testcases := map[string]struct {
+		response Response
+		mockFn   func(*fixture.MockJobUsecase)
+	}{
+		"success": {
+			response: Response{count: 2},
+			mockFn: func(m *fixture.MockJobUsecase) {
+				m.FileUsecase.EXPECT().GetFile(gomock.Any(), 1).Return(&entity.File{ID: 1}, nil)
+				m.JobRepository.EXPECT().ListJobsByFile(gomock.Any(), 1).
+					Return([]*entity.Job{{ID: 1}, {ID: 2}}, nil)
+			},
+		},
+		"file not found": {
+			response: Response{err: entity.ErrorFileNotFound},
+			mockFn: func(m *fixture.MockJobUsecase) {
+				m.FileUsecase.EXPECT().GetFile(gomock.Any(), 1).Return(nil, entity.ErrorFileNotFound)
+			},
+		},
+		"ListJobsByFile error": {
+			response: Response{err: testutil.ErrDB},
+			mockFn: func(m *fixture.MockJobUsecase) {
+				m.FileUsecase.EXPECT().GetFile(gomock.Any(), 1).Return(&entity.File{ID: 1}, nil)
+				m.JobRepository.EXPECT().ListJobsByFile(gomock.Any(), 1).Return(nil, testutil.ErrDB)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ucs, mocks := fixture.NewJobUsecase(ctrl, jobConfig)
+			tc.mockFn(mocks)
+
+			result, err := ucs.ListFileJobs(context.Background(), 1)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			assert.Len(t, result, tc.response.count)
+		})
+	}
+}
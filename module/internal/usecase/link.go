@@ -0,0 +1,115 @@
+package usecase
+
+//go:generate mockgen -source link.go -destination mock/link.go
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"video-server/internal/auth"
+	"video-server/module/entity"
+	repository "video-server/module/internal/repository"
+	"video-server/module/param"
+)
+
+// Query parameters of a signed link, accepted on the file and streaming
+// routes in place of credentials.
+const (
+	LinkParamID        = "link"
+	LinkParamExpires   = "expires"
+	LinkParamSignature = "sig"
+)
+
+type LinkConfig struct {
+	// DefaultTTL is the lifetime of links that don't ask for one, MaxTTL
+	// the longest that may be asked for
+	DefaultTTL time.Duration
+	MaxTTL     time.Duration
+}
+
+type LinkUsecase interface {
+	// CreateLink signs a link to a file the caller may read. It returns the
+	// link with the query that authenticates requests for the file.
+	CreateLink(ctx context.Context, params *param.CreateLink) (*entity.DownloadLink, string, error)
+}
+
+type linkUsecaseRepository struct {
+	link repository.LinkRepository
+}
+
+type linkUsecase struct {
+	repository linkUsecaseRepository
+	file       FileUsecase
+	signer     *auth.Signer
+	access     fileAccess
+	config     LinkConfig
+}
+
+// NewLinkUsecase refuses to create links when signer is nil.
+func NewLinkUsecase(linkRepository repository.LinkRepository, grantRepository repository.GrantRepository, fileUsecase FileUsecase, signer *auth.Signer, cfg LinkConfig) *linkUsecase {
+	return &linkUsecase{
+		repository: linkUsecaseRepository{
+			link: linkRepository,
+		},
+		file:   fileUsecase,
+		signer: signer,
+		access: fileAccess{grant: grantRepository},
+		config: cfg,
+	}
+}
+
+func (u *linkUsecase) CreateLink(ctx context.Context, params *param.CreateLink) (*entity.DownloadLink, string, error) {
+	if u.signer == nil {
+		return nil, "", entity.ErrorLinkDisabled
+	}
+
+	ttl := params.ExpiresIn
+	if ttl == 0 {
+		ttl = u.config.DefaultTTL
+	}
+	if ttl < 0 || (u.config.MaxTTL > 0 && ttl > u.config.MaxTTL) {
+		return nil, "", entity.WithField(entity.ErrorBadRequest, "expires_in", fmt.Sprintf("a link expires within %s", u.config.MaxTTL))
+	}
+	if params.MaxDownloads < 0 {
+		return nil, "", entity.WithField(entity.ErrorBadRequest, "max_downloads", "max downloads must not be negative")
+	}
+	if params.IP != "" {
+		ip := net.ParseIP(params.IP)
+		if ip == nil {
+			return nil, "", entity.WithField(entity.ErrorBadRequest, "ip", fmt.Sprintf("invalid IP address %q", params.IP))
+		}
+		params.IP = ip.String()
+	}
+
+	file, err := u.file.GetFile(ctx, params.FileID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	params.CreatedBy, _ = u.access.owner(ctx)
+	params.TenantID = file.TenantID
+	// the signature covers whole seconds
+	params.ExpiresAt = time.Now().Add(ttl).Truncate(time.Second)
+	link, err := u.repository.link.CreateLink(ctx, params)
+	if err != nil {
+		return nil, "", err
+	}
+
+	expires := link.ExpiresAt.Unix()
+	query := url.Values{
+		LinkParamID:        {strconv.Itoa(link.ID)},
+		LinkParamExpires:   {strconv.FormatInt(expires, 10)},
+		LinkParamSignature: {u.signer.Sign(linkMessage(link.ID, link.FileID, expires))},
+	}
+	return link, query.Encode(), nil
+}
+
+// linkMessage is what a link's signature covers, the file is part of it so
+// a link can't be replayed against another file.
+func linkMessage(linkID, fileID int, expires int64) string {
+	return fmt.Sprintf("%d.%d.%d", linkID, fileID, expires)
+}
@@ -0,0 +1,147 @@
+package usecase_test
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"video-server/internal/auth"
+	"video-server/internal/testutil"
+	"video-server/module/entity"
+	"video-server/module/fixture"
+	"video-server/module/internal/usecase"
+	"video-server/module/param"
+)
+
+// createLinkMatcher matches the link params the usecase completes, with an
+// expiry around ttl from now
+type createLinkMatcher struct {
+	ip           string
+	maxDownloads int
+	ttl          time.Duration
+}
+
+func (m createLinkMatcher) Matches(x interface{}) bool {
+	params, ok := x.(*param.CreateLink)
+	if !ok {
+		return false
+	}
+	expiresIn := time.Until(params.ExpiresAt)
+	return params.FileID == 1 && params.IP == m.ip && params.MaxDownloads == m.maxDownloads &&
+		params.CreatedBy == "alice" && params.TenantID == "acme" &&
+		expiresIn > m.ttl-2*time.Second && expiresIn <= m.ttl
+}
+
+func (m createLinkMatcher) String() string {
+	return fmt.Sprintf("is a link bound to %q, for %d downloads, expiring in %s", m.ip, m.maxDownloads, m.ttl)
+}
+
+func TestLinkUsecase_CreateLink(t *testing.T) {
+	type Response struct {
+		err interface{}
+	}
+
+	signer := auth.NewSigner([]byte("secret"))
+	cfg := usecase.LinkConfig{DefaultTTL: time.Hour, MaxTTL: 24 * time.Hour}
+	file := &entity.File{ID: 1, OwnerID: "bob", TenantID: "acme"}
+	created := func(params *param.CreateLink) (*entity.DownloadLink, error) {
+		return &entity.DownloadLink{ID: 3, FileID: params.FileID, ExpiresAt: params.ExpiresAt}, nil
+	}
+
+	testcases := map[string]struct {
+		signer   *auth.Signer
+		params   *param.CreateLink
+		response Response
+		mockFn   func(*fixture.MockLinkUsecase)
+	}{
+		"default ttl": {
+			signer: signer,
+			params: &param.CreateLink{FileID: 1},
+			mockFn: func(m *fixture.MockLinkUsecase) {
+				m.FileUsecase.EXPECT().GetFile(viewerCtx, 1).Return(file, nil)
+				m.LinkRepository.EXPECT().CreateLink(viewerCtx, createLinkMatcher{ttl: time.Hour}).
+					DoAndReturn(func(_ context.Context, params *param.CreateLink) (*entity.DownloadLink, error) {
+						return created(params)
+					})
+			},
+		},
+		"bound and limited": {
+			signer: signer,
+			params: &param.CreateLink{FileID: 1, IP: "::ffff:203.0.113.7", MaxDownloads: 2, ExpiresIn: 10 * time.Minute},
+			mockFn: func(m *fixture.MockLinkUsecase) {
+				m.FileUsecase.EXPECT().GetFile(viewerCtx, 1).Return(file, nil)
+				m.LinkRepository.EXPECT().CreateLink(viewerCtx, createLinkMatcher{ip: "203.0.113.7", maxDownloads: 2, ttl: 10 * time.Minute}).
+					DoAndReturn(func(_ context.Context, params *param.CreateLink) (*entity.DownloadLink, error) {
+						return created(params)
+					})
+			},
+		},
+		"ttl too long": {
+			signer:   signer,
+			params:   &param.CreateLink{FileID: 1, ExpiresIn: 48 * time.Hour},
+			response: Response{err: "status 400: err Bad Request: a link expires within 24h0m0s"},
+			mockFn:   func(m *fixture.MockLinkUsecase) {},
+		},
+		"invalid ip": {
+			signer:   signer,
+			params:   &param.CreateLink{FileID: 1, IP: "localhost"},
+			response: Response{err: "status 400: err Bad Request: invalid IP address \"localhost\""},
+			mockFn:   func(m *fixture.MockLinkUsecase) {},
+		},
+		"negative max downloads": {
+			signer:   signer,
+			params:   &param.CreateLink{FileID: 1, MaxDownloads: -1},
+			response: Response{err: "status 400: err Bad Request: max downloads must not be negative"},
+			mockFn:   func(m *fixture.MockLinkUsecase) {},
+		},
+		"file not found": {
+			signer:   signer,
+			params:   &param.CreateLink{FileID: 1},
+			response: Response{err: entity.ErrorFileNotFound},
+			mockFn: func(m *fixture.MockLinkUsecase) {
+				m.FileUsecase.EXPECT().GetFile(viewerCtx, 1).Return(nil, entity.ErrorFileNotFound)
+			},
+		},
+		"db error": {
+			signer:   signer,
+			params:   &param.CreateLink{FileID: 1},
+			response: Response{err: testutil.ErrDB},
+			mockFn: func(m *fixture.MockLinkUsecase) {
+				m.FileUsecase.EXPECT().GetFile(viewerCtx, 1).Return(file, nil)
+				m.LinkRepository.EXPECT().CreateLink(viewerCtx, gomock.Any()).Return(nil, testutil.ErrDB)
+			},
+		},
+		"disabled": {
+			params:   &param.CreateLink{FileID: 1},
+			response: Response{err: entity.ErrorLinkDisabled},
+			mockFn:   func(m *fixture.MockLinkUsecase) {},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ucs, mocks := fixture.NewLinkUsecase(ctrl, tc.signer, cfg)
+			tc.mockFn(mocks)
+
+			link, query, err := ucs.CreateLink(viewerCtx, tc.params)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			if tc.response.err != nil {
+				return
+			}
+
+			// the query authenticates as the link
+			values, err := url.ParseQuery(query)
+			assert.NoError(t, err)
+			assert.Equal(t, "3", values.Get(usecase.LinkParamID))
+			assert.Equal(t, fmt.Sprint(link.ExpiresAt.Unix()), values.Get(usecase.LinkParamExpires))
+			assert.True(t, signer.Verify(fmt.Sprintf("3.1.%d", link.ExpiresAt.Unix()), values.Get(usecase.LinkParamSignature)))
+		})
+	}
+}
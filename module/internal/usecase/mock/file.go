@@ -7,9 +7,11 @@ package mock_usecase
 import (
 	context "context"
 	reflect "reflect"
-
+	storage "video-server/internal/storage"
 	util "video-server/internal/util"
 	entity "video-server/module/entity"
+	param "video-server/module/param"
+
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -80,17 +82,49 @@ func (mr *MockFileUsecaseMockRecorder) GetFile(ctx, id interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFile", reflect.TypeOf((*MockFileUsecase)(nil).GetFile), ctx, id)
 }
 
-// ListFiles mocks base method.
-func (m *MockFileUsecase) ListFiles(ctx context.Context) ([]*entity.File, error) {
+// GetFileMetadata mocks base method.
+func (m *MockFileUsecase) GetFileMetadata(ctx context.Context, id int) (*entity.VideoMetadata, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListFiles", ctx)
-	ret0, _ := ret[0].([]*entity.File)
+	ret := m.ctrl.Call(m, "GetFileMetadata", ctx, id)
+	ret0, _ := ret[0].(*entity.VideoMetadata)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
+// GetFileMetadata indicates an expected call of GetFileMetadata.
+func (mr *MockFileUsecaseMockRecorder) GetFileMetadata(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileMetadata", reflect.TypeOf((*MockFileUsecase)(nil).GetFileMetadata), ctx, id)
+}
+
+// ListFiles mocks base method.
+func (m *MockFileUsecase) ListFiles(ctx context.Context, params *param.ListFiles) ([]*entity.File, *util.Pagination, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFiles", ctx, params)
+	ret0, _ := ret[0].([]*entity.File)
+	ret1, _ := ret[1].(*util.Pagination)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
 // ListFiles indicates an expected call of ListFiles.
-func (mr *MockFileUsecaseMockRecorder) ListFiles(ctx interface{}) *gomock.Call {
+func (mr *MockFileUsecaseMockRecorder) ListFiles(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFiles", reflect.TypeOf((*MockFileUsecase)(nil).ListFiles), ctx, params)
+}
+
+// OpenFile mocks base method.
+func (m *MockFileUsecase) OpenFile(ctx context.Context, id int) (*entity.File, storage.Blob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenFile", ctx, id)
+	ret0, _ := ret[0].(*entity.File)
+	ret1, _ := ret[1].(storage.Blob)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// OpenFile indicates an expected call of OpenFile.
+func (mr *MockFileUsecaseMockRecorder) OpenFile(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFiles", reflect.TypeOf((*MockFileUsecase)(nil).ListFiles), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenFile", reflect.TypeOf((*MockFileUsecase)(nil).OpenFile), ctx, id)
 }
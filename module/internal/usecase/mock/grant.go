@@ -0,0 +1,79 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: grant.go
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	reflect "reflect"
+	entity "video-server/module/entity"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockGrantUsecase is a mock of GrantUsecase interface.
+type MockGrantUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockGrantUsecaseMockRecorder
+}
+
+// MockGrantUsecaseMockRecorder is the mock recorder for MockGrantUsecase.
+type MockGrantUsecaseMockRecorder struct {
+	mock *MockGrantUsecase
+}
+
+// NewMockGrantUsecase creates a new mock instance.
+func NewMockGrantUsecase(ctrl *gomock.Controller) *MockGrantUsecase {
+	mock := &MockGrantUsecase{ctrl: ctrl}
+	mock.recorder = &MockGrantUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGrantUsecase) EXPECT() *MockGrantUsecaseMockRecorder {
+	return m.recorder
+}
+
+// CreateGrant mocks base method.
+func (m *MockGrantUsecase) CreateGrant(ctx context.Context, fileID int, subject string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGrant", ctx, fileID, subject)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateGrant indicates an expected call of CreateGrant.
+func (mr *MockGrantUsecaseMockRecorder) CreateGrant(ctx, fileID, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGrant", reflect.TypeOf((*MockGrantUsecase)(nil).CreateGrant), ctx, fileID, subject)
+}
+
+// DeleteGrant mocks base method.
+func (m *MockGrantUsecase) DeleteGrant(ctx context.Context, fileID int, subject string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGrant", ctx, fileID, subject)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGrant indicates an expected call of DeleteGrant.
+func (mr *MockGrantUsecaseMockRecorder) DeleteGrant(ctx, fileID, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGrant", reflect.TypeOf((*MockGrantUsecase)(nil).DeleteGrant), ctx, fileID, subject)
+}
+
+// ListGrants mocks base method.
+func (m *MockGrantUsecase) ListGrants(ctx context.Context, fileID int) ([]*entity.FileGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGrants", ctx, fileID)
+	ret0, _ := ret[0].([]*entity.FileGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGrants indicates an expected call of ListGrants.
+func (mr *MockGrantUsecaseMockRecorder) ListGrants(ctx, fileID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGrants", reflect.TypeOf((*MockGrantUsecase)(nil).ListGrants), ctx, fileID)
+}
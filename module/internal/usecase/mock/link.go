@@ -0,0 +1,53 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: link.go
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	reflect "reflect"
+	entity "video-server/module/entity"
+	param "video-server/module/param"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockLinkUsecase is a mock of LinkUsecase interface.
+type MockLinkUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockLinkUsecaseMockRecorder
+}
+
+// MockLinkUsecaseMockRecorder is the mock recorder for MockLinkUsecase.
+type MockLinkUsecaseMockRecorder struct {
+	mock *MockLinkUsecase
+}
+
+// NewMockLinkUsecase creates a new mock instance.
+func NewMockLinkUsecase(ctrl *gomock.Controller) *MockLinkUsecase {
+	mock := &MockLinkUsecase{ctrl: ctrl}
+	mock.recorder = &MockLinkUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLinkUsecase) EXPECT() *MockLinkUsecaseMockRecorder {
+	return m.recorder
+}
+
+// CreateLink mocks base method.
+func (m *MockLinkUsecase) CreateLink(ctx context.Context, params *param.CreateLink) (*entity.DownloadLink, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLink", ctx, params)
+	ret0, _ := ret[0].(*entity.DownloadLink)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateLink indicates an expected call of CreateLink.
+func (mr *MockLinkUsecaseMockRecorder) CreateLink(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLink", reflect.TypeOf((*MockLinkUsecase)(nil).CreateLink), ctx, params)
+}
@@ -0,0 +1,52 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: reconcile.go
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	reflect "reflect"
+	entity "video-server/module/entity"
+	param "video-server/module/param"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockReconcileUsecase is a mock of ReconcileUsecase interface.
+type MockReconcileUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockReconcileUsecaseMockRecorder
+}
+
+// MockReconcileUsecaseMockRecorder is the mock recorder for MockReconcileUsecase.
+type MockReconcileUsecaseMockRecorder struct {
+	mock *MockReconcileUsecase
+}
+
+// NewMockReconcileUsecase creates a new mock instance.
+func NewMockReconcileUsecase(ctrl *gomock.Controller) *MockReconcileUsecase {
+	mock := &MockReconcileUsecase{ctrl: ctrl}
+	mock.recorder = &MockReconcileUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReconcileUsecase) EXPECT() *MockReconcileUsecaseMockRecorder {
+	return m.recorder
+}
+
+// Reconcile mocks base method.
+func (m *MockReconcileUsecase) Reconcile(ctx context.Context, params *param.Reconcile) (*entity.ReconcileReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reconcile", ctx, params)
+	ret0, _ := ret[0].(*entity.ReconcileReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reconcile indicates an expected call of Reconcile.
+func (mr *MockReconcileUsecaseMockRecorder) Reconcile(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reconcile", reflect.TypeOf((*MockReconcileUsecase)(nil).Reconcile), ctx, params)
+}
@@ -0,0 +1,138 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: stream.go
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+	entity "video-server/module/entity"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockStreamUsecase is a mock of StreamUsecase interface.
+type MockStreamUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockStreamUsecaseMockRecorder
+}
+
+// MockStreamUsecaseMockRecorder is the mock recorder for MockStreamUsecase.
+type MockStreamUsecaseMockRecorder struct {
+	mock *MockStreamUsecase
+}
+
+// NewMockStreamUsecase creates a new mock instance.
+func NewMockStreamUsecase(ctrl *gomock.Controller) *MockStreamUsecase {
+	mock := &MockStreamUsecase{ctrl: ctrl}
+	mock.recorder = &MockStreamUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStreamUsecase) EXPECT() *MockStreamUsecaseMockRecorder {
+	return m.recorder
+}
+
+// CreatePackage mocks base method.
+func (m *MockStreamUsecase) CreatePackage(ctx context.Context, file *entity.File) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePackage", ctx, file)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePackage indicates an expected call of CreatePackage.
+func (mr *MockStreamUsecaseMockRecorder) CreatePackage(ctx, file interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePackage", reflect.TypeOf((*MockStreamUsecase)(nil).CreatePackage), ctx, file)
+}
+
+// GetDASHManifest mocks base method.
+func (m *MockStreamUsecase) GetDASHManifest(ctx context.Context, id int) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDASHManifest", ctx, id)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDASHManifest indicates an expected call of GetDASHManifest.
+func (mr *MockStreamUsecaseMockRecorder) GetDASHManifest(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDASHManifest", reflect.TypeOf((*MockStreamUsecase)(nil).GetDASHManifest), ctx, id)
+}
+
+// GetHLSMasterPlaylist mocks base method.
+func (m *MockStreamUsecase) GetHLSMasterPlaylist(ctx context.Context, id int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHLSMasterPlaylist", ctx, id)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHLSMasterPlaylist indicates an expected call of GetHLSMasterPlaylist.
+func (mr *MockStreamUsecaseMockRecorder) GetHLSMasterPlaylist(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHLSMasterPlaylist", reflect.TypeOf((*MockStreamUsecase)(nil).GetHLSMasterPlaylist), ctx, id)
+}
+
+// GetHLSMediaPlaylist mocks base method.
+func (m *MockStreamUsecase) GetHLSMediaPlaylist(ctx context.Context, id int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHLSMediaPlaylist", ctx, id)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHLSMediaPlaylist indicates an expected call of GetHLSMediaPlaylist.
+func (mr *MockStreamUsecaseMockRecorder) GetHLSMediaPlaylist(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHLSMediaPlaylist", reflect.TypeOf((*MockStreamUsecase)(nil).GetHLSMediaPlaylist), ctx, id)
+}
+
+// WriteInitSegment mocks base method.
+func (m *MockStreamUsecase) WriteInitSegment(ctx context.Context, id int, trackID uint32, w io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteInitSegment", ctx, id, trackID, w)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteInitSegment indicates an expected call of WriteInitSegment.
+func (mr *MockStreamUsecaseMockRecorder) WriteInitSegment(ctx, id, trackID, w interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteInitSegment", reflect.TypeOf((*MockStreamUsecase)(nil).WriteInitSegment), ctx, id, trackID, w)
+}
+
+// WriteMediaSegment mocks base method.
+func (m *MockStreamUsecase) WriteMediaSegment(ctx context.Context, id, index int, w io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteMediaSegment", ctx, id, index, w)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteMediaSegment indicates an expected call of WriteMediaSegment.
+func (mr *MockStreamUsecaseMockRecorder) WriteMediaSegment(ctx, id, index, w interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteMediaSegment", reflect.TypeOf((*MockStreamUsecase)(nil).WriteMediaSegment), ctx, id, index, w)
+}
+
+// WriteTrackSegment mocks base method.
+func (m *MockStreamUsecase) WriteTrackSegment(ctx context.Context, id int, trackID uint32, time uint64, w io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteTrackSegment", ctx, id, trackID, time, w)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteTrackSegment indicates an expected call of WriteTrackSegment.
+func (mr *MockStreamUsecaseMockRecorder) WriteTrackSegment(ctx, id, trackID, time, w interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteTrackSegment", reflect.TypeOf((*MockStreamUsecase)(nil).WriteTrackSegment), ctx, id, trackID, time, w)
+}
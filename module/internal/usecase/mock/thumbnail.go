@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: thumbnail.go
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	reflect "reflect"
+	entity "video-server/module/entity"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockThumbnailUsecase is a mock of ThumbnailUsecase interface.
+type MockThumbnailUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockThumbnailUsecaseMockRecorder
+}
+
+// MockThumbnailUsecaseMockRecorder is the mock recorder for MockThumbnailUsecase.
+type MockThumbnailUsecaseMockRecorder struct {
+	mock *MockThumbnailUsecase
+}
+
+// NewMockThumbnailUsecase creates a new mock instance.
+func NewMockThumbnailUsecase(ctrl *gomock.Controller) *MockThumbnailUsecase {
+	mock := &MockThumbnailUsecase{ctrl: ctrl}
+	mock.recorder = &MockThumbnailUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockThumbnailUsecase) EXPECT() *MockThumbnailUsecaseMockRecorder {
+	return m.recorder
+}
+
+// CreatePoster mocks base method.
+func (m *MockThumbnailUsecase) CreatePoster(ctx context.Context, file *entity.File) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePoster", ctx, file)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePoster indicates an expected call of CreatePoster.
+func (mr *MockThumbnailUsecaseMockRecorder) CreatePoster(ctx, file interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePoster", reflect.TypeOf((*MockThumbnailUsecase)(nil).CreatePoster), ctx, file)
+}
+
+// GetThumbnail mocks base method.
+func (m *MockThumbnailUsecase) GetThumbnail(ctx context.Context, id int, time *float64) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetThumbnail", ctx, id, time)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetThumbnail indicates an expected call of GetThumbnail.
+func (mr *MockThumbnailUsecaseMockRecorder) GetThumbnail(ctx, id, time interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetThumbnail", reflect.TypeOf((*MockThumbnailUsecase)(nil).GetThumbnail), ctx, id, time)
+}
@@ -0,0 +1,111 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: upload.go
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+	entity "video-server/module/entity"
+	param "video-server/module/param"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockUploadUsecase is a mock of UploadUsecase interface.
+type MockUploadUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockUploadUsecaseMockRecorder
+}
+
+// MockUploadUsecaseMockRecorder is the mock recorder for MockUploadUsecase.
+type MockUploadUsecaseMockRecorder struct {
+	mock *MockUploadUsecase
+}
+
+// NewMockUploadUsecase creates a new mock instance.
+func NewMockUploadUsecase(ctrl *gomock.Controller) *MockUploadUsecase {
+	mock := &MockUploadUsecase{ctrl: ctrl}
+	mock.recorder = &MockUploadUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUploadUsecase) EXPECT() *MockUploadUsecaseMockRecorder {
+	return m.recorder
+}
+
+// AppendUpload mocks base method.
+func (m *MockUploadUsecase) AppendUpload(ctx context.Context, id string, offset int64, reader io.Reader) (*entity.Upload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendUpload", ctx, id, offset, reader)
+	ret0, _ := ret[0].(*entity.Upload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AppendUpload indicates an expected call of AppendUpload.
+func (mr *MockUploadUsecaseMockRecorder) AppendUpload(ctx, id, offset, reader interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendUpload", reflect.TypeOf((*MockUploadUsecase)(nil).AppendUpload), ctx, id, offset, reader)
+}
+
+// CreateUpload mocks base method.
+func (m *MockUploadUsecase) CreateUpload(ctx context.Context, params *param.CreateUpload) (*entity.Upload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUpload", ctx, params)
+	ret0, _ := ret[0].(*entity.Upload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUpload indicates an expected call of CreateUpload.
+func (mr *MockUploadUsecaseMockRecorder) CreateUpload(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUpload", reflect.TypeOf((*MockUploadUsecase)(nil).CreateUpload), ctx, params)
+}
+
+// DeleteUpload mocks base method.
+func (m *MockUploadUsecase) DeleteUpload(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUpload", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUpload indicates an expected call of DeleteUpload.
+func (mr *MockUploadUsecaseMockRecorder) DeleteUpload(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUpload", reflect.TypeOf((*MockUploadUsecase)(nil).DeleteUpload), ctx, id)
+}
+
+// GetUpload mocks base method.
+func (m *MockUploadUsecase) GetUpload(ctx context.Context, id string) (*entity.Upload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUpload", ctx, id)
+	ret0, _ := ret[0].(*entity.Upload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUpload indicates an expected call of GetUpload.
+func (mr *MockUploadUsecaseMockRecorder) GetUpload(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUpload", reflect.TypeOf((*MockUploadUsecase)(nil).GetUpload), ctx, id)
+}
+
+// GetUploadOptions mocks base method.
+func (m *MockUploadUsecase) GetUploadOptions(ctx context.Context) *entity.UploadOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUploadOptions", ctx)
+	ret0, _ := ret[0].(*entity.UploadOptions)
+	return ret0
+}
+
+// GetUploadOptions indicates an expected call of GetUploadOptions.
+func (mr *MockUploadUsecaseMockRecorder) GetUploadOptions(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUploadOptions", reflect.TypeOf((*MockUploadUsecase)(nil).GetUploadOptions), ctx)
+}
@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: usage.go
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	reflect "reflect"
+	entity "video-server/module/entity"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockUsageUsecase is a mock of UsageUsecase interface.
+type MockUsageUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockUsageUsecaseMockRecorder
+}
+
+// MockUsageUsecaseMockRecorder is the mock recorder for MockUsageUsecase.
+type MockUsageUsecaseMockRecorder struct {
+	mock *MockUsageUsecase
+}
+
+// NewMockUsageUsecase creates a new mock instance.
+func NewMockUsageUsecase(ctrl *gomock.Controller) *MockUsageUsecase {
+	mock := &MockUsageUsecase{ctrl: ctrl}
+	mock.recorder = &MockUsageUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUsageUsecase) EXPECT() *MockUsageUsecaseMockRecorder {
+	return m.recorder
+}
+
+// GetUsage mocks base method.
+func (m *MockUsageUsecase) GetUsage(ctx context.Context) (*entity.Usage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsage", ctx)
+	ret0, _ := ret[0].(*entity.Usage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsage indicates an expected call of GetUsage.
+func (mr *MockUsageUsecaseMockRecorder) GetUsage(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsage", reflect.TypeOf((*MockUsageUsecase)(nil).GetUsage), ctx)
+}
+
+// ListUsage mocks base method.
+func (m *MockUsageUsecase) ListUsage(ctx context.Context) ([]*entity.Usage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsage", ctx)
+	ret0, _ := ret[0].([]*entity.Usage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsage indicates an expected call of ListUsage.
+func (mr *MockUsageUsecaseMockRecorder) ListUsage(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsage", reflect.TypeOf((*MockUsageUsecase)(nil).ListUsage), ctx)
+}
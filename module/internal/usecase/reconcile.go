@@ -0,0 +1,310 @@
+package usecase
+
+//go:generate mockgen -source reconcile.go -destination mock/reconcile.go
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+	"time"
+
+	"video-server/internal/storage"
+	"video-server/module/entity"
+	repository "video-server/module/internal/repository"
+	"video-server/module/param"
+)
+
+// reconcileBatchSize is how many file or upload rows are read per query
+const reconcileBatchSize = 500
+
+const (
+	blobPrefix    = "blobs/"
+	stagingPrefix = "staging/"
+	uploadsPrefix = "uploads/"
+)
+
+type ReconcileUsecase interface {
+	Reconcile(ctx context.Context, params *param.Reconcile) (*entity.ReconcileReport, error)
+}
+
+type reconcileUsecaseRepository struct {
+	file   repository.FileRepository
+	blob   repository.BlobRepository
+	upload repository.UploadRepository
+}
+
+type reconcileUsecase struct {
+	repository reconcileUsecaseRepository
+	storage    storage.BlobStore
+}
+
+func NewReconcileUsecase(
+	fileRepository repository.FileRepository,
+	blobRepository repository.BlobRepository,
+	uploadRepository repository.UploadRepository,
+	blobStore storage.BlobStore,
+) *reconcileUsecase {
+	return &reconcileUsecase{
+		repository: reconcileUsecaseRepository{
+			file:   fileRepository,
+			blob:   blobRepository,
+			upload: uploadRepository,
+		},
+		storage: blobStore,
+	}
+}
+
+// Reconcile walks the storage backend of every tenant and the files table
+// and repairs the drift between them: unreferenced objects are deleted, ready files whose
+// content is gone are marked broken, uploads that never completed are
+// marked failed and failed rows are removed. Resumable uploads untouched for
+// the grace period are abandoned, they are removed with their chunks.
+// Anything younger than the grace period is only reported.
+func (u *reconcileUsecase) Reconcile(ctx context.Context, params *param.Reconcile) (*entity.ReconcileReport, error) {
+	run := &reconcileRun{
+		usecase:    u,
+		params:     params,
+		cutoff:     time.Now().Add(-params.GracePeriod),
+		referenced: map[string]bool{},
+		uploads:    map[string]bool{},
+		report: &entity.ReconcileReport{
+			StartedAt:   time.Now(),
+			DryRun:      params.DryRun,
+			GracePeriod: params.GracePeriod.String(),
+			Mismatches:  []*entity.Mismatch{},
+		},
+	}
+
+	// listed before the rows are read, objects written in between are
+	// younger than the grace period anyway
+	objects, err := u.storage.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	run.objects = map[string]*storage.BlobInfo{}
+	for _, object := range objects {
+		run.objects[object.Key] = object
+	}
+	run.report.BlobsChecked = len(run.objects)
+
+	afterID := 0
+	for {
+		files, err := u.repository.file.ScanFiles(ctx, afterID, reconcileBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			run.checkFile(ctx, file)
+		}
+		run.report.FilesChecked += len(files)
+
+		if len(files) < reconcileBatchSize {
+			break
+		}
+		afterID = files[len(files)-1].ID
+	}
+
+	afterUploadID := ""
+	for {
+		uploads, err := u.repository.upload.ScanUploads(ctx, afterUploadID, reconcileBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, upload := range uploads {
+			run.checkUpload(ctx, upload)
+		}
+		run.report.UploadsChecked += len(uploads)
+
+		if len(uploads) < reconcileBatchSize {
+			break
+		}
+		afterUploadID = uploads[len(uploads)-1].ID
+	}
+
+	for _, object := range objects {
+		if _, ok := run.objects[object.Key]; !ok || run.referenced[object.Key] {
+			continue
+		}
+		// chunks of upload rows are checked with their row
+		tenant, key := splitTenantKey(object.Key)
+		if id, _, ok := strings.Cut(strings.TrimPrefix(key, uploadsPrefix), "/"); ok &&
+			strings.HasPrefix(key, uploadsPrefix) && run.uploads[tenantPrefix(tenant)+uploadPrefix(id)] {
+			continue
+		}
+		// thumbnails live as long as the content they were taken from
+		if strings.HasPrefix(key, thumbnailPrefix) &&
+			run.referenced[tenantPrefix(tenant)+path.Dir(strings.TrimPrefix(key, thumbnailPrefix))] {
+			continue
+		}
+		// and so do packaged streams, two levels below their content key
+		if strings.HasPrefix(key, streamPrefix) &&
+			run.referenced[tenantPrefix(tenant)+path.Dir(path.Dir(strings.TrimPrefix(key, streamPrefix)))] {
+			continue
+		}
+		run.checkObject(ctx, object)
+	}
+
+	run.report.FinishedAt = time.Now()
+	return run.report, nil
+}
+
+type reconcileRun struct {
+	usecase *reconcileUsecase
+	params  *param.Reconcile
+	cutoff  time.Time
+
+	objects    map[string]*storage.BlobInfo
+	referenced map[string]bool
+	// uploads holds the chunk prefixes of upload rows
+	uploads map[string]bool
+	report  *entity.ReconcileReport
+}
+
+func (r *reconcileRun) checkFile(ctx context.Context, file *entity.File) {
+	key := tenantPrefix(file.TenantID) + fileStorageKey(file)
+	mismatch := &entity.Mismatch{
+		Key:        key,
+		FileID:     file.ID,
+		Size:       file.Size,
+		ModifiedAt: file.CreatedAt,
+	}
+
+	switch file.Status {
+	case entity.FileStatusFailed:
+		// its blob reference went when it failed
+		mismatch.Kind = entity.MismatchFailedFile
+		r.resolve(mismatch, file.CreatedAt, func() (string, error) {
+			return entity.ActionDeleted, r.usecase.repository.file.DeleteFile(ctx, file.ID)
+		})
+
+	case entity.FileStatusPending:
+		if file.CreatedAt.After(r.cutoff) {
+			// an upload in flight
+			r.referenced[key] = true
+			return
+		}
+		mismatch.Kind = entity.MismatchStalePending
+		r.resolve(mismatch, file.CreatedAt, func() (string, error) {
+			err := r.usecase.repository.file.UpdateFileStatus(ctx, file.ID, entity.FileStatusFailed)
+			if err == nil && file.Digest != "" {
+				_, err = r.usecase.repository.blob.ReleaseBlob(ctx, file.TenantID, file.Digest, nil)
+			}
+			return entity.ActionMarkedFailed, err
+		})
+
+	case entity.FileStatusBroken:
+		r.referenced[key] = true
+		if _, ok := r.objects[key]; !ok {
+			return
+		}
+		mismatch.Kind = entity.MismatchRestoredBlob
+		r.resolve(mismatch, time.Time{}, func() (string, error) {
+			return entity.ActionMarkedReady, r.usecase.repository.file.UpdateFileStatus(ctx, file.ID, entity.FileStatusReady)
+		})
+
+	default:
+		r.referenced[key] = true
+		if _, ok := r.objects[key]; ok {
+			return
+		}
+		// the listing may predate the upload, ask again before acting
+		_, err := r.usecase.storage.Stat(ctx, key)
+		if !errors.Is(err, storage.ErrBlobNotFound) {
+			return
+		}
+		mismatch.Kind = entity.MismatchMissingBlob
+		r.resolve(mismatch, time.Time{}, func() (string, error) {
+			return entity.ActionMarkedBroken, r.usecase.repository.file.UpdateFileStatus(ctx, file.ID, entity.FileStatusBroken)
+		})
+	}
+}
+
+// checkUpload removes an upload nobody appended to or completed within the
+// grace period, with the chunks it left.
+func (r *reconcileRun) checkUpload(ctx context.Context, upload *entity.Upload) {
+	prefix := tenantPrefix(upload.TenantID) + uploadPrefix(upload.ID)
+	r.uploads[prefix] = true
+	if upload.UpdatedAt.After(r.cutoff) {
+		return
+	}
+
+	mismatch := &entity.Mismatch{
+		Kind:       entity.MismatchStaleUpload,
+		Key:        prefix,
+		Size:       upload.Offset,
+		ModifiedAt: upload.UpdatedAt,
+	}
+	r.resolve(mismatch, upload.UpdatedAt, func() (string, error) {
+		parts, err := r.usecase.storage.List(ctx, prefix)
+		if err != nil {
+			return entity.ActionError, err
+		}
+		for _, part := range parts {
+			err = r.usecase.storage.Delete(ctx, part.Key)
+			if err != nil && !errors.Is(err, storage.ErrBlobNotFound) {
+				return entity.ActionError, err
+			}
+		}
+		return entity.ActionDeleted, r.usecase.repository.upload.DeleteUpload(ctx, upload.ID)
+	})
+}
+
+// checkObject handles an object no file or upload refers to.
+func (r *reconcileRun) checkObject(ctx context.Context, object *storage.BlobInfo) {
+	mismatch := &entity.Mismatch{
+		Kind:       entity.MismatchOrphanBlob,
+		Key:        object.Key,
+		Size:       object.Size,
+		ModifiedAt: object.ModifiedAt,
+	}
+	tenant, key := splitTenantKey(object.Key)
+	if strings.HasPrefix(key, stagingPrefix) {
+		mismatch.Kind = entity.MismatchStaleStaging
+	}
+
+	r.resolve(mismatch, object.ModifiedAt, func() (string, error) {
+		digest := ""
+		if strings.HasPrefix(key, blobPrefix) {
+			digest = key[strings.LastIndex(key, "/")+1:]
+
+			// a new file may have started sharing the content since the
+			// rows were read
+			count, err := r.usecase.repository.file.CountFilesByDigest(ctx, tenant, digest)
+			if err != nil {
+				return entity.ActionError, err
+			}
+			if count > 0 {
+				return entity.ActionKept, nil
+			}
+		}
+
+		err := r.usecase.storage.Delete(ctx, object.Key)
+		if err != nil && !errors.Is(err, storage.ErrBlobNotFound) {
+			return entity.ActionError, err
+		}
+		if digest != "" {
+			return entity.ActionDeleted, r.usecase.repository.blob.DeleteBlob(ctx, tenant, digest)
+		}
+		return entity.ActionDeleted, nil
+	})
+}
+
+// resolve applies fix unless this is a dry run or the mismatch is younger
+// than the grace period, and records the outcome in the report.
+func (r *reconcileRun) resolve(mismatch *entity.Mismatch, since time.Time, fix func() (string, error)) {
+	switch {
+	case since.After(r.cutoff):
+		mismatch.Action = entity.ActionKept
+	case r.params.DryRun:
+		mismatch.Action = entity.ActionNone
+	default:
+		action, err := fix()
+		mismatch.Action = action
+		if err != nil {
+			mismatch.Action = entity.ActionError
+			mismatch.Error = err.Error()
+		}
+	}
+	r.report.Mismatches = append(r.report.Mismatches, mismatch)
+}
@@ -0,0 +1,332 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"video-server/internal/storage"
+	"video-server/internal/testutil"
+	"video-server/module/entity"
+	"video-server/module/fixture"
+	"video-server/module/param"
+)
+
+func TestReconcileUsecase_Reconcile(t *testing.T) {
+	old := time.Now().Add(-48 * time.Hour)
+	recent := time.Now().Add(-time.Hour)
+	otherDigest := "ab" + sampleDigest[2:]
+
+	type Request struct {
+		params *param.Reconcile
+	}
+	type Response struct {
+		mismatches []entity.Mismatch
+		files      int
+		uploads    int
+		blobs      int
+		err        error
+	}
+
+	testcases := map[string]struct {
+		request  Request
+		response Response
+		mockFn   func(m *fixture.MockReconcileUsecase)
+	}{
+		"in sync": {
+			request: Request{params: &param.Reconcile{GracePeriod: 24 * time.Hour}},
+			response: Response{
+				mismatches: []entity.Mismatch{},
+				files:      2,
+				uploads:    1,
+				blobs:      7,
+			},
+			mockFn: func(m *fixture.MockReconcileUsecase) {
+				m.BlobStore.EXPECT().List(gomock.Any(), "").Return([]*storage.BlobInfo{
+					{Key: storage.DigestKey(sampleDigest), ModifiedAt: old},
+					{Key: "legacy.mp4", ModifiedAt: old},
+					{Key: "thumbnails/" + storage.DigestKey(sampleDigest) + "/poster.jpg", ModifiedAt: old},
+					{Key: "thumbnails/legacy.mp4/poster.jpg", ModifiedAt: old},
+					{Key: "streams/" + storage.DigestKey(sampleDigest) + "/hls/master.m3u8", ModifiedAt: old},
+					{Key: "streams/legacy.mp4/dash/manifest.mpd", ModifiedAt: old},
+					{Key: "uploads/abc/00000000000000000000", ModifiedAt: old},
+				}, nil)
+				m.FileRepository.EXPECT().ScanFiles(gomock.Any(), 0, 500).Return([]*entity.File{
+					{ID: 1, Digest: sampleDigest, Status: entity.FileStatusReady, CreatedAt: old},
+					{ID: 2, Name: "legacy.mp4", Status: entity.FileStatusReady, CreatedAt: old},
+				}, nil)
+				m.UploadRepository.EXPECT().ScanUploads(gomock.Any(), "", 500).Return([]*entity.Upload{
+					{ID: "abc", Offset: 10, UpdatedAt: recent},
+				}, nil)
+			},
+		},
+		"orphans": {
+			request: Request{params: &param.Reconcile{GracePeriod: 24 * time.Hour}},
+			response: Response{
+				mismatches: []entity.Mismatch{
+					{Kind: entity.MismatchOrphanBlob, Key: storage.DigestKey(sampleDigest), Size: 10, ModifiedAt: old, Action: entity.ActionDeleted},
+					{Kind: entity.MismatchStaleStaging, Key: "staging/abc", ModifiedAt: old, Action: entity.ActionDeleted},
+					{Kind: entity.MismatchStaleStaging, Key: "staging/def", ModifiedAt: recent, Action: entity.ActionKept},
+					{Kind: entity.MismatchOrphanBlob, Key: storage.DigestKey(otherDigest), ModifiedAt: old, Action: entity.ActionKept},
+					{Kind: entity.MismatchOrphanBlob, Key: "thumbnails/legacy.mp4/poster.jpg", ModifiedAt: old, Action: entity.ActionDeleted},
+					{Kind: entity.MismatchOrphanBlob, Key: "streams/legacy.mp4/hls/segment-0.m4s", ModifiedAt: old, Action: entity.ActionDeleted},
+				},
+				blobs: 6,
+			},
+			mockFn: func(m *fixture.MockReconcileUsecase) {
+				m.BlobStore.EXPECT().List(gomock.Any(), "").Return([]*storage.BlobInfo{
+					{Key: storage.DigestKey(sampleDigest), Size: 10, ModifiedAt: old},
+					{Key: "staging/abc", ModifiedAt: old},
+					{Key: "staging/def", ModifiedAt: recent},
+					{Key: storage.DigestKey(otherDigest), ModifiedAt: old},
+					{Key: "thumbnails/legacy.mp4/poster.jpg", ModifiedAt: old},
+					{Key: "streams/legacy.mp4/hls/segment-0.m4s", ModifiedAt: old},
+				}, nil)
+				m.FileRepository.EXPECT().ScanFiles(gomock.Any(), 0, 500).Return([]*entity.File{}, nil)
+				m.UploadRepository.EXPECT().ScanUploads(gomock.Any(), "", 500).Return([]*entity.Upload{}, nil)
+
+				m.FileRepository.EXPECT().CountFilesByDigest(gomock.Any(), "", sampleDigest).Return(int64(0), nil)
+				m.BlobStore.EXPECT().Delete(gomock.Any(), storage.DigestKey(sampleDigest)).Return(nil)
+				m.BlobRepository.EXPECT().DeleteBlob(gomock.Any(), "", sampleDigest).Return(nil)
+				m.BlobStore.EXPECT().Delete(gomock.Any(), "staging/abc").Return(nil)
+				// shared again since the rows were read
+				m.FileRepository.EXPECT().CountFilesByDigest(gomock.Any(), "", otherDigest).Return(int64(1), nil)
+				m.BlobStore.EXPECT().Delete(gomock.Any(), "thumbnails/legacy.mp4/poster.jpg").Return(nil)
+				m.BlobStore.EXPECT().Delete(gomock.Any(), "streams/legacy.mp4/hls/segment-0.m4s").Return(nil)
+			},
+		},
+		"file rows": {
+			request: Request{params: &param.Reconcile{GracePeriod: 24 * time.Hour}},
+			response: Response{
+				mismatches: []entity.Mismatch{
+					{Kind: entity.MismatchMissingBlob, Key: storage.DigestKey(sampleDigest), FileID: 1, ModifiedAt: old, Action: entity.ActionMarkedBroken},
+					{Kind: entity.MismatchRestoredBlob, Key: "legacy.mp4", FileID: 2, ModifiedAt: old, Action: entity.ActionMarkedReady},
+					{Kind: entity.MismatchStalePending, Key: storage.DigestKey(otherDigest), FileID: 3, ModifiedAt: old, Action: entity.ActionMarkedFailed},
+					{Kind: entity.MismatchFailedFile, Key: "failed.mp4", FileID: 5, ModifiedAt: old, Action: entity.ActionDeleted},
+					{Kind: entity.MismatchFailedFile, Key: "failed.mp4", FileID: 6, ModifiedAt: recent, Action: entity.ActionKept},
+				},
+				files: 6,
+				blobs: 1,
+			},
+			mockFn: func(m *fixture.MockReconcileUsecase) {
+				m.BlobStore.EXPECT().List(gomock.Any(), "").Return([]*storage.BlobInfo{
+					{Key: "legacy.mp4", ModifiedAt: old},
+				}, nil)
+				m.FileRepository.EXPECT().ScanFiles(gomock.Any(), 0, 500).Return([]*entity.File{
+					{ID: 1, Digest: sampleDigest, Status: entity.FileStatusReady, CreatedAt: old},
+					{ID: 2, Name: "legacy.mp4", Status: entity.FileStatusBroken, CreatedAt: old},
+					{ID: 3, Digest: otherDigest, Status: entity.FileStatusPending, CreatedAt: old},
+					{ID: 4, Name: "pending.mp4", Status: entity.FileStatusPending, CreatedAt: recent},
+					{ID: 5, Name: "failed.mp4", Status: entity.FileStatusFailed, CreatedAt: old},
+					{ID: 6, Name: "failed.mp4", Status: entity.FileStatusFailed, CreatedAt: recent},
+				}, nil)
+				m.UploadRepository.EXPECT().ScanUploads(gomock.Any(), "", 500).Return([]*entity.Upload{}, nil)
+
+				m.BlobStore.EXPECT().Stat(gomock.Any(), storage.DigestKey(sampleDigest)).Return(nil, storage.ErrBlobNotFound)
+				m.FileRepository.EXPECT().UpdateFileStatus(gomock.Any(), 1, entity.FileStatusBroken).Return(nil)
+				m.FileRepository.EXPECT().UpdateFileStatus(gomock.Any(), 2, entity.FileStatusReady).Return(nil)
+				m.FileRepository.EXPECT().UpdateFileStatus(gomock.Any(), 3, entity.FileStatusFailed).Return(nil)
+				m.BlobRepository.EXPECT().ReleaseBlob(gomock.Any(), "", otherDigest, nil).Return(int64(0), nil)
+				m.FileRepository.EXPECT().DeleteFile(gomock.Any(), 5).Return(nil)
+			},
+		},
+		"tenants": {
+			request: Request{params: &param.Reconcile{GracePeriod: 24 * time.Hour}},
+			response: Response{
+				mismatches: []entity.Mismatch{
+					{Kind: entity.MismatchStalePending, Key: "tenants/globex/" + storage.DigestKey(otherDigest), FileID: 2, ModifiedAt: old, Action: entity.ActionMarkedFailed},
+					{Kind: entity.MismatchOrphanBlob, Key: "tenants/globex/" + storage.DigestKey(sampleDigest), ModifiedAt: old, Action: entity.ActionDeleted},
+					{Kind: entity.MismatchStaleStaging, Key: "tenants/globex/staging/abc", ModifiedAt: old, Action: entity.ActionDeleted},
+				},
+				files:   2,
+				uploads: 1,
+				blobs:   5,
+			},
+			mockFn: func(m *fixture.MockReconcileUsecase) {
+				m.BlobStore.EXPECT().List(gomock.Any(), "").Return([]*storage.BlobInfo{
+					{Key: "tenants/acme/" + storage.DigestKey(sampleDigest), ModifiedAt: old},
+					{Key: "tenants/acme/thumbnails/" + storage.DigestKey(sampleDigest) + "/poster.jpg", ModifiedAt: old},
+					{Key: "tenants/acme/uploads/abc/00000000000000000000", ModifiedAt: old},
+					{Key: "tenants/globex/" + storage.DigestKey(sampleDigest), ModifiedAt: old},
+					{Key: "tenants/globex/staging/abc", ModifiedAt: old},
+				}, nil)
+				m.FileRepository.EXPECT().ScanFiles(gomock.Any(), 0, 500).Return([]*entity.File{
+					{ID: 1, Digest: sampleDigest, TenantID: "acme", Status: entity.FileStatusReady, CreatedAt: old},
+					{ID: 2, Digest: otherDigest, TenantID: "globex", Status: entity.FileStatusPending, CreatedAt: old},
+				}, nil)
+				m.UploadRepository.EXPECT().ScanUploads(gomock.Any(), "", 500).Return([]*entity.Upload{
+					{ID: "abc", TenantID: "acme", UpdatedAt: recent},
+				}, nil)
+
+				m.FileRepository.EXPECT().UpdateFileStatus(gomock.Any(), 2, entity.FileStatusFailed).Return(nil)
+				m.BlobRepository.EXPECT().ReleaseBlob(gomock.Any(), "globex", otherDigest, nil).Return(int64(0), nil)
+				// the content of acme doesn't keep the copy of globex
+				m.FileRepository.EXPECT().CountFilesByDigest(gomock.Any(), "globex", sampleDigest).Return(int64(0), nil)
+				m.BlobStore.EXPECT().Delete(gomock.Any(), "tenants/globex/"+storage.DigestKey(sampleDigest)).Return(nil)
+				m.BlobRepository.EXPECT().DeleteBlob(gomock.Any(), "globex", sampleDigest).Return(nil)
+				m.BlobStore.EXPECT().Delete(gomock.Any(), "tenants/globex/staging/abc").Return(nil)
+			},
+		},
+		"blob appeared after listing": {
+			request: Request{params: &param.Reconcile{GracePeriod: 24 * time.Hour}},
+			response: Response{
+				mismatches: []entity.Mismatch{},
+				files:      1,
+			},
+			mockFn: func(m *fixture.MockReconcileUsecase) {
+				m.BlobStore.EXPECT().List(gomock.Any(), "").Return([]*storage.BlobInfo{}, nil)
+				m.FileRepository.EXPECT().ScanFiles(gomock.Any(), 0, 500).Return([]*entity.File{
+					{ID: 1, Digest: sampleDigest, Status: entity.FileStatusReady, CreatedAt: recent},
+				}, nil)
+				m.UploadRepository.EXPECT().ScanUploads(gomock.Any(), "", 500).Return([]*entity.Upload{}, nil)
+				m.BlobStore.EXPECT().Stat(gomock.Any(), storage.DigestKey(sampleDigest)).
+					Return(&storage.BlobInfo{Key: storage.DigestKey(sampleDigest)}, nil)
+			},
+		},
+		"dry run": {
+			request: Request{params: &param.Reconcile{GracePeriod: 24 * time.Hour, DryRun: true}},
+			response: Response{
+				mismatches: []entity.Mismatch{
+					{Kind: entity.MismatchMissingBlob, Key: "legacy.mp4", FileID: 1, ModifiedAt: old, Action: entity.ActionNone},
+					{Kind: entity.MismatchOrphanBlob, Key: "orphan.mp4", ModifiedAt: old, Action: entity.ActionNone},
+				},
+				files: 1,
+				blobs: 1,
+			},
+			mockFn: func(m *fixture.MockReconcileUsecase) {
+				m.BlobStore.EXPECT().List(gomock.Any(), "").Return([]*storage.BlobInfo{
+					{Key: "orphan.mp4", ModifiedAt: old},
+				}, nil)
+				m.FileRepository.EXPECT().ScanFiles(gomock.Any(), 0, 500).Return([]*entity.File{
+					{ID: 1, Name: "legacy.mp4", Status: entity.FileStatusReady, CreatedAt: old},
+				}, nil)
+				m.UploadRepository.EXPECT().ScanUploads(gomock.Any(), "", 500).Return([]*entity.Upload{}, nil)
+				m.BlobStore.EXPECT().Stat(gomock.Any(), "legacy.mp4").Return(nil, storage.ErrBlobNotFound)
+			},
+		},
+		"fix error": {
+			request: Request{params: &param.Reconcile{GracePeriod: 24 * time.Hour}},
+			response: Response{
+				mismatches: []entity.Mismatch{
+					{Kind: entity.MismatchOrphanBlob, Key: "orphan.mp4", ModifiedAt: old, Action: entity.ActionError, Error: testutil.ErrStorage.Error()},
+				},
+				blobs: 1,
+			},
+			mockFn: func(m *fixture.MockReconcileUsecase) {
+				m.BlobStore.EXPECT().List(gomock.Any(), "").Return([]*storage.BlobInfo{
+					{Key: "orphan.mp4", ModifiedAt: old},
+				}, nil)
+				m.FileRepository.EXPECT().ScanFiles(gomock.Any(), 0, 500).Return([]*entity.File{}, nil)
+				m.UploadRepository.EXPECT().ScanUploads(gomock.Any(), "", 500).Return([]*entity.Upload{}, nil)
+				m.BlobStore.EXPECT().Delete(gomock.Any(), "orphan.mp4").Return(testutil.ErrStorage)
+			},
+		},
+		"List error": {
+			request: Request{params: &param.Reconcile{}},
+			response: Response{
+				err: testutil.ErrStorage,
+			},
+			mockFn: func(m *fixture.MockReconcileUsecase) {
+				m.BlobStore.EXPECT().List(gomock.Any(), "").Return(nil, testutil.ErrStorage)
+			},
+		},
+		"ScanFiles error": {
+			request: Request{params: &param.Reconcile{}},
+			response: Response{
+				err: testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockReconcileUsecase) {
+				m.BlobStore.EXPECT().List(gomock.Any(), "").Return([]*storage.BlobInfo{}, nil)
+				m.FileRepository.EXPECT().ScanFiles(gomock.Any(), 0, 500).Return(nil, testutil.ErrDB)
+			},
+		},
+		"upload rows": {
+			request: Request{params: &param.Reconcile{GracePeriod: 24 * time.Hour}},
+			response: Response{
+				mismatches: []entity.Mismatch{
+					{Kind: entity.MismatchStaleUpload, Key: "tenants/acme/uploads/abandoned/", Size: 10, ModifiedAt: old, Action: entity.ActionDeleted},
+					{Kind: entity.MismatchStaleUpload, Key: "uploads/completed/", Size: 20, ModifiedAt: old, Action: entity.ActionDeleted},
+					{Kind: entity.MismatchOrphanBlob, Key: "uploads/deleted/00000000000000000000-a", ModifiedAt: old, Action: entity.ActionDeleted},
+				},
+				uploads: 3,
+				blobs:   4,
+			},
+			mockFn: func(m *fixture.MockReconcileUsecase) {
+				fileID := 1
+				m.BlobStore.EXPECT().List(gomock.Any(), "").Return([]*storage.BlobInfo{
+					{Key: "tenants/acme/uploads/abandoned/00000000000000000000-a", ModifiedAt: old},
+					{Key: "uploads/active/00000000000000000000-a", ModifiedAt: old},
+					{Key: "uploads/active/00000000000000000010-b", ModifiedAt: recent},
+					{Key: "uploads/deleted/00000000000000000000-a", ModifiedAt: old},
+				}, nil)
+				m.FileRepository.EXPECT().ScanFiles(gomock.Any(), 0, 500).Return([]*entity.File{}, nil)
+				m.UploadRepository.EXPECT().ScanUploads(gomock.Any(), "", 500).Return([]*entity.Upload{
+					{ID: "abandoned", TenantID: "acme", Offset: 10, UpdatedAt: old},
+					{ID: "active", Offset: 20, UpdatedAt: recent},
+					{ID: "completed", FileID: &fileID, Offset: 20, UpdatedAt: old},
+				}, nil)
+
+				m.BlobStore.EXPECT().List(gomock.Any(), "tenants/acme/uploads/abandoned/").Return([]*storage.BlobInfo{
+					{Key: "tenants/acme/uploads/abandoned/00000000000000000000-a"},
+				}, nil)
+				m.BlobStore.EXPECT().Delete(gomock.Any(), "tenants/acme/uploads/abandoned/00000000000000000000-a").Return(nil)
+				m.UploadRepository.EXPECT().DeleteUpload(gomock.Any(), "abandoned").Return(nil)
+				m.BlobStore.EXPECT().List(gomock.Any(), "uploads/completed/").Return([]*storage.BlobInfo{}, nil)
+				m.UploadRepository.EXPECT().DeleteUpload(gomock.Any(), "completed").Return(nil)
+				// the row went, its chunk was left behind
+				m.BlobStore.EXPECT().Delete(gomock.Any(), "uploads/deleted/00000000000000000000-a").Return(nil)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ucs, mocks := fixture.NewReconcileUsecase(ctrl)
+			tc.mockFn(mocks)
+
+			result, err := ucs.Reconcile(context.Background(), tc.request.params)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			if tc.response.err != nil {
+				return
+			}
+
+			assert.Equal(t, tc.request.params.DryRun, result.DryRun)
+			assert.Equal(t, tc.response.files, result.FilesChecked)
+			assert.Equal(t, tc.response.uploads, result.UploadsChecked)
+			assert.Equal(t, tc.response.blobs, result.BlobsChecked)
+			mismatches := []entity.Mismatch{}
+			for _, mismatch := range result.Mismatches {
+				mismatches = append(mismatches, *mismatch)
+			}
+			assert.Equal(t, tc.response.mismatches, mismatches)
+		})
+	}
+}
+
+func TestReconcileUsecase_Reconcile_Batches(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ucs, mocks := fixture.NewReconcileUsecase(ctrl)
+
+	batch := make([]*entity.File, 500)
+	for i := range batch {
+		batch[i] = &entity.File{ID: i + 1, Name: "legacy.mp4", Status: entity.FileStatusReady}
+	}
+	mocks.BlobStore.EXPECT().List(gomock.Any(), "").Return([]*storage.BlobInfo{{Key: "legacy.mp4"}}, nil)
+	gomock.InOrder(
+		mocks.FileRepository.EXPECT().ScanFiles(gomock.Any(), 0, 500).Return(batch, nil),
+		mocks.FileRepository.EXPECT().ScanFiles(gomock.Any(), 500, 500).Return([]*entity.File{}, nil),
+	)
+	mocks.UploadRepository.EXPECT().ScanUploads(gomock.Any(), "", 500).Return([]*entity.Upload{}, nil)
+
+	result, err := ucs.Reconcile(context.Background(), &param.Reconcile{})
+	assert.NoError(t, err)
+	assert.Equal(t, 500, result.FilesChecked)
+	assert.Empty(t, result.Mismatches)
+}
@@ -0,0 +1,479 @@
+package usecase
+
+//go:generate mockgen -source stream.go -destination mock/stream.go
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"video-server/internal/dash"
+	"video-server/internal/hls"
+	"video-server/internal/mp4"
+	"video-server/internal/storage"
+	"video-server/module/entity"
+)
+
+const (
+	// segmentDuration is the minimum segment length, segments are cut at
+	// the first key frame after it
+	segmentDuration = 6.0
+
+	// movieCacheSize bounds how many parsed sample tables are kept around,
+	// a long movie's tables are a few MB
+	movieCacheSize = 32
+
+	// streamPrefix holds the packaged playlists and segments of each
+	// content, the HLS master playlist is stored last
+	streamPrefix       = "streams/"
+	streamCompleteName = "hls/" + hls.MasterPlaylistName
+)
+
+var streamMimeTypes = map[string]bool{
+	"video/mp4":       true,
+	"video/quicktime": true,
+	"video/x-m4v":     true,
+}
+
+type StreamUsecase interface {
+	// CreatePackage cuts the movie of a file into segments and stores them
+	// with its playlists, it fails with ErrorStreamUnsupported like the
+	// other methods
+	CreatePackage(ctx context.Context, file *entity.File) error
+	GetHLSMasterPlaylist(ctx context.Context, id int) (string, error)
+	GetHLSMediaPlaylist(ctx context.Context, id int) (string, error)
+	GetDASHManifest(ctx context.Context, id int) ([]byte, error)
+	// WriteInitSegment writes the init segment of one track, or of all
+	// tracks when trackID is 0
+	WriteInitSegment(ctx context.Context, id int, trackID uint32, w io.Writer) error
+	// WriteMediaSegment writes the index-th segment muxing all tracks
+	WriteMediaSegment(ctx context.Context, id int, index int, w io.Writer) error
+	// WriteTrackSegment writes the segment of a single track starting at
+	// time, in the track timescale
+	WriteTrackSegment(ctx context.Context, id int, trackID uint32, time uint64, w io.Writer) error
+}
+
+type streamUsecase struct {
+	file    FileUsecase
+	storage storage.BlobStore
+
+	mu     sync.Mutex
+	movies map[string]*packagedMovie
+	order  []string
+}
+
+// packagedMovie is a parsed movie with its segment boundaries, which only
+// depend on the content and are cached by content key.
+type packagedMovie struct {
+	movie    *mp4.Movie
+	segments []*mp4.Segment
+}
+
+// renderFunc renders an item of the package of a movie whose content is
+// read from blob.
+type renderFunc func(pkg *packagedMovie, blob storage.Blob, w io.Writer) error
+
+func NewStreamUsecase(fileUsecase FileUsecase, blobStore storage.BlobStore) *streamUsecase {
+	return &streamUsecase{
+		file:    fileUsecase,
+		storage: blobStore,
+		movies:  map[string]*packagedMovie{},
+	}
+}
+
+// CreatePackage stores every item the stream routes serve. The master
+// playlist goes last and marks the package complete, content already
+// packaged for another file is left alone.
+func (u *streamUsecase) CreatePackage(ctx context.Context, file *entity.File) error {
+	if !streamMimeTypes[file.MimeType] {
+		return entity.ErrorStreamUnsupported
+	}
+
+	store := tenantStore(u.storage, file.TenantID)
+	_, err := store.Stat(ctx, streamKey(file, streamCompleteName))
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, storage.ErrBlobNotFound) {
+		return err
+	}
+
+	blob, err := u.openContent(ctx, file)
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+
+	pkg, err := parseMovie(file, blob)
+	if err != nil {
+		return err
+	}
+
+	put := func(name string, render renderFunc) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		buf := &bytes.Buffer{}
+		err := render(pkg, blob, buf)
+		if err != nil {
+			return err
+		}
+		return store.Put(ctx, streamKey(file, name), buf, int64(buf.Len()))
+	}
+
+	for index := range pkg.segments {
+		err = put(hlsName(hls.SegmentName(index)), renderMediaSegment(index))
+		if err != nil {
+			return err
+		}
+	}
+	for _, track := range pkg.movie.Tracks {
+		for _, segment := range pkg.segments {
+			start, _, ok := segment.Timing(track)
+			if !ok {
+				continue
+			}
+			err = put(dashName(dash.MediaSegmentName(track.ID, start)), renderTrackSegment(track.ID, start))
+			if err != nil {
+				return err
+			}
+		}
+		err = put(dashName(dash.InitSegmentName(track.ID)), renderInitSegment(track.ID))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, item := range []struct {
+		name   string
+		render renderFunc
+	}{
+		{hlsName(hls.InitSegmentName), renderInitSegment(0)},
+		{dashName(dash.ManifestName), renderDASHManifest},
+		{hlsName(hls.MediaPlaylistName), renderHLSMediaPlaylist},
+		{streamCompleteName, renderHLSMasterPlaylist},
+	} {
+		err = put(item.name, item.render)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *streamUsecase) GetHLSMasterPlaylist(ctx context.Context, id int) (string, error) {
+	buf := &bytes.Buffer{}
+	err := u.serve(ctx, id, hlsName(hls.MasterPlaylistName), buf, renderHLSMasterPlaylist)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (u *streamUsecase) GetHLSMediaPlaylist(ctx context.Context, id int) (string, error) {
+	buf := &bytes.Buffer{}
+	err := u.serve(ctx, id, hlsName(hls.MediaPlaylistName), buf, renderHLSMediaPlaylist)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (u *streamUsecase) GetDASHManifest(ctx context.Context, id int) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	err := u.serve(ctx, id, dashName(dash.ManifestName), buf, renderDASHManifest)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (u *streamUsecase) WriteInitSegment(ctx context.Context, id int, trackID uint32, w io.Writer) error {
+	name := hlsName(hls.InitSegmentName)
+	if trackID != 0 {
+		name = dashName(dash.InitSegmentName(trackID))
+	}
+	return u.serve(ctx, id, name, w, renderInitSegment(trackID))
+}
+
+func (u *streamUsecase) WriteMediaSegment(ctx context.Context, id int, index int, w io.Writer) error {
+	return u.serve(ctx, id, hlsName(hls.SegmentName(index)), w, renderMediaSegment(index))
+}
+
+func (u *streamUsecase) WriteTrackSegment(ctx context.Context, id int, trackID uint32, time uint64, w io.Writer) error {
+	return u.serve(ctx, id, dashName(dash.MediaSegmentName(trackID, time)), w, renderTrackSegment(trackID, time))
+}
+
+// serve writes the stored item name of the package of a file. Files that
+// aren't packaged yet, uploaded before packaging existed or whose package
+// job hasn't run, are rendered on the fly with render.
+func (u *streamUsecase) serve(ctx context.Context, id int, name string, w io.Writer, render renderFunc) error {
+	file, err := u.file.GetFile(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !streamMimeTypes[file.MimeType] {
+		return entity.ErrorStreamUnsupported
+	}
+
+	store := tenantStore(u.storage, file.TenantID)
+	stored, err := store.Get(ctx, streamKey(file, name))
+	if err == nil {
+		defer stored.Close()
+		_, err = io.Copy(w, stored)
+		return err
+	}
+	if !errors.Is(err, storage.ErrBlobNotFound) {
+		return err
+	}
+
+	// a complete package holds every item there is
+	_, err = store.Stat(ctx, streamKey(file, streamCompleteName))
+	if err == nil {
+		return entity.ErrorSegmentNotFound
+	}
+	if !errors.Is(err, storage.ErrBlobNotFound) {
+		return err
+	}
+
+	pkg, blob, err := u.openMovie(ctx, file)
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+
+	return render(pkg, blob, w)
+}
+
+// openMovie opens the file content and returns its packaging, parsing the
+// movie on first use. The caller closes the blob.
+func (u *streamUsecase) openMovie(ctx context.Context, file *entity.File) (*packagedMovie, storage.Blob, error) {
+	blob, err := u.openContent(ctx, file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := movieCacheKey(file)
+	if pkg := u.cachedMovie(key); pkg != nil {
+		return pkg, blob, nil
+	}
+
+	pkg, err := parseMovie(file, blob)
+	if err != nil {
+		blob.Close()
+		return nil, nil, err
+	}
+
+	u.cacheMovie(key, pkg)
+	return pkg, blob, nil
+}
+
+func (u *streamUsecase) openContent(ctx context.Context, file *entity.File) (storage.Blob, error) {
+	blob, err := tenantStore(u.storage, file.TenantID).Get(ctx, fileStorageKey(file))
+	if err != nil {
+		if errors.Is(err, storage.ErrBlobNotFound) {
+			return nil, entity.ErrorFileNotFound
+		}
+		return nil, err
+	}
+	return blob, nil
+}
+
+func parseMovie(file *entity.File, blob storage.Blob) (*packagedMovie, error) {
+	movie, err := mp4.Parse(blob, file.Size)
+	if err != nil {
+		return nil, entity.ErrorStreamUnsupported
+	}
+
+	pkg := &packagedMovie{
+		movie:    movie,
+		segments: movie.Segments(segmentDuration),
+	}
+	if len(pkg.segments) == 0 {
+		return nil, entity.ErrorStreamUnsupported
+	}
+	return pkg, nil
+}
+
+func (u *streamUsecase) cachedMovie(key string) *packagedMovie {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.movies[key]
+}
+
+func (u *streamUsecase) cacheMovie(key string, pkg *packagedMovie) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if _, ok := u.movies[key]; ok {
+		return
+	}
+	if len(u.order) >= movieCacheSize {
+		delete(u.movies, u.order[0])
+		u.order = u.order[1:]
+	}
+	u.movies[key] = pkg
+	u.order = append(u.order, key)
+}
+
+// movieCacheKey identifies the content, legacy files without a digest are
+// keyed by id and size since their name can be reused.
+func movieCacheKey(file *entity.File) string {
+	if file.Digest == "" {
+		return fmt.Sprintf("file:%d:%d", file.ID, file.Size)
+	}
+	return file.Digest
+}
+
+// streamKey derives from the content key like posterKey, so files sharing
+// content share their package. name is the item below the stream routes.
+func streamKey(file *entity.File, name string) string {
+	return streamPrefix + fileStorageKey(file) + "/" + name
+}
+
+func hlsName(name string) string {
+	return "hls/" + name
+}
+
+func dashName(name string) string {
+	return "dash/" + name
+}
+
+func renderHLSMasterPlaylist(pkg *packagedMovie, blob storage.Blob, w io.Writer) error {
+	variant := hls.Variant{URI: hls.MediaPlaylistName}
+	variant.Bandwidth, variant.AverageBandwidth = pkg.bandwidth(nil)
+	for _, track := range pkg.movie.Tracks {
+		if track.Codec != "" {
+			variant.Codecs = append(variant.Codecs, track.Codec)
+		}
+	}
+	if video := pkg.movie.VideoTrack(); video != nil {
+		variant.Width = int(video.Width)
+		variant.Height = int(video.Height)
+		variant.FrameRate = video.FrameRate()
+	}
+
+	_, err := io.WriteString(w, hls.MasterPlaylist(variant))
+	return err
+}
+
+func renderHLSMediaPlaylist(pkg *packagedMovie, blob storage.Blob, w io.Writer) error {
+	durations := make([]float64, len(pkg.segments))
+	for i, segment := range pkg.segments {
+		durations[i] = segment.Duration
+	}
+
+	_, err := io.WriteString(w, hls.MediaPlaylist(mp4.TargetDuration(pkg.segments), durations))
+	return err
+}
+
+func renderDASHManifest(pkg *packagedMovie, blob storage.Blob, w io.Writer) error {
+	sets := []dash.AdaptationSet{}
+	for _, contentType := range []string{"video", "audio"} {
+		set := dash.AdaptationSet{ContentType: contentType}
+		for _, track := range pkg.movie.Tracks {
+			if (contentType == "video" && !track.IsVideo()) || (contentType == "audio" && !track.IsAudio()) {
+				continue
+			}
+			set.Representations = append(set.Representations, pkg.representation(track))
+		}
+		if len(set.Representations) > 0 {
+			sets = append(sets, set)
+		}
+	}
+
+	manifest, err := dash.Manifest(pkg.movie.DurationSeconds(), sets...)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(manifest)
+	return err
+}
+
+func renderInitSegment(trackID uint32) renderFunc {
+	return func(pkg *packagedMovie, blob storage.Blob, w io.Writer) error {
+		if trackID == 0 {
+			return pkg.movie.WriteInit(w)
+		}
+		if pkg.movie.Track(trackID) == nil {
+			return entity.ErrorSegmentNotFound
+		}
+		return pkg.movie.WriteInit(w, trackID)
+	}
+}
+
+func renderMediaSegment(index int) renderFunc {
+	return func(pkg *packagedMovie, blob storage.Blob, w io.Writer) error {
+		if index < 0 || index >= len(pkg.segments) {
+			return entity.ErrorSegmentNotFound
+		}
+		return pkg.movie.WriteSegment(w, blob, pkg.segments[index], uint32(index+1))
+	}
+}
+
+func renderTrackSegment(trackID uint32, time uint64) renderFunc {
+	return func(pkg *packagedMovie, blob storage.Blob, w io.Writer) error {
+		track := pkg.movie.Track(trackID)
+		if track == nil {
+			return entity.ErrorSegmentNotFound
+		}
+		for _, segment := range pkg.segments {
+			start, _, ok := segment.Timing(track)
+			if ok && start == time {
+				return pkg.movie.WriteSegment(w, blob, segment, uint32(segment.Index+1), trackID)
+			}
+		}
+		return entity.ErrorSegmentNotFound
+	}
+}
+
+// bandwidth returns the peak and average bit rate over the segments, of a
+// single track or of all tracks when track is nil.
+func (p *packagedMovie) bandwidth(track *mp4.Track) (int64, int64) {
+	trackIDs := []uint32{}
+	if track != nil {
+		trackIDs = append(trackIDs, track.ID)
+	}
+
+	var peak, total float64
+	var duration float64
+	for _, segment := range p.segments {
+		bits := float64(segment.Bytes(p.movie, trackIDs...) * 8)
+		total += bits
+		duration += segment.Duration
+		if segment.Duration > 0 && bits/segment.Duration > peak {
+			peak = bits / segment.Duration
+		}
+	}
+	if duration == 0 {
+		return 0, 0
+	}
+	return int64(peak), int64(total / duration)
+}
+
+// representation describes a track as a DASH representation, skipping the
+// segments it has no samples in.
+func (p *packagedMovie) representation(track *mp4.Track) dash.Representation {
+	rep := dash.Representation{
+		ID:         track.ID,
+		Codecs:     track.Codec,
+		Timescale:  track.Timescale,
+		Width:      int(track.Width),
+		Height:     int(track.Height),
+		FrameRate:  track.FrameRate(),
+		SampleRate: int(track.SampleRate),
+		Channels:   int(track.Channels),
+	}
+	rep.Bandwidth, _ = p.bandwidth(track)
+
+	for _, segment := range p.segments {
+		start, duration, ok := segment.Timing(track)
+		if ok {
+			rep.Segments = append(rep.Segments, dash.Segment{Time: start, Duration: duration})
+		}
+	}
+	return rep
+}
@@ -0,0 +1,432 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"video-server/internal/mp4"
+	"video-server/internal/storage"
+	"video-server/internal/testutil"
+	"video-server/module/entity"
+	"video-server/module/fixture"
+	"video-server/module/internal/usecase"
+)
+
+const samplePath = "./../../../test/post_1/sample.mp4"
+
+func sampleFile(t *testing.T) (*entity.File, *os.File) {
+	blob, err := os.Open(samplePath)
+	require.NoError(t, err)
+	info, err := blob.Stat()
+	require.NoError(t, err)
+
+	return &entity.File{ID: 1, Name: "sample.mp4", MimeType: "video/mp4", Size: info.Size(), Digest: sampleDigest}, blob
+}
+
+// sampleStreamFile is the sample movie as stored by streamStore.
+func sampleStreamFile(t *testing.T) *entity.File {
+	file, blob := sampleFile(t)
+	require.NoError(t, blob.Close())
+	return file
+}
+
+// streamStore backs the blob store mock with a local store holding the
+// sample movie, and the upload part as the legacy file "part.txt".
+func streamStore(t *testing.T, m *fixture.MockStreamUsecase) storage.BlobStore {
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	for key, path := range map[string]string{sampleKey: samplePath, "part.txt": uploadPartPath} {
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.NoError(t, store.Put(context.Background(), key, bytes.NewReader(content), int64(len(content))))
+	}
+
+	m.BlobStore.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(store.Put).AnyTimes()
+	m.BlobStore.EXPECT().Get(gomock.Any(), gomock.Any()).
+		DoAndReturn(store.Get).AnyTimes()
+	m.BlobStore.EXPECT().Stat(gomock.Any(), gomock.Any()).
+		DoAndReturn(store.Stat).AnyTimes()
+	return store
+}
+
+func TestStreamUsecase_GetHLSMasterPlaylist(t *testing.T) {
+	type Response struct {
+		contains []string
+		err      error
+	}
+
+	testcases := map[string]struct {
+		response Response
+		mockFn   func(*testing.T, *fixture.MockStreamUsecase)
+	}{
+		"success": {
+			response: Response{
+				contains: []string{
+					`CODECS="avc1.640028,mp4a.40.2"`,
+					"RESOLUTION=1920x1080",
+					"\nmedia.m3u8\n",
+				},
+			},
+			mockFn: func(t *testing.T, m *fixture.MockStreamUsecase) {
+				streamStore(t, m)
+				m.FileUsecase.EXPECT().GetFile(gomock.Any(), 1).
+					Return(sampleStreamFile(t), nil)
+			},
+		},
+		"file not found": {
+			response: Response{
+				err: entity.ErrorFileNotFound,
+			},
+			mockFn: func(t *testing.T, m *fixture.MockStreamUsecase) {
+				m.FileUsecase.EXPECT().GetFile(gomock.Any(), 1).
+					Return(nil, entity.ErrorFileNotFound)
+			},
+		},
+		"content not stored": {
+			response: Response{
+				err: entity.ErrorFileNotFound,
+			},
+			mockFn: func(t *testing.T, m *fixture.MockStreamUsecase) {
+				streamStore(t, m)
+				m.FileUsecase.EXPECT().GetFile(gomock.Any(), 1).
+					Return(&entity.File{ID: 1, Name: "missing.mp4", MimeType: "video/mp4", Size: 19}, nil)
+			},
+		},
+		"unsupported mime type": {
+			response: Response{
+				err: entity.ErrorStreamUnsupported,
+			},
+			mockFn: func(t *testing.T, m *fixture.MockStreamUsecase) {
+				file := sampleStreamFile(t)
+				file.MimeType = "video/webm"
+				m.FileUsecase.EXPECT().GetFile(gomock.Any(), 1).
+					Return(file, nil)
+			},
+		},
+		"not a movie": {
+			response: Response{
+				err: entity.ErrorStreamUnsupported,
+			},
+			mockFn: func(t *testing.T, m *fixture.MockStreamUsecase) {
+				streamStore(t, m)
+				m.FileUsecase.EXPECT().GetFile(gomock.Any(), 1).
+					Return(&entity.File{ID: 1, Name: "part.txt", MimeType: "video/mp4", Size: 19}, nil)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ucs, mocks := fixture.NewStreamUsecase(ctrl)
+			tc.mockFn(t, mocks)
+
+			result, err := ucs.GetHLSMasterPlaylist(context.Background(), 1)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			for _, s := range tc.response.contains {
+				assert.Contains(t, result, s)
+			}
+		})
+	}
+}
+
+func TestStreamUsecase_GetHLSMediaPlaylist(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ucs, mocks := fixture.NewStreamUsecase(ctrl)
+	streamStore(t, mocks)
+	// the parsed movie is cached, both calls open the file but parse once
+	mocks.FileUsecase.EXPECT().GetFile(gomock.Any(), 1).
+		Return(sampleStreamFile(t), nil).Times(2)
+
+	for i := 0; i < 2; i++ {
+		result, err := ucs.GetHLSMediaPlaylist(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Contains(t, result, "#EXT-X-TARGETDURATION:6\n")
+		assert.Contains(t, result, "#EXT-X-MAP:URI=\"init.mp4\"\n")
+		assert.Contains(t, result, "\nsegment-0.m4s\n")
+		assert.NotContains(t, result, "segment-1.m4s")
+		assert.Contains(t, result, "#EXT-X-ENDLIST\n")
+	}
+}
+
+func TestStreamUsecase_WriteInitSegment(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ucs, mocks := fixture.NewStreamUsecase(ctrl)
+	streamStore(t, mocks)
+	mocks.FileUsecase.EXPECT().GetFile(gomock.Any(), 1).
+		Return(sampleStreamFile(t), nil)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, ucs.WriteInitSegment(context.Background(), 1, 0, buf))
+
+	movie, err := mp4.Parse(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	assert.Len(t, movie.Tracks, 2)
+}
+
+func TestStreamUsecase_WriteMediaSegment(t *testing.T) {
+	type Response struct {
+		err error
+	}
+
+	testcases := map[string]struct {
+		index    int
+		response Response
+	}{
+		"success": {
+			index:    0,
+			response: Response{err: nil},
+		},
+		"segment out of range": {
+			index:    1,
+			response: Response{err: entity.ErrorSegmentNotFound},
+		},
+		"negative segment": {
+			index:    -1,
+			response: Response{err: entity.ErrorSegmentNotFound},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ucs, mocks := fixture.NewStreamUsecase(ctrl)
+			streamStore(t, mocks)
+			mocks.FileUsecase.EXPECT().GetFile(gomock.Any(), 1).
+				Return(sampleStreamFile(t), nil)
+
+			buf := &bytes.Buffer{}
+			err := ucs.WriteMediaSegment(context.Background(), 1, tc.index, buf)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			if err == nil {
+				boxes, err := mp4.ReadBoxes(buf.Bytes(), 0)
+				require.NoError(t, err)
+				assert.Equal(t, "moof", boxes[1].Type)
+			}
+		})
+	}
+}
+
+func TestStreamUsecase_GetDASHManifest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ucs, mocks := fixture.NewStreamUsecase(ctrl)
+	streamStore(t, mocks)
+	mocks.FileUsecase.EXPECT().GetFile(gomock.Any(), 1).
+		Return(sampleStreamFile(t), nil)
+
+	result, err := ucs.GetDASHManifest(context.Background(), 1)
+	require.NoError(t, err)
+
+	manifest := string(result)
+	assert.Contains(t, manifest, `<Representation id="1" codecs="avc1.640028"`)
+	assert.Contains(t, manifest, `width="1920" height="1080"`)
+	assert.Contains(t, manifest, `<Representation id="2" codecs="mp4a.40.2"`)
+	assert.Contains(t, manifest, `<SegmentTemplate timescale="15360"`)
+	assert.Contains(t, manifest, `<S t="0" d="87552"></S>`)
+}
+
+func TestStreamUsecase_WriteTrackSegment(t *testing.T) {
+	type Request struct {
+		trackID uint32
+		time    uint64
+	}
+
+	type Response struct {
+		err error
+	}
+
+	testcases := map[string]struct {
+		request  Request
+		response Response
+	}{
+		"video": {
+			request:  Request{trackID: 1, time: 0},
+			response: Response{err: nil},
+		},
+		"audio": {
+			request:  Request{trackID: 2, time: 0},
+			response: Response{err: nil},
+		},
+		"unknown time": {
+			request:  Request{trackID: 1, time: 100},
+			response: Response{err: entity.ErrorSegmentNotFound},
+		},
+		"unknown track": {
+			request:  Request{trackID: 3, time: 0},
+			response: Response{err: entity.ErrorSegmentNotFound},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ucs, mocks := fixture.NewStreamUsecase(ctrl)
+			streamStore(t, mocks)
+			mocks.FileUsecase.EXPECT().GetFile(gomock.Any(), 1).
+				Return(sampleStreamFile(t), nil)
+
+			buf := &bytes.Buffer{}
+			err := ucs.WriteTrackSegment(context.Background(), 1, tc.request.trackID, tc.request.time, buf)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			if err != nil {
+				return
+			}
+
+			boxes, err := mp4.ReadBoxes(buf.Bytes(), 0)
+			require.NoError(t, err)
+			moof, err := boxes[1].Children()
+			require.NoError(t, err)
+			// mfhd and a single traf
+			assert.Len(t, moof, 2)
+		})
+	}
+}
+
+func TestStreamUsecase_WriteInitSegment_Track(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ucs, mocks := fixture.NewStreamUsecase(ctrl)
+	streamStore(t, mocks)
+	mocks.FileUsecase.EXPECT().GetFile(gomock.Any(), 1).
+		Return(sampleStreamFile(t), nil).Times(2)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, ucs.WriteInitSegment(context.Background(), 1, 2, buf))
+	movie, err := mp4.Parse(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, movie.Tracks, 1)
+	assert.Equal(t, uint32(2), movie.Tracks[0].ID)
+
+	err = ucs.WriteInitSegment(context.Background(), 1, 3, &bytes.Buffer{})
+	testutil.AssertErrorExAc(t, entity.ErrorSegmentNotFound, err)
+}
+
+func TestStreamUsecase_CreatePackage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// rendered on request by a usecase whose store holds no package
+	live, liveMocks := fixture.NewStreamUsecase(ctrl)
+	streamStore(t, liveMocks)
+	liveMocks.FileUsecase.EXPECT().GetFile(gomock.Any(), 1).
+		Return(sampleStreamFile(t), nil).AnyTimes()
+
+	ucs, mocks := fixture.NewStreamUsecase(ctrl)
+	store := streamStore(t, mocks)
+	mocks.FileUsecase.EXPECT().GetFile(gomock.Any(), 1).
+		Return(sampleStreamFile(t), nil).AnyTimes()
+
+	ctx := context.Background()
+	require.NoError(t, ucs.CreatePackage(ctx, sampleStreamFile(t)))
+	// the package is served without the content
+	require.NoError(t, store.Delete(ctx, sampleKey))
+
+	for name, write := range map[string]func(usecase.StreamUsecase, *bytes.Buffer) error{
+		"master playlist": func(u usecase.StreamUsecase, buf *bytes.Buffer) error {
+			playlist, err := u.GetHLSMasterPlaylist(ctx, 1)
+			buf.WriteString(playlist)
+			return err
+		},
+		"media playlist": func(u usecase.StreamUsecase, buf *bytes.Buffer) error {
+			playlist, err := u.GetHLSMediaPlaylist(ctx, 1)
+			buf.WriteString(playlist)
+			return err
+		},
+		"dash manifest": func(u usecase.StreamUsecase, buf *bytes.Buffer) error {
+			manifest, err := u.GetDASHManifest(ctx, 1)
+			buf.Write(manifest)
+			return err
+		},
+		"init segment": func(u usecase.StreamUsecase, buf *bytes.Buffer) error {
+			return u.WriteInitSegment(ctx, 1, 0, buf)
+		},
+		"track init segment": func(u usecase.StreamUsecase, buf *bytes.Buffer) error {
+			return u.WriteInitSegment(ctx, 1, 2, buf)
+		},
+		"media segment": func(u usecase.StreamUsecase, buf *bytes.Buffer) error {
+			return u.WriteMediaSegment(ctx, 1, 0, buf)
+		},
+		"track segment": func(u usecase.StreamUsecase, buf *bytes.Buffer) error {
+			return u.WriteTrackSegment(ctx, 1, 1, 0, buf)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			expected, stored := &bytes.Buffer{}, &bytes.Buffer{}
+			require.NoError(t, write(live, expected))
+			require.NoError(t, write(ucs, stored))
+			assert.Equal(t, expected.Bytes(), stored.Bytes())
+		})
+	}
+
+	t.Run("missing segment", func(t *testing.T) {
+		err := ucs.WriteMediaSegment(ctx, 1, 1, &bytes.Buffer{})
+		testutil.AssertErrorExAc(t, entity.ErrorSegmentNotFound, err)
+	})
+}
+
+func TestStreamUsecase_CreatePackage_Skip(t *testing.T) {
+	type Response struct {
+		err error
+	}
+
+	testcases := map[string]struct {
+		file     func(t *testing.T) *entity.File
+		response Response
+		mockFn   func(*testing.T, *fixture.MockStreamUsecase)
+	}{
+		"already packaged": {
+			file:     sampleStreamFile,
+			response: Response{err: nil},
+			mockFn: func(t *testing.T, m *fixture.MockStreamUsecase) {
+				m.BlobStore.EXPECT().Stat(gomock.Any(), "streams/"+sampleKey+"/hls/master.m3u8").
+					Return(&storage.BlobInfo{}, nil)
+			},
+		},
+		"unsupported mime type": {
+			file: func(t *testing.T) *entity.File {
+				file := sampleStreamFile(t)
+				file.MimeType = "video/webm"
+				return file
+			},
+			response: Response{err: entity.ErrorStreamUnsupported},
+			mockFn:   func(t *testing.T, m *fixture.MockStreamUsecase) {},
+		},
+		"Stat error": {
+			file:     sampleStreamFile,
+			response: Response{err: testutil.ErrStorage},
+			mockFn: func(t *testing.T, m *fixture.MockStreamUsecase) {
+				m.BlobStore.EXPECT().Stat(gomock.Any(), gomock.Any()).
+					Return(nil, testutil.ErrStorage)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ucs, mocks := fixture.NewStreamUsecase(ctrl)
+			tc.mockFn(t, mocks)
+
+			err := ucs.CreatePackage(context.Background(), tc.file(t))
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+		})
+	}
+}
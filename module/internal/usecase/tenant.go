@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"video-server/internal/storage"
+	"video-server/module/entity"
+	repository "video-server/module/internal/repository"
+)
+
+// tenantsPrefix partitions the storage backend, the default tenant keeps
+// the root so content stored before tenants existed stays in place.
+const tenantsPrefix = "tenants/"
+
+// tenantPrefix is where the objects of a tenant are stored.
+func tenantPrefix(tenant string) string {
+	if tenant == "" {
+		return ""
+	}
+	return tenantsPrefix + tenant + "/"
+}
+
+// tenantStore returns the partition of store that belongs to tenant.
+func tenantStore(store storage.BlobStore, tenant string) storage.BlobStore {
+	return storage.WithPrefix(store, tenantPrefix(tenant))
+}
+
+// splitTenantKey splits a key of the whole backend into its tenant and the
+// key within the tenant partition.
+func splitTenantKey(key string) (string, string) {
+	if !strings.HasPrefix(key, tenantsPrefix) {
+		return "", key
+	}
+	tenant, rest, ok := strings.Cut(key[len(tenantsPrefix):], "/")
+	if !ok {
+		return "", key
+	}
+	return tenant, rest
+}
+
+// Quota limits what a tenant may store, 0 doesn't limit.
+type Quota struct {
+	MaxBytes int64
+	MaxFiles int64
+}
+
+func (q Quota) limited() bool {
+	return q.MaxBytes > 0 || q.MaxFiles > 0
+}
+
+type QuotaConfig struct {
+	// Default applies to tenants without an entry in Tenants
+	Default Quota
+	Tenants map[string]Quota
+}
+
+// For returns the quota of tenant.
+func (c QuotaConfig) For(tenant string) Quota {
+	if quota, ok := c.Tenants[tenant]; ok {
+		return quota
+	}
+	return c.Default
+}
+
+// uploadLimits bounds uploads by the file size limit and the quota of their
+// tenant, for direct and resumable uploads alike.
+type uploadLimits struct {
+	file    repository.FileRepository
+	maxSize int64
+	quota   QuotaConfig
+}
+
+// limit returns how many bytes an upload to tenant may take, and whether
+// that is bound by the quota rather than the file size limit. A tenant
+// already at its quota gets ErrorQuotaExceeded. Concurrent uploads are
+// checked against the same usage and may overshoot the quota together.
+func (l uploadLimits) limit(ctx context.Context, tenant string) (int64, bool, error) {
+	quota := l.quota.For(tenant)
+	if !quota.limited() {
+		return l.maxSize, false, nil
+	}
+
+	usage, err := l.file.GetUsage(ctx, tenant)
+	if err != nil {
+		return 0, false, err
+	}
+	if quota.MaxFiles > 0 && usage.Files >= quota.MaxFiles {
+		return 0, false, entity.WithReason(entity.ErrorQuotaExceeded, fmt.Sprintf("the tenant is limited to %d files", quota.MaxFiles))
+	}
+	if quota.MaxBytes <= 0 {
+		return l.maxSize, false, nil
+	}
+
+	left := quota.MaxBytes - usage.Bytes
+	if left <= 0 {
+		return 0, false, entity.WithReason(entity.ErrorQuotaExceeded, fmt.Sprintf("the tenant is limited to %d bytes", quota.MaxBytes))
+	}
+	if l.maxSize > 0 && l.maxSize <= left {
+		return l.maxSize, false, nil
+	}
+	return left, true, nil
+}
+
+// check rejects an upload of size bytes to tenant before any of it is
+// received.
+func (l uploadLimits) check(ctx context.Context, tenant string, size int64) error {
+	limit, quotaBound, err := l.limit(ctx, tenant)
+	if err != nil {
+		return err
+	}
+	if limit <= 0 || size <= limit {
+		return nil
+	}
+	if quotaBound {
+		return entity.WithReason(entity.ErrorQuotaExceeded, fmt.Sprintf("the upload exceeds the %d bytes left to the tenant", limit))
+	}
+	return entity.WithReason(entity.ErrorFileTooLarge, fmt.Sprintf("files are limited to %d bytes", l.maxSize))
+}
@@ -0,0 +1,129 @@
+package usecase
+
+//go:generate mockgen -source thumbnail.go -destination mock/thumbnail.go
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+
+	"video-server/internal/storage"
+	"video-server/internal/thumbnail"
+	"video-server/module/entity"
+	repository "video-server/module/internal/repository"
+)
+
+const thumbnailPrefix = "thumbnails/"
+
+type ThumbnailUsecase interface {
+	// CreatePoster extracts the first key frame of a file and stores it
+	CreatePoster(ctx context.Context, file *entity.File) error
+	// GetThumbnail returns the poster of a file as a JPEG image, or the
+	// key frame shown at time seconds when time is set
+	GetThumbnail(ctx context.Context, id int, time *float64) ([]byte, error)
+}
+
+type thumbnailUsecaseRepository struct {
+	file repository.FileRepository
+}
+
+type thumbnailUsecase struct {
+	repository thumbnailUsecaseRepository
+	access     fileAccess
+	storage    storage.BlobStore
+	extractor  thumbnail.FrameExtractor
+}
+
+func NewThumbnailUsecase(
+	fileRepository repository.FileRepository,
+	grantRepository repository.GrantRepository,
+	blobStore storage.BlobStore,
+	extractor thumbnail.FrameExtractor,
+) *thumbnailUsecase {
+	return &thumbnailUsecase{
+		repository: thumbnailUsecaseRepository{
+			file: fileRepository,
+		},
+		access:    fileAccess{grant: grantRepository},
+		storage:   blobStore,
+		extractor: extractor,
+	}
+}
+
+func (u *thumbnailUsecase) CreatePoster(ctx context.Context, file *entity.File) error {
+	poster, err := u.extract(ctx, file, -1)
+	if err != nil {
+		return err
+	}
+
+	return tenantStore(u.storage, file.TenantID).Put(ctx, posterKey(file), bytes.NewReader(poster), int64(len(poster)))
+}
+
+func (u *thumbnailUsecase) GetThumbnail(ctx context.Context, id int, time *float64) ([]byte, error) {
+	file, err := u.repository.file.GetFile(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.access.read(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+
+	if time != nil {
+		return u.extract(ctx, file, *time)
+	}
+
+	blob, err := tenantStore(u.storage, file.TenantID).Get(ctx, posterKey(file))
+	if err == nil {
+		defer blob.Close()
+		return io.ReadAll(blob)
+	}
+	if !errors.Is(err, storage.ErrBlobNotFound) {
+		return nil, err
+	}
+
+	// files uploaded before posters existed, or whose poster failed
+	poster, err := u.extract(ctx, file, -1)
+	if err != nil {
+		return nil, err
+	}
+	_ = tenantStore(u.storage, file.TenantID).Put(ctx, posterKey(file), bytes.NewReader(poster), int64(len(poster)))
+
+	return poster, nil
+}
+
+func (u *thumbnailUsecase) extract(ctx context.Context, file *entity.File, time float64) ([]byte, error) {
+	if !streamMimeTypes[file.MimeType] {
+		return nil, entity.ErrorThumbnailUnsupported
+	}
+
+	blob, err := tenantStore(u.storage, file.TenantID).Get(ctx, fileStorageKey(file))
+	if err != nil {
+		if errors.Is(err, storage.ErrBlobNotFound) {
+			return nil, entity.ErrorFileNotFound
+		}
+		return nil, err
+	}
+	defer blob.Close()
+
+	buf := &bytes.Buffer{}
+	err = thumbnail.Extract(ctx, u.extractor, blob, file.Size, time, buf)
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, err
+		}
+		// whatever the container, codec or decoder failure, the file has
+		// no thumbnail to offer
+		return nil, entity.WithReason(entity.ErrorThumbnailUnsupported, err.Error())
+	}
+
+	return buf.Bytes(), nil
+}
+
+// posterKey derives from the content key, so files sharing content share
+// their poster and reconcile can tell when it is orphaned.
+func posterKey(file *entity.File) string {
+	return thumbnailPrefix + fileStorageKey(file) + "/poster.jpg"
+}
@@ -0,0 +1,167 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"video-server/internal/storage"
+	"video-server/internal/testutil"
+	"video-server/module/entity"
+	"video-server/module/fixture"
+)
+
+const mjpegPath = "./../../../test/mjpeg/sample.mov"
+
+func mjpegFile(t *testing.T) (*entity.File, *os.File) {
+	blob, err := os.Open(mjpegPath)
+	require.NoError(t, err)
+	info, err := blob.Stat()
+	require.NoError(t, err)
+
+	return &entity.File{ID: 1, Name: "sample.mov", MimeType: "video/quicktime", Size: info.Size(), Digest: sampleDigest}, blob
+}
+
+func TestThumbnailUsecase_GetThumbnail(t *testing.T) {
+	posterKey := "thumbnails/" + sampleKey + "/poster.jpg"
+	at := func(t float64) *float64 { return &t }
+
+	type Response struct {
+		// image is set for a stored poster, other results are decoded
+		image []byte
+		err   error
+	}
+
+	testcases := map[string]struct {
+		time     *float64
+		response Response
+		mockFn   func(*testing.T, *fixture.MockThumbnailUsecase)
+	}{
+		"stored poster": {
+			response: Response{
+				image: []byte("poster"),
+			},
+			mockFn: func(t *testing.T, m *fixture.MockThumbnailUsecase) {
+				file, blob := mjpegFile(t)
+				blob.Close()
+				m.FileRepository.EXPECT().GetFile(gomock.Any(), 1).Return(file, nil)
+
+				path := filepath.Join(t.TempDir(), "poster.jpg")
+				require.NoError(t, os.WriteFile(path, []byte("poster"), 0o644))
+				poster, err := os.Open(path)
+				require.NoError(t, err)
+				m.BlobStore.EXPECT().Get(gomock.Any(), posterKey).Return(poster, nil)
+			},
+		},
+		"poster extracted on first request": {
+			mockFn: func(t *testing.T, m *fixture.MockThumbnailUsecase) {
+				file, blob := mjpegFile(t)
+				m.FileRepository.EXPECT().GetFile(gomock.Any(), 1).Return(file, nil)
+				m.BlobStore.EXPECT().Get(gomock.Any(), posterKey).Return(nil, storage.ErrBlobNotFound)
+				m.BlobStore.EXPECT().Get(gomock.Any(), sampleKey).Return(blob, nil)
+				m.BlobStore.EXPECT().Put(gomock.Any(), posterKey, gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		"at time": {
+			time: at(1.5),
+			mockFn: func(t *testing.T, m *fixture.MockThumbnailUsecase) {
+				file, blob := mjpegFile(t)
+				m.FileRepository.EXPECT().GetFile(gomock.Any(), 1).Return(file, nil)
+				m.BlobStore.EXPECT().Get(gomock.Any(), sampleKey).Return(blob, nil)
+			},
+		},
+		"no decoder for the codec": {
+			time: at(0),
+			response: Response{
+				err: entity.ErrorThumbnailUnsupported,
+			},
+			mockFn: func(t *testing.T, m *fixture.MockThumbnailUsecase) {
+				file, blob := sampleFile(t)
+				m.FileRepository.EXPECT().GetFile(gomock.Any(), 1).Return(file, nil)
+				m.BlobStore.EXPECT().Get(gomock.Any(), sampleKey).Return(blob, nil)
+			},
+		},
+		"not an mp4": {
+			time: at(0),
+			response: Response{
+				err: entity.ErrorThumbnailUnsupported,
+			},
+			mockFn: func(t *testing.T, m *fixture.MockThumbnailUsecase) {
+				m.FileRepository.EXPECT().GetFile(gomock.Any(), 1).
+					Return(&entity.File{ID: 1, MimeType: "video/webm", Digest: sampleDigest}, nil)
+			},
+		},
+		"content missing": {
+			time: at(0),
+			response: Response{
+				err: entity.ErrorFileNotFound,
+			},
+			mockFn: func(t *testing.T, m *fixture.MockThumbnailUsecase) {
+				file, blob := mjpegFile(t)
+				blob.Close()
+				m.FileRepository.EXPECT().GetFile(gomock.Any(), 1).Return(file, nil)
+				m.BlobStore.EXPECT().Get(gomock.Any(), sampleKey).Return(nil, storage.ErrBlobNotFound)
+			},
+		},
+		"file not found": {
+			response: Response{
+				err: entity.ErrorFileNotFound,
+			},
+			mockFn: func(t *testing.T, m *fixture.MockThumbnailUsecase) {
+				m.FileRepository.EXPECT().GetFile(gomock.Any(), 1).Return(nil, entity.ErrorFileNotFound)
+			},
+		},
+		"storage error": {
+			response: Response{
+				err: testutil.ErrStorage,
+			},
+			mockFn: func(t *testing.T, m *fixture.MockThumbnailUsecase) {
+				file, blob := mjpegFile(t)
+				blob.Close()
+				m.FileRepository.EXPECT().GetFile(gomock.Any(), 1).Return(file, nil)
+				m.BlobStore.EXPECT().Get(gomock.Any(), posterKey).Return(nil, testutil.ErrStorage)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ucs, mocks := fixture.NewThumbnailUsecase(ctrl)
+			tc.mockFn(t, mocks)
+
+			result, err := ucs.GetThumbnail(context.Background(), 1, tc.time)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			switch {
+			case tc.response.err != nil:
+			case tc.response.image != nil:
+				assert.Equal(t, tc.response.image, result)
+			default:
+				config, err := jpeg.DecodeConfig(bytes.NewReader(result))
+				require.NoError(t, err)
+				assert.Equal(t, 32, config.Width)
+			}
+		})
+	}
+}
+
+func TestThumbnailUsecase_CreatePoster(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ucs, mocks := fixture.NewThumbnailUsecase(ctrl)
+	file, blob := mjpegFile(t)
+	mocks.BlobStore.EXPECT().Get(gomock.Any(), sampleKey).Return(blob, nil)
+	mocks.BlobStore.EXPECT().Put(gomock.Any(), "thumbnails/"+sampleKey+"/poster.jpg", gomock.Any(), gomock.Any()).
+		Return(nil)
+
+	assert.NoError(t, ucs.CreatePoster(context.Background(), file))
+}
@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"video-server/internal/tracing"
+	"video-server/module/entity"
+)
+
+// Attributes of the spans of the usecases.
+const (
+	uploadIDKey  = attribute.Key("upload.id")
+	errorCodeKey = attribute.Key("error.code")
+)
+
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "usecase."+name, attrs...)
+}
+
+// setFileAttributes tags span with what is known of file once it is found.
+func setFileAttributes(span trace.Span, file *entity.File) {
+	if file == nil {
+		return
+	}
+	span.SetAttributes(
+		tracing.FileIDKey.Int(file.ID),
+		tracing.FileSizeKey.Int64(file.Size),
+		tracing.FileMimeTypeKey.String(file.MimeType),
+	)
+}
+
+// endSpan ends span with the outcome err. Errors answered to the client
+// only tag it with their code, the request was served as it should be.
+func endSpan(span trace.Span, err error) {
+	defer span.End()
+	if e, ok := err.(entity.RequestError); ok {
+		span.SetAttributes(errorCodeKey.String(e.Code))
+		return
+	}
+	tracing.RecordError(span, err)
+}
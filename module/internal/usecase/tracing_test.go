@@ -0,0 +1,81 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"video-server/internal/tracing"
+	"video-server/module/entity"
+	"video-server/module/fixture"
+	"video-server/module/internal/usecase"
+)
+
+func TestFileUsecase_GetFileSpan(t *testing.T) {
+	testcases := map[string]struct {
+		file       *entity.File
+		err        error
+		status     codes.Code
+		attributes []attribute.KeyValue
+	}{
+		"success": {
+			file:   &entity.File{ID: 1, Size: 100, MimeType: "video/mp4"},
+			status: codes.Unset,
+			attributes: []attribute.KeyValue{
+				tracing.FileIDKey.Int(1),
+				tracing.FileSizeKey.Int64(100),
+				tracing.FileMimeTypeKey.String("video/mp4"),
+			},
+		},
+		"not found": {
+			err:    entity.ErrorFileNotFound,
+			status: codes.Unset,
+			attributes: []attribute.KeyValue{
+				tracing.FileIDKey.Int(1),
+				attribute.String("error.code", "file_not_found"),
+			},
+		},
+		"db error": {
+			err:    errors.New("DB Error"),
+			status: codes.Error,
+			attributes: []attribute.KeyValue{
+				tracing.FileIDKey.Int(1),
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+			t.Cleanup(func() {
+				otel.SetTracerProvider(trace.NewNoopTracerProvider())
+			})
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			ucs, mocks := fixture.NewFileUsecase(ctrl, usecase.FileConfig{})
+			mocks.FileRepository.EXPECT().GetFile(gomock.Any(), 1).
+				Return(tc.file, tc.err)
+
+			_, _ = ucs.GetFile(context.Background(), 1)
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			assert.Equal(t, "usecase.FileUsecase.GetFile", spans[0].Name())
+			assert.Equal(t, tc.status, spans[0].Status().Code)
+			for _, attr := range tc.attributes {
+				assert.Contains(t, spans[0].Attributes(), attr)
+			}
+		})
+	}
+}
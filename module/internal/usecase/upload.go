@@ -0,0 +1,396 @@
+package usecase
+
+//go:generate mockgen -source upload.go -destination mock/upload.go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+
+	"video-server/internal/storage"
+	"video-server/internal/tracing"
+	"video-server/internal/util"
+	"video-server/module/entity"
+	repository "video-server/module/internal/repository"
+	"video-server/module/param"
+)
+
+type UploadUsecase interface {
+	GetUploadOptions(ctx context.Context) *entity.UploadOptions
+	CreateUpload(ctx context.Context, params *param.CreateUpload) (*entity.Upload, error)
+	GetUpload(ctx context.Context, id string) (*entity.Upload, error)
+	AppendUpload(ctx context.Context, id string, offset int64, reader io.Reader) (*entity.Upload, error)
+	DeleteUpload(ctx context.Context, id string) error
+}
+
+// uploadClaimLease is how long a completion may take before another request
+// may take it over, for requests that died while creating the file.
+const uploadClaimLease = time.Hour
+
+type UploadConfig struct {
+	// MaxSize is the largest upload in bytes, 0 doesn't limit
+	MaxSize int64
+
+	// Quota limits what each tenant may store
+	Quota QuotaConfig
+}
+
+type uploadUsecaseRepository struct {
+	upload repository.UploadRepository
+}
+
+type uploadUsecase struct {
+	repository uploadUsecaseRepository
+	access     fileAccess
+	limits     uploadLimits
+	storage    storage.BlobStore
+	file       FileUsecase
+	config     UploadConfig
+}
+
+func NewUploadUsecase(
+	uploadRepository repository.UploadRepository,
+	fileRepository repository.FileRepository,
+	blobStore storage.BlobStore,
+	fileUsecase FileUsecase,
+	config UploadConfig,
+) *uploadUsecase {
+	return &uploadUsecase{
+		repository: uploadUsecaseRepository{
+			upload: uploadRepository,
+		},
+		limits:  uploadLimits{file: fileRepository, maxSize: config.MaxSize, quota: config.Quota},
+		storage: blobStore,
+		file:    fileUsecase,
+		config:  config,
+	}
+}
+
+func (u *uploadUsecase) GetUploadOptions(ctx context.Context) *entity.UploadOptions {
+	return &entity.UploadOptions{
+		MaxSize: u.config.MaxSize,
+	}
+}
+
+// CreateUpload refuses an upload the size limit or the quota of the tenant
+// would reject once complete, before the client sends any of it.
+func (u *uploadUsecase) CreateUpload(ctx context.Context, params *param.CreateUpload) (*entity.Upload, error) {
+	if params.Length <= 0 {
+		return nil, entity.ErrorBadRequest
+	}
+
+	err := u.access.create(ctx)
+	if err != nil {
+		return nil, err
+	}
+	params.OwnerID, params.TenantID = u.access.owner(ctx)
+
+	err = u.limits.check(ctx, params.TenantID, params.Length)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.repository.upload.CreateUpload(ctx, params)
+}
+
+// GetUpload returns an upload of the caller, others are not found.
+func (u *uploadUsecase) GetUpload(ctx context.Context, id string) (*entity.Upload, error) {
+	upload, err := u.repository.upload.GetUpload(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.access.upload(ctx, upload)
+	if err != nil {
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+func (u *uploadUsecase) AppendUpload(ctx context.Context, id string, offset int64, reader io.Reader) (*entity.Upload, error) {
+	ctx, span := startSpan(ctx, "UploadUsecase.AppendUpload", uploadIDKey.String(id), attribute.Int64("upload.offset", offset))
+	upload, err := u.appendUpload(ctx, id, offset, reader)
+	if upload != nil {
+		span.SetAttributes(attribute.Int64("upload.length", upload.Length))
+		if upload.FileID != nil {
+			span.SetAttributes(tracing.FileIDKey.Int(*upload.FileID))
+		}
+	}
+	endSpan(span, err)
+	return upload, err
+}
+
+func (u *uploadUsecase) appendUpload(ctx context.Context, id string, offset int64, reader io.Reader) (*entity.Upload, error) {
+	upload, err := u.GetUpload(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if upload.Offset != offset {
+		return nil, entity.ErrorUploadOffsetMismatch
+	}
+
+	if upload.Offset < upload.Length {
+		// keep whatever arrived before the client went away, the client
+		// resumes from the offset we report back
+		store := tenantStore(u.storage, upload.TenantID)
+		key := uploadPartKey(upload.ID, upload.Offset)
+		chunk := &partialReader{reader: io.LimitReader(reader, upload.Length-upload.Offset)}
+		err = store.Put(ctx, key, chunk, -1)
+		if err != nil {
+			return nil, err
+		}
+		if chunk.size == 0 {
+			_ = store.Delete(ctx, key)
+			return upload, nil
+		}
+
+		err = u.repository.upload.UpdateUploadOffset(ctx, upload.ID, upload.Offset, upload.Offset+chunk.size)
+		if err != nil {
+			// a concurrent request moved the offset first, its chunk is
+			// the one kept
+			_ = store.Delete(ctx, key)
+			return nil, err
+		}
+		upload.Offset += chunk.size
+	}
+
+	if upload.Offset == upload.Length && !upload.IsComplete() {
+		return u.completeUpload(ctx, upload)
+	}
+	return upload, nil
+}
+
+func (u *uploadUsecase) DeleteUpload(ctx context.Context, id string) error {
+	upload, err := u.GetUpload(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = u.deleteUploadParts(ctx, upload)
+	if err != nil {
+		return err
+	}
+	return u.repository.upload.DeleteUpload(ctx, upload.ID)
+}
+
+// completeUpload stitches the stored chunks back together and hands them to
+// FileUsecase.CreateFile, so resumable uploads get the same validation as
+// POST /v1/files. Of concurrent requests only the one claiming the upload
+// creates the file, the others get ErrorUploadCompleting. The file belongs
+// to the owner of the upload, also when an admin sent the last chunk.
+func (u *uploadUsecase) completeUpload(ctx context.Context, upload *entity.Upload) (*entity.Upload, error) {
+	err := u.repository.upload.ClaimUpload(ctx, upload.ID, time.Now().Add(-uploadClaimLease))
+	if err != nil {
+		return nil, err
+	}
+
+	ownerCtx := uploadOwnerContext(ctx, upload)
+	file, err := u.createUploadFile(ownerCtx, upload)
+	if err != nil {
+		// the content itself was rejected, retrying cannot help
+		var requestErr entity.RequestError
+		if errors.As(err, &requestErr) {
+			_ = u.DeleteUpload(ctx, upload.ID)
+			return nil, err
+		}
+		_ = u.repository.upload.ReleaseUpload(ctx, upload.ID)
+		return nil, err
+	}
+
+	err = u.repository.upload.CompleteUpload(ctx, upload.ID, file.ID)
+	if err != nil {
+		// a retry creates the file again, this one would be left behind
+		_ = u.file.DeleteFile(ownerCtx, file.ID)
+		_ = u.repository.upload.ReleaseUpload(ctx, upload.ID)
+		return nil, err
+	}
+	upload.FileID = &file.ID
+
+	return upload, u.deleteUploadParts(ctx, upload)
+}
+
+// createUploadFile streams the parts straight from storage into
+// FileUsecase.CreateFile, one part open at a time.
+func (u *uploadUsecase) createUploadFile(ctx context.Context, upload *entity.Upload) (*entity.File, error) {
+	store := tenantStore(u.storage, upload.TenantID)
+	parts, err := store.List(ctx, uploadPrefix(upload.ID))
+	if err != nil {
+		return nil, err
+	}
+	chain := uploadChain(parts, uploadPrefix(upload.ID), upload.Length)
+	if chain == nil {
+		return nil, fmt.Errorf("upload %s: no parts cover the %d bytes", upload.ID, upload.Length)
+	}
+
+	readers := make([]io.Reader, len(chain))
+	for i, part := range chain {
+		readers[i] = &uploadPartReader{ctx: ctx, store: store, part: part}
+	}
+	defer func() {
+		for _, reader := range readers {
+			reader.(*uploadPartReader).close()
+		}
+	}()
+
+	name := upload.Name
+	if name == "" {
+		name = upload.ID
+	}
+	return u.file.CreateFile(ctx, util.NewFileReader(io.MultiReader(readers...), name))
+}
+
+func (u *uploadUsecase) deleteUploadParts(ctx context.Context, upload *entity.Upload) error {
+	store := tenantStore(u.storage, upload.TenantID)
+	parts, err := store.List(ctx, uploadPrefix(upload.ID))
+	if err != nil {
+		return err
+	}
+
+	for _, part := range parts {
+		err = store.Delete(ctx, part.Key)
+		if err != nil && !errors.Is(err, storage.ErrBlobNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+func uploadPrefix(id string) string {
+	return fmt.Sprintf("uploads/%s/", id)
+}
+
+// uploadPartKey zero pads the offset so parts list in upload order, the
+// random suffix keeps chunks of concurrent requests for the same offset
+// apart.
+func uploadPartKey(id string, offset int64) string {
+	return fmt.Sprintf("%s%020d-%s", uploadPrefix(id), offset, uuid.NewString())
+}
+
+// uploadChain picks parts covering [0, length) back to back, leaving out
+// chunks of requests that lost the offset race but could not be deleted. It
+// returns nil without such parts.
+func uploadChain(parts []*storage.BlobInfo, prefix string, length int64) []*storage.BlobInfo {
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].Key < parts[j].Key
+	})
+	byOffset := map[int64][]*storage.BlobInfo{}
+	for _, part := range parts {
+		name := strings.TrimPrefix(part.Key, prefix)
+		if len(name) < 20 || part.Size <= 0 {
+			continue
+		}
+		offset, err := strconv.ParseInt(name[:20], 10, 64)
+		if err != nil {
+			continue
+		}
+		byOffset[offset] = append(byOffset[offset], part)
+	}
+
+	dead := map[int64]bool{}
+	var walk func(offset int64) []*storage.BlobInfo
+	walk = func(offset int64) []*storage.BlobInfo {
+		if offset == length {
+			return []*storage.BlobInfo{}
+		}
+		if offset > length || dead[offset] {
+			return nil
+		}
+		for _, part := range byOffset[offset] {
+			if rest := walk(offset + part.Size); rest != nil {
+				return append([]*storage.BlobInfo{part}, rest...)
+			}
+		}
+		dead[offset] = true
+		return nil
+	}
+	return walk(0)
+}
+
+// uploadOwnerContext scopes ctx to the owner and tenant of upload, the
+// principal keeps its role and method.
+func uploadOwnerContext(ctx context.Context, upload *entity.Upload) context.Context {
+	principal := entity.PrincipalFromContext(ctx)
+	if principal == nil {
+		if tenant, _ := entity.TenantFromContext(ctx); tenant != upload.TenantID {
+			return entity.WithTenant(ctx, upload.TenantID)
+		}
+		return ctx
+	}
+	if principal.Subject == upload.OwnerID && principal.Tenant == upload.TenantID {
+		return ctx
+	}
+
+	owner := *principal
+	owner.Subject = upload.OwnerID
+	owner.Tenant = upload.TenantID
+	return entity.WithPrincipal(ctx, &owner)
+}
+
+// uploadPartReader opens a stored part on its first read and closes it at
+// its end. A part shorter than listed fails instead of shifting the rest of
+// the upload.
+type uploadPartReader struct {
+	ctx   context.Context
+	store storage.BlobStore
+	part  *storage.BlobInfo
+	blob  storage.Blob
+	read  int64
+	done  bool
+}
+
+func (r *uploadPartReader) Read(p []byte) (int, error) {
+	if r.done {
+		return 0, io.EOF
+	}
+	if r.blob == nil {
+		blob, err := r.store.Get(r.ctx, r.part.Key)
+		if err != nil {
+			return 0, err
+		}
+		r.blob = blob
+	}
+
+	n, err := r.blob.Read(p)
+	r.read += int64(n)
+	if err == io.EOF {
+		r.close()
+		if r.read != r.part.Size {
+			return n, fmt.Errorf("upload part %s: read %d bytes, listed %d", r.part.Key, r.read, r.part.Size)
+		}
+	}
+	return n, err
+}
+
+func (r *uploadPartReader) close() {
+	r.done = true
+	if r.blob != nil {
+		r.blob.Close()
+		r.blob = nil
+	}
+}
+
+// partialReader reports a broken request body as a clean EOF so the bytes
+// received so far are still stored.
+type partialReader struct {
+	reader io.Reader
+	size   int64
+}
+
+func (r *partialReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.size += int64(n)
+	if err != nil && err != io.EOF {
+		return n, io.EOF
+	}
+	return n, err
+}
@@ -0,0 +1,607 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"video-server/internal/storage"
+	"video-server/internal/testutil"
+	"video-server/internal/util"
+	"video-server/module/entity"
+	"video-server/module/fixture"
+	"video-server/module/internal/usecase"
+	"video-server/module/param"
+)
+
+const uploadPartPath = "./../../../test/post_4/test.txt"
+
+// storeUploadFile stands in for FileUsecase.CreateFile, storing the
+// assembled upload like it does before returning file and err. It fails
+// unless the stored content is content.
+func storeUploadFile(content []byte, file *entity.File, err error) func(context.Context, util.FileReader) (*entity.File, error) {
+	return func(ctx context.Context, fileReader util.FileReader) (*entity.File, error) {
+		dir, mkErr := os.MkdirTemp("", "upload-test-*")
+		if mkErr != nil {
+			return nil, mkErr
+		}
+		defer os.RemoveAll(dir)
+		store, storeErr := storage.NewLocalStore(dir)
+		if storeErr != nil {
+			return nil, storeErr
+		}
+
+		storeErr = fileReader.Store(ctx, store, 0)
+		if storeErr != nil {
+			return nil, storeErr
+		}
+		defer fileReader.Discard(ctx)
+		stored := make([]byte, fileReader.GetSize())
+		_, _ = fileReader.ReadAt(stored, 0)
+		if !bytes.Equal(stored, content) {
+			return nil, fmt.Errorf("stored %q, uploaded %q", stored, content)
+		}
+		return file, err
+	}
+}
+
+// ownerCtxMatcher matches a context acting for subject of the acme tenant
+type ownerCtxMatcher struct {
+	subject string
+}
+
+func (m ownerCtxMatcher) Matches(x interface{}) bool {
+	ctx, ok := x.(context.Context)
+	if !ok {
+		return false
+	}
+	principal := entity.PrincipalFromContext(ctx)
+	return principal != nil && principal.Subject == m.subject && principal.Tenant == "acme"
+}
+
+func (m ownerCtxMatcher) String() string {
+	return fmt.Sprintf("acts for %q of acme", m.subject)
+}
+
+func TestUploadUsecase_CreateUpload(t *testing.T) {
+	type Response struct {
+		result interface{}
+		err    interface{}
+	}
+
+	testcases := map[string]struct {
+		config   usecase.UploadConfig
+		params   *param.CreateUpload
+		response Response
+		mockFn   func(*fixture.MockUploadUsecase, *param.CreateUpload)
+	}{
+		"success": {
+			params: &param.CreateUpload{Name: "sample.mp4", Length: 100},
+			response: Response{
+				result: map[string]interface{}{"ID": "some-id"},
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockUploadUsecase, params *param.CreateUpload) {
+				m.UploadRepository.EXPECT().CreateUpload(gomock.Any(), params).
+					Return(&entity.Upload{ID: "some-id"}, nil)
+			},
+		},
+		"empty upload": {
+			params: &param.CreateUpload{Name: "sample.mp4", Length: 0},
+			response: Response{
+				result: nil,
+				err:    entity.ErrorBadRequest,
+			},
+			mockFn: func(m *fixture.MockUploadUsecase, params *param.CreateUpload) {},
+		},
+		"too large": {
+			config: usecase.UploadConfig{MaxSize: 50},
+			params: &param.CreateUpload{Name: "sample.mp4", Length: 100},
+			response: Response{
+				result: nil,
+				err:    "status 413: err File too large: files are limited to 50 bytes",
+			},
+			mockFn: func(m *fixture.MockUploadUsecase, params *param.CreateUpload) {},
+		},
+		"within quota": {
+			config: usecase.UploadConfig{MaxSize: 1000, Quota: usecase.QuotaConfig{Default: usecase.Quota{MaxBytes: 1000}}},
+			params: &param.CreateUpload{Name: "sample.mp4", Length: 100},
+			response: Response{
+				result: map[string]interface{}{"ID": "some-id"},
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockUploadUsecase, params *param.CreateUpload) {
+				m.FileRepository.EXPECT().GetUsage(gomock.Any(), "").
+					Return(&entity.Usage{Files: 2, Bytes: 900}, nil)
+				m.UploadRepository.EXPECT().CreateUpload(gomock.Any(), params).
+					Return(&entity.Upload{ID: "some-id"}, nil)
+			},
+		},
+		"quota left exceeded": {
+			config: usecase.UploadConfig{MaxSize: 1000, Quota: usecase.QuotaConfig{Default: usecase.Quota{MaxBytes: 1000}}},
+			params: &param.CreateUpload{Name: "sample.mp4", Length: 100},
+			response: Response{
+				result: nil,
+				err:    "status 507: err Quota exceeded: the upload exceeds the 99 bytes left to the tenant",
+			},
+			mockFn: func(m *fixture.MockUploadUsecase, params *param.CreateUpload) {
+				m.FileRepository.EXPECT().GetUsage(gomock.Any(), "").
+					Return(&entity.Usage{Files: 2, Bytes: 901}, nil)
+			},
+		},
+		"quota files exceeded": {
+			config: usecase.UploadConfig{Quota: usecase.QuotaConfig{Default: usecase.Quota{MaxFiles: 2}}},
+			params: &param.CreateUpload{Name: "sample.mp4", Length: 100},
+			response: Response{
+				result: nil,
+				err:    "status 507: err Quota exceeded: the tenant is limited to 2 files",
+			},
+			mockFn: func(m *fixture.MockUploadUsecase, params *param.CreateUpload) {
+				m.FileRepository.EXPECT().GetUsage(gomock.Any(), "").
+					Return(&entity.Usage{Files: 2, Bytes: 100}, nil)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ucs, mocks := fixture.NewUploadUsecase(ctrl, tc.config)
+			tc.mockFn(mocks, tc.params)
+
+			result, err := ucs.CreateUpload(context.Background(), tc.params)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			testutil.AssertStructExAc(t, tc.response.result, result)
+		})
+	}
+}
+
+func TestUploadUsecase_AppendUpload(t *testing.T) {
+	type Request struct {
+		ctx    context.Context
+		offset int64
+		body   string
+	}
+
+	type Response struct {
+		result interface{}
+		err    error
+	}
+
+	partKey := "uploads/some-id/00000000000000000000-a"
+	content, _ := os.ReadFile(uploadPartPath)
+	size := int64(len(content))
+	fileID := 7
+
+	newUpload := func(offset int64) *entity.Upload {
+		return &entity.Upload{ID: "some-id", Name: "test.txt", Length: size, Offset: offset}
+	}
+	// chunks land under their offset, the suffix differs per request
+	var putKey string
+	putChunk := func(ctx context.Context, key string, r io.Reader, size int64) error {
+		if !strings.HasPrefix(key, "uploads/some-id/00000000000000000000-") {
+			return fmt.Errorf("unexpected part key %s", key)
+		}
+		putKey = key
+		_, err := io.ReadAll(r)
+		return err
+	}
+	deletePut := func(ctx context.Context, key string) error {
+		if key != putKey {
+			return fmt.Errorf("deleted %s, put %s", key, putKey)
+		}
+		return nil
+	}
+
+	testcases := map[string]struct {
+		request  Request
+		response Response
+		mockFn   func(*fixture.MockUploadUsecase, Request)
+	}{
+		"partial chunk": {
+			request: Request{
+				ctx:    context.Background(),
+				offset: 0,
+				body:   string(content[:5]),
+			},
+			response: Response{
+				result: map[string]interface{}{"Offset": int64(5)},
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockUploadUsecase, req Request) {
+				m.UploadRepository.EXPECT().GetUpload(req.ctx, "some-id").
+					Return(newUpload(0), nil)
+				m.BlobStore.EXPECT().Put(req.ctx, gomock.Any(), gomock.Any(), int64(-1)).
+					DoAndReturn(putChunk)
+				m.UploadRepository.EXPECT().UpdateUploadOffset(req.ctx, "some-id", int64(0), int64(5)).
+					Return(nil)
+			},
+		},
+		"last chunk creates file": {
+			request: Request{
+				ctx:    context.Background(),
+				offset: 0,
+				body:   string(content),
+			},
+			response: Response{
+				result: map[string]interface{}{"Offset": int64(len(content)), "FileID": &fileID},
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockUploadUsecase, req Request) {
+				m.UploadRepository.EXPECT().GetUpload(req.ctx, "some-id").
+					Return(newUpload(0), nil)
+				m.BlobStore.EXPECT().Put(req.ctx, gomock.Any(), gomock.Any(), int64(-1)).
+					DoAndReturn(putChunk)
+				m.UploadRepository.EXPECT().UpdateUploadOffset(req.ctx, "some-id", int64(0), int64(len(content))).
+					Return(nil)
+				m.UploadRepository.EXPECT().ClaimUpload(req.ctx, "some-id", gomock.Any()).
+					Return(nil)
+				m.BlobStore.EXPECT().List(req.ctx, "uploads/some-id/").
+					Return([]*storage.BlobInfo{{Key: partKey, Size: size}}, nil).Times(2)
+				m.BlobStore.EXPECT().Get(req.ctx, partKey).
+					DoAndReturn(func(ctx context.Context, key string) (storage.Blob, error) {
+						return os.Open(uploadPartPath)
+					})
+				m.FileUsecase.EXPECT().CreateFile(req.ctx, gomock.Any()).
+					DoAndReturn(storeUploadFile(content, &entity.File{ID: fileID}, nil))
+				m.UploadRepository.EXPECT().CompleteUpload(req.ctx, "some-id", fileID).
+					Return(nil)
+				m.BlobStore.EXPECT().Delete(req.ctx, partKey).
+					Return(nil)
+			},
+		},
+		"last chunk rejected": {
+			request: Request{
+				ctx:    context.Background(),
+				offset: 0,
+				body:   string(content),
+			},
+			response: Response{
+				result: nil,
+				err:    entity.ErrorFileUnsupported,
+			},
+			mockFn: func(m *fixture.MockUploadUsecase, req Request) {
+				m.UploadRepository.EXPECT().GetUpload(req.ctx, "some-id").
+					Return(newUpload(0), nil).Times(2)
+				m.BlobStore.EXPECT().Put(req.ctx, gomock.Any(), gomock.Any(), int64(-1)).
+					DoAndReturn(putChunk)
+				m.UploadRepository.EXPECT().UpdateUploadOffset(req.ctx, "some-id", int64(0), int64(len(content))).
+					Return(nil)
+				m.UploadRepository.EXPECT().ClaimUpload(req.ctx, "some-id", gomock.Any()).
+					Return(nil)
+				m.BlobStore.EXPECT().List(req.ctx, "uploads/some-id/").
+					Return([]*storage.BlobInfo{{Key: partKey, Size: size}}, nil).Times(2)
+				m.BlobStore.EXPECT().Get(req.ctx, partKey).
+					DoAndReturn(func(ctx context.Context, key string) (storage.Blob, error) {
+						return os.Open(uploadPartPath)
+					})
+				m.FileUsecase.EXPECT().CreateFile(req.ctx, gomock.Any()).
+					DoAndReturn(storeUploadFile(content, nil, entity.ErrorFileUnsupported))
+				m.BlobStore.EXPECT().Delete(req.ctx, partKey).
+					Return(nil)
+				m.UploadRepository.EXPECT().DeleteUpload(req.ctx, "some-id").
+					Return(nil)
+			},
+		},
+		"offset race lost": {
+			request: Request{
+				ctx:    context.Background(),
+				offset: 0,
+				body:   string(content[:5]),
+			},
+			response: Response{
+				result: nil,
+				err:    entity.ErrorUploadOffsetMismatch,
+			},
+			mockFn: func(m *fixture.MockUploadUsecase, req Request) {
+				m.UploadRepository.EXPECT().GetUpload(req.ctx, "some-id").
+					Return(newUpload(0), nil)
+				m.BlobStore.EXPECT().Put(req.ctx, gomock.Any(), gomock.Any(), int64(-1)).
+					DoAndReturn(putChunk)
+				m.UploadRepository.EXPECT().UpdateUploadOffset(req.ctx, "some-id", int64(0), int64(5)).
+					Return(entity.ErrorUploadOffsetMismatch)
+				m.BlobStore.EXPECT().Delete(req.ctx, gomock.Any()).
+					DoAndReturn(deletePut)
+			},
+		},
+		"empty chunk": {
+			request: Request{
+				ctx:    context.Background(),
+				offset: 0,
+				body:   "",
+			},
+			response: Response{
+				result: map[string]interface{}{"Offset": int64(0)},
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockUploadUsecase, req Request) {
+				m.UploadRepository.EXPECT().GetUpload(req.ctx, "some-id").
+					Return(newUpload(0), nil)
+				m.BlobStore.EXPECT().Put(req.ctx, gomock.Any(), gomock.Any(), int64(-1)).
+					DoAndReturn(putChunk)
+				m.BlobStore.EXPECT().Delete(req.ctx, gomock.Any()).
+					DoAndReturn(deletePut)
+			},
+		},
+		"completion claimed": {
+			request: Request{
+				ctx:    context.Background(),
+				offset: size,
+				body:   "",
+			},
+			response: Response{
+				result: nil,
+				err:    entity.ErrorUploadCompleting,
+			},
+			mockFn: func(m *fixture.MockUploadUsecase, req Request) {
+				m.UploadRepository.EXPECT().GetUpload(req.ctx, "some-id").
+					Return(newUpload(size), nil)
+				m.UploadRepository.EXPECT().ClaimUpload(req.ctx, "some-id", gomock.Any()).
+					Return(entity.ErrorUploadCompleting)
+			},
+		},
+		"orphan part skipped": {
+			request: Request{
+				ctx:    context.Background(),
+				offset: size,
+				body:   "",
+			},
+			response: Response{
+				result: map[string]interface{}{"Offset": size, "FileID": &fileID},
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockUploadUsecase, req Request) {
+				parts := []*storage.BlobInfo{
+					{Key: "uploads/some-id/00000000000000000000-0", Size: 3},
+					{Key: partKey, Size: size},
+				}
+				m.UploadRepository.EXPECT().GetUpload(req.ctx, "some-id").
+					Return(newUpload(size), nil)
+				m.UploadRepository.EXPECT().ClaimUpload(req.ctx, "some-id", gomock.Any()).
+					Return(nil)
+				m.BlobStore.EXPECT().List(req.ctx, "uploads/some-id/").
+					Return(parts, nil).Times(2)
+				m.BlobStore.EXPECT().Get(req.ctx, partKey).
+					DoAndReturn(func(ctx context.Context, key string) (storage.Blob, error) {
+						return os.Open(uploadPartPath)
+					})
+				m.FileUsecase.EXPECT().CreateFile(req.ctx, gomock.Any()).
+					DoAndReturn(storeUploadFile(content, &entity.File{ID: fileID}, nil))
+				m.UploadRepository.EXPECT().CompleteUpload(req.ctx, "some-id", fileID).
+					Return(nil)
+				m.BlobStore.EXPECT().Delete(req.ctx, gomock.Any()).
+					Return(nil).Times(2)
+			},
+		},
+		"CreateFile error releases claim": {
+			request: Request{
+				ctx:    context.Background(),
+				offset: size,
+				body:   "",
+			},
+			response: Response{
+				result: nil,
+				err:    testutil.ErrStorage,
+			},
+			mockFn: func(m *fixture.MockUploadUsecase, req Request) {
+				m.UploadRepository.EXPECT().GetUpload(req.ctx, "some-id").
+					Return(newUpload(size), nil)
+				m.UploadRepository.EXPECT().ClaimUpload(req.ctx, "some-id", gomock.Any()).
+					Return(nil)
+				m.BlobStore.EXPECT().List(req.ctx, "uploads/some-id/").
+					Return([]*storage.BlobInfo{{Key: partKey, Size: size}}, nil)
+				m.BlobStore.EXPECT().Get(req.ctx, partKey).
+					DoAndReturn(func(ctx context.Context, key string) (storage.Blob, error) {
+						return os.Open(uploadPartPath)
+					})
+				m.FileUsecase.EXPECT().CreateFile(req.ctx, gomock.Any()).
+					DoAndReturn(storeUploadFile(content, nil, testutil.ErrStorage))
+				m.UploadRepository.EXPECT().ReleaseUpload(req.ctx, "some-id").
+					Return(nil)
+			},
+		},
+		"CompleteUpload error deletes file": {
+			request: Request{
+				ctx:    context.Background(),
+				offset: size,
+				body:   "",
+			},
+			response: Response{
+				result: nil,
+				err:    testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockUploadUsecase, req Request) {
+				m.UploadRepository.EXPECT().GetUpload(req.ctx, "some-id").
+					Return(newUpload(size), nil)
+				m.UploadRepository.EXPECT().ClaimUpload(req.ctx, "some-id", gomock.Any()).
+					Return(nil)
+				m.BlobStore.EXPECT().List(req.ctx, "uploads/some-id/").
+					Return([]*storage.BlobInfo{{Key: partKey, Size: size}}, nil)
+				m.BlobStore.EXPECT().Get(req.ctx, partKey).
+					DoAndReturn(func(ctx context.Context, key string) (storage.Blob, error) {
+						return os.Open(uploadPartPath)
+					})
+				m.FileUsecase.EXPECT().CreateFile(req.ctx, gomock.Any()).
+					DoAndReturn(storeUploadFile(content, &entity.File{ID: fileID}, nil))
+				m.UploadRepository.EXPECT().CompleteUpload(req.ctx, "some-id", fileID).
+					Return(testutil.ErrDB)
+				m.FileUsecase.EXPECT().DeleteFile(req.ctx, fileID).
+					Return(nil)
+				m.UploadRepository.EXPECT().ReleaseUpload(req.ctx, "some-id").
+					Return(nil)
+			},
+		},
+		"admin completes for the owner": {
+			request: Request{
+				ctx:    adminCtx,
+				offset: size,
+				body:   "",
+			},
+			response: Response{
+				result: map[string]interface{}{"Offset": size, "FileID": &fileID},
+				err:    nil,
+			},
+			mockFn: func(m *fixture.MockUploadUsecase, req Request) {
+				upload := newUpload(size)
+				upload.OwnerID, upload.TenantID = "alice", "acme"
+				acmePartKey := "tenants/acme/" + partKey
+				m.UploadRepository.EXPECT().GetUpload(req.ctx, "some-id").
+					Return(upload, nil)
+				m.UploadRepository.EXPECT().ClaimUpload(req.ctx, "some-id", gomock.Any()).
+					Return(nil)
+				m.BlobStore.EXPECT().List(gomock.Any(), "tenants/acme/uploads/some-id/").
+					Return([]*storage.BlobInfo{{Key: acmePartKey, Size: size}}, nil).Times(2)
+				m.BlobStore.EXPECT().Get(gomock.Any(), acmePartKey).
+					DoAndReturn(func(ctx context.Context, key string) (storage.Blob, error) {
+						return os.Open(uploadPartPath)
+					})
+				m.FileUsecase.EXPECT().CreateFile(ownerCtxMatcher{subject: "alice"}, gomock.Any()).
+					DoAndReturn(storeUploadFile(content, &entity.File{ID: fileID}, nil))
+				m.UploadRepository.EXPECT().CompleteUpload(req.ctx, "some-id", fileID).
+					Return(nil)
+				m.BlobStore.EXPECT().Delete(req.ctx, acmePartKey).
+					Return(nil)
+			},
+		},
+		"offset mismatch": {
+			request: Request{
+				ctx:    context.Background(),
+				offset: 3,
+				body:   "abc",
+			},
+			response: Response{
+				result: nil,
+				err:    entity.ErrorUploadOffsetMismatch,
+			},
+			mockFn: func(m *fixture.MockUploadUsecase, req Request) {
+				m.UploadRepository.EXPECT().GetUpload(req.ctx, "some-id").
+					Return(newUpload(0), nil)
+			},
+		},
+		"Store error": {
+			request: Request{
+				ctx:    context.Background(),
+				offset: 0,
+				body:   "abc",
+			},
+			response: Response{
+				result: nil,
+				err:    testutil.ErrStorage,
+			},
+			mockFn: func(m *fixture.MockUploadUsecase, req Request) {
+				m.UploadRepository.EXPECT().GetUpload(req.ctx, "some-id").
+					Return(newUpload(0), nil)
+				m.BlobStore.EXPECT().Put(req.ctx, gomock.Any(), gomock.Any(), int64(-1)).
+					Return(testutil.ErrStorage)
+			},
+		},
+		"GetUpload error": {
+			request: Request{
+				ctx:    context.Background(),
+				offset: 0,
+				body:   "abc",
+			},
+			response: Response{
+				result: nil,
+				err:    entity.ErrorUploadNotFound,
+			},
+			mockFn: func(m *fixture.MockUploadUsecase, req Request) {
+				m.UploadRepository.EXPECT().GetUpload(req.ctx, "some-id").
+					Return(nil, entity.ErrorUploadNotFound)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ucs, mocks := fixture.NewUploadUsecase(ctrl, usecase.UploadConfig{})
+			tc.mockFn(mocks, tc.request)
+
+			result, err := ucs.AppendUpload(tc.request.ctx, "some-id", tc.request.offset, strings.NewReader(tc.request.body))
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			if tc.response.result == nil {
+				assert.Nil(t, result)
+				return
+			}
+			expected := tc.response.result.(map[string]interface{})
+			assert.Equal(t, expected["Offset"], result.Offset)
+			if fileID, ok := expected["FileID"]; ok {
+				assert.Equal(t, fileID, result.FileID)
+			}
+		})
+	}
+}
+
+func TestUploadUsecase_DeleteUpload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	ucs, mocks := fixture.NewUploadUsecase(ctrl, usecase.UploadConfig{})
+	mocks.UploadRepository.EXPECT().GetUpload(ctx, "some-id").
+		Return(&entity.Upload{ID: "some-id"}, nil)
+	mocks.BlobStore.EXPECT().List(ctx, "uploads/some-id/").
+		Return([]*storage.BlobInfo{{Key: "uploads/some-id/00000000000000000000"}}, nil)
+	mocks.BlobStore.EXPECT().Delete(ctx, "uploads/some-id/00000000000000000000").
+		Return(storage.ErrBlobNotFound)
+	mocks.UploadRepository.EXPECT().DeleteUpload(ctx, "some-id").
+		Return(nil)
+
+	err := ucs.DeleteUpload(ctx, "some-id")
+	testutil.AssertErrorExAc(t, nil, err)
+}
+
+func TestUploadUsecase_GetUpload(t *testing.T) {
+	testcases := map[string]struct {
+		ctx    context.Context
+		upload *entity.Upload
+		err    error
+	}{
+		"owner": {
+			ctx:    viewerCtx,
+			upload: &entity.Upload{ID: "some-id", OwnerID: "alice", TenantID: "acme"},
+		},
+		"admin": {
+			ctx:    adminCtx,
+			upload: &entity.Upload{ID: "some-id", OwnerID: "alice", TenantID: "acme"},
+		},
+		"other owner": {
+			ctx:    viewerCtx,
+			upload: &entity.Upload{ID: "some-id", OwnerID: "bob", TenantID: "acme"},
+			err:    entity.ErrorUploadNotFound,
+		},
+		"other tenant": {
+			ctx:    adminCtx,
+			upload: &entity.Upload{ID: "some-id", OwnerID: "alice", TenantID: "globex"},
+			err:    entity.ErrorUploadNotFound,
+		},
+		"link": {
+			ctx:    linkCtx,
+			upload: &entity.Upload{ID: "some-id", OwnerID: "alice", TenantID: "acme"},
+			err:    entity.ErrorUploadNotFound,
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ucs, mocks := fixture.NewUploadUsecase(ctrl, usecase.UploadConfig{})
+			mocks.UploadRepository.EXPECT().GetUpload(tc.ctx, "some-id").
+				Return(tc.upload, nil)
+
+			_, err := ucs.GetUpload(tc.ctx, "some-id")
+			testutil.AssertErrorExAc(t, tc.err, err)
+		})
+	}
+}
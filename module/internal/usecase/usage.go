@@ -0,0 +1,67 @@
+package usecase
+
+//go:generate mockgen -source usage.go -destination mock/usage.go
+
+import (
+	"context"
+
+	"video-server/module/entity"
+	repository "video-server/module/internal/repository"
+)
+
+// UsageUsecase reports what the tenant of the caller stores against its
+// quota.
+type UsageUsecase interface {
+	GetUsage(ctx context.Context) (*entity.Usage, error)
+	// ListUsage reports every tenant with their quota, for monitoring. It
+	// isn't scoped to the caller.
+	ListUsage(ctx context.Context) ([]*entity.Usage, error)
+}
+
+type usageUsecaseRepository struct {
+	file repository.FileRepository
+}
+
+type usageUsecase struct {
+	repository usageUsecaseRepository
+	quota      QuotaConfig
+}
+
+func NewUsageUsecase(
+	fileRepository repository.FileRepository,
+	quota QuotaConfig,
+) *usageUsecase {
+	return &usageUsecase{
+		repository: usageUsecaseRepository{
+			file: fileRepository,
+		},
+		quota: quota,
+	}
+}
+
+func (u *usageUsecase) GetUsage(ctx context.Context) (*entity.Usage, error) {
+	tenant, _ := entity.TenantFromContext(ctx)
+	usage, err := u.repository.file.GetUsage(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	quota := u.quota.For(tenant)
+	usage.MaxFiles = quota.MaxFiles
+	usage.MaxBytes = quota.MaxBytes
+	return usage, nil
+}
+
+func (u *usageUsecase) ListUsage(ctx context.Context) ([]*entity.Usage, error) {
+	usages, err := u.repository.file.ListUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, usage := range usages {
+		quota := u.quota.For(usage.TenantID)
+		usage.MaxFiles = quota.MaxFiles
+		usage.MaxBytes = quota.MaxBytes
+	}
+	return usages, nil
+}
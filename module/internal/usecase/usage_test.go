@@ -0,0 +1,141 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"video-server/internal/testutil"
+	"video-server/module/entity"
+	"video-server/module/fixture"
+	"video-server/module/internal/usecase"
+)
+
+func TestUsageUsecase_GetUsage(t *testing.T) {
+	quota := usecase.QuotaConfig{
+		Default: usecase.Quota{MaxBytes: 1000, MaxFiles: 10},
+		Tenants: map[string]usecase.Quota{"acme": {MaxBytes: 5000}},
+	}
+
+	type Response struct {
+		result interface{}
+		err    error
+	}
+
+	testcases := map[string]struct {
+		ctx      context.Context
+		response Response
+		mockFn   func(*fixture.MockUsageUsecase, context.Context)
+	}{
+		"principal tenant": {
+			ctx: viewerCtx,
+			response: Response{
+				result: map[string]interface{}{"TenantID": "acme", "Files": int64(2), "Bytes": int64(300), "MaxFiles": int64(0), "MaxBytes": int64(5000)},
+			},
+			mockFn: func(m *fixture.MockUsageUsecase, ctx context.Context) {
+				m.FileRepository.EXPECT().GetUsage(ctx, "acme").
+					Return(&entity.Usage{TenantID: "acme", Files: 2, Bytes: 300}, nil)
+			},
+		},
+		"scoped tenant": {
+			ctx: entity.WithTenant(context.Background(), "globex"),
+			response: Response{
+				result: map[string]interface{}{"TenantID": "globex", "Files": int64(1), "MaxFiles": int64(10), "MaxBytes": int64(1000)},
+			},
+			mockFn: func(m *fixture.MockUsageUsecase, ctx context.Context) {
+				m.FileRepository.EXPECT().GetUsage(ctx, "globex").
+					Return(&entity.Usage{TenantID: "globex", Files: 1}, nil)
+			},
+		},
+		"default tenant": {
+			ctx: context.Background(),
+			response: Response{
+				result: map[string]interface{}{"TenantID": "", "MaxFiles": int64(10), "MaxBytes": int64(1000)},
+			},
+			mockFn: func(m *fixture.MockUsageUsecase, ctx context.Context) {
+				m.FileRepository.EXPECT().GetUsage(ctx, "").
+					Return(&entity.Usage{}, nil)
+			},
+		},
+		"GetUsage error": {
+			ctx: viewerCtx,
+			response: Response{
+				err: testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockUsageUsecase, ctx context.Context) {
+				m.FileRepository.EXPECT().GetUsage(ctx, "acme").
+					Return(nil, testutil.ErrDB)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ucs, mocks := fixture.NewUsageUsecase(ctrl, quota)
+			tc.mockFn(mocks, tc.ctx)
+
+			result, err := ucs.GetUsage(tc.ctx)
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			testutil.AssertStructExAc(t, tc.response.result, result)
+		})
+	}
+}
+
+func TestUsageUsecase_ListUsage(t *testing.T) {
+	quota := usecase.QuotaConfig{
+		Default: usecase.Quota{MaxBytes: 1000, MaxFiles: 10},
+		Tenants: map[string]usecase.Quota{"acme": {MaxBytes: 5000}},
+	}
+
+	type Response struct {
+		result []*entity.Usage
+		err    error
+	}
+
+	testcases := map[string]struct {
+		response Response
+		mockFn   func(*fixture.MockUsageUsecase)
+	}{
+		"success": {
+			response: Response{
+				result: []*entity.Usage{
+					{TenantID: "", Files: 1, Bytes: 100, MaxFiles: 10, MaxBytes: 1000},
+					{TenantID: "acme", Files: 2, Bytes: 300, MaxBytes: 5000},
+				},
+			},
+			mockFn: func(m *fixture.MockUsageUsecase) {
+				m.FileRepository.EXPECT().ListUsage(gomock.Any()).
+					Return([]*entity.Usage{
+						{TenantID: "", Files: 1, Bytes: 100},
+						{TenantID: "acme", Files: 2, Bytes: 300},
+					}, nil)
+			},
+		},
+		"ListUsage error": {
+			response: Response{
+				err: testutil.ErrDB,
+			},
+			mockFn: func(m *fixture.MockUsageUsecase) {
+				m.FileRepository.EXPECT().ListUsage(gomock.Any()).
+					Return(nil, testutil.ErrDB)
+			},
+		},
+	}
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ucs, mocks := fixture.NewUsageUsecase(ctrl, quota)
+			tc.mockFn(mocks)
+
+			result, err := ucs.ListUsage(context.Background())
+			testutil.AssertErrorExAc(t, tc.response.err, err)
+			assert.Equal(t, tc.response.result, result)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package param
+
+import "time"
+
+// Credential is what a request presented, at most one is set.
+type Credential struct {
+	APIKey string
+	Token  string
+	Link   *LinkCredential
+}
+
+// LinkCredential is the signature of a download link found in the query of
+// a request for FileID.
+type LinkCredential struct {
+	FileID    int
+	LinkID    int
+	Expires   int64
+	Signature string
+	// IP is the address of the client
+	IP string
+	// Download tells whether the request starts a download or playback,
+	// which is counted against the link's limit
+	Download bool
+	// Partial tells whether the request reads part of a download or
+	// playback, a later range or anything of a stream. Links with a download
+	// limit count either kind once per client and playback.
+	Partial bool
+}
+
+// CreateAPIKey describes a new key, Prefix and Hash are derived from the
+// generated key by the usecase
+type CreateAPIKey struct {
+	Name      string
+	Subject   string
+	Role      string
+	Tenant    string
+	ExpiresAt *time.Time
+	Prefix    string
+	Hash      string
+}
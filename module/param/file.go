@@ -1,7 +1,58 @@
 package param
 
+import "time"
+
 type CreateFile struct {
 	MimeType string
 	Name     string
 	Size     int64
+	Digest   string
+	OwnerID  string
+	TenantID string
+}
+
+// FileFilter narrows a file listing, zero values don't filter.
+type FileFilter struct {
+	NamePrefix    string
+	MimeType      string
+	MinSize       *int64
+	MaxSize       *int64
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// Status lists files in another status than ready
+	Status string
+
+	// Access limits the files to those the caller may see, nil doesn't
+	Access *FileAccess
+}
+
+// FileAccess scopes files to a tenant, and unless All to the files the
+// subject owns or was granted.
+type FileAccess struct {
+	TenantID string
+	Subject  string
+	All      bool
+}
+
+type ListFiles struct {
+	FileFilter
+	Sort   string
+	Desc   bool
+	Limit  int
+	Offset int
+	// Cursor is the opaque cursor of a previous page, it replaces Offset
+	Cursor string
+	// After is the decoded Cursor, rows sorting after it are returned
+	After *FileCursor
+}
+
+// FileCursor holds the sort key of the last row of a page.
+type FileCursor struct {
+	Sort      string     `json:"s"`
+	Desc      bool       `json:"d,omitempty"`
+	ID        int        `json:"i"`
+	Name      string     `json:"n,omitempty"`
+	Size      int64      `json:"z,omitempty"`
+	MimeType  string     `json:"m,omitempty"`
+	CreatedAt *time.Time `json:"c,omitempty"`
 }
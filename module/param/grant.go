@@ -0,0 +1,7 @@
+package param
+
+type CreateGrant struct {
+	FileID    int
+	Subject   string
+	GrantedBy string
+}
@@ -0,0 +1,15 @@
+package param
+
+import "time"
+
+type CreateJob struct {
+	FileID int
+	Type   string
+}
+
+// FinishJob ends an attempt, RunAt is only used to queue it again
+type FinishJob struct {
+	Status string
+	Error  string
+	RunAt  time.Time
+}
@@ -0,0 +1,16 @@
+package param
+
+import "time"
+
+// CreateLink describes a new download link, the usecase derives ExpiresAt
+// from ExpiresIn and fills in who created it
+type CreateLink struct {
+	FileID       int
+	IP           string
+	MaxDownloads int
+	ExpiresIn    time.Duration
+
+	TenantID  string
+	CreatedBy string
+	ExpiresAt time.Time
+}
@@ -0,0 +1,14 @@
+package param
+
+type CreateMetadata struct {
+	FileID     int
+	Container  string
+	Duration   float64
+	Width      int
+	Height     int
+	FrameRate  float64
+	Bitrate    int64
+	VideoCodec string
+	AudioCodec string
+	Tracks     int
+}
@@ -0,0 +1,11 @@
+package param
+
+import "time"
+
+type Reconcile struct {
+	// GracePeriod protects objects and rows younger than it, they may
+	// belong to an upload in flight
+	GracePeriod time.Duration
+	// DryRun only reports
+	DryRun bool
+}
@@ -0,0 +1,9 @@
+package param
+
+type CreateUpload struct {
+	Name     string
+	Length   int64
+	Metadata string
+	OwnerID  string
+	TenantID string
+}
@@ -1,10 +1,32 @@
 package response
 
-import "time"
+import (
+	"time"
+
+	"video-server/internal/util"
+)
 
 type File struct {
 	ID        string    `json:"fileid"`
 	Name      string    `json:"name"`
 	Size      int64     `json:"size"`
 	CreatedAt time.Time `json:"created_at"`
+	Metadata  *Metadata `json:"metadata,omitempty"`
+}
+
+type Metadata struct {
+	Container  string  `json:"container"`
+	Duration   float64 `json:"duration"`
+	Width      int     `json:"width,omitempty"`
+	Height     int     `json:"height,omitempty"`
+	FrameRate  float64 `json:"frame_rate,omitempty"`
+	Bitrate    int64   `json:"bitrate"`
+	VideoCodec string  `json:"video_codec,omitempty"`
+	AudioCodec string  `json:"audio_codec,omitempty"`
+	Tracks     int     `json:"tracks"`
+}
+
+type FileList struct {
+	Data       []*File          `json:"data"`
+	Pagination *util.Pagination `json:"pagination"`
 }
@@ -0,0 +1,14 @@
+package response
+
+import "time"
+
+type Grant struct {
+	FileID    string    `json:"fileid"`
+	Subject   string    `json:"subject"`
+	GrantedBy string    `json:"granted_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GrantList struct {
+	Data []*Grant `json:"data"`
+}
@@ -0,0 +1,18 @@
+package response
+
+// Health is the state of the service, components are only reported by the
+// readiness probe.
+type Health struct {
+	Status     string                      `json:"status"`
+	Components map[string]*ComponentHealth `json:"components,omitempty"`
+}
+
+// ComponentHealth is the result of one check, the disk check reports its
+// space in bytes.
+type ComponentHealth struct {
+	Status       string `json:"status"`
+	Error        string `json:"error,omitempty"`
+	FreeBytes    *int64 `json:"free_bytes,omitempty"`
+	TotalBytes   *int64 `json:"total_bytes,omitempty"`
+	MinFreeBytes *int64 `json:"min_free_bytes,omitempty"`
+}
@@ -0,0 +1,22 @@
+package response
+
+import "time"
+
+type Job struct {
+	ID         string     `json:"jobid"`
+	FileID     string     `json:"fileid"`
+	Type       string     `json:"type"`
+	Status     string     `json:"status"`
+	Attempts   int        `json:"attempts"`
+	Progress   int        `json:"progress"`
+	Error      string     `json:"error,omitempty"`
+	RunAt      time.Time  `json:"run_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+type JobList struct {
+	Data []*Job `json:"data"`
+}
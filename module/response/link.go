@@ -0,0 +1,13 @@
+package response
+
+import "time"
+
+type Link struct {
+	ID     string `json:"linkid"`
+	FileID string `json:"fileid"`
+	// URL downloads the file, streaming routes accept its query too
+	URL          string    `json:"url"`
+	IP           string    `json:"ip,omitempty"`
+	MaxDownloads int       `json:"max_downloads,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
@@ -0,0 +1,19 @@
+package response
+
+// Problem is an RFC 7807 problem details body. Code names the error for
+// clients, Detail is the human readable message.
+type Problem struct {
+	Type          string          `json:"type"`
+	Title         string          `json:"title"`
+	Status        int             `json:"status"`
+	Detail        string          `json:"detail,omitempty"`
+	Instance      string          `json:"instance,omitempty"`
+	Code          string          `json:"code"`
+	RequestID     string          `json:"request_id,omitempty"`
+	InvalidParams []*InvalidParam `json:"invalid_params,omitempty"`
+}
+
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,11 @@
+package response
+
+// Usage reports what a tenant stores, max_files and max_bytes are 0 when
+// not limited.
+type Usage struct {
+	Tenant   string `json:"tenant"`
+	Files    int64  `json:"files"`
+	Bytes    int64  `json:"bytes"`
+	MaxFiles int64  `json:"max_files"`
+	MaxBytes int64  `json:"max_bytes"`
+}